@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/34South/envr"
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
 	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -22,7 +24,7 @@ func init() {
 type directoryIndex struct {
 	Name      string
 	RawData   []member.Member
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -36,20 +38,31 @@ func (di *directoryIndex) indexName() string {
 	return di.Name
 }
 
-func (di *directoryIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (di *directoryIndex) partialIndex() ([]search.Object, error) {
 	di.fetchLimitedData()
 	di.removeExcludedMembers()
 	di.createIndexObjects()
 	return di.IndexData, di.Error
 }
 
-func (di *directoryIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (di *directoryIndex) fullIndex() ([]search.Object, error) {
 	di.fetchAllData()
 	di.removeExcludedMembers()
 	di.createIndexObjects()
 	return di.IndexData, di.Error
 }
 
+func (di *directoryIndex) aggregate() string {
+	return outbox.Member
+}
+
+func (di *directoryIndex) changedIndex(ids []int) ([]search.Object, error) {
+	di.fetchChangedData(ids)
+	di.removeExcludedMembers()
+	di.createIndexObjects()
+	return di.IndexData, di.Error
+}
+
 func (di *directoryIndex) fetchLimitedData() {
 	timeBack := time.Now().AddDate(0, 0, -1).Format(time.RFC3339)
 	query := bson.M{"memberships.title": bson.M{"$exists": true}, "updatedAt": bson.M{"$gte": timeBack}}
@@ -61,6 +74,16 @@ func (di *directoryIndex) fetchAllData() {
 	di.RawData, di.Error = member.SearchDocDB(DS, query)
 }
 
+// fetchChangedData fetches the members with the ids, a member that is not found is no longer in the index
+func (di *directoryIndex) fetchChangedData(ids []int) {
+	query := bson.M{"memberships.title": bson.M{"$exists": true}, "id": bson.M{"$in": ids}}
+	di.RawData, di.Error = member.SearchDocDB(DS, query)
+	if di.Error == mgo.ErrNotFound {
+		di.Error = nil
+	}
+	di.IndexData = nil
+}
+
 func (di *directoryIndex) removeExcludedMembers() {
 	var xm []member.Member
 	for _, m := range di.RawData {
@@ -73,7 +96,7 @@ func (di *directoryIndex) removeExcludedMembers() {
 
 func (di *directoryIndex) createIndexObjects() {
 	for i := range di.RawData {
-		di.IndexData = append(di.IndexData, algoliasearch.Object{})
+		di.IndexData = append(di.IndexData, search.Object{})
 		di.createObject(i)
 	}
 }
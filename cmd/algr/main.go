@@ -9,6 +9,7 @@ import (
 
 	"github.com/34South/envr"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 )
 
 // updateSched is a date flag used to determine the updateSched for a particular index
@@ -35,6 +36,9 @@ const (
 var updateTypes = []string{"partial", "full", "atomic"}
 
 var collections = flag.String("c", "", "collections to sync - 'all', 'directory', 'members', 'modules', 'resources', 'qualifications', 'organisations'")
+var streaming = flag.Bool("stream", false, "update the directory, members, modules and resources indexes continuously from the change events in the outbox")
+var interval = flag.Duration("interval", 5*time.Second, "stream mode - how long to wait before checking for new change events")
+var batchSize = flag.Int("batch", outbox.DefaultBatchSize, "stream mode - the most change events to read at a time")
 
 var directoryIndexName string
 var memberIndexName string
@@ -81,6 +85,11 @@ func main() {
 	qualificationIndexName = os.Getenv("MAPPCPD_ALGOLIA_QUALIFICATIONS_INDEX")
 	organisationIndexName = os.Getenv("MAPPCPD_ALGOLIA_ORGANISATIONS_INDEX")
 
+	if *streaming {
+		streamIndexes(eventIndexers(), *interval, *batchSize)
+		return
+	}
+
 	switch *collections {
 	case "all":
 		updateDirectoryIndex()
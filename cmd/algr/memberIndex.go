@@ -4,15 +4,17 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
 	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 type memberIndex struct {
 	Name      string
 	RawData   []member.Member
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -27,18 +29,28 @@ func (mi *memberIndex) indexName() string {
 	return mi.Name
 }
 
-func (mi *memberIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (mi *memberIndex) partialIndex() ([]search.Object, error) {
 	mi.fetchLimitedData()
 	mi.createIndexObjects()
 	return mi.IndexData, mi.Error
 }
 
-func (mi *memberIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (mi *memberIndex) fullIndex() ([]search.Object, error) {
 	mi.fetchAllData()
 	mi.createIndexObjects()
 	return mi.IndexData, mi.Error
 }
 
+func (mi *memberIndex) aggregate() string {
+	return outbox.Member
+}
+
+func (mi *memberIndex) changedIndex(ids []int) ([]search.Object, error) {
+	mi.fetchChangedData(ids)
+	mi.createIndexObjects()
+	return mi.IndexData, mi.Error
+}
+
 func (mi *memberIndex) fetchLimitedData() {
 	timeBack := time.Now().AddDate(0, 0, -1).Format(time.RFC3339)
 	query := bson.M{"memberships.title": bson.M{"$exists": true}, "updatedAt": bson.M{"$gte": timeBack}}
@@ -50,9 +62,19 @@ func (mi *memberIndex) fetchAllData() {
 	mi.RawData, mi.Error = member.SearchDocDB(DS, query)
 }
 
+// fetchChangedData fetches the members with the ids, a member that is not found is no longer in the index
+func (mi *memberIndex) fetchChangedData(ids []int) {
+	query := bson.M{"memberships.title": bson.M{"$exists": true}, "id": bson.M{"$in": ids}}
+	mi.RawData, mi.Error = member.SearchDocDB(DS, query)
+	if mi.Error == mgo.ErrNotFound {
+		mi.Error = nil
+	}
+	mi.IndexData = nil
+}
+
 func (mi *memberIndex) createIndexObjects() {
 	for i := range mi.RawData {
-		mi.IndexData = append(mi.IndexData, algoliasearch.Object{})
+		mi.IndexData = append(mi.IndexData, search.Object{})
 		mi.createObject(i)
 	}
 }
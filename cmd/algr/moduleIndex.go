@@ -3,15 +3,16 @@ package main
 import (
 	"time"
 
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
 	"github.com/cardiacsociety/web-services/internal/module"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
 	"gopkg.in/mgo.v2/bson"
 )
 
 type moduleIndex struct {
 	Name      string
 	RawData   []module.Module
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -26,18 +27,28 @@ func (mi *moduleIndex) indexName() string {
 	return mi.Name
 }
 
-func (mi *moduleIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (mi *moduleIndex) partialIndex() ([]search.Object, error) {
 	mi.fetchLimitedData()
 	mi.createIndexObjects()
 	return mi.IndexData, mi.Error
 }
 
-func (mi *moduleIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (mi *moduleIndex) fullIndex() ([]search.Object, error) {
 	mi.fetchAllData()
 	mi.createIndexObjects()
 	return mi.IndexData, mi.Error
 }
 
+func (mi *moduleIndex) aggregate() string {
+	return outbox.Module
+}
+
+func (mi *moduleIndex) changedIndex(ids []int) ([]search.Object, error) {
+	mi.fetchChangedData(ids)
+	mi.createIndexObjects()
+	return mi.IndexData, mi.Error
+}
+
 func (mi *moduleIndex) fetchLimitedData() {
 	timeBack := time.Now().AddDate(0, 0, -1).Format(time.RFC3339)
 	query := bson.M{"current": true, "updatedAt": bson.M{"$gte": timeBack}}
@@ -49,9 +60,16 @@ func (mi *moduleIndex) fetchAllData() {
 	mi.RawData, mi.Error = module.FetchModules(DS, query, 0)
 }
 
+// fetchChangedData fetches the modules with the ids, unpublished modules are no longer in the index
+func (mi *moduleIndex) fetchChangedData(ids []int) {
+	query := bson.M{"current": true, "id": bson.M{"$in": ids}}
+	mi.RawData, mi.Error = module.FetchModules(DS, query, 0)
+	mi.IndexData = nil
+}
+
 func (mi *moduleIndex) createIndexObjects() {
 	for i := range mi.RawData {
-		mi.IndexData = append(mi.IndexData, algoliasearch.Object{})
+		mi.IndexData = append(mi.IndexData, search.Object{})
 		mi.createObject(i)
 	}
 }
@@ -1,14 +1,14 @@
 package main
 
 import (
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
 	"github.com/cardiacsociety/web-services/internal/organisation"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
 )
 
 type organisationIndex struct {
 	Name      string
 	RawData   []organisation.Organisation
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -24,11 +24,11 @@ func (oi *organisationIndex) indexName() string {
 }
 
 // Number of organisations is relatively small so this does same as fullIndex
-func (oi *organisationIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (oi *organisationIndex) partialIndex() ([]search.Object, error) {
 	return oi.fullIndex()
 }
 
-func (oi *organisationIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (oi *organisationIndex) fullIndex() ([]search.Object, error) {
 	oi.fetchAllData()
 	oi.createIndexObjects()
 	return oi.IndexData, oi.Error
@@ -41,7 +41,7 @@ func (oi *organisationIndex) fetchAllData() {
 func (oi *organisationIndex) createIndexObjects() {
 
 	for i := range oi.RawData {
-		oi.IndexData = append(oi.IndexData, algoliasearch.Object{})
+		oi.IndexData = append(oi.IndexData, search.Object{})
 		oi.createObject(i)
 	}
 }
@@ -1,14 +1,14 @@
 package main
 
 import (
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
 	"github.com/cardiacsociety/web-services/internal/qualification"
 )
 
 type qualificationIndex struct {
 	Name      string
 	RawData   []qualification.Qualification
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -24,11 +24,11 @@ func (qi *qualificationIndex) indexName() string {
 }
 
 // Number of qualifications is relatively small so this does same as fullIndex
-func (qi *qualificationIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (qi *qualificationIndex) partialIndex() ([]search.Object, error) {
 	return qi.fullIndex()
 }
 
-func (qi *qualificationIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (qi *qualificationIndex) fullIndex() ([]search.Object, error) {
 	qi.fetchAllData()
 	qi.createIndexObjects()
 	return qi.IndexData, qi.Error
@@ -40,7 +40,7 @@ func (qi *qualificationIndex) fetchAllData() {
 
 func (qi *qualificationIndex) createIndexObjects() {
 	for i := range qi.RawData {
-		qi.IndexData = append(qi.IndexData, algoliasearch.Object{})
+		qi.IndexData = append(qi.IndexData, search.Object{})
 		qi.createObject(i)
 	}
 }
@@ -3,7 +3,8 @@ package main
 import (
 	"time"
 
-	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
 	"github.com/cardiacsociety/web-services/internal/resource"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -11,7 +12,7 @@ import (
 type resourceIndex struct {
 	Name      string
 	RawData   []resource.Resource
-	IndexData []algoliasearch.Object
+	IndexData []search.Object
 	Error     error
 }
 
@@ -26,18 +27,28 @@ func (ri *resourceIndex) indexName() string {
 	return ri.Name
 }
 
-func (ri *resourceIndex) partialIndex() ([]algoliasearch.Object, error) {
+func (ri *resourceIndex) partialIndex() ([]search.Object, error) {
 	ri.fetchLimitedData()
 	ri.createIndexObjects()
 	return ri.IndexData, ri.Error
 }
 
-func (ri *resourceIndex) fullIndex() ([]algoliasearch.Object, error) {
+func (ri *resourceIndex) fullIndex() ([]search.Object, error) {
 	ri.fetchAllData()
 	ri.createIndexObjects()
 	return ri.IndexData, ri.Error
 }
 
+func (ri *resourceIndex) aggregate() string {
+	return outbox.Resource
+}
+
+func (ri *resourceIndex) changedIndex(ids []int) ([]search.Object, error) {
+	ri.fetchChangedData(ids)
+	ri.createIndexObjects()
+	return ri.IndexData, ri.Error
+}
+
 func (ri *resourceIndex) fetchLimitedData() {
 	timeBack := time.Now().AddDate(0, 0, -1).Format(time.RFC3339)
 	query := bson.M{"active": true, "primary": true, "updatedAt": bson.M{"$gte": timeBack}}
@@ -49,9 +60,16 @@ func (ri *resourceIndex) fetchAllData() {
 	ri.RawData, ri.Error = resource.FetchResources(DS, query, 0)
 }
 
+// fetchChangedData fetches the resources with the ids, inactive resources are no longer in the index
+func (ri *resourceIndex) fetchChangedData(ids []int) {
+	query := bson.M{"active": true, "primary": true, "id": bson.M{"$in": ids}}
+	ri.RawData, ri.Error = resource.FetchResources(DS, query, 0)
+	ri.IndexData = nil
+}
+
 func (ri *resourceIndex) createIndexObjects() {
 	for i := range ri.RawData {
-		ri.IndexData = append(ri.IndexData, algoliasearch.Object{})
+		ri.IndexData = append(ri.IndexData, search.Object{})
 		ri.createObject(i)
 	}
 }
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/module"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// consumerName is the name of the outbox checkpoint for algr
+const consumerName = "algr"
+
+// eventIndexer is an index that can be updated with only the records named in change events
+type eventIndexer interface {
+	indexName() string
+	aggregate() string
+	changedIndex(ids []int) ([]search.Object, error)
+}
+
+// eventIndexers returns the indexes selected with the -c flag that can be updated from change events.
+// The qualifications and organisations indexes are rebuilt on a schedule only.
+func eventIndexers() []eventIndexer {
+
+	var xi []eventIndexer
+	add := func(c, name string, i eventIndexer) {
+		if *collections != "all" && *collections != c {
+			return
+		}
+		if name == "" {
+			log.Printf("%s index name is an empty string - skipping", c)
+			return
+		}
+		xi = append(xi, i)
+	}
+
+	di := newDirectoryIndex(directoryIndexName)
+	add("directory", directoryIndexName, &di)
+	mi := newMemberIndex(memberIndexName)
+	add("members", memberIndexName, &mi)
+	moi := newModuleIndex(moduleIndexName)
+	add("modules", moduleIndexName, &moi)
+	ri := newResourceIndex(resourceIndexName)
+	add("resources", resourceIndexName, &ri)
+
+	if len(xi) == 0 {
+		log.Fatalln("No indexes to stream, -c must be 'all', 'directory', 'members', 'modules' or 'resources'")
+	}
+	return xi
+}
+
+// streamIndexes reads change events from the outbox and updates the indexes, until the process is
+// stopped. When there are no new events it waits for interval before checking again.
+func streamIndexes(xi []eventIndexer, interval time.Duration, batchSize int) {
+
+	c := outbox.Consumer{Name: consumerName, BatchSize: batchSize}
+	seen := map[string]bool{}
+	for _, i := range xi {
+		if !seen[i.aggregate()] {
+			c.Aggregates = append(c.Aggregates, i.aggregate())
+			seen[i.aggregate()] = true
+		}
+		log.Printf("Streaming updates to index: %s, aggregate: %s", i.indexName(), i.aggregate())
+	}
+
+	c.Run(DS, interval, stopOnSignal(), func(xe []outbox.Event) error {
+		return applyEvents(xi, xe)
+	})
+}
+
+// stopOnSignal returns a channel that is closed when the process is interrupted or terminated, so that a
+// batch of events that is in progress is completed
+func stopOnSignal() <-chan struct{} {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		log.Println("Received", <-sig, "- stopping")
+		close(stop)
+	}()
+	return stop
+}
+
+// applyEvents saves the changed records to the document database and then updates the indexes. Records
+// that are not found, or no longer belong in an index, are removed from it.
+func applyEvents(xi []eventIndexer, xe []outbox.Event) error {
+
+	synced := map[string]bool{}
+	for _, i := range xi {
+		ids := outbox.IDs(xe, i.aggregate())
+		if len(ids) == 0 {
+			continue
+		}
+		if !synced[i.aggregate()] {
+			if err := syncDocs(i.aggregate(), ids); err != nil {
+				return err
+			}
+			synced[i.aggregate()] = true
+		}
+
+		objects, err := i.changedIndex(ids)
+		if err != nil {
+			return errors.Wrap(err, i.indexName())
+		}
+		if len(objects) > 0 {
+			if err := updateIndex(i.indexName(), objects); err != nil {
+				return errors.Wrap(err, i.indexName())
+			}
+		}
+
+		indexed := map[int]bool{}
+		for _, o := range objects {
+			if id, ok := o["id"].(int); ok {
+				indexed[id] = true
+			}
+		}
+		var removed int
+		for _, id := range ids {
+			if indexed[id] {
+				continue
+			}
+			if err := searchIndex.Delete(i.indexName(), map[string]interface{}{"id": id}); err != nil {
+				return errors.Wrap(err, i.indexName())
+			}
+			removed++
+		}
+		log.Printf("Index %s - updated %d, removed %d", i.indexName(), len(objects), removed)
+	}
+
+	return nil
+}
+
+// syncDocs saves the records from the MySQL database to the document database, as syncr does, so that
+// the index is built from the current records even if syncr has not yet processed the events
+func syncDocs(aggregate string, ids []int) error {
+
+	// members are synced together, with one query per relation
+	if aggregate == outbox.Member {
+		_, err := member.SyncByIDs(DS, ids)
+		return errors.Wrapf(err, "sync %d %s records", len(ids), aggregate)
+	}
+
+	for _, id := range ids {
+		var err error
+		switch aggregate {
+		case outbox.Resource:
+			_, err = resource.SyncByID(DS, id)
+		case outbox.Module:
+			_, err = module.SyncByID(DS, id)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "sync %s id %d", aggregate, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/34South/envr"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// Number of urls to check at once
+var workers int
+
+// Minimum time between requests to the same host, in milliseconds
+var hostDelay int
+
+// Number of times to retry a url that fails
+var retries int
+
+// Number of consecutive broken checks before a resource is deactivated
+var maxFailures int
+
+// Maximum number of resources to check, 0 for all
+var limit int
+
+// Report broken links without saving the results
+var dryRun bool
+
+// Datastore
+var store datastore.Datastore
+
+func init() {
+
+	envr.New("checkrEnv", []string{
+		"MAPPCPD_MONGO_DBNAME",
+		"MAPPCPD_MONGO_DESC",
+		"MAPPCPD_MONGO_URL",
+		"MAPPCPD_MYSQL_DESC",
+		"MAPPCPD_MYSQL_URL",
+	}).Auto()
+
+	flag.IntVar(&workers, "w", 8, "Number of urls to check at once")
+	flag.IntVar(&hostDelay, "d", 1000, "Minimum time between requests to the same host, in milliseconds")
+	flag.IntVar(&retries, "r", 2, "Number of times to retry a url that fails")
+	flag.IntVar(&maxFailures, "f", 3, "Consecutive broken checks before a resource is deactivated, 0 to never deactivate")
+	flag.IntVar(&limit, "n", 0, "Maximum number of resources to check, least recently checked first, 0 for all")
+	flag.BoolVar(&dryRun, "dry-run", false, "Report broken links without saving the results")
+
+	var err error
+	store, err = datastore.FromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func main() {
+
+	flag.Parse()
+	log.Printf("Running checkr with workers: %d, host delay: %dms, retries: %d, max failures: %d, dry run: %v",
+		workers, hostDelay, retries, maxFailures, dryRun)
+
+	xlc, err := resource.LinkChecksDue(store, limit)
+	if err != nil {
+		log.Fatalf("resource.LinkChecksDue() err = %s", err)
+	}
+	log.Printf("Checking %d resource urls", len(xlc))
+
+	c := resource.NewChecker()
+	c.Workers = workers
+	c.HostDelay = time.Duration(hostDelay) * time.Millisecond
+	c.Retries = retries
+	xlc = c.Check(xlc)
+
+	var broken, deactivated int
+	for _, lc := range xlc {
+		if !dryRun {
+			if err := lc.Save(store, maxFailures); err != nil {
+				log.Fatalf("LinkCheck.Save() resource id %d err = %s", lc.ResourceID, err)
+			}
+		}
+		if !lc.Broken() {
+			continue
+		}
+		broken++
+		log.Printf("Broken: resource id %d %s - status %d %s", lc.ResourceID, lc.URL, lc.StatusCode, lc.Error)
+		if lc.Deactivated {
+			deactivated++
+			log.Printf("Deactivated: resource id %d after %d failed checks", lc.ResourceID, lc.Failures)
+		}
+	}
+
+	if dryRun {
+		log.Printf("Dry run, %d of %d links broken, results were not saved", broken, len(xlc))
+		return
+	}
+	log.Printf("%d of %d links broken, %d resources deactivated", broken, len(xlc), deactivated)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/34South/envr"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/s3"
 	"github.com/cardiacsociety/web-services/internal/resource"
 	"github.com/pkg/errors"
 	"gopkg.in/mgo.v2"
@@ -63,7 +64,7 @@ var backdays int
 // tasksFlag flag is used to specify specific functions to run, comma-separated
 var tasksFlag string
 
-var validTasks = []string{"fixResources", "pubmedData"}
+var validTasks = []string{"fixResources", "pubmedData", "privateBuckets"}
 
 var DS datastore.Datastore
 
@@ -130,6 +131,15 @@ func main() {
 			fmt.Println("Running task:", v)
 			updatePubmedData()
 		}
+
+		if v == "privateBuckets" {
+			fmt.Println("Running task:", v)
+			if err := makeBucketsPrivate(); err != nil {
+				fmt.Println(errors.Cause(err))
+				os.Exit(1)
+			}
+			fmt.Println("--- done")
+		}
 	}
 }
 
@@ -153,6 +163,46 @@ func verifyTasks(tasks []string) error {
 	return nil
 }
 
+// makeBucketsPrivate blocks public access to all of the S3 buckets used by active file sets. Attachments
+// are then only accessible via the short-lived signed urls issued by the api.
+func makeBucketsPrivate() error {
+
+	for _, v := range s3.EnvVars {
+		if os.Getenv(v) == "" {
+			return fmt.Errorf("env var %s is required to update S3 buckets", v)
+		}
+	}
+	store, err := s3.New(os.Getenv("AWS_REGION"))
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT DISTINCT volume_name FROM fs_set WHERE active = 1 AND storage_type = 'AWS-S3'"
+	rows, err := DS.MySQL.Session.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var buckets []string
+	for rows.Next() {
+		var b string
+		if err := rows.Scan(&b); err != nil {
+			return err
+		}
+		buckets = append(buckets, b)
+	}
+
+	for _, b := range buckets {
+		fmt.Println("Blocking public access to bucket:", b)
+		if err := store.MakePrivate(b); err != nil {
+			return errors.Wrapf(err, "bucket %s", b)
+		}
+	}
+
+	return nil
+}
+
 // checkShortLinks checks all the Resource records for a short link, and if incorrect or not found, fixes them.
 func checkShortLinks() error {
 
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/34South/envr"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/migrate"
+)
+
+// Directory containing the migration files
+var dir string
+
+// Apply the pending migrations
+var up bool
+
+// Number of migrations to reverse
+var down int
+
+// Print the state of each migration
+var status bool
+
+// Print the migrations that would be applied or reversed without running them
+var dryRun bool
+
+// Datastore - only MySQL is used
+var store datastore.Datastore
+
+func init() {
+
+	envr.New("migratrEnv", []string{
+		"MAPPCPD_MYSQL_DESC",
+		"MAPPCPD_MYSQL_URL",
+	}).Auto()
+
+	flag.StringVar(&dir, "dir", "migrations", "Directory containing the migration files")
+	flag.BoolVar(&up, "up", false, "Apply the pending migrations")
+	flag.IntVar(&down, "down", 0, "Reverse the last n applied migrations")
+	flag.BoolVar(&status, "status", false, "Print the state of each migration")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the migrations that would run, and their sql, without running them")
+
+	store.MySQL = datastore.MySQLConnection{
+		DSN:  os.Getenv("MAPPCPD_MYSQL_URL"),
+		Desc: os.Getenv("MAPPCPD_MYSQL_DESC"),
+	}
+	if err := store.MySQL.Connect(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func main() {
+
+	flag.Parse()
+	flagCheck()
+
+	xm, err := migrate.Load(dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	switch {
+	case status:
+		printStatus(xm)
+	case up:
+		migrateUp(xm)
+	default:
+		migrateDown(xm)
+	}
+}
+
+// flagCheck ensures that exactly one of -up, -down and -status is set
+func flagCheck() {
+
+	var n int
+	for _, b := range []bool{up, down != 0, status} {
+		if b {
+			n++
+		}
+	}
+	if n != 1 {
+		fmt.Println("Specify one of -up, -down or -status")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if down < 0 {
+		log.Fatalln("-down must be the number of migrations to reverse")
+	}
+	if status && dryRun {
+		log.Fatalln("-dry-run is not used with -status")
+	}
+}
+
+func printStatus(xm []migrate.Migration) {
+
+	xs, err := migrate.Status(store, xm)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, s := range xs {
+		state := "pending"
+		if s.Applied {
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Changed {
+			state += " - CHANGED since it was applied"
+		}
+		fmt.Printf("%04d %-32s %s\n", s.Version, s.Name, state)
+	}
+}
+
+func migrateUp(xm []migrate.Migration) {
+
+	if dryRun {
+		pending, err := migrate.Pending(store, xm)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, m := range pending {
+			printSQL("up", m, m.Up)
+		}
+		log.Printf("Dry run, %d migrations were not applied", len(pending))
+		return
+	}
+
+	done, err := migrate.Up(store, xm)
+	for _, m := range done {
+		log.Printf("Applied %04d %s", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Applied %d migrations", len(done))
+}
+
+func migrateDown(xm []migrate.Migration) {
+
+	if dryRun {
+		last, err := migrate.Last(store, xm, down)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, m := range last {
+			printSQL("down", m, m.Down)
+		}
+		log.Printf("Dry run, %d migrations were not reversed", len(last))
+		return
+	}
+
+	done, err := migrate.Down(store, xm, down)
+	for _, m := range done {
+		log.Printf("Reversed %04d %s", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Reversed %d migrations", len(done))
+}
+
+// printSQL prints the statements that would be run for a migration
+func printSQL(direction string, m migrate.Migration, sql string) {
+	fmt.Printf("-- %04d %s (%s)\n", m.Version, m.Name, direction)
+	for _, s := range migrate.Statements(sql) {
+		fmt.Printf("%s\n\n", s)
+	}
+}
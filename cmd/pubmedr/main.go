@@ -457,6 +457,8 @@ func (pa PubMedArticleSet) indexSummaries(attributes map[string]interface{}) {
 		attributes["sourceIssue"] = v.Issue
 		attributes["sourcePages"] = v.Pages
 		attributes["sourcePubDate"] = v.PubYear + " " + v.PubMonth + " " + v.PubDay
+		// Authors are also kept in order, "LastName Initials", for citations
+		attributes["authors"] = xa
 
 		//attributes["sourceRef"] = fmt.Sprintf("%s %s %s;%s(%s):%s", v.JournalAbbrev, v.PubYear, v.PubMonth, v.Volume, v.Issue, v.Pages)
 		r.Attributes = attributes
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/34South/envr"
+	"github.com/cardiacsociety/web-services/internal/attachments"
+	"github.com/cardiacsociety/web-services/internal/fileset"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
+)
+
+// Minimum age, in hours, of a file before it can be removed
+var minAge int
+
+// Report orphaned files without removing them
+var dryRun bool
+
+// Datastore
+var store datastore.Datastore
+
+func init() {
+
+	envr.New("sweeprEnv", []string{
+		"MAPPCPD_MONGO_DBNAME",
+		"MAPPCPD_MONGO_DESC",
+		"MAPPCPD_MONGO_URL",
+		"MAPPCPD_MYSQL_DESC",
+		"MAPPCPD_MYSQL_URL",
+	}).Auto()
+
+	flag.IntVar(&minAge, "a", 24, "Minimum age of files to remove, in hours")
+	flag.BoolVar(&dryRun, "dry-run", false, "Report orphaned files without removing them")
+
+	var err error
+	store, err = datastore.FromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	store.Storage, err = storage.FromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func main() {
+
+	flag.Parse()
+	if minAge < 1 {
+		log.Fatalln("Minimum age (-a) must be at least 1 hour so that files being uploaded are not removed")
+	}
+	log.Printf("Running sweepr with minimum age: %dh, dry run: %v", minAge, dryRun)
+
+	sets, err := fileset.All(store)
+	if err != nil {
+		log.Fatalf("fileset.All() err = %s", err)
+	}
+
+	var total int
+	for _, fs := range sets {
+		if !attachments.Supports(fs) {
+			log.Printf("Skipping file set id %d - no attachments for entity %q", fs.ID, fs.Entity)
+			continue
+		}
+		xs, err := attachments.Sweep(store, fs, time.Duration(minAge)*time.Hour, dryRun)
+		for _, k := range xs {
+			log.Printf("Orphan: %s%s", fs.Volume, k)
+		}
+		if err != nil {
+			log.Fatalf("attachments.Sweep() file set id %d err = %s", fs.ID, err)
+		}
+		log.Printf("File set id %d (%s%s) - %d orphaned files", fs.ID, fs.Volume, fs.Path, len(xs))
+		total += len(xs)
+	}
+
+	if dryRun {
+		log.Printf("Dry run, %d orphaned files were not removed", total)
+		return
+	}
+	log.Printf("Removed %d orphaned files", total)
+}
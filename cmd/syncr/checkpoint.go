@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// mysqlTime is the format for DATETIME values, and updated_at clauses
+const mysqlTime = "2006-01-02 15:04:05"
+
+// checkpoint is the progress of a sync run for a collection, saved in the sync_checkpoint table
+type checkpoint struct {
+	collection string
+	// since is the updated_at cut-off for the run
+	since     time.Time
+	startedAt time.Time
+	// lastID is the highest id for which it and every lower id in the run has been synced
+	lastID   int
+	finished bool
+}
+
+// loadCheckpoint fetches the checkpoint for a collection, and returns nil if there is none
+func loadCheckpoint(collection string) (*checkpoint, error) {
+
+	cp := checkpoint{collection: collection}
+	var since, startedAt string
+	var finishedAt sql.NullString
+	err := store.MySQL.Session.QueryRow(`SELECT since, started_at, last_id, finished_at
+		FROM sync_checkpoint WHERE collection = ?`, collection).Scan(&since, &startedAt, &cp.lastID, &finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loadCheckpoint() %s err = %s", collection, err)
+	}
+	// saved in local time, as for the updated_at clause
+	cp.since, _ = time.ParseInLocation(mysqlTime, since, time.Local)
+	cp.startedAt, _ = time.ParseInLocation(mysqlTime, startedAt, time.Local)
+	cp.finished = finishedAt.Valid
+
+	return &cp, nil
+}
+
+// save records the checkpoint, replacing the one from any previous run
+func (cp *checkpoint) save() error {
+
+	var finishedAt interface{}
+	if cp.finished {
+		finishedAt = time.Now().Format(mysqlTime)
+	}
+	_, err := store.MySQL.Session.Exec(`INSERT INTO sync_checkpoint
+		(collection, since, started_at, last_id, finished_at, updated_at) VALUES (?, ?, ?, ?, ?, NOW())
+		ON DUPLICATE KEY UPDATE since = VALUES(since), started_at = VALUES(started_at), last_id = VALUES(last_id),
+		finished_at = VALUES(finished_at), updated_at = NOW()`,
+		cp.collection, cp.since.Format(mysqlTime), cp.startedAt.Format(mysqlTime), cp.lastID, finishedAt)
+	if err != nil {
+		return fmt.Errorf("checkpoint.save() %s err = %s", cp.collection, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/cardiacsociety/web-services/internal/generic"
+	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/module"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// docSet describes how the docs in a collection relate to the rows in a table, for finding docs whose
+// rows have been deleted or deactivated, and docs that differ from their rows
+type docSet struct {
+	name       string
+	collection func() (*mgo.Collection, error)
+	table      string
+	// related tables with a relatedFK column, whose updates change the doc
+	related   []string
+	relatedFK string
+	// rowClause selects the rows that should have a doc
+	rowClause string
+	// activeClause selects the rows whose doc should be active, empty if the docs have no active field
+	activeClause string
+	sync         func(datastore.Datastore, int) (bool, error)
+	remove       func(datastore.Datastore, int) (bool, error)
+	verify       func(datastore.Datastore, int) ([]generic.FieldDiff, error)
+	// syncBatch syncs a batch of records and returns the ids of the docs removed, nil if the collection
+	// is synced one record at a time
+	syncBatch func(datastore.Datastore, []int) ([]int, error)
+}
+
+// pruneCount is the result of comparing a collection with its table
+type pruneCount struct {
+	docs    int
+	removed int
+	flagged int
+}
+
+func memberDocs() docSet {
+	return docSet{
+		name:         "member",
+		collection:   store.MongoDB.MembersCollection,
+		table:        memberTable,
+		related:      memberRelatedTables,
+		relatedFK:    memberFKColName,
+		activeClause: "WHERE active = 1",
+		sync:         member.SyncByID,
+		syncBatch:    member.SyncByIDs,
+		remove:       member.RemoveDoc,
+		verify:       member.VerifyDoc,
+	}
+}
+
+// unpublished modules do not have a doc
+func moduleDocs() docSet {
+	return docSet{
+		name:       "module",
+		collection: store.MongoDB.ModulesCollection,
+		table:      moduleTable,
+		rowClause:  "WHERE active = 1 AND published = 1",
+		sync:       module.SyncByID,
+		remove:     module.RemoveDoc,
+		verify:     module.VerifyDoc,
+	}
+}
+
+func resourceDocs() docSet {
+	return docSet{
+		name:         "resource",
+		collection:   store.MongoDB.ResourcesCollection,
+		table:        resourceTable,
+		activeClause: "WHERE active = 1",
+		sync:         resource.SyncByID,
+		remove:       resource.RemoveDoc,
+		verify:       resource.VerifyDoc,
+	}
+}
+
+// pruneDocs compares the ids of the docs in the collection with the ids in the table. Docs for rows that
+// have been deleted are removed, and docs with an active value that differs from the row are synced again
+// so that they are flagged as inactive, or active again. Only ids are read to find the differences, so
+// this finds deletions that cannot be found from updated_at.
+func pruneDocs(ds docSet) (pruneCount, error) {
+
+	var pc pruneCount
+
+	rows, err := idSet(generic.GetIDs(store, ds.table, ds.rowClause))
+	if err != nil {
+		return pc, fmt.Errorf("pruneDocs() %s rows err = %s", ds.name, err)
+	}
+	var active map[int]bool
+	if ds.activeClause != "" {
+		active, err = idSet(generic.GetIDs(store, ds.table, ds.activeClause))
+		if err != nil {
+			return pc, fmt.Errorf("pruneDocs() %s active rows err = %s", ds.name, err)
+		}
+	}
+
+	c, err := ds.collection()
+	if err != nil {
+		return pc, fmt.Errorf("pruneDocs() %s collection err = %s", ds.name, err)
+	}
+	var docs []struct {
+		ID     int  `bson:"id"`
+		Active bool `bson:"active"`
+	}
+	err = c.Find(nil).Select(bson.M{"id": 1, "active": 1}).All(&docs)
+	if err != nil {
+		return pc, fmt.Errorf("pruneDocs() %s docs err = %s", ds.name, err)
+	}
+	pc.docs = len(docs)
+
+	// an empty table is more likely to be a problem with the database than the deletion of every row
+	if len(rows) == 0 && len(docs) > 0 {
+		return pc, fmt.Errorf("pruneDocs() %s table %s has no rows - not removing %d docs", ds.name, ds.table, len(docs))
+	}
+
+	for _, d := range docs {
+		switch {
+		case !rows[d.ID] && dryRun:
+			fmt.Printf("%s %d - doc would be removed\n", ds.name, d.ID)
+			pc.removed++
+		case !rows[d.ID]:
+			removed, err := ds.remove(store, d.ID)
+			if err != nil {
+				return pc, fmt.Errorf("pruneDocs() %s id %d err = %s", ds.name, d.ID, err)
+			}
+			if removed {
+				pc.removed++
+			}
+		case active != nil && d.Active != active[d.ID] && dryRun:
+			fmt.Printf("%s %d - doc would be sync'd with active %t\n", ds.name, d.ID, active[d.ID])
+			pc.flagged++
+		case active != nil && d.Active != active[d.ID]:
+			if _, err := ds.sync(store, d.ID); err != nil {
+				return pc, fmt.Errorf("pruneDocs() %s id %d err = %s", ds.name, d.ID, err)
+			}
+			pc.flagged++
+		}
+	}
+
+	if dryRun {
+		log.Printf("Dry run - checked %d %s docs, %d would be removed and %d re-sync'd with a changed active value",
+			pc.docs, ds.name, pc.removed, pc.flagged)
+		return pc, nil
+	}
+	log.Printf("Checked %d %s docs - removed %d deleted, re-sync'd %d with a changed active value",
+		pc.docs, ds.name, pc.removed, pc.flagged)
+	return pc, nil
+}
+
+// idSet converts the ids from generic.GetIDs to a set
+func idSet(xi []int, err error) (map[int]bool, error) {
+	set := make(map[int]bool, len(xi))
+	for _, id := range xi {
+		set[id] = true
+	}
+	return set, err
+}
@@ -8,11 +8,8 @@ import (
 	"time"
 
 	"github.com/34South/envr"
-	"github.com/cardiacsociety/web-services/internal/generic"
-	"github.com/cardiacsociety/web-services/internal/member"
-	"github.com/cardiacsociety/web-services/internal/module"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
-	"github.com/cardiacsociety/web-services/internal/resource"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 )
 
 // database table names
@@ -44,8 +41,21 @@ var backdays int
 // Collection to sync
 var collection string
 
-// sql clause
-var clause string
+// Number of records to sync at a time
+var workers int
+
+// Report what would change without making changes
+var dryRun bool
+
+// Stream mode
+var streaming bool
+var interval time.Duration
+var batchSize int
+var keep time.Duration
+
+// Verify mode
+var verifying bool
+var repair bool
 
 // Datastore
 var store datastore.Datastore
@@ -62,6 +72,14 @@ func init() {
 
 	flag.IntVar(&backdays, "b", 0, "Specify backdays as an integer > 0")
 	flag.StringVar(&collection, "c", "", "Specify what to sync - 'members', 'modules', 'resources' or 'all'")
+	flag.IntVar(&workers, "workers", 4, "The number of records to sync at a time")
+	flag.BoolVar(&dryRun, "dry-run", false, "Report what would change, without changing any docs")
+	flag.BoolVar(&streaming, "stream", false, "Run continuously, syncing the records named in the change events in the outbox")
+	flag.DurationVar(&interval, "interval", 5*time.Second, "Stream mode - how long to wait before checking for new change events")
+	flag.IntVar(&batchSize, "batch", outbox.DefaultBatchSize, "Stream mode - the most change events to read at a time")
+	flag.DurationVar(&keep, "keep", 7*24*time.Hour, "Stream mode - how long to keep change events that have been processed")
+	flag.BoolVar(&verifying, "verify", false, "Compare every document with the record in MySQL and report the fields that differ")
+	flag.BoolVar(&repair, "repair", false, "Verify mode - sync the records whose document differs")
 
 	var err error
 	store, err = datastore.FromEnv()
@@ -76,177 +94,60 @@ func main() {
 	if err != nil {
 		log.Fatalf("flagCheck() err = %s", err)
 	}
-	log.Printf("Running syncr with backdays: %d on collection: %s", backdays, collection)
-
-	clause = sqlClause()
-
-	err = sync()
-	if err != nil {
-		log.Fatalf("sync() err = %s", err)
-	}
-}
-
-func flagCheck() error {
-	flag.Parse()
-	if backdays < 1 {
-		return errors.New("Backdays (-b) required, -h for help")
-	}
-	if collection == "" {
-		return errors.New("Sync target (-c) required, -h for help")
-	}
-	return nil
-}
-
-// sqlClause returns an sql clause for selection of records with an updated_at
-// date >= current date - backdays.
-func sqlClause() string {
-	// MySQL timestamp
-	t := time.Now().AddDate(0, 0, -backdays).Format("2006-01-02 15:04:05")
-
-	return fmt.Sprintf("WHERE updated_at >= '%s'", t)
-}
-
-func sync() error {
-
-	switch collection {
-	case "member", "members":
-		return syncMembers()
-	case "module", "modules":
-		return syncModules()
-	case "resource", "resources":
-		return syncResources()
-	case "all":
-		return syncAll()
-	}
-	return nil
-}
-
-func syncAll() error {
-	err := syncMembers()
-	if err != nil {
-		return err
-	}
-	err = syncModules()
-	if err != nil {
-		return err
-	}
-	err = syncResources()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func syncMembers() error {
-
-	var count int
-
-	// IDs of members that need to be synced
-	ids, err := updateMemberIDs()
-	if err != nil {
-		return fmt.Errorf("syncMembers() err = %s", err)
-	}
 
-	for _, id := range ids {
-		m, err := member.ByID(store, id)
+	if verifying {
+		err = verify()
 		if err != nil {
-			// continue if the member record is not found as it is possible for
-			// an member_id value to be present in a related table but not in
-			// the member table itself. So log message and continue on.
-			//log.Printf("Member ID %v was not found - skipping", id)
-			continue
+			log.Fatalf("verify() err = %s", err)
 		}
-		err = m.Sync(store)
-		if err != nil {
-			return fmt.Errorf("syncMembers() err = %s", err)
-		}
-		count++
+		return
 	}
 
-	log.Printf("Sync'd %d member", count)
-	return nil
-}
-
-// updateMemberIDs fetches a list of ids for members records that need to be
-// updated. It searches the main member table, as well as related tables
-// containing data that is included in a complete member record.
-func updateMemberIDs() ([]int, error) {
+	stop := stopOnSignal()
 
-	ids := []int{}
-
-	// check member table for updates
-	xi, err := generic.GetIDs(store, memberTable, clause)
-	if err != nil {
-		return ids, err
-	}
-	ids = append(ids, xi...)
-
-	// check member-related tables for updates
-	for _, t := range memberRelatedTables {
-		xi, err = generic.GetIntCol(store, t, memberFKColName, clause)
+	// backdays can be used with stream mode to catch up before streaming
+	if backdays > 0 {
+		log.Printf("Running syncr with backdays: %d on collection: %s", backdays, collection)
+		err = syncCollections(stop)
 		if err != nil {
-			return ids, err
+			log.Fatalf("syncCollections() err = %s", err)
 		}
-		ids = append(ids, xi...)
-	}
-
-	return unique(ids), nil
-}
-
-func syncModules() error {
-
-	var count int
-
-	ids, err := generic.GetIDs(store, moduleTable, clause)
-	if err != nil {
-		return fmt.Errorf("syncModules() - GetIDs() err = %s", err)
 	}
 
-	for _, id := range ids {
-		mod, err := module.ByID(store, id)
-		if err != nil {
-			// most likely error is sql: no rows error for inactive record
-			// return fmt.Errorf("syncModules() - ByID() err = %s", err)
-			continue
-		}
-		err = mod.Sync(store)
+	if streaming {
+		err = streamSync(stop)
 		if err != nil {
-			return fmt.Errorf("syncModules() - Sync() err = %s", err)
+			log.Fatalf("streamSync() err = %s", err)
 		}
-		count++
 	}
-
-	log.Printf("Sync'd %d modules", count)
-	return nil
 }
 
-func syncResources() error {
-
-	var count int
-
-	ids, err := generic.GetIDs(store, resourceTable, clause)
-	if err != nil {
-		return fmt.Errorf("syncResources() err = %s", err)
+func flagCheck() error {
+	flag.Parse()
+	if backdays < 1 && !streaming && !verifying {
+		return errors.New("Backdays (-b), stream mode (-stream) or verify mode (-verify) required, -h for help")
 	}
-
-	for _, id := range ids {
-		res, err := resource.ByID(store, id)
-		if err != nil {
-			// most likely error is sql: no rows error for inactive record
-			//return fmt.Errorf("syncResources() err = %s", err)
-			continue
-		}
-		err = res.Sync(store)
-		if err != nil {
-			return fmt.Errorf("syncResources() err = %s", err)
-		}
-		count++
+	if repair && !verifying {
+		return errors.New("Repair (-repair) is only used with verify mode (-verify), -h for help")
+	}
+	if dryRun && (streaming || repair) {
+		return errors.New("Dry run (-dry-run) cannot be used with stream mode (-stream) or repair (-repair), -h for help")
+	}
+	if workers < 1 {
+		return errors.New("Workers (-workers) must be at least 1, -h for help")
+	}
+	if collection == "" {
+		return errors.New("Sync target (-c) required, -h for help")
 	}
-
-	log.Printf("Sync'd %d resources", count)
 	return nil
 }
 
+// sqlClause returns an sql clause for selection of records with an updated_at
+// date >= t.
+func sqlClause(t time.Time) string {
+	return fmt.Sprintf("WHERE updated_at >= '%s'", t.Format(mysqlTime))
+}
+
 // unique removes duplicates from the []int
 func unique(xi []int) []int {
 
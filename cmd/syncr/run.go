@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/generic"
+)
+
+// progressInterval is how often progress is logged, and the checkpoint saved, during a run
+const progressInterval = 10 * time.Second
+
+// syncBatchSize is the number of ids given to a worker at a time. Collections with a syncBatch func
+// load each batch with one query per relation.
+const syncBatchSize = 100
+
+// syncCount is the result of a sync run for a collection
+type syncCount struct {
+	total   int
+	synced  int
+	removed int
+	changed int
+	failed  int
+}
+
+// syncResult is the result of syncing the id at index i in a run
+type syncResult struct {
+	i       int
+	removed bool
+	changed bool
+	err     error
+}
+
+// docSets returns the doc sets for the -c flag
+func docSets() ([]docSet, error) {
+	switch collection {
+	case "member", "members":
+		return []docSet{memberDocs()}, nil
+	case "module", "modules":
+		return []docSet{moduleDocs()}, nil
+	case "resource", "resources":
+		return []docSet{resourceDocs()}, nil
+	case "all":
+		return []docSet{memberDocs(), moduleDocs(), resourceDocs()}, nil
+	}
+	return nil, fmt.Errorf("unknown collection '%s', -c must be 'members', 'modules', 'resources' or 'all'", collection)
+}
+
+// syncCollections syncs the records updated within backdays in each of the selected collections. A
+// collection that fails does not stop the others, unless the process is stopping.
+func syncCollections(stop <-chan struct{}) error {
+
+	xds, err := docSets()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, ds := range xds {
+		if err := syncDocs(ds, stop); err != nil {
+			select {
+			case <-stop:
+				return err
+			default:
+			}
+			log.Println(err)
+			failed = append(failed, ds.name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("sync of %v did not finish", failed)
+	}
+	return nil
+}
+
+// syncDocs syncs the records in a collection that have been updated within backdays, using a pool of
+// workers. The ids are synced in order, and the checkpoint records the highest id for which every lower
+// id has been synced. If a run does not finish the next run resumes from the checkpoint - it skips the
+// ids up to the checkpoint, unless the record has changed since the stopped run started, and includes the
+// records from the stopped run's updated_at cut-off if that is earlier. A record that fails is logged and
+// the run carries on, but the checkpoint does not move past it so it is tried again by the next run.
+func syncDocs(ds docSet, stop <-chan struct{}) error {
+
+	start := time.Now()
+	since := start.AddDate(0, 0, -backdays)
+
+	cp, err := loadCheckpoint(ds.name)
+	if err != nil {
+		return err
+	}
+	resume := cp != nil && !cp.finished
+	if resume {
+		log.Printf("Resuming %s sync after id %d, from the run started at %s", ds.name, cp.lastID,
+			cp.startedAt.Format(mysqlTime))
+		if cp.since.Before(since) {
+			since = cp.since
+		}
+	} else {
+		cp = &checkpoint{collection: ds.name, startedAt: start}
+	}
+	cp.since = since
+
+	ids, err := changedIDs(ds, since)
+	if err != nil {
+		return fmt.Errorf("syncDocs() %s err = %s", ds.name, err)
+	}
+	if resume {
+		ids, err = resumeIDs(ds, ids, cp)
+		if err != nil {
+			return fmt.Errorf("syncDocs() %s err = %s", ds.name, err)
+		}
+	}
+	if !dryRun {
+		if err := cp.save(); err != nil {
+			return err
+		}
+	}
+
+	sc := syncCount{total: len(ids)}
+	log.Printf("Syncing %d %s records updated since %s with %d workers", sc.total, ds.name,
+		since.Format(mysqlTime), workers)
+
+	results := make(chan syncResult)
+	jobs := make(chan int) // the index of the first id in a batch
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				end := i + syncBatchSize
+				if end > len(ids) {
+					end = len(ids)
+				}
+				for _, r := range syncBatch(ds, i, ids[i:end]) {
+					results <- r
+				}
+			}
+		}()
+	}
+	stopped := false
+	go func() {
+		defer close(jobs)
+		for i := 0; i < len(ids); i += syncBatchSize {
+			select {
+			case jobs <- i:
+			case <-stop:
+				stopped = true
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// next is the index of the first id that has not been synced
+	done := make([]bool, len(ids))
+	next := 0
+	checkpointAt := func() {
+		if next > 0 {
+			cp.lastID = ids[next-1]
+		}
+	}
+
+	tick := time.NewTicker(progressInterval)
+	defer tick.Stop()
+	for results != nil {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				results = nil
+				continue
+			}
+			if r.err != nil {
+				log.Printf("%s id %d err = %s", ds.name, ids[r.i], r.err)
+				sc.failed++
+				continue
+			}
+			sc.synced++
+			if r.removed {
+				sc.removed++
+			}
+			if r.changed {
+				sc.changed++
+			}
+			done[r.i] = true
+			for next < len(done) && done[next] {
+				next++
+			}
+		case <-tick.C:
+			logProgress(ds.name, sc, start)
+			checkpointAt()
+			if !dryRun {
+				if err := cp.save(); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+	}
+
+	checkpointAt()
+	cp.finished = !stopped && next == len(ids)
+	if !dryRun {
+		if err := cp.save(); err != nil {
+			return err
+		}
+	}
+
+	elapsed := time.Since(start)
+	if dryRun {
+		log.Printf("Dry run - %d of %d %s records would change, checked in %s (%.1f/s), %d failed",
+			sc.changed, sc.total, ds.name, elapsed.Round(time.Second), rate(sc.synced+sc.failed, elapsed), sc.failed)
+	} else {
+		log.Printf("Sync'd %d of %d %s records in %s (%.1f/s) - %d docs removed, %d failed",
+			sc.synced, sc.total, ds.name, elapsed.Round(time.Second), rate(sc.synced+sc.failed, elapsed),
+			sc.removed, sc.failed)
+	}
+
+	if stopped {
+		return fmt.Errorf("syncDocs() %s stopped after id %d - run again to resume", ds.name, cp.lastID)
+	}
+	if sc.failed > 0 {
+		return fmt.Errorf("syncDocs() %s had %d records fail - run again to retry from id %d", ds.name,
+			sc.failed, ids[next])
+	}
+
+	_, err = pruneDocs(ds)
+	return err
+}
+
+// syncBatch syncs the ids, which start at index i, and returns a result for each. If the collection has no
+// syncBatch func, or this is a dry run, the records are synced one at a time. If the batch fails every
+// record in it has failed, so the checkpoint does not move past them.
+func syncBatch(ds docSet, i int, ids []int) []syncResult {
+
+	xr := make([]syncResult, len(ids))
+	if dryRun || ds.syncBatch == nil {
+		for j, id := range ids {
+			xr[j] = syncOne(ds, i+j, id)
+		}
+		return xr
+	}
+
+	removed, err := idSet(ds.syncBatch(store, ids))
+	for j := range ids {
+		xr[j] = syncResult{i: i + j, removed: removed[ids[j]], changed: true, err: err}
+	}
+	return xr
+}
+
+// syncOne syncs the record with the id at index i. In a dry run the doc is verified instead, and the
+// fields that would change are printed.
+func syncOne(ds docSet, i, id int) syncResult {
+
+	if !dryRun {
+		removed, err := ds.sync(store, id)
+		return syncResult{i: i, removed: removed, changed: true, err: err}
+	}
+
+	xd, err := ds.verify(store, id)
+	for _, d := range xd {
+		fmt.Printf("%s %d - %s\n", ds.name, id, d)
+	}
+	return syncResult{i: i, changed: len(xd) > 0, err: err}
+}
+
+// changedIDs returns the ids of the records updated since t, including records with an update in a
+// related table, in order
+func changedIDs(ds docSet, t time.Time) ([]int, error) {
+
+	clause := sqlClause(t)
+	ids, err := generic.GetIDs(store, ds.table, clause)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range ds.related {
+		xi, err := generic.GetIntCol(store, table, ds.relatedFK, clause)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, xi...)
+	}
+
+	ids = unique(ids)
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// resumeIDs removes the ids that were synced by a run that stopped, unless the record has changed since
+// that run started
+func resumeIDs(ds docSet, ids []int, cp *checkpoint) ([]int, error) {
+
+	recent, err := idSet(changedIDs(ds, cp.startedAt))
+	if err != nil {
+		return nil, err
+	}
+	var xi []int
+	for _, id := range ids {
+		if id > cp.lastID || recent[id] {
+			xi = append(xi, id)
+		}
+	}
+	return xi, nil
+}
+
+func logProgress(name string, sc syncCount, start time.Time) {
+	n := sc.synced + sc.failed
+	var pc float64
+	if sc.total > 0 {
+		pc = float64(n) / float64(sc.total) * 100
+	}
+	log.Printf("%s: %d of %d records (%.0f%%), %.1f/s, %d failed", name, n, sc.total, pc,
+		rate(n, time.Since(start)), sc.failed)
+}
+
+// rate returns the number of records per second
+func rate(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/module"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// consumerName is the name of the outbox checkpoint for syncr
+const consumerName = "syncr"
+
+// purgeInterval is how often processed events are removed from the outbox
+const purgeInterval = time.Hour
+
+// streamSync reads change events from the outbox and saves the changed records to the document
+// database, until the process is stopped
+func streamSync(stop <-chan struct{}) error {
+
+	aggregates, err := streamAggregates()
+	if err != nil {
+		return err
+	}
+	c := outbox.Consumer{Name: consumerName, Aggregates: aggregates, BatchSize: batchSize}
+	log.Printf("Streaming changes to %v, checking every %s", aggregates, interval)
+
+	go purgeEvents(stop)
+	c.Run(store, interval, stop, syncEvents)
+	return nil
+}
+
+// streamAggregates returns the aggregates for the -c flag
+func streamAggregates() ([]string, error) {
+	switch collection {
+	case "member", "members":
+		return []string{outbox.Member}, nil
+	case "module", "modules":
+		return []string{outbox.Module}, nil
+	case "resource", "resources":
+		return []string{outbox.Resource}, nil
+	case "all":
+		return []string{outbox.Member, outbox.Module, outbox.Resource}, nil
+	}
+	return nil, fmt.Errorf("unknown collection %q", collection)
+}
+
+// syncEvents saves the records named in the events to the document database. The members are synced
+// together, with one query per relation.
+func syncEvents(xe []outbox.Event) error {
+
+	synced := map[string]int{}
+	removed := map[string]int{}
+
+	if ids := outbox.IDs(xe, outbox.Member); len(ids) > 0 {
+		xr, err := member.SyncByIDs(store, ids)
+		if err != nil {
+			return fmt.Errorf("syncEvents() member err = %s", err)
+		}
+		synced[outbox.Member] = len(ids) - len(xr)
+		removed[outbox.Member] = len(xr)
+	}
+
+	for _, e := range xe {
+		var r bool
+		var err error
+		switch e.Aggregate {
+		case outbox.Module:
+			r, err = module.SyncByID(store, e.AggregateID)
+		case outbox.Resource:
+			r, err = resource.SyncByID(store, e.AggregateID)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("syncEvents() %s id %d err = %s", e.Aggregate, e.AggregateID, err)
+		}
+		if r {
+			removed[e.Aggregate]++
+			continue
+		}
+		synced[e.Aggregate]++
+	}
+
+	for _, a := range []string{outbox.Member, outbox.Module, outbox.Resource} {
+		if synced[a] > 0 || removed[a] > 0 {
+			log.Printf("Sync'd %d %s docs, removed %d", synced[a], a, removed[a])
+		}
+	}
+	return nil
+}
+
+// purgeEvents removes events older than the -keep duration that have been processed by every consumer
+func purgeEvents(stop <-chan struct{}) {
+	for {
+		n, err := outbox.Purge(store, time.Now().Add(-keep))
+		if err != nil {
+			log.Printf("purgeEvents() err = %s", err)
+		} else if n > 0 {
+			log.Printf("Purged %d change events", n)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(purgeInterval):
+		}
+	}
+}
+
+// stopOnSignal returns a channel that is closed when the process is interrupted or terminated, so that a
+// batch of events that is in progress is completed
+func stopOnSignal() <-chan struct{} {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		log.Println("Received", <-sig, "- stopping")
+		close(stop)
+	}()
+	return stop
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/cardiacsociety/web-services/internal/generic"
+)
+
+// verifyCount is the result of verifying the docs in a collection
+type verifyCount struct {
+	checked  int
+	differ   int
+	repaired int
+}
+
+// verify compares every doc in the selected collections with the record rebuilt from MySQL, and prints
+// the fields that differ. With -repair the records that differ are synced again.
+func verify() error {
+
+	xds, err := docSets()
+	if err != nil {
+		return fmt.Errorf("verify() err = %s", err)
+	}
+	for _, ds := range xds {
+		if _, err := verifyDocs(ds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyDocs checks every record that has a row in the table or a doc in the collection, so that missing
+// docs and docs for deleted records are reported as well as docs that are out of date. Each field that
+// differs is printed on its own line, as "name id - field: want mysql value, got mongo value".
+func verifyDocs(ds docSet) (verifyCount, error) {
+
+	var vc verifyCount
+
+	ids, err := allIDs(ds)
+	if err != nil {
+		return vc, fmt.Errorf("verifyDocs() %s err = %s", ds.name, err)
+	}
+	log.Printf("Verifying %d %s records", len(ids), ds.name)
+
+	for _, id := range ids {
+		xd, err := ds.verify(store, id)
+		if err != nil {
+			return vc, fmt.Errorf("verifyDocs() %s id %d err = %s", ds.name, id, err)
+		}
+		vc.checked++
+		if len(xd) == 0 {
+			continue
+		}
+		vc.differ++
+		for _, d := range xd {
+			fmt.Printf("%s %d - %s\n", ds.name, id, d)
+		}
+
+		if !repair {
+			continue
+		}
+		if _, err := ds.sync(store, id); err != nil {
+			return vc, fmt.Errorf("verifyDocs() %s id %d repair err = %s", ds.name, id, err)
+		}
+		vc.repaired++
+	}
+
+	log.Printf("Verified %d %s records - %d differ, %d repaired", vc.checked, ds.name, vc.differ, vc.repaired)
+	return vc, nil
+}
+
+// allIDs returns the ids of every row in the table and every doc in the collection, in order
+func allIDs(ds docSet) ([]int, error) {
+
+	xi, err := generic.GetIDs(store, ds.table, "")
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := ds.collection()
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID int `bson:"id"`
+	}
+	err = c.Find(nil).Select(bson.M{"id": 1}).All(&docs)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		xi = append(xi, d.ID)
+	}
+
+	ids := unique(xi)
+	sort.Ints(ids)
+	return ids, nil
+}
@@ -180,10 +180,9 @@ var activityAttachmentType = graphql.NewObject(graphql.ObjectConfig{
 			Type:        graphql.Int,
 			Description: "The id of the member activity attachment record",
 		},
-		// todo this should be a signed url
 		"url": &graphql.Field{
 			Type:        graphql.String,
-			Description: "The url for accessing the file",
+			Description: "A short-lived signed url for accessing the file, request the activity again for a fresh url",
 		},
 	},
 })
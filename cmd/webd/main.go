@@ -9,7 +9,11 @@ import (
 
 	"github.com/34South/envr"
 	"github.com/cardiacsociety/web-services/cmd/webd/server"
+	"github.com/cardiacsociety/web-services/internal/attachments"
+	"github.com/cardiacsociety/web-services/internal/events"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 )
 
 const defaultServerPort = "5000"
@@ -52,6 +56,31 @@ func main() {
 		log.Fatalln("Could not set datastore -", err)
 	}
 
+	// File storage backend, S3 unless otherwise configured
+	ds.Storage, err = storage.FromEnv()
+	if err != nil {
+		log.Fatalln("Could not set file storage -", err)
+	}
+
+	// Key for the QR codes on event tickets
+	server.TicketKey, err = events.TicketKey()
+	if err != nil {
+		log.Fatalln("Could not set ticket key -", err)
+	}
+
+	// Local search index for development and tests, otherwise search is done by the clients
+	ds.Search, err = search.FromEnv()
+	if err != nil {
+		log.Fatalln("Could not set search index -", err)
+	}
+
+	// Optional virus scanning of files uploaded via the server
+	if addr := os.Getenv("MAPPCPD_CLAMD_ADDR"); addr != "" {
+		server.Scanner = attachments.Clamd{Addr: addr}
+	} else {
+		log.Println("MAPPCPD_CLAMD_ADDR not set, uploaded files will not be scanned")
+	}
+
 	// Override default port numbers with optional -p flag (if set) or with env var PORT.
 	var serverPort = defaultServerPort
 	portFlag := flag.String("p", "", "Override default port")
@@ -8,11 +8,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/cardiacsociety/web-services/internal/activity"
 	"github.com/cardiacsociety/web-services/internal/attachments"
 	"github.com/cardiacsociety/web-services/internal/cpd"
 	"github.com/cardiacsociety/web-services/internal/fileset"
-	"github.com/cardiacsociety/web-services/internal/platform/s3"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 	"github.com/gorilla/mux"
 	"github.com/imdario/mergo"
 	"gopkg.in/mgo.v2"
@@ -24,7 +23,7 @@ func Activities(w http.ResponseWriter, _ *http.Request) {
 
 	p := NewResponder(UserAuthToken.Encoded)
 
-	al, err := activity.All(DS)
+	al, err := activityRepo.All()
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
 		p.Send(w)
@@ -53,7 +52,7 @@ func ActivitiesID(w http.ResponseWriter, r *http.Request) {
 		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
 	}
 
-	a, err := activity.ByID(DS, id)
+	a, err := activityRepo.ByID(id)
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
 		p.Send(w)
@@ -82,7 +81,7 @@ func MembersActivitiesID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Response
-	a, err := cpd.ByID(DS, int(id))
+	a, err := cpdRepo.ByID(int(id))
 	switch {
 	case err == sql.ErrNoRows:
 		p.Message = Message{http.StatusNotFound, "failed", err.Error()}
@@ -123,7 +122,7 @@ func MembersActivitiesAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	aid, err := cpd.Add(DS, a)
+	aid, err := cpdRepo.Add(a)
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failure", err.Error()}
 		p.Send(w)
@@ -131,7 +130,7 @@ func MembersActivitiesAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch the new record for return
-	ar, err := cpd.ByID(DS, int(aid))
+	ar, err := cpdRepo.ByID(int(aid))
 	if err != nil {
 		msg := "Could not fetch the new record"
 		p.Message = Message{http.StatusInternalServerError, "failure", msg + " " + err.Error()}
@@ -161,7 +160,7 @@ func MembersActivitiesUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch the original activity record
-	a, err := cpd.ByID(DS, int(id))
+	a, err := cpdRepo.ByID(int(id))
 	switch {
 	case err == sql.ErrNoRows:
 		p.Message = Message{http.StatusNotFound, "failed", err.Error()}
@@ -215,7 +214,7 @@ func MembersActivitiesUpdate(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("New:", na)
 
 	// Update the activity record
-	err = cpd.Update(DS, na)
+	err = cpdRepo.Update(na)
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failure", err.Error()}
 		p.Send(w)
@@ -223,7 +222,7 @@ func MembersActivitiesUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// updated record - fetch for response
-	ur, err := cpd.ByID(DS, int(id))
+	ur, err := cpdRepo.ByID(int(id))
 	if err != nil {
 		msg := "Could not fetch the updated record"
 		p.Message = Message{http.StatusInternalServerError, "failure", msg + " " + err.Error()}
@@ -422,7 +421,7 @@ func MembersActivitiesAttachmentRequest(w http.ResponseWriter, r *http.Request)
 		p.Message = Message{http.StatusBadRequest, "failed", msg}
 	}
 
-	a, err := cpd.ByID(DS, int(id))
+	a, err := cpdRepo.ByID(int(id))
 	switch {
 	case err == sql.ErrNoRows:
 		msg := fmt.Sprintf("No activity found with id %d -", id) + err.Error()
@@ -453,13 +452,13 @@ func MembersActivitiesAttachmentRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Build FULL file path or 'key' in S3 parlance
-	filePath := fs.Path + strconv.Itoa(id) + "/" + upload.FileName
+	filePath := fs.Key(id, upload.FileName)
 
 	// Prepend the volume name to pass back to the client for subsequent file registration
 	upload.VolumeFilePath = fs.Volume + filePath
 
 	// get a signed request
-	url, err := s3.PutRequest(filePath, fs.Volume)
+	url, err := DS.Storage.PutURL(fs.Volume, filePath, storage.DefaultTTL)
 	if err != nil {
 		msg := "Error getting a signed request for upload " + err.Error()
 		p.Message = Message{http.StatusInternalServerError, "failed", msg}
@@ -492,7 +491,7 @@ func MembersActivitiesAttachmentRegister(w http.ResponseWriter, r *http.Request)
 		p.Send(w)
 		return
 	}
-	activity, err := cpd.ByID(DS, int(id))
+	activity, err := cpdRepo.ByID(int(id))
 	switch {
 	case err == sql.ErrNoRows:
 		msg := fmt.Sprintf("No activity found with id %d -", id) + err.Error()
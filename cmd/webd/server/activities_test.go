@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/activity"
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/platform/jwt"
+)
+
+// setupActivities replaces the repositories with in-memory fakes holding an activity, and a cpd record
+// that belongs to member 7
+func setupActivities(t *testing.T) int {
+
+	ar := activity.NewMemory(activity.Activity{ID: 1, Name: "Meetings", CreditPerUnit: 1, MaxCredit: 50})
+	ar.AddTypes(1, activity.Type{ID: 11, Name: "Conference"})
+	cr := cpd.NewMemory(ar)
+	id, err := cr.Add(cpd.Input{MemberID: 7, ActivityID: 1, TypeID: 11, Date: "2019-03-01", Quantity: 2,
+		Description: "Annual scientific meeting"})
+	if err != nil {
+		t.Fatalf("Add() err = %s", err)
+	}
+	activityRepo = ar
+	cpdRepo = cr
+	return id
+}
+
+func TestActivities(t *testing.T) {
+
+	setupActivities(t)
+	w := httptest.NewRecorder()
+	Activities(w, httptest.NewRequest("GET", "/v1/activities", nil))
+
+	var p struct {
+		Status int                 `json:"status"`
+		Data   []activity.Activity `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("Decode() err = %s", err)
+	}
+	if p.Status != http.StatusOK || len(p.Data) != 1 || p.Data[0].Name != "Meetings" {
+		t.Errorf("Activities() status = %d, data = %v, want one activity", p.Status, p.Data)
+	}
+}
+
+func TestMembersActivitiesID(t *testing.T) {
+
+	id := setupActivities(t)
+	defer func(t jwt.Token) { UserAuthToken = t }(UserAuthToken)
+
+	cases := []struct {
+		memberID int
+		want     int
+	}{
+		{7, http.StatusOK},
+		{8, http.StatusUnauthorized}, // not the owner
+	}
+	for _, c := range cases {
+		UserAuthToken = jwt.Token{Claims: jwt.TokenClaims{ID: c.memberID}}
+		r := httptest.NewRequest("GET", "/v1/m/activities/"+strconv.Itoa(id), nil)
+		r = mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(id)})
+		w := httptest.NewRecorder()
+		MembersActivitiesID(w, r)
+		if w.Code != c.want {
+			t.Errorf("MembersActivitiesID() member %d status = %d, want %d", c.memberID, w.Code, c.want)
+		}
+	}
+}
@@ -12,7 +12,6 @@ import (
 
 	"github.com/gorilla/mux"
 	uuid "github.com/hashicorp/go-uuid"
-	"gopkg.in/mgo.v2/bson"
 
 	"github.com/cardiacsociety/web-services/internal/application"
 	"github.com/cardiacsociety/web-services/internal/attachments"
@@ -23,7 +22,7 @@ import (
 	"github.com/cardiacsociety/web-services/internal/note"
 	"github.com/cardiacsociety/web-services/internal/notification"
 	"github.com/cardiacsociety/web-services/internal/payment"
-	"github.com/cardiacsociety/web-services/internal/platform/s3"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 	"github.com/cardiacsociety/web-services/internal/position"
 	"github.com/cardiacsociety/web-services/internal/resource"
 )
@@ -188,7 +187,7 @@ func AdminMembersID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the Member record
-	m, err := member.ByID(DS, int(id))
+	m, err := memberRepo.ByID(int(id))
 	// Response
 	switch {
 	case err == sql.ErrNoRows:
@@ -356,13 +355,13 @@ func AdminNotesAttachmentRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build FULL file path or 'key' in S3 parlance
-	filePath := fs.Path + strconv.Itoa(id) + "/" + upload.FileName
+	filePath := fs.Key(id, upload.FileName)
 
 	// Prepend the volume name to pass back to the client for subsequent file registration
 	upload.VolumeFilePath = fs.Volume + filePath
 
 	// get a signed request
-	url, err := s3.PutRequest(filePath, fs.Volume)
+	url, err := DS.Storage.PutURL(fs.Volume, filePath, storage.DefaultTTL)
 	if err != nil {
 		msg := "Error getting a signed request for upload " + err.Error()
 		p.Message = Message{http.StatusInternalServerError, "failed", msg}
@@ -492,13 +491,13 @@ func AdminResourcesAttachmentRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build FULL file path or 'key' in S3 parlance
-	filePath := fs.Path + strconv.Itoa(id) + "/" + upload.FileName
+	filePath := fs.Key(id, upload.FileName)
 
 	// Prepend the volume name to pass back to the client for subsequent file registration
 	upload.VolumeFilePath = fs.Volume + filePath
 
 	// get a signed request
-	url, err := s3.PutRequest(filePath, fs.Volume)
+	url, err := DS.Storage.PutURL(fs.Volume, filePath, storage.DefaultTTL)
 	if err != nil {
 		msg := "Error getting a signed request for upload " + err.Error()
 		p.Message = Message{http.StatusInternalServerError, "failed", msg}
@@ -644,24 +643,10 @@ func AdminReportMemberExcel(w http.ResponseWriter, r *http.Request) {
 
 	// generate the report
 	go func() {
-		var memberList member.Members
-
-		// this one by one search of MySQL is SLOW
-		// for _, id := range memberIDs {
-		// 	fmt.Printf("fetching member id %v\n", id)
-		// 	m, err := member.ByID(DS, id)
-		// 	if err != nil {
-		// 		msg := fmt.Sprintf("Can't find member id %d - err = %s - skipping", id, err)
-		// 		log.Println(msg)
-		// 	}
-		// 	memberList = append(memberList, *m)
-		// }
-
-		// Try MongoDB
-		query := bson.M{"id": bson.M{"$in": memberIDs}}
-		memberList, err := member.SearchDocDB(DS, query)
+		// members are fetched from MySQL together, with one query per relation
+		memberList, err := memberRepo.ByIDs(memberIDs)
 		if err != nil {
-			log.Printf(fmt.Sprintf("SearchDocDB() err = %s\n", err))
+			log.Printf(fmt.Sprintf("memberRepo.ByIDs() err = %s\n", err))
 		}
 
 		excelFile, err := member.ExcelReport(memberList)
@@ -697,11 +682,9 @@ func AdminReportMemberJournalExcel(w http.ResponseWriter, r *http.Request) {
 	p.Send(w)
 
 	go func() {
-		var memberList member.Members
-		query := bson.M{"id": bson.M{"$in": memberIDs}}
-		memberList, err := member.SearchDocDB(DS, query)
+		memberList, err := memberRepo.ByIDs(memberIDs)
 		if err != nil {
-			log.Printf(fmt.Sprintf("SearchDocDB() err = %s\n", err))
+			log.Printf(fmt.Sprintf("memberRepo.ByIDs() err = %s\n", err))
 		}
 
 		excelFile, err := member.ExcelReportJournal(memberList)
@@ -777,7 +760,7 @@ func AdminReportInvoiceExcel(w http.ResponseWriter, r *http.Request) {
 
 	// generate the report
 	go func() {
-		xi, err := invoice.ByIDs(DS, invoiceIDs)
+		xi, err := invoiceRepo.ByIDs(invoiceIDs)
 		if err != nil {
 			log.Printf(fmt.Sprintf("invoice.ByIDs() err = %s\n", err))
 		}
@@ -873,7 +856,7 @@ func AdminLapseMembers(w http.ResponseWriter, r *http.Request) {
 	messages := []string{}
 	// lapse each of the ids
 	for _, id := range memberIDs {
-		m, err := member.ByID(DS, id)
+		m, err := memberRepo.ByID(id)
 		if err != nil {
 			messages = append(messages, fmt.Sprintf("Could not get member id %v", id))
 			continue
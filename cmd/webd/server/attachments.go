@@ -0,0 +1,317 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/attachments"
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/fileset"
+	"github.com/cardiacsociety/web-services/internal/note"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// attachment table names, as used in fs_set.entity_name
+const (
+	activityAttachmentEntity = "ce_m_activity_attachment"
+	noteAttachmentEntity     = "wf_attachment"
+	resourceAttachmentEntity = "ol_resource_file"
+)
+
+// Scanner checks files uploaded via the server before they are stored, and is not used when nil
+var Scanner attachments.Scanner
+
+// MembersActivitiesAttachments lists the attachments for a member activity
+func MembersActivitiesAttachments(w http.ResponseWriter, r *http.Request) {
+	listAttachments(w, r, activityAttachmentEntity)
+}
+
+// MembersActivitiesAttachment issues a short-lived signed url for downloading an activity attachment.
+// Attachments are not publicly accessible so the url must be requested by the owner of the activity.
+func MembersActivitiesAttachment(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	a, ok := attachmentFromPath(w, r, p, activityAttachmentEntity)
+	if !ok {
+		return
+	}
+	if a.URL == "" {
+		p.Message = Message{http.StatusInternalServerError, "failed", "File storage is not configured"}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Signed download url in data.url"}
+	p.Data = map[string]interface{}{
+		"url":     a.URL,
+		"expires": time.Now().Add(storage.DefaultTTL).UTC(),
+	}
+	p.Send(w)
+}
+
+// MembersActivitiesAttachmentDelete deletes an attachment, and the stored file, from a member activity
+func MembersActivitiesAttachmentDelete(w http.ResponseWriter, r *http.Request) {
+	deleteAttachment(w, r, activityAttachmentEntity)
+}
+
+// MembersActivitiesAttachmentUpload receives a file for a member activity as multipart form data
+func MembersActivitiesAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	uploadAttachment(w, r, activityAttachmentEntity)
+}
+
+// AdminNotesAttachments lists the attachments for a note
+func AdminNotesAttachments(w http.ResponseWriter, r *http.Request) {
+	listAttachments(w, r, noteAttachmentEntity)
+}
+
+// AdminNotesAttachmentDelete deletes an attachment, and the stored file, from a note
+func AdminNotesAttachmentDelete(w http.ResponseWriter, r *http.Request) {
+	deleteAttachment(w, r, noteAttachmentEntity)
+}
+
+// AdminNotesAttachmentUpload receives a file for a note as multipart form data
+func AdminNotesAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	uploadAttachment(w, r, noteAttachmentEntity)
+}
+
+// AdminResourcesAttachments lists the attachments for a resource
+func AdminResourcesAttachments(w http.ResponseWriter, r *http.Request) {
+	listAttachments(w, r, resourceAttachmentEntity)
+}
+
+// AdminResourcesAttachmentDelete deletes an attachment, and the stored file, from a resource
+func AdminResourcesAttachmentDelete(w http.ResponseWriter, r *http.Request) {
+	deleteAttachment(w, r, resourceAttachmentEntity)
+}
+
+// AdminResourcesAttachmentUpload receives a file for a resource as multipart form data. If ?thumbnail=1
+// is passed on the url then the resource file is designated as a thumbnail.
+func AdminResourcesAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	var flag string
+	if r.FormValue("thumbnail") == "1" {
+		flag = "thumbnail"
+	}
+	uploadAttachment(w, r, resourceAttachmentEntity, flag)
+}
+
+func listAttachments(w http.ResponseWriter, r *http.Request, entity string) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	id, ok := parentFromPath(w, r, p, entity)
+	if !ok {
+		return
+	}
+
+	xa, err := attachments.ByEntity(DS, entity, id)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", "Database error - " + err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from " + DS.MySQL.Desc}
+	p.Meta = map[string]int{"count": len(xa)}
+	p.Data = xa
+	p.Send(w)
+}
+
+func deleteAttachment(w http.ResponseWriter, r *http.Request, entity string) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	a, ok := attachmentFromPath(w, r, p, entity)
+	if !ok {
+		return
+	}
+
+	if err := a.Delete(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", "Error deleting attachment - " + err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Attachment id %d deleted", a.ID)}
+	p.Send(w)
+}
+
+// uploadAttachment streams the "file" part of a multipart request through attachments.Receive, which
+// enforces the upload policy for the file set and runs the Scanner, and then stores and registers the file.
+// This is an alternative to the signed url flow, where the file goes straight from the client to storage.
+func uploadAttachment(w http.ResponseWriter, r *http.Request, entity string, flags ...string) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	id, ok := parentFromPath(w, r, p, entity)
+	if !ok {
+		return
+	}
+
+	policy, err := attachments.PolicyFor(entity)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+	fs, err := currentFileSet(entity)
+	if err != nil {
+		msg := "Could not determine the storage information for attachments - " + err.Error()
+		p.Message = Message{http.StatusInternalServerError, "failed", msg}
+		p.Send(w)
+		return
+	}
+
+	// allow some room for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, policy.MaxSize+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", "Expected multipart form data - " + err.Error()}
+		p.Send(w)
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = mr.NextPart()
+		if err != nil || part.FormName() == "file" {
+			break
+		}
+	}
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", "No file found in the form field 'file'"}
+		p.Send(w)
+		return
+	}
+	defer part.Close()
+
+	f, err := attachments.Receive(part, part.FileName(), policy, Scanner)
+	if err != nil {
+		p.Message = Message{uploadStatus(err), "failed", "Upload rejected - " + err.Error()}
+		p.Meta = policy
+		p.Send(w)
+		return
+	}
+	defer f.Close()
+
+	a := attachments.New()
+	a.EntityID = id
+	a.UserID = UserAuthToken.Claims.ID
+	a.FileSet = fs
+	if err := a.Upload(DS, f, flags...); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", "Error uploading attachment - " + err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Attachment uploaded and registered"}
+	p.Data = a
+	p.Send(w)
+}
+
+// uploadStatus returns the http status code for an error returned by attachments.Receive
+func uploadStatus(err error) int {
+	switch errors.Cause(err) {
+	case attachments.ErrTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case attachments.ErrType:
+		return http.StatusUnsupportedMediaType
+	case attachments.ErrInfected:
+		return http.StatusUnprocessableEntity
+	case attachments.ErrEmpty:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// currentFileSet returns the file set to which new attachments for entity are uploaded
+func currentFileSet(entity string) (fileset.FileSet, error) {
+	switch entity {
+	case activityAttachmentEntity:
+		return fileset.ActivityAttachment(DS)
+	case noteAttachmentEntity:
+		return fileset.NoteAttachment(DS)
+	}
+	return fileset.ResourceAttachment(DS)
+}
+
+// attachmentFromPath fetches the attachment identified by {attachmentId} in the url path, and checks that it
+// belongs to the parent record identified by {id}.
+func attachmentFromPath(w http.ResponseWriter, r *http.Request, p *Payload, entity string) (a attachments.Attachment, ok bool) {
+
+	id, ok := parentFromPath(w, r, p, entity)
+	if !ok {
+		return a, false
+	}
+
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["attachmentId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", "Missing or malformed attachment id in url path - " + err.Error()}
+		p.Send(w)
+		return a, false
+	}
+
+	a, err = attachments.ByID(DS, entity, attachmentID)
+	if err == sql.ErrNoRows || (err == nil && a.EntityID != id) {
+		msg := fmt.Sprintf("No attachment found with id %d for record id %d", attachmentID, id)
+		p.Message = Message{http.StatusNotFound, "failed", msg}
+		p.Send(w)
+		return a, false
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", "Database error - " + err.Error()}
+		p.Send(w)
+		return a, false
+	}
+
+	return a, true
+}
+
+// parentFromPath returns the id of the record, identified by {id} in the url path, to which attachments
+// belong. It checks that the record exists and, for member activities, that it is owned by the member.
+func parentFromPath(w http.ResponseWriter, r *http.Request, p *Payload, entity string) (int, bool) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", "Missing or malformed id in url path - " + err.Error()}
+		p.Send(w)
+		return 0, false
+	}
+
+	var memberID int
+	switch entity {
+	case activityAttachmentEntity:
+		var a cpd.CPD
+		a, err = cpdRepo.ByID(id)
+		memberID = a.MemberID
+	case noteAttachmentEntity:
+		_, err = note.ByID(DS, id)
+	case resourceAttachmentEntity:
+		_, err = resource.ByID(DS, id)
+	}
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No record found with id %d", id)}
+		p.Send(w)
+		return 0, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", "Database error - " + err.Error()}
+		p.Send(w)
+		return 0, false
+	}
+
+	if entity == activityAttachmentEntity && !AuthorizeID(w, r, memberID) {
+		p.Message = Message{http.StatusUnauthorized, "failed", "Encoded does not belong to the owner of this resource"}
+		p.Send(w)
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// maxCiteIDs is the most resources that can be exported at once
+const maxCiteIDs = 500
+
+// ResourcesCite downloads the citation for a resource, ?format=bibtex|ris|csl-json|apa
+func ResourcesCite(w http.ResponseWriter, r *http.Request) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeCitations(w, r, []int{id})
+}
+
+// ResourcesCiteBulk downloads the citations for a list of resources, ?ids=1,2,3&format=bibtex|ris|csl-json|apa.
+// The citations are in the same order as the ids.
+func ResourcesCiteBulk(w http.ResponseWriter, r *http.Request) {
+
+	var ids []int
+	for _, v := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid resource id %q", v), http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ids is required, eg ?ids=1,2,3", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxCiteIDs {
+		http.Error(w, fmt.Sprintf("No more than %d resources can be exported at once", maxCiteIDs), http.StatusBadRequest)
+		return
+	}
+
+	writeCitations(w, r, ids)
+}
+
+// writeCitations fetches the resources and writes the citations in the format from the query string. The
+// format defaults to bibtex.
+func writeCitations(w http.ResponseWriter, r *http.Request, ids []int) {
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = resource.CiteBibTeX
+	}
+
+	var xc []resource.Citation
+	for _, id := range ids {
+		res, err := resource.ByID(DS, id)
+		switch {
+		case errors.Cause(err) == sql.ErrNoRows:
+			http.Error(w, fmt.Sprintf("No resource found with id %d", id), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		xc = append(xc, res.Citation())
+	}
+
+	body, contentType, fileName, err := resource.Cite(xc, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	w.Write(body)
+}
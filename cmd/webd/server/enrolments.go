@@ -0,0 +1,193 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/module"
+)
+
+// MembersModulesStart enrols the member in a module, or returns their unfinished enrolment so
+// that the module can be resumed
+func MembersModulesStart(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	e, err := module.Start(DS, id, UserAuthToken.Claims.ID)
+	if err != nil && err.Error() == module.ErrorNoModule {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No module found with id %d", id)}
+		p.Send(w)
+		return
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Enrolled in module id %d", id)}
+	p.Data = e
+	p.Send(w)
+}
+
+// MembersModulesEnrolments lists the member's module history
+func MembersModulesEnrolments(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	xe, err := module.MemberEnrolments(DS, UserAuthToken.Claims.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	finished := 0
+	for _, e := range xe {
+		if e.Finished() {
+			finished++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xe), "finished": finished}
+	p.Data = xe
+	p.Send(w)
+}
+
+// MembersModulesEnrolment fetches an enrolment, with the sections of the module and the member's progress
+func MembersModulesEnrolment(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Data = e
+	p.Send(w)
+}
+
+// MembersModulesSection records the completion of a section. The optional JSON body adds to the time
+// spent on the section, eg: {"elapsedSeconds": 120}
+func MembersModulesSection(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	sectionID, err := strconv.Atoi(mux.Vars(r)["sectionId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	var body struct {
+		ElapsedSeconds int `json:"elapsedSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	err = e.CompleteSection(DS, sectionID, body.ElapsedSeconds)
+	if err != nil {
+		p.Message = Message{enrolmentStatus(err), "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Section id %d completed", sectionID)}
+	p.Data = e
+	p.Send(w)
+}
+
+// MembersModulesFinish marks the module as finished and records any CPD for the module
+func MembersModulesFinish(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := e.Finish(DS); err != nil {
+		p.Message = Message{enrolmentStatus(err), "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	msg := "Module finished"
+	if e.CPDID > 0 {
+		msg += fmt.Sprintf(", CPD activity id %d recorded", e.CPDID)
+	}
+	p.Message = Message{http.StatusOK, "success", msg}
+	p.Data = e
+	p.Send(w)
+}
+
+// enrolmentStatus maps the errors from module enrolment methods to a response status
+func enrolmentStatus(err error) int {
+	switch err.Error() {
+	case module.ErrorFinished, module.ErrorIncomplete, module.ErrorNoAttempt:
+		return http.StatusConflict
+	case module.ErrorNoSection:
+		return http.StatusNotFound
+	case module.ErrorNegativeElapsed:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// enrolmentFromPath fetches the enrolment identified by the id in the url path, and ensures it belongs to
+// the member. If the enrolment cannot be fetched the appropriate response is sent and ok is false.
+func enrolmentFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (e module.Enrolment, ok bool) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return e, false
+	}
+
+	e, err = module.EnrolmentByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No enrolment found with id %d", id)}
+		p.Send(w)
+		return e, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return e, false
+	}
+
+	if !AuthorizeID(w, r, e.MemberID) {
+		p.Message = Message{http.StatusUnauthorized, "failed", "Encoded does not belong to the owner of this resource"}
+		p.Send(w)
+		return e, false
+	}
+
+	return e, true
+}
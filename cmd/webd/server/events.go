@@ -0,0 +1,255 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/events"
+)
+
+// calendarName is displayed by calendar clients that subscribe to the events feed
+const calendarName = "CPD Events"
+
+// Events lists events filtered by the optional query params:
+// ?from=[YYYY-MM-DD]&to=[YYYY-MM-DD]&location=[text]
+func Events(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	f, err := eventFilter(r)
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	xe, err := events.Query(DS, f)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xe)}
+	p.Data = xe
+	p.Send(w)
+}
+
+// EventsID fetches a single event
+func EventsID(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Data = e
+	p.Send(w)
+}
+
+// AdminEventsCreate creates a new event from the JSON request body, eg:
+// {"name": "...", "location": "...", "dateStart": "2019-08-08", "dateEnd": "2019-08-11",
+// "description": "...", "url": "https://..."}
+func AdminEventsCreate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	var e events.Event
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	e.ID = 0 // id is set by the database
+
+	if err := e.InsertRow(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	e, err := events.ByID(DS, e.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusCreated, "success", "Event created"}
+	p.Data = e
+	p.Send(w)
+}
+
+// AdminEventsUpdate updates an event with the values in the JSON request body
+func AdminEventsUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id := e.ID
+
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	e.ID = id // not updatable
+
+	if err := e.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	e, err := events.ByID(DS, id)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Event updated"}
+	p.Data = e
+	p.Send(w)
+}
+
+// AdminEventsDelete deletes an event
+func AdminEventsDelete(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := e.Delete(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Event id %d deleted", e.ID)}
+	p.Send(w)
+}
+
+// EventsICal is a public iCalendar feed of events, for subscribing to the calendar. By default it includes
+// events that started in the past year and all future events. The same query params as Events can be used.
+func EventsICal(w http.ResponseWriter, r *http.Request) {
+
+	f, err := eventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.From == "" && f.To == "" {
+		f.From = time.Now().AddDate(-1, 0, 0).Format(events.DateFormat)
+	}
+
+	xe, err := events.Query(DS, f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeICal(w, "events.ics", xe)
+}
+
+// EventICal downloads a single event as an iCalendar file
+func EventICal(w http.ResponseWriter, r *http.Request) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e, err := events.ByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, fmt.Sprintf("No event found with id %d", id), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeICal(w, fmt.Sprintf("event-%d.ics", id), []events.Event{e})
+}
+
+func writeICal(w http.ResponseWriter, fileName string, xe []events.Event) {
+
+	c := events.Calendar{Name: calendarName, Domain: "localhost"}
+	if u, err := url.Parse(os.Getenv("MAPPCPD_API_URL")); err == nil && u.Hostname() != "" {
+		c.Domain = u.Hostname()
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, fileName))
+	if err := c.WriteICal(w, xe); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// eventFromPath fetches the event identified by the id in the url path. If the event cannot
+// be fetched the appropriate response is sent and ok is false.
+func eventFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (e events.Event, ok bool) {
+
+	v := mux.Vars(r)
+	id, err := strconv.Atoi(v["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return e, false
+	}
+
+	e, err = events.ByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No event found with id %d", id)}
+		p.Send(w)
+		return e, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return e, false
+	}
+
+	return e, true
+}
+
+// eventFilter builds an events.Filter from the request query params
+func eventFilter(r *http.Request) (events.Filter, error) {
+
+	f := events.Filter{
+		From:     r.FormValue("from"),
+		To:       r.FormValue("to"),
+		Location: r.FormValue("location"),
+	}
+
+	for k, v := range map[string]string{"from": f.From, "to": f.To} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse(events.DateFormat, v); err != nil {
+			return f, fmt.Errorf("query param %s should be a date in the format YYYY-MM-DD", k)
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// feedTitle is displayed by feed readers that subscribe to the resources feeds
+const feedTitle = "Latest Resources"
+
+// ResourcesAtom is a public Atom feed of the latest resources
+func ResourcesAtom(w http.ResponseWriter, r *http.Request) {
+	writeFeed(w, r, "application/atom+xml; charset=utf-8", resource.Feed.Atom)
+}
+
+// ResourcesRSS is a public RSS 2.0 feed of the latest resources
+func ResourcesRSS(w http.ResponseWriter, r *http.Request) {
+	writeFeed(w, r, "application/rss+xml; charset=utf-8", resource.Feed.RSS)
+}
+
+// ResourcesJSONFeed is a public JSON Feed of the latest resources
+func ResourcesJSONFeed(w http.ResponseWriter, r *http.Request) {
+	writeFeed(w, r, "application/feed+json; charset=utf-8", resource.Feed.JSONFeed)
+}
+
+// writeFeed fetches the latest resources and writes the feed in the format. The resources can be filtered with
+// ?type=name-or-id&keyword=x&category=y, and ?n sets the number of resources, up to resource.FeedMaxLimit.
+// Conditional requests, If-None-Match and If-Modified-Since, are answered with 304 Not Modified if the feed
+// has not changed.
+func writeFeed(w http.ResponseWriter, r *http.Request, contentType string, format func(resource.Feed) ([]byte, error)) {
+
+	q := r.URL.Query()
+	f := resource.FeedFilter{
+		Keyword:  strings.TrimSpace(q.Get("keyword")),
+		Category: strings.TrimSpace(q.Get("category")),
+	}
+	if v := strings.TrimSpace(q.Get("type")); v != "" {
+		if id, err := strconv.Atoi(v); err == nil {
+			f.TypeID = id
+		} else {
+			f.Type = v
+		}
+	}
+	if v := q.Get("n"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "n must be a number greater than 0", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+
+	xr, err := resourceRepo.Latest(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	base := strings.TrimSuffix(os.Getenv("MAPPCPD_API_URL"), "/")
+	feed := resource.Feed{
+		Title:       feedTitle,
+		Description: "The latest resources added to the library",
+		Link:        base,
+		FeedURL:     base + r.URL.RequestURI(),
+		Resources:   xr,
+	}
+	body, err := format(feed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha1.Sum(body)))
+	http.ServeContent(w, r, "", feed.Updated(), bytes.NewReader(body))
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/issue"
+	"github.com/cardiacsociety/web-services/internal/note"
+)
+
+// AdminIssues fetches a list of issues filtered by the optional query params:
+// ?type=&category=&resolved=0|1&member=&association=&associationId=&assigned=
+func AdminIssues(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	f, err := issueFilter(r)
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	xi, err := issue.Query(DS, f)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xi)}
+	p.Data = xi
+	p.Send(w)
+}
+
+// AdminIssuesMine fetches the open issues assigned to the admin user making the request
+func AdminIssuesMine(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	xi, err := issue.ByAssignee(DS, UserAuthToken.Claims.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xi)}
+	p.Data = xi
+	p.Send(w)
+}
+
+// AdminIssuesID fetches a single issue, including the notes threaded on it
+func AdminIssuesID(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := i.SetNotes(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Data = i
+	p.Send(w)
+}
+
+// AdminIssuesAssign assigns an issue to the admin user specified in the request body,
+// eg {"adminId": 1}. An empty body assigns the issue to the admin user making the request.
+func AdminIssuesAssign(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	body := struct {
+		AdminID int `json:"adminId"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+			p.Send(w)
+			return
+		}
+	}
+	if body.AdminID == 0 {
+		body.AdminID = UserAuthToken.Claims.ID
+	}
+
+	if err := i.Assign(DS, body.AdminID); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Issue assigned to admin user id %d", body.AdminID)}
+	p.Data = i
+	p.Send(w)
+}
+
+// AdminIssuesResolve flags an issue as resolved
+func AdminIssuesResolve(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := i.Resolve(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Issue resolved"}
+	p.Data = i
+	p.Send(w)
+}
+
+// AdminIssuesReopen flags a resolved issue as unresolved
+func AdminIssuesReopen(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := i.Reopen(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Issue reopened"}
+	p.Data = i
+	p.Send(w)
+}
+
+// AdminIssuesNotes fetches the notes threaded on an issue
+func AdminIssuesNotes(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := i.SetNotes(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(i.Notes)}
+	p.Data = i.Notes
+	p.Send(w)
+}
+
+// AdminIssuesNotesAdd adds a note to an issue. The request body requires the note
+// type and content, eg {"typeId": 10001, "content": "Called member"}
+func AdminIssuesNotesAdd(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	i, ok := issueFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	body := struct {
+		TypeID  int    `json:"typeId"`
+		Content string `json:"content"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	n, err := i.AddNote(DS, note.Note{TypeID: body.TypeID, Content: body.Content})
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusCreated, "success", "Note added to issue"}
+	p.Data = n
+	p.Send(w)
+}
+
+// issueFromPath fetches the issue identified by the id in the url path. If the issue cannot
+// be fetched the appropriate response is sent and ok is false.
+func issueFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (i issue.Issue, ok bool) {
+
+	v := mux.Vars(r)
+	id, err := strconv.Atoi(v["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return i, false
+	}
+
+	i, err = issue.ByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No issue found with id %d", id)}
+		p.Send(w)
+		return i, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return i, false
+	}
+
+	return i, true
+}
+
+// issueFilter builds an issue.Filter from the request query params
+func issueFilter(r *http.Request) (issue.Filter, error) {
+
+	var f issue.Filter
+	var err error
+
+	ints := map[string]*int{
+		"type":          &f.TypeID,
+		"category":      &f.CategoryID,
+		"member":        &f.MemberID,
+		"associationId": &f.AssociationID,
+		"assigned":      &f.AssignedTo,
+	}
+	for k, ptr := range ints {
+		v := r.FormValue(k)
+		if v == "" {
+			continue
+		}
+		*ptr, err = strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("query param %s should be an integer", k)
+		}
+	}
+
+	f.Association = r.FormValue("association")
+
+	if v := r.FormValue("resolved"); v != "" {
+		resolved, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("query param resolved should be 0 or 1")
+		}
+		f.Resolved = &resolved
+	}
+
+	return f, nil
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	mgo "gopkg.in/mgo.v2"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// ShortLink redirects a short link to the url of the resource, and counts the click. A short link for a
+// resource that is no longer active is gone. The redirect is temporary so that browsers do not cache it,
+// and every click is counted.
+func ShortLink(w http.ResponseWriter, r *http.Request) {
+
+	path := mux.Vars(r)["shortPath"]
+
+	l, err := resource.LinkByShortPath(DS, path)
+	switch {
+	case err == mgo.ErrNotFound:
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id, active, err := l.Resource(DS)
+	switch {
+	case err == sql.ErrNoRows:
+		id = 0
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	case !active:
+		http.Error(w, fmt.Sprintf("The resource for /%s is no longer available", path), http.StatusGone)
+		return
+	}
+
+	// A failure to count the click should not stop the redirect
+	if err := l.Click(DS, id, r.Referer(), time.Now()); err != nil {
+		log.Printf("ShortLink() /%s click err = %s\n", path, err)
+	}
+
+	http.Redirect(w, r, l.LongUrl, http.StatusFound)
+}
+
+// AdminResourcesClicks fetches the short link click analytics for a resource. The period defaults to the
+// last 30 days and can be set with ?from=2006-01-02&to=2006-01-02.
+func AdminResourcesClicks(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -29)
+	q := r.URL.Query()
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			p.Message = Message{http.StatusBadRequest, "failed", "from date must be in the form YYYY-MM-DD"}
+			p.Send(w)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			p.Message = Message{http.StatusBadRequest, "failed", "to date must be in the form YYYY-MM-DD"}
+			p.Send(w)
+			return
+		}
+	}
+
+	cs, err := resource.Clicks(DS, id, from, to)
+	switch {
+	case errors.Cause(err) == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No resource found with id %d", id)}
+		p.Send(w)
+		return
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"total": cs.Total, "clicks": cs.Clicks}
+	p.Data = cs
+	p.Send(w)
+}
+
+// AdminResourcesBrokenLinks reports the resources whose url was broken when it was last checked, including
+// those that were deactivated because the url kept failing
+func AdminResourcesBrokenLinks(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	xlc, err := resource.BrokenLinks(DS)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	deactivated := 0
+	for _, lc := range xlc {
+		if lc.Deactivated {
+			deactivated++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xlc), "deactivated": deactivated}
+	p.Data = xlc
+	p.Send(w)
+}
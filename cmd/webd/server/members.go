@@ -10,7 +10,6 @@ import (
 	"net/http"
 
 	"github.com/cardiacsociety/web-services/internal/cpd"
-	"github.com/cardiacsociety/web-services/internal/member"
 	"github.com/cardiacsociety/web-services/internal/notification"
 	"github.com/cardiacsociety/web-services/internal/platform/email"
 )
@@ -24,7 +23,7 @@ func MembersProfile(w http.ResponseWriter, _ *http.Request) {
 	id := UserAuthToken.Claims.ID
 
 	// Get the Member record
-	m, err := member.ByID(DS, id)
+	m, err := memberRepo.ByID(id)
 	// Response
 	switch {
 	case err == sql.ErrNoRows:
@@ -48,7 +47,7 @@ func MembersActivities(w http.ResponseWriter, _ *http.Request) {
 
 	p := NewResponder(UserAuthToken.Encoded)
 
-	a, err := cpd.ByMemberID(DS, UserAuthToken.Claims.ID)
+	a, err := cpdRepo.ByMemberID(UserAuthToken.Claims.ID)
 
 	// Response
 	switch {
@@ -76,7 +75,7 @@ func MembersEvaluation(w http.ResponseWriter, _ *http.Request) {
 	p := NewResponder(UserAuthToken.Encoded)
 
 	// Collect the evaluation periods
-	es, err := cpd.MemberActivityReports(DS, UserAuthToken.Claims.ID)
+	es, err := cpd.Reports(cpdRepo, activityRepo, UserAuthToken.Claims.ID)
 	// Response
 	switch {
 	case err == sql.ErrNoRows:
@@ -100,7 +99,7 @@ func MembersEvaluation(w http.ResponseWriter, _ *http.Request) {
 func CurrentActivityReport(w http.ResponseWriter, _ *http.Request) {
 
 	p := NewResponder(UserAuthToken.Encoded)
-	reportData, err := cpd.CurrentEvaluationPeriodReport(DS, UserAuthToken.Claims.ID)
+	reportData, err := cpd.CurrentReport(cpdRepo, activityRepo, UserAuthToken.Claims.ID)
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
 		p.Send(w)
@@ -117,7 +116,7 @@ func CurrentActivityReport(w http.ResponseWriter, _ *http.Request) {
 func EmailCurrentActivityReport(w http.ResponseWriter, _ *http.Request) {
 
 	p := NewResponder(UserAuthToken.Encoded)
-	reportData, err := cpd.CurrentEvaluationPeriodReport(DS, UserAuthToken.Claims.ID)
+	reportData, err := cpd.CurrentReport(cpdRepo, activityRepo, UserAuthToken.Claims.ID)
 	if err != nil {
 		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
 		p.Send(w)
@@ -170,7 +169,7 @@ func MemberSendNotification(w http.ResponseWriter, r *http.Request) {
 	p := NewResponder(UserAuthToken.Encoded)
 
 	// member record id in token
-	mem, err := member.ByID(DS, UserAuthToken.Claims.ID)
+	mem, err := memberRepo.ByID(UserAuthToken.Claims.ID)
 	if err != nil {
 		msg := fmt.Sprintf("Could not find member record with id %v", UserAuthToken.Claims.ID)
 		p.Message = Message{http.StatusBadRequest, "failed", msg}
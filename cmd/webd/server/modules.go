@@ -3,6 +3,7 @@ package server
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -32,7 +33,9 @@ func ModulesID(w http.ResponseWriter, r *http.Request) {
 	default:
 		p.Message = Message{http.StatusOK, "success", "Data retrieved from ???"}
 		p.Data = m
-		module.SyncModule(DS, m)
+		if m.Published {
+			module.SyncModule(DS, m)
+		}
 	}
 
 	p.Send(w)
@@ -68,3 +71,345 @@ func ModulesCollection(w http.ResponseWriter, r *http.Request) {
 	p.Data = res
 	p.Send(w)
 }
+
+// AdminModulesCreate creates a new, unpublished module from the JSON request body, eg:
+// {"name": "...", "description": "...", "objective": "...", "instruction": "...", "durationMinutes": 60}
+// POST /modules is the search of the modules collection, so this is POST /modules/new.
+func AdminModulesCreate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	var m module.Module
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	m.ID = 0 // id is set by the database
+
+	if err := m.InsertRow(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	m2, err := module.ByID(DS, m.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusCreated, "success", "Module created"}
+	p.Data = m2
+	p.Send(w)
+}
+
+// AdminModulesUpdate updates a module with the values in the JSON request body. Sections and resources
+// are updated with their own endpoints.
+func AdminModulesUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id := m.ID
+
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	m.ID = id // not updatable
+
+	if err := m.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Module updated"}
+	p.Data = m
+	p.Send(w)
+}
+
+// AdminModulesPublish makes a module available to members, and updates the modules collection
+func AdminModulesPublish(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	err := m.Publish(DS)
+	if err != nil && err.Error() == module.ErrorNoSections {
+		p.Message = Message{http.StatusConflict, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Module id %d published", m.ID)}
+	p.Data = m
+	p.Send(w)
+}
+
+// AdminModulesUnpublish withdraws a module from members, and removes it from the modules collection
+func AdminModulesUnpublish(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := m.Unpublish(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Module id %d unpublished", m.ID)}
+	p.Data = m
+	p.Send(w)
+}
+
+// AdminModulesSectionCreate adds a section to a module from the JSON request body, eg:
+// {"summary": "...", "content": "<p>...</p>"}. The section is added after the existing sections
+// unless a sequence is specified.
+func AdminModulesSectionCreate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	var s module.Section
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	s.ID = 0 // id is set by the database
+	s.ModuleID = m.ID
+
+	if err := s.InsertRow(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	sendModuleSections(w, p, m.ID, http.StatusCreated, "Section created")
+}
+
+// AdminModulesSectionUpdate updates a section with the values in the JSON request body
+func AdminModulesSectionUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	s, ok := sectionFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id, moduleID := s.ID, s.ModuleID
+
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	s.ID, s.ModuleID = id, moduleID // not updatable
+
+	if err := s.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	sendModuleSections(w, p, moduleID, http.StatusOK, "Section updated")
+}
+
+// AdminModulesSectionDelete removes a section from a module
+func AdminModulesSectionDelete(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	s, ok := sectionFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := s.Delete(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	sendModuleSections(w, p, s.ModuleID, http.StatusOK, fmt.Sprintf("Section id %d deleted", s.ID))
+}
+
+// AdminModulesSectionsOrder sets the order of the sections of a module from a JSON array of all of the
+// section ids, eg: [3, 1, 2]
+func AdminModulesSectionsOrder(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	var ids []int
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	if err := module.OrderSections(DS, m.ID, ids); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == module.ErrorSectionOrder {
+			status = http.StatusBadRequest
+		}
+		p.Message = Message{status, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	sendModuleSections(w, p, m.ID, http.StatusOK, "Sections ordered")
+}
+
+// AdminModulesResources replaces the resources listed with a module with a JSON array of resource ids,
+// in the order they should be listed, eg: [6576, 6578]
+func AdminModulesResources(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	m, ok := moduleFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	var ids []int
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	if err := module.SetResources(DS, m.ID, ids); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == module.ErrorNoResource {
+			status = http.StatusBadRequest
+		}
+		p.Message = Message{status, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	m, err := module.ByID(DS, m.ID)
+	if err == nil && m.Published {
+		err = m.SaveDoc(DS)
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Resources updated"}
+	p.Meta = map[string]int{"count": len(m.Resources)}
+	p.Data = m.Resources
+	p.Send(w)
+}
+
+// sendModuleSections responds with the sections of a module after they have been changed. If the module
+// is published the document is updated.
+func sendModuleSections(w http.ResponseWriter, p *Payload, moduleID, status int, msg string) {
+
+	m, err := module.ByID(DS, moduleID)
+	if err == nil && m.Published {
+		err = m.SaveDoc(DS)
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{status, "success", msg}
+	p.Meta = map[string]int{"count": len(m.Sections)}
+	p.Data = m.Sections
+	p.Send(w)
+}
+
+// moduleFromPath fetches the module identified by the id in the url path. If the module cannot be fetched
+// the appropriate response is sent and ok is false.
+func moduleFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (m *module.Module, ok bool) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return m, false
+	}
+
+	m, err = module.ByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No module found with id %d", id)}
+		p.Send(w)
+		return m, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return m, false
+	}
+
+	return m, true
+}
+
+// sectionFromPath fetches the section identified by the sectionId in the url path, and ensures it is a
+// section of the module identified by id. If the section cannot be fetched the appropriate response is
+// sent and ok is false.
+func sectionFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (s module.Section, ok bool) {
+
+	v := mux.Vars(r)
+	moduleID, err := strconv.Atoi(v["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return s, false
+	}
+	id, err := strconv.Atoi(v["sectionId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return s, false
+	}
+
+	s, err = module.SectionByID(DS, id)
+	if err == sql.ErrNoRows || (err == nil && s.ModuleID != moduleID) {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No section found with id %d", id)}
+		p.Send(w)
+		return s, false
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return s, false
+	}
+
+	return s, true
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/note"
+)
+
+// AdminNotesSearch searches notes by the optional query params:
+// ?q=[terms]&member=[id]&type=[id]&from=[YYYY-MM-DD]&to=[YYYY-MM-DD]
+// The terms in q are matched against note content and attachment file names.
+func AdminNotesSearch(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	f, err := noteFilter(r)
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	xn, err := note.Search(DS, f)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xn)}
+	p.Data = xn
+	p.Send(w)
+}
+
+// AdminNoteTypes fetches the list of active note types
+func AdminNoteTypes(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	xt, err := note.Types(DS)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xt)}
+	p.Data = xt
+	p.Send(w)
+}
+
+// AdminNotesCreate creates a new note from the JSON request body, eg:
+// {"memberId": 1, "typeId": 10001, "content": "...", "dateEffective": "2019-01-01",
+// "association": "application", "associationId": 1}
+func AdminNotesCreate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	var n note.Note
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	n.ID = 0 // id is set by the database
+
+	if err := n.InsertRow(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	n, err := note.ByID(DS, n.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusCreated, "success", "Note created"}
+	p.Data = n
+	p.Send(w)
+}
+
+// AdminNotesUpdate updates the type, content, effective date and association of a note
+// with the values in the JSON request body.
+func AdminNotesUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	n, ok := noteFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id, memberID := n.ID, n.MemberID
+
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	n.ID, n.MemberID = id, memberID // not updatable
+
+	if err := n.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	n, err := note.ByID(DS, id)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Note updated"}
+	p.Data = n
+	p.Send(w)
+}
+
+// AdminNotesDelete deletes a note
+func AdminNotesDelete(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	n, ok := noteFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := n.Delete(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Note id %d deleted", n.ID)}
+	p.Send(w)
+}
+
+// noteFromPath fetches the note identified by the id in the url path. If the note cannot
+// be fetched the appropriate response is sent and ok is false.
+func noteFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (n note.Note, ok bool) {
+
+	v := mux.Vars(r)
+	id, err := strconv.Atoi(v["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return n, false
+	}
+
+	n, err = note.ByID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No note found with id %d", id)}
+		p.Send(w)
+		return n, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return n, false
+	}
+
+	return n, true
+}
+
+// noteFilter builds a note.Filter from the request query params
+func noteFilter(r *http.Request) (note.Filter, error) {
+
+	var err error
+	f := note.Filter{
+		Text: r.FormValue("q"),
+		From: r.FormValue("from"),
+		To:   r.FormValue("to"),
+	}
+
+	if v := r.FormValue("member"); v != "" {
+		f.MemberID, err = strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("query param member should be an integer")
+		}
+	}
+	if v := r.FormValue("type"); v != "" {
+		f.TypeID, err = strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("query param type should be an integer")
+		}
+	}
+	for k, v := range map[string]string{"from": f.From, "to": f.To} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return f, fmt.Errorf("query param %s should be a date in the format YYYY-MM-DD", k)
+		}
+	}
+
+	return f, nil
+}
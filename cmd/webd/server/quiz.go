@@ -0,0 +1,396 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/module"
+)
+
+// MembersModulesAttemptStart starts an attempt at the module quiz, or returns the attempt in progress
+func MembersModulesAttemptStart(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	a, err := e.StartAttempt(DS)
+	if err != nil {
+		p.Message = Message{quizStatus(err), "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Attempt id %d in progress", a.ID)}
+	p.Meta = map[string]int{"count": len(a.Questions)}
+	p.Data = a
+	p.Send(w)
+}
+
+// MembersModulesAttempts lists the quiz attempts for an enrolment
+func MembersModulesAttempts(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	xa, err := module.Attempts(DS, e.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	passed := 0
+	for _, a := range xa {
+		if a.Passed {
+			passed++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xa), "passed": passed}
+	p.Data = xa
+	p.Send(w)
+}
+
+// MembersModulesAttempt fetches a quiz attempt. The answers and results are included once it is submitted.
+func MembersModulesAttempt(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	a, ok := attemptFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Data = a
+	p.Send(w)
+}
+
+// MembersModulesAttemptSubmit scores the answers in the JSON request body, eg:
+// [{"questionId": 5, "optionIds": [1]}, {"questionId": 6, "optionIds": [4, 5, 6]}]
+func MembersModulesAttemptSubmit(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	a, ok := attemptFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	var answers []module.Answer
+	if err := json.NewDecoder(r.Body).Decode(&answers); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	if err := a.Submit(DS, answers); err != nil {
+		p.Message = Message{quizStatus(err), "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	msg := fmt.Sprintf("Attempt scored %v%%", a.Percentage)
+	if a.Passed {
+		msg += ", passed"
+	}
+	p.Message = Message{http.StatusOK, "success", msg}
+	p.Data = a
+	p.Send(w)
+}
+
+// AdminModulesQuiz fetches the quiz settings and question bank for a module
+func AdminModulesQuiz(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	q, ok := quizFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(q.Questions)}
+	p.Data = q
+	p.Send(w)
+}
+
+// AdminModulesQuizUpdate updates the quiz settings for a module from the JSON request body, eg:
+// {"passPercentage": 60, "questionCount": 10, "attemptLimit": 3, "attemptLimitResetDays": 30}
+func AdminModulesQuizUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	q, ok := quizFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id := q.ModuleID
+
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	q.ModuleID = id // not updatable
+
+	if err := q.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	q, err := module.QuizByModuleID(DS, id)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Quiz updated"}
+	p.Data = q
+	p.Send(w)
+}
+
+// AdminModulesQuestionCreate adds a question to the question bank for a module, eg:
+// {"type": "single", "question": "...", "options": [{"text": "...", "correct": true}, {"text": "..."}]}
+func AdminModulesQuestionCreate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	quiz, ok := quizFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	var q module.Question
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	q.ID = 0 // id is set by the database
+	q.ModuleID = quiz.ModuleID
+	if q.Sequence == 0 {
+		q.Sequence = len(quiz.Questions) + 1
+	}
+
+	if err := q.InsertRow(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	q, err := module.QuestionByID(DS, q.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusCreated, "success", "Question created"}
+	p.Data = q
+	p.Send(w)
+}
+
+// AdminModulesQuestionUpdate updates a question with the values in the JSON request body. The options
+// in the body replace the existing options.
+func AdminModulesQuestionUpdate(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	q, ok := questionFromPath(w, r, p)
+	if !ok {
+		return
+	}
+	id, moduleID := q.ID, q.ModuleID
+
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+	q.ID, q.ModuleID = id, moduleID // not updatable
+
+	if err := q.Update(DS); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	q, err := module.QuestionByID(DS, id)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Question updated"}
+	p.Data = q
+	p.Send(w)
+}
+
+// AdminModulesQuestionDelete removes a question from the question bank for a module
+func AdminModulesQuestionDelete(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	q, ok := questionFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := q.Delete(DS); err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Question id %d deleted", q.ID)}
+	p.Send(w)
+}
+
+// AdminModulesQuizReport reports on the difficulty of the questions in the question bank for a module,
+// from the submitted attempts
+func AdminModulesQuizReport(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	q, ok := quizFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	xs, err := module.ItemDifficulty(DS, q.ModuleID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xs)}
+	p.Data = xs
+	p.Send(w)
+}
+
+// quizStatus maps the errors from quiz methods to a response status
+func quizStatus(err error) int {
+	switch err.Error() {
+	case module.ErrorFinished, module.ErrorPassed, module.ErrorSubmitted:
+		return http.StatusConflict
+	case module.ErrorAttemptLimit:
+		return http.StatusForbidden
+	case module.ErrorNoModule, module.ErrorNoQuiz:
+		return http.StatusNotFound
+	case module.ErrorAnswerQuestion, module.ErrorAnswerOption, module.ErrorAnswerSingle:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// attemptFromPath fetches the attempt identified by the attemptId in the url path, and ensures it is part of
+// the enrolment identified by id, which belongs to the member. If the attempt cannot be fetched the
+// appropriate response is sent and ok is false.
+func attemptFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (a module.Attempt, ok bool) {
+
+	e, ok := enrolmentFromPath(w, r, p)
+	if !ok {
+		return a, false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["attemptId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return a, false
+	}
+
+	a, err = module.AttemptByID(DS, id)
+	if err == sql.ErrNoRows || (err == nil && a.EnrolmentID != e.ID) {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No attempt found with id %d", id)}
+		p.Send(w)
+		return a, false
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return a, false
+	}
+
+	return a, true
+}
+
+// quizFromPath fetches the quiz for the module identified by the id in the url path. If the quiz cannot be
+// fetched the appropriate response is sent and ok is false.
+func quizFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (q module.Quiz, ok bool) {
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return q, false
+	}
+
+	q, err = module.QuizByModuleID(DS, id)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No module found with id %d", id)}
+		p.Send(w)
+		return q, false
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return q, false
+	}
+
+	return q, true
+}
+
+// questionFromPath fetches the question identified by the questionId in the url path, and ensures it is in
+// the question bank for the module identified by id. If the question cannot be fetched the appropriate
+// response is sent and ok is false.
+func questionFromPath(w http.ResponseWriter, r *http.Request, p *Payload) (q module.Question, ok bool) {
+
+	v := mux.Vars(r)
+	moduleID, err := strconv.Atoi(v["id"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return q, false
+	}
+	id, err := strconv.Atoi(v["questionId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return q, false
+	}
+
+	q, err = module.QuestionByID(DS, id)
+	if err == sql.ErrNoRows || (err == nil && q.ModuleID != moduleID) {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No question found with id %d", id)}
+		p.Send(w)
+		return q, false
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return q, false
+	}
+
+	return q, true
+}
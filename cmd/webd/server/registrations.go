@@ -0,0 +1,440 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/events"
+	"github.com/cardiacsociety/web-services/internal/notification"
+)
+
+// TicketKey signs and verifies the QR codes on event tickets, and is set from events.TicketKey
+var TicketKey []byte
+
+// sender of the event registration emails
+const (
+	registrationFromName  = "CPD Events"
+	registrationFromEmail = "system@mappcpd.com"
+)
+
+// MembersEventsRegister registers the member for an event, or places them on the waitlist if the event is full
+func MembersEventsRegister(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	reg, err := events.Register(DS, e.ID, UserAuthToken.Claims.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	msg := "Registered for event"
+	if reg.Status == events.StatusWaitlisted {
+		msg = "Event is full, added to the waitlist"
+	}
+	if err := sendRegistrationEmail(e, reg); err != nil {
+		msg += fmt.Sprintf(" - could not send the confirmation email: %s", err)
+	}
+	p.Message = Message{http.StatusOK, "success", msg}
+	p.Data = reg
+	p.Send(w)
+}
+
+// MembersEventsCancel cancels the member's registration for an event
+func MembersEventsCancel(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	reg, err := events.MemberRegistration(DS, e.ID, UserAuthToken.Claims.ID)
+	switch {
+	case err == sql.ErrNoRows:
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("Not registered for event id %d", e.ID)}
+		p.Send(w)
+		return
+	case err != nil:
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	err = reg.Cancel(DS)
+	if err != nil && (err.Error() == events.ErrorCancelAttended || err.Error() == events.ErrorAlreadyCancelled) {
+		p.Message = Message{http.StatusConflict, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Registration cancelled"}
+	p.Data = reg
+	p.Send(w)
+}
+
+// MembersEventsTicket downloads the PDF ticket for the member's registration, which has the QR code
+// that is scanned to check in at the event
+func MembersEventsTicket(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	reg, err := events.MemberRegistration(DS, e.ID, UserAuthToken.Claims.ID)
+	if err == sql.ErrNoRows || (err == nil && reg.Status != events.StatusRegistered && reg.Status != events.StatusAttended) {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No confirmed registration for event id %d", e.ID)}
+		p.Send(w)
+		return
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	xb, err := ticketPDF(e, reg)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d.pdf"`, reg.ID))
+	w.Write(xb)
+}
+
+// MembersEventsRegistrations lists the member's event registrations
+func MembersEventsRegistrations(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	xr, err := events.MemberRegistrations(DS, UserAuthToken.Claims.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = map[string]int{"count": len(xr)}
+	p.Data = xr
+	p.Send(w)
+}
+
+// AdminEventsRegistrations lists the registrations for an event, including the waitlist and attendance
+func AdminEventsRegistrations(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	xr, err := events.Registrations(DS, e.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	meta := map[string]int{"count": len(xr)}
+	for _, reg := range xr {
+		meta[reg.Status]++
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = meta
+	p.Data = xr
+	p.Send(w)
+}
+
+// AdminEventsAttendance marks a single member as having attended an event, and records the event CPD
+func AdminEventsAttendance(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(mux.Vars(r)["memberId"])
+	if err != nil {
+		p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	reg, err := events.MarkAttended(DS, e.ID, memberID)
+	if err != nil && err.Error() == events.ErrorNoMember {
+		p.Message = Message{http.StatusNotFound, "failed", fmt.Sprintf("No member found with id %d", memberID)}
+		p.Send(w)
+		return
+	}
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Attendance recorded"}
+	p.Data = reg
+	p.Send(w)
+}
+
+// AdminEventsAttendanceBulk marks a list of members as having attended an event. The body is either a
+// JSON array of member ids, eg [1, 2, 3], or a CSV file (Content-Type text/csv) with the member id in
+// the first column. A CSV header row is skipped. The result for each member is returned in the data.
+func AdminEventsAttendanceBulk(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	defer r.Body.Close()
+	var ids []int
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "text/csv" {
+		var err error
+		ids, err = memberIDsFromCSV(r.Body)
+		if err != nil {
+			p.Message = Message{http.StatusBadRequest, "failed", err.Error()}
+			p.Send(w)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	xr := events.MarkAttendedBulk(DS, e.ID, ids)
+	var failed int
+	for _, res := range xr {
+		if res.Error != "" {
+			failed++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Attendance recorded for %d of %d members", len(xr)-failed, len(xr))}
+	p.Meta = map[string]int{"count": len(xr), "failed": failed}
+	p.Data = xr
+	p.Send(w)
+}
+
+// AdminEventsCheckIn records a single ticket scan, eg:
+// {"token": "1.23.456.abc...", "scannedAt": "2019-08-08T08:45:00+09:30", "device": "door-1"}
+func AdminEventsCheckIn(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	var s events.Scan
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	c, err := events.CheckInScan(DS, s, TicketKey)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case events.ErrorTicket:
+			status = http.StatusForbidden
+		case events.ErrorNotRegistered:
+			status = http.StatusConflict
+		case events.ErrorScannedAt:
+			status = http.StatusBadRequest
+		}
+		p.Message = Message{status, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	msg := "Checked in"
+	if c.Duplicate {
+		msg = "Duplicate scan, already checked in"
+	}
+	p.Message = Message{http.StatusOK, "success", msg}
+	p.Data = c
+	p.Send(w)
+}
+
+// AdminEventsCheckInSync records a batch of ticket scans made by a device while offline. The body is
+// a JSON array of scans in the same format as AdminEventsCheckIn. The result for each scan is returned
+// in the data, and sending the same batch again does not record the scans twice.
+func AdminEventsCheckInSync(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	var xs []events.Scan
+	if err := json.NewDecoder(r.Body).Decode(&xs); err != nil {
+		p.Message = Message{http.StatusBadRequest, "failure", errMessageDecodeJSON}
+		p.Send(w)
+		return
+	}
+
+	xr := events.SyncCheckIns(DS, xs, TicketKey)
+	meta := map[string]int{"count": len(xr)}
+	for _, res := range xr {
+		switch {
+		case res.Error != "":
+			meta["failed"]++
+		case res.CheckIn.Duplicate:
+			meta["duplicate"]++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", fmt.Sprintf("Synced %d scans", len(xr))}
+	p.Meta = meta
+	p.Data = xr
+	p.Send(w)
+}
+
+// AdminEventsCheckIns lists the ticket scans for an event, including duplicates
+func AdminEventsCheckIns(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	e, ok := eventFromPath(w, r, p)
+	if !ok {
+		return
+	}
+
+	xc, err := events.CheckIns(DS, e.ID)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	meta := map[string]int{"count": len(xc)}
+	for _, c := range xc {
+		if c.Duplicate {
+			meta["duplicate"]++
+		}
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from MySQL"}
+	p.Meta = meta
+	p.Data = xc
+	p.Send(w)
+}
+
+// sendRegistrationEmail emails the member to confirm a registration, with the ticket attached,
+// or to let them know they are on the waitlist
+func sendRegistrationEmail(e events.Event, reg events.Registration) error {
+
+	m, err := memberRepo.ByID(reg.MemberID)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s %s", m.FirstName, m.LastName)
+
+	em := notification.Email{
+		FromName:  registrationFromName,
+		FromEmail: registrationFromEmail,
+		ToName:    name,
+		ToEmail:   m.Contact.EmailPrimary,
+	}
+
+	if reg.Status == events.StatusWaitlisted {
+		em.Subject = fmt.Sprintf("Waitlisted: %s", e.Name)
+		em.PlainContent = fmt.Sprintf("Dear %s,\n\n%s is full and you have been added to the waitlist. "+
+			"We will let you know if a place becomes available.", name, e.Name)
+		em.HTMLContent = strings.Replace(em.PlainContent, "\n", "<br>", -1)
+		return em.Send()
+	}
+
+	xb, err := ticketPDF(e, reg)
+	if err != nil {
+		return err
+	}
+	em.Subject = fmt.Sprintf("Registration confirmed: %s", e.Name)
+	em.PlainContent = fmt.Sprintf("Dear %s,\n\nYour registration for %s is confirmed. "+
+		"Please find your ticket attached, and show the QR code to check in at the event.", name, e.Name)
+	em.HTMLContent = strings.Replace(em.PlainContent, "\n", "<br>", -1)
+	em.Attachments = []notification.Attachment{
+		{
+			MIMEType:      "application/pdf",
+			FileName:      fmt.Sprintf("ticket-%d.pdf", reg.ID),
+			Base64Content: base64.StdEncoding.EncodeToString(xb),
+		},
+	}
+	return em.Send()
+}
+
+// ticketPDF returns the PDF ticket for a registration
+func ticketPDF(e events.Event, reg events.Registration) ([]byte, error) {
+	m, err := memberRepo.ByID(reg.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	name := fmt.Sprintf("%s %s", m.FirstName, m.LastName)
+	err = events.WriteTicket(&b, e, reg, name, TicketKey)
+	return b.Bytes(), err
+}
+
+// memberIDsFromCSV reads member ids from the first column of a CSV file, skipping a header row
+func memberIDsFromCSV(r io.Reader) ([]int, error) {
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var ids []int
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		v := strings.TrimSpace(rec[0])
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: member id %q is not a number", line, v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
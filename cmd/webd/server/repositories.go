@@ -0,0 +1,28 @@
+package server
+
+import (
+	"github.com/cardiacsociety/web-services/internal/activity"
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/invoice"
+	"github.com/cardiacsociety/web-services/internal/member"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/resource"
+)
+
+// Repositories used by the handlers. Router sets them from the datastore, and tests can replace them with
+// the in-memory fakes from each package.
+var (
+	activityRepo activity.Repository
+	cpdRepo      cpd.Repository
+	invoiceRepo  invoice.Repository
+	memberRepo   member.Repository
+	resourceRepo resource.Repository
+)
+
+func setRepositories(ds datastore.Datastore) {
+	activityRepo = activity.NewStore(ds)
+	cpdRepo = cpd.NewStore(ds)
+	invoiceRepo = invoice.NewStore(ds)
+	memberRepo = member.NewStore(ds)
+	resourceRepo = resource.NewStore(ds)
+}
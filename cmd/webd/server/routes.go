@@ -30,7 +30,36 @@ func AdminSubRouter(prefix string) *mux.Router {
 	admin.Methods("GET").Path("/members/{id:[0-9]+}").HandlerFunc(AdminMembersID)
 	//admin.Methods("POST").Path("/members/{id:[0-9]+}").HandlerFunc(AdminMembersUpdate)
 	admin.Methods("GET").Path("/members/{id:[0-9]+}/notes").HandlerFunc(AdminMembersNotes)
+	admin.Methods("GET").Path("/notes").HandlerFunc(AdminNotesSearch)
+	admin.Methods("POST").Path("/notes").HandlerFunc(AdminNotesCreate)
+	admin.Methods("GET").Path("/notes/types").HandlerFunc(AdminNoteTypes)
 	admin.Methods("GET").Path("/notes/{id:[0-9]+}").HandlerFunc(AdminNotes)
+	admin.Methods("PUT").Path("/notes/{id:[0-9]+}").HandlerFunc(AdminNotesUpdate)
+	admin.Methods("DELETE").Path("/notes/{id:[0-9]+}").HandlerFunc(AdminNotesDelete)
+
+	// Workflow issues
+	admin.Methods("GET").Path("/issues").HandlerFunc(AdminIssues)
+	admin.Methods("GET").Path("/issues/mine").HandlerFunc(AdminIssuesMine)
+	admin.Methods("GET").Path("/issues/{id:[0-9]+}").HandlerFunc(AdminIssuesID)
+	admin.Methods("PUT").Path("/issues/{id:[0-9]+}/assign").HandlerFunc(AdminIssuesAssign)
+	admin.Methods("PUT").Path("/issues/{id:[0-9]+}/resolve").HandlerFunc(AdminIssuesResolve)
+	admin.Methods("PUT").Path("/issues/{id:[0-9]+}/reopen").HandlerFunc(AdminIssuesReopen)
+	admin.Methods("GET").Path("/issues/{id:[0-9]+}/notes").HandlerFunc(AdminIssuesNotes)
+	admin.Methods("POST").Path("/issues/{id:[0-9]+}/notes").HandlerFunc(AdminIssuesNotesAdd)
+
+	// Events
+	admin.Methods("GET").Path("/events").HandlerFunc(Events)
+	admin.Methods("POST").Path("/events").HandlerFunc(AdminEventsCreate)
+	admin.Methods("GET").Path("/events/{id:[0-9]+}").HandlerFunc(EventsID)
+	admin.Methods("PUT").Path("/events/{id:[0-9]+}").HandlerFunc(AdminEventsUpdate)
+	admin.Methods("DELETE").Path("/events/{id:[0-9]+}").HandlerFunc(AdminEventsDelete)
+	admin.Methods("GET").Path("/events/{id:[0-9]+}/registrations").HandlerFunc(AdminEventsRegistrations)
+	admin.Methods("POST").Path("/events/{id:[0-9]+}/attendance").HandlerFunc(AdminEventsAttendanceBulk)
+	admin.Methods("PUT").Path("/events/{id:[0-9]+}/attendance/{memberId:[0-9]+}").HandlerFunc(AdminEventsAttendance)
+	admin.Methods("GET").Path("/events/{id:[0-9]+}/checkins").HandlerFunc(AdminEventsCheckIns)
+	admin.Methods("POST").Path("/events/checkin").HandlerFunc(AdminEventsCheckIn)
+	admin.Methods("POST").Path("/events/checkin/sync").HandlerFunc(AdminEventsCheckInSync)
+
 	admin.Methods("GET").Path("/organisations").HandlerFunc(AllOrganisations)
 	admin.Methods("GET").Path("/organisations/{id:[0-9]+}").HandlerFunc(OrganisationByID)
 
@@ -40,15 +69,43 @@ func AdminSubRouter(prefix string) *mux.Router {
 	admin.Methods("GET").Path("/modules/{id:[0-9]+}").HandlerFunc(ModulesID)
 	admin.Methods("POST").Path("/modules").HandlerFunc(ModulesCollection)
 
+	// Module authoring
+	admin.Methods("POST").Path("/modules/new").HandlerFunc(AdminModulesCreate)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}").HandlerFunc(AdminModulesUpdate)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/publish").HandlerFunc(AdminModulesPublish)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/unpublish").HandlerFunc(AdminModulesUnpublish)
+	admin.Methods("POST").Path("/modules/{id:[0-9]+}/sections").HandlerFunc(AdminModulesSectionCreate)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/sections").HandlerFunc(AdminModulesSectionsOrder)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/sections/{sectionId:[0-9]+}").HandlerFunc(AdminModulesSectionUpdate)
+	admin.Methods("DELETE").Path("/modules/{id:[0-9]+}/sections/{sectionId:[0-9]+}").HandlerFunc(AdminModulesSectionDelete)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/resources").HandlerFunc(AdminModulesResources)
+
+	// Module quiz
+	admin.Methods("GET").Path("/modules/{id:[0-9]+}/quiz").HandlerFunc(AdminModulesQuiz)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/quiz").HandlerFunc(AdminModulesQuizUpdate)
+	admin.Methods("GET").Path("/modules/{id:[0-9]+}/quiz/report").HandlerFunc(AdminModulesQuizReport)
+	admin.Methods("POST").Path("/modules/{id:[0-9]+}/quiz/questions").HandlerFunc(AdminModulesQuestionCreate)
+	admin.Methods("PUT").Path("/modules/{id:[0-9]+}/quiz/questions/{questionId:[0-9]+}").HandlerFunc(AdminModulesQuestionUpdate)
+	admin.Methods("DELETE").Path("/modules/{id:[0-9]+}/quiz/questions/{questionId:[0-9]+}").HandlerFunc(AdminModulesQuestionDelete)
+
 	// Note Attachments
 	admin.Methods("OPTIONS").Path("/notes/{id:[0-9]+}/attachments/request").HandlerFunc(Preflight)
 	admin.Methods("GET").Path("/notes/{id:[0-9]+}/attachments/request").HandlerFunc(AdminNotesAttachmentRequest)
 	admin.Methods("PUT").Path("/notes/{id:[0-9]+}/attachments").HandlerFunc(AdminNotesAttachmentRegister)
+	admin.Methods("GET").Path("/notes/{id:[0-9]+}/attachments").HandlerFunc(AdminNotesAttachments)
+	admin.Methods("POST").Path("/notes/{id:[0-9]+}/attachments/upload").HandlerFunc(AdminNotesAttachmentUpload)
+	admin.Methods("DELETE").Path("/notes/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}").HandlerFunc(AdminNotesAttachmentDelete)
 
 	// Resource Attachments
 	admin.Methods("OPTIONS").Path("/resources/{id:[0-9]+}/attachments/request").HandlerFunc(Preflight)
 	admin.Methods("GET").Path("/resources/{id:[0-9]+}/attachments/request").HandlerFunc(AdminResourcesAttachmentRequest)
 	admin.Methods("PUT").Path("/resources/{id:[0-9]+}/attachments").HandlerFunc(AdminResourcesAttachmentRegister)
+	admin.Methods("GET").Path("/resources/{id:[0-9]+}/attachments").HandlerFunc(AdminResourcesAttachments)
+	admin.Methods("POST").Path("/resources/{id:[0-9]+}/attachments/upload").HandlerFunc(AdminResourcesAttachmentUpload)
+	admin.Methods("DELETE").Path("/resources/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}").HandlerFunc(AdminResourcesAttachmentDelete)
+	admin.Methods("GET").Path("/resources/{id:[0-9]+}/clicks").HandlerFunc(AdminResourcesClicks)
+	admin.Methods("GET").Path("/resources/links/broken").HandlerFunc(AdminResourcesBrokenLinks)
+	admin.Methods("GET").Path("/search/{index}").HandlerFunc(AdminSearchIndex)
 
 	// Batch routes for bulk uploading
 	admin.Methods("POST").Path("/batch/resources").HandlerFunc(AdminBatchResourcesPost)
@@ -112,6 +169,13 @@ func GeneralSubRouter(prefix string) *mux.Router {
 	general.Methods("GET").Path("/resources/{id:[0-9]+}").HandlerFunc(ResourcesID)
 	general.Methods("POST").Path("/resources").HandlerFunc(ResourcesCollection)
 	general.Methods("GET").Path("/resources/latest/{n:[0-9]+}").HandlerFunc(ResourcesLatest)
+	general.Methods("GET").Path("/resources/{id:[0-9]+}/cite").HandlerFunc(ResourcesCite)
+	general.Methods("GET").Path("/resources/cite").HandlerFunc(ResourcesCiteBulk)
+	general.Methods("GET").Path("/search/{index}").HandlerFunc(SearchIndex)
+
+	// Events
+	general.Methods("GET").Path("/events").HandlerFunc(Events)
+	general.Methods("GET").Path("/events/{id:[0-9]+}").HandlerFunc(EventsID)
 
 	// Modules
 	general.Methods("GET").Path("/modules/{id:[0-9]+}").HandlerFunc(ModulesID)
@@ -160,6 +224,12 @@ func MemberSubRouter(prefix string) *mux.Router {
 	members.Methods("GET").Path("/activities/{id:[0-9]+}/attachments/request").HandlerFunc(MembersActivitiesAttachmentRequest)
 	// This is idempotent, hence PUT
 	members.Methods("PUT").Path("/activities/{id:[0-9]+}/attachments").HandlerFunc(MembersActivitiesAttachmentRegister)
+	members.Methods("GET").Path("/activities/{id:[0-9]+}/attachments").HandlerFunc(MembersActivitiesAttachments)
+	members.Methods("OPTIONS").Path("/activities/{id:[0-9]+}/attachments/upload").HandlerFunc(Preflight)
+	members.Methods("POST").Path("/activities/{id:[0-9]+}/attachments/upload").HandlerFunc(MembersActivitiesAttachmentUpload)
+	members.Methods("GET").Path("/activities/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}").HandlerFunc(MembersActivitiesAttachment)
+	members.Methods("OPTIONS").Path("/activities/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}").HandlerFunc(Preflight)
+	members.Methods("DELETE").Path("/activities/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}").HandlerFunc(MembersActivitiesAttachmentDelete)
 
 	members.Methods("GET").Path("/activities/recurring").HandlerFunc(MembersActivitiesRecurring)
 	members.Methods("POST").Path("/activities/recurring").HandlerFunc(MembersActivitiesRecurringAdd)
@@ -172,6 +242,24 @@ func MemberSubRouter(prefix string) *mux.Router {
 
 	members.Methods("GET").Path("/evaluations").HandlerFunc(MembersEvaluation)
 
+	// Event registration
+	members.Methods("GET").Path("/events/registrations").HandlerFunc(MembersEventsRegistrations)
+	members.Methods("OPTIONS").Path("/events/{id:[0-9]+}/registration").HandlerFunc(Preflight)
+	members.Methods("POST").Path("/events/{id:[0-9]+}/registration").HandlerFunc(MembersEventsRegister)
+	members.Methods("DELETE").Path("/events/{id:[0-9]+}/registration").HandlerFunc(MembersEventsCancel)
+	members.Methods("GET").Path("/events/{id:[0-9]+}/ticket").HandlerFunc(MembersEventsTicket)
+
+	// Module enrolment and progress
+	members.Methods("POST").Path("/modules/{id:[0-9]+}/enrolment").HandlerFunc(MembersModulesStart)
+	members.Methods("GET").Path("/modules/enrolments").HandlerFunc(MembersModulesEnrolments)
+	members.Methods("GET").Path("/modules/enrolments/{id:[0-9]+}").HandlerFunc(MembersModulesEnrolment)
+	members.Methods("PUT").Path("/modules/enrolments/{id:[0-9]+}/sections/{sectionId:[0-9]+}").HandlerFunc(MembersModulesSection)
+	members.Methods("POST").Path("/modules/enrolments/{id:[0-9]+}/finish").HandlerFunc(MembersModulesFinish)
+	members.Methods("POST").Path("/modules/enrolments/{id:[0-9]+}/attempts").HandlerFunc(MembersModulesAttemptStart)
+	members.Methods("GET").Path("/modules/enrolments/{id:[0-9]+}/attempts").HandlerFunc(MembersModulesAttempts)
+	members.Methods("GET").Path("/modules/enrolments/{id:[0-9]+}/attempts/{attemptId:[0-9]+}").HandlerFunc(MembersModulesAttempt)
+	members.Methods("POST").Path("/modules/enrolments/{id:[0-9]+}/attempts/{attemptId:[0-9]+}/submit").HandlerFunc(MembersModulesAttemptSubmit)
+
 	members.Methods("POST").Path("/notifications").HandlerFunc(MemberSendNotification)
 
 	members.Methods("GET").Path("/reports/cpd/current").HandlerFunc(CurrentActivityReport)
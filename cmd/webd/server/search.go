@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+)
+
+// publicIndexEnvVars name the indexes that can be searched by any user, the member index is for admins only
+var publicIndexEnvVars = []string{
+	"MAPPCPD_ALGOLIA_DIRECTORY_INDEX",
+	"MAPPCPD_ALGOLIA_MODULES_INDEX",
+	"MAPPCPD_ALGOLIA_RESOURCES_INDEX",
+	"MAPPCPD_ALGOLIA_QUALIFICATIONS_INDEX",
+	"MAPPCPD_ALGOLIA_ORGANISATIONS_INDEX",
+}
+
+// SearchIndex searches one of the public indexes in the local search index
+func SearchIndex(w http.ResponseWriter, r *http.Request) {
+
+	p := NewResponder(UserAuthToken.Encoded)
+
+	index := mux.Vars(r)["index"]
+	public := false
+	for _, v := range publicIndexEnvVars {
+		if os.Getenv(v) != "" && os.Getenv(v) == index {
+			public = true
+		}
+	}
+	if !public {
+		p.Message = Message{http.StatusNotFound, "failed", "No search index named " + index}
+		p.Send(w)
+		return
+	}
+
+	searchIndex(w, r, p, index)
+}
+
+// AdminSearchIndex searches any index in the local search index
+func AdminSearchIndex(w http.ResponseWriter, r *http.Request) {
+	p := NewResponder(UserAuthToken.Encoded)
+	searchIndex(w, r, p, mux.Vars(r)["index"])
+}
+
+// searchIndex runs the search in the query string, ?q=text&page=0&hitsPerPage=20. Results can be filtered
+// on fields with one or more ?filter=field:value. Search is only available with the local search backend,
+// otherwise clients search the indexes directly.
+func searchIndex(w http.ResponseWriter, r *http.Request, p *Payload, index string) {
+
+	if DS.Search == nil {
+		p.Message = Message{http.StatusNotImplemented, "failed", "The local search index is not enabled"}
+		p.Send(w)
+		return
+	}
+
+	v := r.URL.Query()
+	q := search.Query{Text: v.Get("q"), Filters: map[string]interface{}{}}
+	var err error
+	if s := v.Get("page"); s != "" {
+		if q.Page, err = strconv.Atoi(s); err != nil {
+			p.Message = Message{http.StatusBadRequest, "failed", "page must be a number"}
+			p.Send(w)
+			return
+		}
+	}
+	if s := v.Get("hitsPerPage"); s != "" {
+		if q.HitsPerPage, err = strconv.Atoi(s); err != nil {
+			p.Message = Message{http.StatusBadRequest, "failed", "hitsPerPage must be a number"}
+			p.Send(w)
+			return
+		}
+	}
+	for _, f := range v["filter"] {
+		xs := strings.SplitN(f, ":", 2)
+		if len(xs) != 2 || xs[0] == "" {
+			p.Message = Message{http.StatusBadRequest, "failed", "filter must be in the form field:value"}
+			p.Send(w)
+			return
+		}
+		q.Filters[xs[0]] = xs[1]
+	}
+
+	res, err := DS.Search.Search(index, q)
+	if err != nil {
+		p.Message = Message{http.StatusInternalServerError, "failed", err.Error()}
+		p.Send(w)
+		return
+	}
+
+	p.Message = Message{http.StatusOK, "success", "Data retrieved from the local search index"}
+	p.Meta = map[string]int{"count": len(res.Hits), "total": res.NbHits, "page": res.Page, "pages": res.NbPages}
+	p.Data = res.Hits
+	p.Send(w)
+}
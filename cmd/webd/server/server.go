@@ -5,17 +5,24 @@ import (
 
 	"github.com/cardiacsociety/web-services/cmd/webd/graphql"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 )
 
 const (
-	v1AuthBase    = "/v1/auth"
-	v1MemberBase  = "/v1/m"
-	v1AdminBase   = "/v1/a"
-	v1GeneralBase = "/v1/g"
-	v1ReportBase  = "/v1/r"
-	graphQLBase = "/graphql"
+	v1AuthBase          = "/v1/auth"
+	v1MemberBase        = "/v1/m"
+	v1AdminBase         = "/v1/a"
+	v1GeneralBase       = "/v1/g"
+	v1ReportBase        = "/v1/r"
+	v1EventsICal        = "/v1/events.ics"
+	v1EventICal         = "/v1/events/{id:[0-9]+}.ics"
+	v1ResourcesAtom     = "/v1/resources.atom"
+	v1ResourcesRSS      = "/v1/resources.rss"
+	v1ResourcesJSONFeed = "/v1/resources.json"
+	shortLinkPath       = "/{shortPath:[A-Za-z0-9_-]+}"
+	graphQLBase         = "/graphql"
 )
 
 // DS represents the global datastore passed to internal packages by the handlers
@@ -25,6 +32,7 @@ var DS datastore.Datastore
 func Router(ds datastore.Datastore) http.Handler {
 
 	DS = ds
+	setRepositories(ds)
 
 	// Router
 	r := mux.NewRouter()
@@ -56,10 +64,27 @@ func Router(ds datastore.Datastore) http.Handler {
 	rGeneralMiddleware := GeneralMiddleware(rGeneral)
 	r.PathPrefix(v1GeneralBase).Handler(rGeneralMiddleware)
 
+	// Public calendar feeds, no middleware required
+	r.Methods("GET").Path(v1EventsICal).HandlerFunc(EventsICal)
+	r.Methods("GET").Path(v1EventICal).HandlerFunc(EventICal)
+
+	// Public feeds of the latest resources, no middleware required
+	r.Methods("GET").Path(v1ResourcesAtom).HandlerFunc(ResourcesAtom)
+	r.Methods("GET").Path(v1ResourcesRSS).HandlerFunc(ResourcesRSS)
+	r.Methods("GET").Path(v1ResourcesJSONFeed).HandlerFunc(ResourcesJSONFeed)
+
+	// Signed urls for the local and memory storage backends, the signature is the authorisation
+	if ds.Storage != nil {
+		r.PathPrefix(storage.FilesPath).Handler(storage.Handler(ds.Storage, storage.FilesPath))
+	}
+
 	// GraphQL
 	rGraphQL := graphql.Server(ds)
 	r.PathPrefix(graphQLBase).Handler(rGraphQL)
 
+	// Short link redirects, last so that a short path does not shadow any other route
+	r.Methods("GET").Path(shortLinkPath).HandlerFunc(ShortLink)
+
 	// CORS handler - needed to add OptionsPassThrough for preflight requests which use OPTIONS http method
 	//handler := cors.Default().Handler(r)
 	// Todo... tighten this up - not sure if needed  with preflightHandler??
@@ -0,0 +1,82 @@
+package activity
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+)
+
+// Memory is an in-memory Repository for unit tests. It behaves as Store does, eg ByID returns a zero
+// Activity for an unknown id, and all of the activities are active.
+type Memory struct {
+	mu         sync.Mutex
+	activities map[int]Activity
+	types      map[int][]Type
+}
+
+// NewMemory returns a Memory holding the activities
+func NewMemory(xa ...Activity) *Memory {
+	m := &Memory{activities: map[int]Activity{}, types: map[int][]Type{}}
+	for _, a := range xa {
+		m.activities[a.ID] = a
+	}
+	return m
+}
+
+// AddTypes adds types to an activity
+func (m *Memory) AddTypes(activityID int, xt ...Type) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.types[activityID] = append(m.types[activityID], xt...)
+}
+
+// All returns the activities, in id order
+func (m *Memory) All() ([]Activity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var xa []Activity
+	for _, a := range m.activities {
+		xa = append(xa, a)
+	}
+	sort.Slice(xa, func(i, j int) bool { return xa[i].ID < xa[j].ID })
+	return xa, nil
+}
+
+// ByID returns an activity
+func (m *Memory) ByID(id int) (Activity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.activities[id], nil
+}
+
+// ByTypeID returns the activity that a type belongs to
+func (m *Memory) ByTypeID(typeID int) (Activity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, xt := range m.types {
+		for _, t := range xt {
+			if t.ID == typeID {
+				return m.activities[id], nil
+			}
+		}
+	}
+	return Activity{}, sql.ErrNoRows
+}
+
+// Types returns the types of an activity
+func (m *Memory) Types(activityID int) ([]Type, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.types[activityID], nil
+}
+
+// CreditPerUnit returns the credit per unit for an activity
+func (m *Memory) CreditPerUnit(activityID int) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.activities[activityID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return a.CreditPerUnit, nil
+}
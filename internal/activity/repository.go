@@ -0,0 +1,55 @@
+package activity
+
+import (
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Repository provides the activity records, so that code that needs them can be tested with Memory
+// instead of a database
+type Repository interface {
+	All() ([]Activity, error)
+	ByID(id int) (Activity, error)
+	ByTypeID(typeID int) (Activity, error)
+	Types(activityID int) ([]Type, error)
+	CreditPerUnit(activityID int) (float64, error)
+}
+
+var (
+	_ Repository = Store{}
+	_ Repository = (*Memory)(nil)
+)
+
+// Store is the Repository for the MySQL database
+type Store struct {
+	ds datastore.Datastore
+}
+
+// NewStore returns a Repository for the MySQL database in ds
+func NewStore(ds datastore.Datastore) Store {
+	return Store{ds: ds}
+}
+
+// All fetches the active activities
+func (s Store) All() ([]Activity, error) {
+	return activityList(s.ds)
+}
+
+// ByID fetches an activity, or returns a zero Activity if there is none
+func (s Store) ByID(id int) (Activity, error) {
+	return activityByID(s.ds, id)
+}
+
+// ByTypeID fetches the activity that an activity type belongs to
+func (s Store) ByTypeID(typeID int) (Activity, error) {
+	return activityByTypeID(s.ds, typeID)
+}
+
+// Types fetches the types of an activity
+func (s Store) Types(activityID int) ([]Type, error) {
+	return activityTypes(s.ds, activityID)
+}
+
+// CreditPerUnit fetches the credit per unit for an active activity
+func (s Store) CreditPerUnit(activityID int) (float64, error) {
+	return activityCreditPerUnit(s.ds, activityID)
+}
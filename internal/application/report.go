@@ -30,14 +30,24 @@ func ExcelReport(ds datastore.Datastore, applications []Application) (*excelize.
 		"Comment",
 	})
 
+	// fetch all of the members at once
+	var ids []int
+	for _, a := range applications {
+		ids = append(ids, a.MemberID)
+	}
+	members, err := member.MapByIDs(ds, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	// data rows
 	for _, a := range applications {
 
 		var tags string
 		var region string
-		m, err := member.ByID(ds, a.MemberID)
-		if err != nil {
-			msg := fmt.Sprintf("member.ByID() err = %s", err)
+		m, ok := members[a.MemberID]
+		if !ok {
+			msg := fmt.Sprintf("No member record with id %d", a.MemberID)
 			log.Printf(msg)
 			f.AddError(a.ID, msg)
 		} else {
@@ -7,9 +7,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/cardiacsociety/web-services/internal/fileset"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 	"github.com/pkg/errors"
 )
 
@@ -35,12 +37,19 @@ type Attachment struct {
 	// Path is the full path in clud storage, the 'key'
 	Path string `json:"path"`
 
-	// URL is the public URL to access this attachment
-	// todo can we use signed urls to request access?
+	// URL is a short-lived signed URL to access this attachment, generated for each request
 	URL string `json:"url"`
 
+	// ContentType, Size and Checksum (SHA-256) are set when the file is uploaded via the server
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Checksum    string `json:"checksum,omitempty"`
+
 	// FileSet represents the files storage information
 	FileSet fileset.FileSet
+
+	// key is the key at which the file was found in storage, see resolveKey
+	key string
 }
 
 // attachmentQueries stores relevant sql for a particular attachment type.
@@ -114,7 +123,18 @@ func (a *Attachment) Register(ds datastore.Datastore, flags ...string) error {
 		return nil
 	}
 
-	// If we're here the attachment is NOT already registered, so register it
+	// If we're here the attachment is NOT already registered. When storage is configured make sure the
+	// file has actually been uploaded, rather than registering a file that does not exist.
+	if ds.Storage != nil {
+		ok, err := ds.Storage.Exists(a.FileSet.Volume, a.Key())
+		if err != nil {
+			return errors.New("Error checking storage for uploaded file - " + err.Error())
+		}
+		if !ok {
+			return fmt.Errorf("No file found in storage at %s%s - upload the file before registering it", a.FileSet.Volume, a.Key())
+		}
+	}
+
 	var query string
 
 	switch a.FileSet.Entity {
@@ -205,61 +225,232 @@ func (a *Attachment) Exists(ds datastore.Datastore) error {
 	return nil
 }
 
-// setURL sets the public URL for an attachment by looking up fs_url record
+// setURL sets a short-lived signed URL for downloading the attachment. Files are not publicly accessible
+// so the URL must be generated for each request, and is left empty if storage is not configured.
 func (a *Attachment) setURL(ds datastore.Datastore) error {
+	if ds.Storage == nil {
+		return nil
+	}
+	url, err := a.SignedURL(ds, storage.DefaultTTL)
+	if err != nil {
+		return fmt.Errorf("Error signing url for attachment id %d - %s", a.ID, err)
+	}
+	a.URL = url
+	return nil
+}
 
-	var url string
+// Key returns the full path to the attachment in storage. Files are stored with the
+// CloudyFilename when it is present, otherwise with the CleanFilename. Note attachments may
+// instead be stored at the legacy key, in which case Key returns that once SignedURL has found it.
+func (a *Attachment) Key() string {
+	if a.key != "" {
+		return a.key
+	}
+	return a.uploadKey()
+}
 
-	query := "SELECT base_url FROM fs_url WHERE active = 1 AND fs_set_id = %d ORDER BY priority ASC LIMIT 1"
-	query = fmt.Sprintf(query, a.FileSet.ID)
-	err := ds.MySQL.Session.QueryRow(query).Scan(&url)
-	if err == sql.ErrNoRows {
-		msg := fmt.Sprintf("No fs_url record found for file_set.id = %d - %s", a.FileSet.ID, err.Error())
-		return errors.New(msg)
+// uploadKey returns the key that files are uploaded to
+func (a *Attachment) uploadKey() string {
+	fileName := a.CloudyFilename
+	if fileName == "" {
+		fileName = a.CleanFilename
 	}
+	return a.FileSet.Key(a.EntityID, fileName)
+}
+
+// SignedURL returns a URL that allows the attachment to be downloaded for the duration of ttl
+func (a *Attachment) SignedURL(ds datastore.Datastore, ttl time.Duration) (string, error) {
+	if ds.Storage == nil {
+		return "", errors.New("Storage is not configured")
+	}
+	if err := a.resolveKey(ds); err != nil {
+		return "", err
+	}
+	return ds.Storage.GetURL(a.FileSet.Volume, a.Key(), ttl)
+}
+
+// legacyKey returns the key used by the original uploader for note attachments, which included
+// the attachment id in the file name, eg /note/123/456-filename.pdf
+func (a *Attachment) legacyKey() string {
+	return a.FileSet.Key(a.EntityID, strconv.Itoa(a.ID)+"-"+a.CleanFilename)
+}
+
+// storedKeys returns all of the keys at which the file for the attachment may be stored, in the
+// order they are tried by resolveKey
+func (a *Attachment) storedKeys() []string {
+	xk := []string{a.uploadKey()}
+	if a.FileSet.Entity == "wf_attachment" {
+		xk = append(xk, a.legacyKey())
+	}
+	return xk
+}
+
+// resolveKey checks storage for the attachments that may be stored at more than one key, and sets
+// the key to the first one that exists. It is done once, and if the file is not found at any of
+// the keys Key is left as it is.
+func (a *Attachment) resolveKey(ds datastore.Datastore) error {
+	if a.key != "" || a.FileSet.Entity != "wf_attachment" {
+		return nil
+	}
+	xk := a.storedKeys()
+	for _, k := range xk {
+		ok, err := ds.Storage.Exists(a.FileSet.Volume, k)
+		if err != nil {
+			return fmt.Errorf("Error checking storage for attachment id %d - %s", a.ID, err)
+		}
+		if ok {
+			a.key = k
+			a.Path = a.FileSet.Volume + k
+			return nil
+		}
+	}
+	a.key = xk[0]
+	return nil
+}
+
+// keys returns all of the keys at which the file for the attachment may be stored, normalised with
+// storage.CleanKey
+func (a *Attachment) keys() []string {
+	xk := a.storedKeys()
+	for i, k := range xk {
+		xk[i] = storage.CleanKey(k)
+	}
+	return xk
+}
+
+// Delete removes the attachment registration from the database, and then the file from storage. The row is
+// removed first so that a failure to remove the file leaves an orphan, which will be cleaned up by Sweep,
+// rather than a registration for a file that no longer exists.
+func (a *Attachment) Delete(ds datastore.Datastore) error {
+
+	if a.ID == 0 {
+		return errors.New("Attachment.ID (int) has a zero value")
+	}
+	if _, ok := selectQueries[a.FileSet.Entity]; !ok {
+		return errors.New("Unknown entity: " + a.FileSet.Entity)
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ? LIMIT 1", a.FileSet.Entity)
+	if _, err := ds.MySQL.Session.Exec(query, a.ID); err != nil {
+		return errors.New("Database error - " + err.Error())
+	}
+
+	if ds.Storage == nil {
+		return nil
+	}
+	for _, k := range a.keys() {
+		if err := ds.Storage.Delete(a.FileSet.Volume, k); err != nil {
+			return fmt.Errorf("Error removing file %s%s from storage - %s", a.FileSet.Volume, k, err)
+		}
+	}
+
+	return nil
+}
+
+// ByEntity fetches the active attachments for the record identified by entityID, in the attachment table
+// specified by entity, eg "ce_m_activity_attachment", "wf_attachment" or "ol_resource_file".
+func ByEntity(ds datastore.Datastore, entity string, entityID int) ([]Attachment, error) {
+	q, err := selectQuery(entity)
 	if err != nil {
-		msg := fmt.Sprintf("Error looking up base url for file_set.id = %d - %s", a.FileSet.ID, err.Error())
-		return errors.New(msg)
+		return nil, err
 	}
+	q += fmt.Sprintf(" WHERE a.active = 1 AND a.%s = ? ORDER BY a.id", entityColumns[entity])
+	return attachments(ds, entity, q, entityID)
+}
 
-	a.URL = url + a.FileSet.Path + strconv.Itoa(a.EntityID) + "/" + a.CloudyFilename
+// ByID fetches a single active attachment from the attachment table specified by entity
+func ByID(ds datastore.Datastore, entity string, id int) (Attachment, error) {
+	q, err := selectQuery(entity)
+	if err != nil {
+		return Attachment{}, err
+	}
+	xa, err := attachments(ds, entity, q+" WHERE a.active = 1 AND a.id = ?", id)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if len(xa) == 0 {
+		return Attachment{}, sql.ErrNoRows
+	}
+	return xa[0], nil
+}
+
+// DeleteByEntity deletes all of the attachments, and stored files, for the record identified by entityID
+func DeleteByEntity(ds datastore.Datastore, entity string, entityID int) error {
+	xa, err := ByEntity(ds, entity, entityID)
+	if err != nil {
+		return err
+	}
+	for _, a := range xa {
+		if err := a.Delete(ds); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// MemberActivityAttachments fetches the attachments for a member activity.
+// MemberActivityAttachments fetches the attachments for a member activity, with signed URLs
 func MemberActivityAttachments(ds datastore.Datastore, memberActivityID int) ([]Attachment, error) {
+	return ByEntity(ds, "ce_m_activity_attachment", memberActivityID)
+}
 
-	var xa []Attachment
+// ActivityAttachmentByID fetches a single member activity attachment, with a signed URL
+func ActivityAttachmentByID(ds datastore.Datastore, id int) (Attachment, error) {
+	return ByID(ds, "ce_m_activity_attachment", id)
+}
 
-	query := `SELECT
-			a.id AS 'attachmentId',
-			a.clean_filename AS 'fileName',
-			CONCAT(fs.volume_name, fs.set_path, a.ce_m_activity_id, '/', a.cloudy_filename) AS 'filePath',
-			CONCAT(fu.base_url, fs.set_path, a.ce_m_activity_id, '/', a.cloudy_filename) AS 'fileUrl'
-			FROM ce_m_activity_attachment a
-			LEFT JOIN fs_set fs ON a.fs_set_id = fs.id
-			LEFT JOIN fs_url fu ON fs.id = fu.fs_set_id
-			WHERE a.ce_m_activity_id = ?`
-
-	rows, err := ds.MySQL.Session.Query(query, memberActivityID)
-	if err == sql.ErrNoRows {
-		return xa, nil
+func selectQuery(entity string) (string, error) {
+	k, ok := selectQueries[entity]
+	if !ok {
+		return "", errors.New("Unknown entity: " + entity)
 	}
+	return queries[k], nil
+}
+
+// attachments fetches attachments with signed URLs
+func attachments(ds datastore.Datastore, entity, query string, args ...interface{}) ([]Attachment, error) {
+	xa, err := list(ds, entity, query, args...)
 	if err != nil {
 		return xa, err
 	}
+	for i := range xa {
+		if err := xa[i].setURL(ds); err != nil {
+			return xa, err
+		}
+	}
+	return xa, nil
+}
+
+// list fetches attachments without signing URLs
+func list(ds datastore.Datastore, entity, query string, args ...interface{}) ([]Attachment, error) {
+
+	var xa []Attachment
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xa, err
+	}
+	defer rows.Close()
 
 	for rows.Next() {
 
 		a := Attachment{}
-		rows.Scan(
+		a.FileSet.Entity = entity
+		err := rows.Scan(
 			&a.ID,
+			&a.EntityID,
+			&a.UserID,
 			&a.CleanFilename,
-			&a.Path,
-			&a.URL,
+			&a.CloudyFilename,
+			&a.FileSet.ID,
+			&a.FileSet.Volume,
+			&a.FileSet.Path,
 		)
+		if err != nil {
+			return xa, err
+		}
+		a.Path = a.FileSet.Volume + a.Key()
 		xa = append(xa, a)
 	}
 
-	return xa, nil
+	return xa, rows.Err()
 }
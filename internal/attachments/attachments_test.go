@@ -0,0 +1,299 @@
+package attachments_test
+
+import (
+	"database/sql"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/attachments"
+	"github.com/cardiacsociety/web-services/internal/fileset"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
+	"github.com/cardiacsociety/web-services/testdata"
+)
+
+var ds datastore.Datastore
+
+func TestAttachments(t *testing.T) {
+
+	var teardown func()
+	ds, teardown = setup()
+	defer teardown()
+
+	t.Run("attachments", func(t *testing.T) {
+		t.Run("testPingDatabase", testPingDatabase)
+		t.Run("testByEntity", testByEntity)
+		t.Run("testByEntityUnknown", testByEntityUnknown)
+		t.Run("testSignedURL", testSignedURL)
+		t.Run("testDelete", testDelete)
+		t.Run("testSweep", testSweep)
+		t.Run("testSweepS3Keys", testSweepS3Keys)
+		t.Run("testSweepSharedPath", testSweepSharedPath)
+		t.Run("testUploadTwice", testUploadTwice)
+	})
+}
+
+func setup() (datastore.Datastore, func()) {
+	var db = testdata.NewDataStore()
+	err := db.SetupMySQL()
+	if err != nil {
+		log.Fatalf("SetupMySQL() err = %s", err)
+	}
+	return db.Store, func() {
+		err := db.TearDownMySQL()
+		if err != nil {
+			log.Fatalf("TearDownMySQL() err = %s", err)
+		}
+	}
+}
+
+func testPingDatabase(t *testing.T) {
+	err := ds.MySQL.Session.Ping()
+	if err != nil {
+		t.Fatalf("Ping() err = %s", err)
+	}
+}
+
+func testByEntity(t *testing.T) {
+	cases := []struct {
+		entity string
+		id     int
+		want   int // count
+	}{
+		{"ce_m_activity_attachment", 247, 2},
+		{"ce_m_activity_attachment", 1, 0},
+		{"wf_attachment", 1, 1},
+		{"ol_resource_file", 1, 0},
+	}
+
+	for _, c := range cases {
+		xa, err := attachments.ByEntity(ds, c.entity, c.id)
+		if err != nil {
+			t.Fatalf("attachments.ByEntity(%s, %d) err = %s", c.entity, c.id, err)
+		}
+		got := len(xa)
+		if got != c.want {
+			t.Errorf("attachments.ByEntity(%s, %d) count = %d, want %d", c.entity, c.id, got, c.want)
+		}
+	}
+}
+
+func testByEntityUnknown(t *testing.T) {
+	_, err := attachments.ByEntity(ds, "member", 1)
+	if err == nil {
+		t.Errorf("attachments.ByEntity(member) err = nil, want error")
+	}
+}
+
+func testSignedURL(t *testing.T) {
+	sds := ds
+	sds.Storage = storage.NewMemory("https://files.test.com", "key")
+
+	a, err := attachments.ActivityAttachmentByID(sds, 74)
+	if err != nil {
+		t.Fatalf("attachments.ActivityAttachmentByID(74) err = %s", err)
+	}
+	want := "https://files.test.com/test-volume/cpd/247/04abe653d926a3ccb122245671e6c064.png?"
+	if !strings.HasPrefix(a.URL, want) {
+		t.Errorf("Attachment.URL = %s, want prefix %s", a.URL, want)
+	}
+}
+
+func testDelete(t *testing.T) {
+	sds := ds
+	sds.Storage = storage.NewMemory("https://files.test.com", "key")
+
+	a, err := attachments.ActivityAttachmentByID(sds, 77)
+	if err != nil {
+		t.Fatalf("attachments.ActivityAttachmentByID(77) err = %s", err)
+	}
+	sds.Storage.Put(a.FileSet.Volume, a.Key(), strings.NewReader("content"), "image/png")
+
+	if err := a.Delete(sds); err != nil {
+		t.Fatalf("Attachment.Delete() err = %s", err)
+	}
+	_, err = attachments.ActivityAttachmentByID(sds, 77)
+	if err != sql.ErrNoRows {
+		t.Errorf("attachments.ActivityAttachmentByID(77) after delete err = %v, want %v", err, sql.ErrNoRows)
+	}
+	ok, _ := sds.Storage.Exists(a.FileSet.Volume, a.Key())
+	if ok {
+		t.Errorf("Storage.Exists(%s) after delete = true, want false", a.Key())
+	}
+}
+
+func testSweep(t *testing.T) {
+	sds := ds
+	mem := storage.NewMemory("https://files.test.com", "key")
+	sds.Storage = mem
+
+	registered := "/cpd/249/04abe653d926a3ccb122245671e6c064.png" // attachment id 78
+	orphan := "/cpd/999/orphan.pdf"
+	mem.Put("test-volume", registered, strings.NewReader("content"), "image/png")
+	mem.Put("test-volume", orphan, strings.NewReader("content"), "application/pdf")
+	mem.Put("test-volume", "/note/1/other-set.pdf", strings.NewReader("content"), "application/pdf")
+
+	fs, err := fileset.ActivityAttachment(sds)
+	if err != nil {
+		t.Fatalf("fileset.ActivityAttachment() err = %s", err)
+	}
+
+	// recent files may be waiting to be registered
+	xs, err := attachments.Orphans(sds, fs, time.Hour)
+	if err != nil || len(xs) != 0 {
+		t.Errorf("attachments.Orphans(1h) = %v, %v, want none", xs, err)
+	}
+
+	xs, err = attachments.Sweep(sds, fs, 0, false)
+	if err != nil {
+		t.Fatalf("attachments.Sweep() err = %s", err)
+	}
+	if len(xs) != 1 || xs[0] != orphan {
+		t.Errorf("attachments.Sweep() = %v, want [%s]", xs, orphan)
+	}
+	want := "/cpd/249/04abe653d926a3ccb122245671e6c064.png,/note/1/other-set.pdf"
+	got := strings.Join(mem.Keys("test-volume"), ",")
+	if got != want {
+		t.Errorf("Storage keys after sweep = %s, want %s", got, want)
+	}
+}
+
+// s3Store is a fake Storage that keeps keys the way Amazon S3 does, without a leading slash
+type s3Store struct {
+	*storage.Memory
+	keys map[string]bool
+}
+
+func (s s3Store) Put(volume, key string, r io.Reader, contentType string) error {
+	s.keys[strings.TrimLeft(key, "/")] = true
+	return s.Memory.Put(volume, key, r, contentType)
+}
+
+func (s s3Store) Delete(volume, key string) error {
+	delete(s.keys, strings.TrimLeft(key, "/"))
+	return s.Memory.Delete(volume, key)
+}
+
+func (s s3Store) List(volume, prefix string) ([]storage.Object, error) {
+	var xo []storage.Object
+	for k := range s.keys {
+		if strings.HasPrefix(k, strings.TrimLeft(prefix, "/")) {
+			xo = append(xo, storage.Object{Key: k})
+		}
+	}
+	return xo, nil
+}
+
+// test that files listed without a leading slash are matched with their attachments
+func testSweepS3Keys(t *testing.T) {
+	sds := ds
+	st := s3Store{Memory: storage.NewMemory("https://files.test.com", "key"), keys: map[string]bool{}}
+	sds.Storage = st
+
+	st.Put("test-volume", "cpd/249/04abe653d926a3ccb122245671e6c064.png", strings.NewReader("content"), "image/png") // attachment id 78
+	st.Put("test-volume", "cpd/999/orphan.pdf", strings.NewReader("content"), "application/pdf")
+
+	fs, err := fileset.ActivityAttachment(sds)
+	if err != nil {
+		t.Fatalf("fileset.ActivityAttachment() err = %s", err)
+	}
+
+	xs, err := attachments.Sweep(sds, fs, 0, false)
+	if err != nil {
+		t.Fatalf("attachments.Sweep() err = %s", err)
+	}
+	if len(xs) != 1 || xs[0] != "cpd/999/orphan.pdf" {
+		t.Errorf("attachments.Sweep() = %v, want [cpd/999/orphan.pdf]", xs)
+	}
+	if len(st.keys) != 1 || !st.keys["cpd/249/04abe653d926a3ccb122245671e6c064.png"] {
+		t.Errorf("Storage keys after sweep = %v, want the registered file only", st.keys)
+	}
+}
+
+// test that sweeping either of two file sets that share a path leaves the files registered in the other
+func testSweepSharedPath(t *testing.T) {
+	sds := ds
+	mem := storage.NewMemory("https://files.test.com", "key")
+	sds.Storage = mem
+
+	// an old set for activity attachments, replaced by the current set 4 with the same path
+	res, err := sds.MySQL.Session.Exec(`INSERT INTO fs_set (active, current, created_at, updated_at, storage_type,
+		storage_credentials, volume_name, set_path, entity_name)
+		VALUES (1, 0, NOW(), NOW(), 'AWS-S3', '{}', 'test-volume', '/cpd/', 'ce_m_activity_attachment')`)
+	if err != nil {
+		t.Fatalf("Exec() err = %s", err)
+	}
+	oldID, _ := res.LastInsertId()
+	_, err = sds.MySQL.Session.Exec(`INSERT INTO ce_m_activity_attachment (ce_m_activity_id, fs_set_id, active,
+		created_at, updated_at, clean_filename, cloudy_filename) VALUES (249, ?, 1, NOW(), NOW(), 'old.pdf', 'old-set.pdf')`, oldID)
+	if err != nil {
+		t.Fatalf("Exec() err = %s", err)
+	}
+
+	current := "/cpd/249/04abe653d926a3ccb122245671e6c064.png" // attachment id 78, in set 4
+	old := "/cpd/249/old-set.pdf"
+	orphan := "/cpd/999/orphan.pdf"
+	for _, k := range []string{current, old, orphan} {
+		mem.Put("test-volume", k, strings.NewReader("content"), "application/pdf")
+	}
+
+	xfs, err := fileset.All(sds)
+	if err != nil {
+		t.Fatalf("fileset.All() err = %s", err)
+	}
+	for _, fs := range xfs {
+		if fs.Path != "/cpd/" {
+			continue
+		}
+		xs, err := attachments.Orphans(sds, fs, 0)
+		if err != nil {
+			t.Fatalf("attachments.Orphans() file set id %d err = %s", fs.ID, err)
+		}
+		if len(xs) != 1 || xs[0] != orphan {
+			t.Errorf("attachments.Orphans() file set id %d = %v, want [%s]", fs.ID, xs, orphan)
+		}
+	}
+}
+
+// test that the same file uploaded twice is stored twice, so deleting one attachment leaves the other's file
+func testUploadTwice(t *testing.T) {
+	sds := ds
+	sds.Storage = storage.NewMemory("https://files.test.com", "key")
+
+	fs, err := fileset.ActivityAttachment(sds)
+	if err != nil {
+		t.Fatalf("fileset.ActivityAttachment() err = %s", err)
+	}
+	policy, _ := attachments.PolicyFor(fs.Entity)
+
+	var xa []*attachments.Attachment
+	for i := 0; i < 2; i++ {
+		f, err := attachments.Receive(strings.NewReader("evidence"), "evidence.txt", policy, nil)
+		if err != nil {
+			t.Fatalf("attachments.Receive() err = %s", err)
+		}
+		a := attachments.New()
+		a.EntityID = 249
+		a.FileSet = fs
+		err = a.Upload(sds, f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Attachment.Upload() err = %s", err)
+		}
+		xa = append(xa, a)
+	}
+	if xa[0].ID == xa[1].ID || xa[0].Key() == xa[1].Key() {
+		t.Fatalf("Attachment.Upload() twice = ids %d, %d keys %s, %s, want different", xa[0].ID, xa[1].ID, xa[0].Key(), xa[1].Key())
+	}
+
+	if err := xa[0].Delete(sds); err != nil {
+		t.Fatalf("Attachment.Delete() err = %s", err)
+	}
+	ok, _ := sds.Storage.Exists(fs.Volume, xa[1].Key())
+	if !ok {
+		t.Errorf("Storage.Exists(%s) after deleting the other upload = false, want true", xa[1].Key())
+	}
+}
@@ -0,0 +1,61 @@
+package attachments
+
+var queries = map[string]string{
+	"select-activity-attachments": selectActivityAttachments,
+	"select-note-attachments":     selectNoteAttachments,
+	"select-resource-attachments": selectResourceAttachments,
+}
+
+// selectQueries maps each attachment table to the query that selects its rows
+var selectQueries = map[string]string{
+	"ce_m_activity_attachment": "select-activity-attachments",
+	"wf_attachment":            "select-note-attachments",
+	"ol_resource_file":         "select-resource-attachments",
+}
+
+// entityColumns maps each attachment table to the column that holds the id of the parent record
+var entityColumns = map[string]string{
+	"ce_m_activity_attachment": "ce_m_activity_id",
+	"wf_attachment":            "wf_note_id",
+	"ol_resource_file":         "ol_resource_id",
+}
+
+// Each of the select queries returns the same columns so the rows can be scanned in the same way
+const selectActivityAttachments = `
+SELECT
+	a.id AS 'attachmentId',
+	a.ce_m_activity_id AS 'entityId',
+	0 AS 'userId',
+	a.clean_filename AS 'fileName',
+	a.cloudy_filename AS 'cloudyFileName',
+	fs.id AS 'fileSetId',
+	fs.volume_name AS 'volume',
+	fs.set_path AS 'setPath'
+FROM ce_m_activity_attachment a
+	LEFT JOIN fs_set fs ON a.fs_set_id = fs.id`
+
+const selectNoteAttachments = `
+SELECT
+	a.id AS 'attachmentId',
+	a.wf_note_id AS 'entityId',
+	a.ad_user_id AS 'userId',
+	a.clean_filename AS 'fileName',
+	'' AS 'cloudyFileName',
+	fs.id AS 'fileSetId',
+	fs.volume_name AS 'volume',
+	fs.set_path AS 'setPath'
+FROM wf_attachment a
+	LEFT JOIN fs_set fs ON a.fs_set_id = fs.id`
+
+const selectResourceAttachments = `
+SELECT
+	a.id AS 'attachmentId',
+	a.ol_resource_id AS 'entityId',
+	a.ad_user_id AS 'userId',
+	a.clean_filename AS 'fileName',
+	a.cloudy_filename AS 'cloudyFileName',
+	fs.id AS 'fileSetId',
+	fs.volume_name AS 'volume',
+	fs.set_path AS 'setPath'
+FROM ol_resource_file a
+	LEFT JOIN fs_set fs ON a.fs_set_id = fs.id`
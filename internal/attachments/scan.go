@@ -0,0 +1,110 @@
+package attachments
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scanner checks file content for malware. Scan returns an error that wraps ErrInfected when
+// the content is infected, or some other error if the content could not be scanned.
+type Scanner interface {
+	Scan(r io.Reader) error
+}
+
+// Clamd is a Scanner that streams content to a ClamAV daemon using the INSTREAM command
+type Clamd struct {
+	// Addr is the network address of clamd, eg "localhost:3310"
+	Addr    string
+	Timeout time.Duration
+}
+
+// Scan sends the content to clamd in chunks and reads the verdict
+func (c Clamd) Scan(r io.Reader) error {
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	conn, err := net.DialTimeout("tcp", c.Addr, timeout)
+	if err != nil {
+		return errors.Wrap(err, "Error connecting to clamd")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return errors.Wrap(err, "Error sending to clamd")
+	}
+	buf := make([]byte, 32<<10)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, buf[:n]...)); err != nil {
+				return errors.Wrap(err, "Error sending to clamd")
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return errors.Wrap(err, "Error sending to clamd")
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "Error reading clamd reply")
+	}
+	return verdict(strings.TrimRight(reply, "\x00\n"))
+}
+
+// verdict interprets a clamd reply, eg "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+func verdict(reply string) error {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return errors.Wrap(ErrInfected, strings.TrimSuffix(reply, " FOUND"))
+	}
+	return errors.New("Unexpected reply from clamd: " + reply)
+}
+
+// eicar is the standard anti-virus test file content
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!H+H*`
+
+// SignatureScanner is a local stand-in for clamd, for tests and development. It reports content that
+// contains any of its signatures as infected, using the same verdicts as clamd.
+type SignatureScanner map[string][]byte
+
+// NewSignatureScanner returns a SignatureScanner that detects the EICAR test file
+func NewSignatureScanner() SignatureScanner {
+	return SignatureScanner{"Eicar-Test-Signature": []byte(eicar)}
+}
+
+// Scan reads all of the content and checks for each signature
+func (s SignatureScanner) Scan(r io.Reader) error {
+	var b bytes.Buffer
+	if _, err := b.ReadFrom(r); err != nil {
+		return err
+	}
+	for name, sig := range s {
+		if bytes.Contains(b.Bytes(), sig) {
+			return verdict("stream: " + name + " FOUND")
+		}
+	}
+	return verdict("stream: OK")
+}
@@ -0,0 +1,120 @@
+package attachments
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/fileset"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
+	"github.com/pkg/errors"
+)
+
+// Supports reports whether the attachments for a file set can be managed by this package
+func Supports(fs fileset.FileSet) bool {
+	_, ok := selectQueries[fs.Entity]
+	return ok
+}
+
+// Orphans returns the stored files in a file set that have no attachment row in the database. A soft-deleted
+// row still refers to its file. Files modified within minAge are ignored as they may have been uploaded but
+// not yet registered.
+func Orphans(ds datastore.Datastore, fs fileset.FileSet, minAge time.Duration) ([]string, error) {
+
+	var orphans []string
+
+	if ds.Storage == nil {
+		return orphans, errors.New("Storage is not configured")
+	}
+	if err := fs.CheckFields(); err != nil {
+		return orphans, err
+	}
+	registered, err := registeredKeys(ds, fs)
+	if err != nil {
+		return orphans, err
+	}
+
+	xo, err := ds.Storage.List(fs.Volume, fs.Path)
+	if err != nil {
+		return orphans, err
+	}
+	cutoff := time.Now().Add(-minAge)
+	for _, o := range xo {
+		// the key is compared in the same form as the registered keys, but returned as listed, so that
+		// it is removed from the store as it is
+		if registered[storage.CleanKey(o.Key)] || o.Modified.After(cutoff) || strings.HasSuffix(o.Key, "/") {
+			continue
+		}
+		orphans = append(orphans, o.Key)
+	}
+
+	return orphans, nil
+}
+
+// registeredKeys returns the keys of the files registered in a file set, and in the other file sets on the
+// same volume whose path overlaps it. An old set and the current set that replaced it usually share a path,
+// so the files listed for one set include those registered in the other. A set whose files are not managed
+// by this package cannot be checked, so an overlap with one is an error.
+func registeredKeys(ds datastore.Datastore, fs fileset.FileSet) (map[string]bool, error) {
+
+	xfs, err := fileset.All(ds)
+	if err != nil {
+		return nil, err
+	}
+	sets := []fileset.FileSet{fs}
+	for _, s := range xfs {
+		if s.ID == fs.ID || s.Volume != fs.Volume {
+			continue
+		}
+		p1, p2 := pathPrefix(s.Path), pathPrefix(fs.Path)
+		if !strings.HasPrefix(p1, p2) && !strings.HasPrefix(p2, p1) {
+			continue
+		}
+		if !Supports(s) {
+			return nil, errors.Errorf("file set id %d (%s) shares the path %s%s and cannot be checked", s.ID, s.Entity, s.Volume, s.Path)
+		}
+		sets = append(sets, s)
+	}
+
+	registered := map[string]bool{}
+	for _, s := range sets {
+		q, err := selectQuery(s.Entity)
+		if err != nil {
+			return nil, err
+		}
+		xa, err := list(ds, s.Entity, q+" WHERE a.fs_set_id = ?", s.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range xa {
+			for _, k := range a.keys() {
+				registered[k] = true
+			}
+		}
+	}
+	return registered, nil
+}
+
+// pathPrefix returns a set path in the same form as the registered keys, with a trailing slash so that
+// /cpd/ does not match /cpd2/
+func pathPrefix(p string) string {
+	return strings.TrimSuffix(storage.CleanKey(p), "/") + "/"
+}
+
+// Sweep removes the orphaned files in a file set, and returns their keys. If dryRun is true the
+// files are reported but not removed.
+func Sweep(ds datastore.Datastore, fs fileset.FileSet, minAge time.Duration, dryRun bool) ([]string, error) {
+
+	orphans, err := Orphans(ds, fs, minAge)
+	if err != nil || dryRun {
+		return orphans, err
+	}
+
+	for i, k := range orphans {
+		if err := ds.Storage.Delete(fs.Volume, k); err != nil {
+			return orphans[:i], errors.Wrapf(err, "Error removing %s%s", fs.Volume, k)
+		}
+	}
+
+	return orphans, nil
+}
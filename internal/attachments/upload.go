@@ -0,0 +1,218 @@
+package attachments
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/pkg/errors"
+)
+
+// Upload errors that are the fault of the client, and can be identified with errors.Cause()
+var (
+	ErrTooLarge  = errors.New("file exceeds the maximum size for this type of attachment")
+	ErrEmpty     = errors.New("file is empty")
+	ErrType      = errors.New("file type is not allowed for this type of attachment")
+	ErrInfected  = errors.New("file failed the virus scan")
+	ErrNoStorage = errors.New("Storage is not configured")
+)
+
+// Policy restricts the files that can be uploaded to a file set
+type Policy struct {
+	// MaxSize is the maximum file size in bytes
+	MaxSize int64 `json:"maxSize"`
+	// Types are the allowed media types, as sniffed from the content of the file
+	Types []string `json:"types"`
+}
+
+// documentTypes are sniffed types for common evidence files. Office documents (docx, xlsx) are zip archives.
+var documentTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"text/plain",
+}
+
+// Policies are the upload policies for each file set entity
+var Policies = map[string]Policy{
+	"ce_m_activity_attachment": {MaxSize: 10 << 20, Types: documentTypes},
+	"wf_attachment":            {MaxSize: 20 << 20, Types: documentTypes},
+	"ol_resource_file":         {MaxSize: 50 << 20, Types: []string{"application/pdf", "image/gif", "image/jpeg", "image/png"}},
+}
+
+// PolicyFor returns the upload Policy for a file set entity
+func PolicyFor(entity string) (Policy, error) {
+	p, ok := Policies[entity]
+	if !ok {
+		return p, errors.New("No upload policy for entity: " + entity)
+	}
+	return p, nil
+}
+
+// Allows checks if a media type is in the allow-list, ignoring any parameters such as charset
+func (p Policy) Allows(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range p.Types {
+		if mt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// File is an uploaded file that has been spooled to a temporary file and checked against a Policy.
+// Close must be called to remove the temporary file.
+type File struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	MD5         string `json:"md5"`
+	SHA256      string `json:"sha256"`
+	tmp         *os.File
+}
+
+// Receive reads an uploaded file from r into a temporary file, computing checksums as it goes. Nothing reaches
+// storage until the file is within the size limit, the sniffed content type is allowed by the Policy, and the
+// scanner, if not nil, has passed the file. The name is the original file name, which is cleaned.
+func Receive(r io.Reader, name string, p Policy, sc Scanner) (*File, error) {
+
+	tmp, err := ioutil.TempFile("", "upload-")
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Name: CleanFilename(name), tmp: tmp}
+
+	h5 := md5.New()
+	h256 := sha256.New()
+	// read one byte over the limit to detect a file that is too large
+	n, err := io.Copy(io.MultiWriter(tmp, h5, h256), io.LimitReader(r, p.MaxSize+1))
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "Error reading upload")
+	}
+	if n > p.MaxSize {
+		f.Close()
+		return nil, ErrTooLarge
+	}
+	if n == 0 {
+		f.Close()
+		return nil, ErrEmpty
+	}
+	f.Size = n
+	f.MD5 = hex.EncodeToString(h5.Sum(nil))
+	f.SHA256 = hex.EncodeToString(h256.Sum(nil))
+
+	// sniff the real content type rather than trusting the client
+	head := make([]byte, 512)
+	hn, err := f.reader().Read(head)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+	f.ContentType = http.DetectContentType(head[:hn])
+	if !p.Allows(f.ContentType) {
+		f.Close()
+		return nil, errors.Wrap(ErrType, f.ContentType)
+	}
+
+	if sc != nil {
+		if err := sc.Scan(f.reader()); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// reader returns a reader positioned at the start of the spooled file
+func (f *File) reader() io.Reader {
+	f.tmp.Seek(0, io.SeekStart)
+	return f.tmp
+}
+
+// Close removes the temporary file
+func (f *File) Close() error {
+	f.tmp.Close()
+	return os.Remove(f.tmp.Name())
+}
+
+// Upload stores a received File and registers the attachment. The CleanFilename is set from the
+// File name and the CloudyFilename from its MD5 checksum and a random suffix, so the attachment requires
+// only the EntityID, FileSet and, for note and resource attachments, the UserID. The suffix keeps the
+// same file uploaded twice in separate objects, so deleting one attachment does not remove the file of
+// the other. Note attachments have no cloudy_filename so are stored with the CleanFilename, and a second
+// upload with the same name replaces the file of the one registration. Flags are passed to Register.
+func (a *Attachment) Upload(ds datastore.Datastore, f *File, flags ...string) error {
+
+	if ds.Storage == nil {
+		return ErrNoStorage
+	}
+
+	a.CleanFilename = f.Name
+	a.CloudyFilename = ""
+	if a.FileSet.Entity != "wf_attachment" {
+		suffix, err := uniqueSuffix()
+		if err != nil {
+			return errors.Wrap(err, "Error generating file name")
+		}
+		a.CloudyFilename = f.MD5 + "-" + suffix + strings.ToLower(filepath.Ext(f.Name))
+	}
+	a.ContentType = f.ContentType
+	a.Size = f.Size
+	a.Checksum = f.SHA256
+
+	if err := a.Validate(); err != nil {
+		return errors.New("Attachment validation error - " + err.Error())
+	}
+
+	if err := ds.Storage.Put(a.FileSet.Volume, a.Key(), f.reader(), f.ContentType); err != nil {
+		return fmt.Errorf("Error storing file at %s%s - %s", a.FileSet.Volume, a.Key(), err)
+	}
+	a.Path = a.FileSet.Volume + a.Key()
+
+	if len(flags) == 0 {
+		flags = []string{""}
+	}
+	if err := a.Register(ds, flags...); err != nil {
+		return err
+	}
+	return a.setURL(ds)
+}
+
+// uniqueSuffix returns a random hex string for the CloudyFilename of an upload
+func uniqueSuffix() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// CleanFilename returns the base name of a file with unsafe characters replaced
+func CleanFilename(name string) string {
+	name = filepath.Base(strings.Replace(name, `\`, "/", -1))
+	name = strings.Trim(unsafeFilenameChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return "file"
+	}
+	return name
+}
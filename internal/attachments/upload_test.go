@@ -0,0 +1,120 @@
+package attachments_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/attachments"
+)
+
+const pdf = "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n"
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!H+H*`
+
+func TestReceive(t *testing.T) {
+
+	p := attachments.Policy{MaxSize: 1024, Types: []string{"application/pdf", "text/plain"}}
+	sc := attachments.NewSignatureScanner()
+
+	cases := []struct {
+		content string
+		name    string
+		wantErr error
+	}{
+		{pdf, "../My Evidence (1).pdf", nil},
+		{"plain text", "notes.txt", nil},
+		{strings.Repeat("a", 1025), "big.txt", attachments.ErrTooLarge},
+		{"", "empty.txt", attachments.ErrEmpty},
+		{"\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16), "image.pdf", attachments.ErrType},
+		{"some text then " + eicar, "virus.txt", attachments.ErrInfected},
+	}
+
+	for _, c := range cases {
+		f, err := attachments.Receive(strings.NewReader(c.content), c.name, p, sc)
+		if errors.Cause(err) != c.wantErr {
+			t.Errorf("Receive(%s) err = %v, want %v", c.name, err, c.wantErr)
+		}
+		if err == nil {
+			f.Close()
+		}
+	}
+
+	f, err := attachments.Receive(strings.NewReader(pdf), "../My Evidence (1).pdf", p, nil)
+	if err != nil {
+		t.Fatalf("Receive() err = %s", err)
+	}
+	defer f.Close()
+	got := []string{f.Name, f.ContentType, f.MD5[:8], f.SHA256[:8]}
+	want := []string{"My-Evidence-1-.pdf", "application/pdf", "b697116f", "4579fbf2"}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("File = %v, want %v", got, want)
+			break
+		}
+	}
+	if f.Size != int64(len(pdf)) {
+		t.Errorf("File.Size = %d, want %d", f.Size, len(pdf))
+	}
+}
+
+// TestClamd runs the Clamd scanner against a fake clamd that reports the EICAR test file
+func TestClamd(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() err = %s", err)
+	}
+	defer l.Close()
+	go fakeClamd(l)
+
+	sc := attachments.Clamd{Addr: l.Addr().String()}
+	if err := sc.Scan(strings.NewReader(pdf)); err != nil {
+		t.Errorf("Clamd.Scan(clean) err = %s", err)
+	}
+	err = sc.Scan(strings.NewReader(strings.Repeat("x", 40000) + eicar))
+	if errors.Cause(err) != attachments.ErrInfected {
+		t.Errorf("Clamd.Scan(eicar) err = %v, want %v", err, attachments.ErrInfected)
+	}
+}
+
+func fakeClamd(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+				return
+			}
+			var content []byte
+			size := make([]byte, 4)
+			for {
+				if _, err := io.ReadFull(r, size); err != nil {
+					return
+				}
+				n := binary.BigEndian.Uint32(size)
+				if n == 0 {
+					break
+				}
+				chunk, _ := ioutil.ReadAll(io.LimitReader(r, int64(n)))
+				content = append(content, chunk...)
+			}
+			err := attachments.NewSignatureScanner().Scan(strings.NewReader(string(content)))
+			if err != nil {
+				conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+				return
+			}
+			conn.Write([]byte("stream: OK\x00"))
+		}(conn)
+	}
+}
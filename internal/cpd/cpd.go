@@ -10,6 +10,7 @@ import (
 	"gopkg.in/go-playground/validator.v9"
 
 	"github.com/cardiacsociety/web-services/internal/activity"
+	"github.com/cardiacsociety/web-services/internal/attachments"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 )
 
@@ -75,7 +76,7 @@ func DuplicateOf(ds datastore.Datastore, a Input) (int, error) {
 
 // Delete ensures the record is owned by MemberID before deleting from specified datastore - used for testing
 func Delete(ds datastore.Datastore, memberID, activityID int) error {
-	return delete(ds, memberID, activityID)
+	return remove(ds, memberID, activityID)
 }
 
 func cpdByID(ds datastore.Datastore, id int) (CPD, error) {
@@ -245,10 +246,9 @@ func add(ds datastore.Datastore, a Input) (int, error) {
 	query := `INSERT INTO ce_m_activity
 	(member_id, ce_activity_id, ce_activity_type_id, evidence, created_at, updated_at,
 	activity_on, quantity, points_per_unit, description)
-	VALUES("%v", "%v", "%v", "%v", NOW(), NOW(), "%v", "%v", "%v", "%v")`
-	query = fmt.Sprintf(query, a.MemberID, a.ActivityID, a.TypeID, evidence, a.Date, a.Quantity, a.UnitCredit, a.Description)
+	VALUES(?, ?, ?, ?, NOW(), NOW(), ?, ?, ?, ?)`
 
-	r, err := ds.MySQL.Session.Exec(query)
+	r, err := ds.MySQL.Session.Exec(query, a.MemberID, a.ActivityID, a.TypeID, evidence, a.Date, a.Quantity, a.UnitCredit, a.Description)
 	if err != nil {
 		return 0, err
 	}
@@ -282,11 +282,10 @@ func update(ds datastore.Datastore, a Input) error {
 		evidence = 1
 	}
 
-	query := `UPDATE ce_m_activity SET ce_activity_id= "%v", ce_activity_type_id= "%v", evidence= "%v",
-    updated_at = NOW(), activity_on = "%v", quantity= "%v", points_per_unit= "%v", description = "%v"
-    WHERE id = %v LIMIT 1`
-	query = fmt.Sprintf(query, a.ActivityID, a.TypeID, evidence, a.Date, a.Quantity, a.UnitCredit, a.Description, a.ID)
-	_, err = ds.MySQL.Session.Exec(query)
+	query := `UPDATE ce_m_activity SET ce_activity_id = ?, ce_activity_type_id = ?, evidence = ?,
+    updated_at = NOW(), activity_on = ?, quantity = ?, points_per_unit = ?, description = ?
+    WHERE id = ? LIMIT 1`
+	_, err = ds.MySQL.Session.Exec(query, a.ActivityID, a.TypeID, evidence, a.Date, a.Quantity, a.UnitCredit, a.Description, a.ID)
 	if err != nil {
 		return err
 	}
@@ -294,12 +293,20 @@ func update(ds datastore.Datastore, a Input) error {
 	return nil
 }
 
-// delete requires memberID to ensure ownership of the cpd record
-func delete(ds datastore.Datastore, memberID, activityID int) error {
+// remove requires memberID to ensure ownership of the cpd record. Attachments for the record
+// are also removed, so that their files are not left orphaned in storage.
+func remove(ds datastore.Datastore, memberID, activityID int) error {
 	query := `DELETE FROM ce_m_activity WHERE member_id = %d AND id = %d LIMIT 1`
 	query = fmt.Sprintf(query, memberID, activityID)
-	_, err := ds.MySQL.Session.Exec(query)
-	return err
+	result, err := ds.MySQL.Session.Exec(query)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return err
+	}
+	return attachments.DeleteByEntity(ds, "ce_m_activity_attachment", activityID)
 }
 
 func duplicateOf(ds datastore.Datastore, a Input) (int, error) {
@@ -312,11 +319,10 @@ func duplicateOf(ds datastore.Datastore, a Input) (int, error) {
 		return dupId, err
 	}
 
-	query := `SELECT id FROM ce_m_activity WHERE member_id = "%v" AND ce_activity_id = "%v" AND 
-		ce_activity_type_id = "%v" AND activity_on = "%v" AND description = "%v" LIMIT 1`
-	query = fmt.Sprintf(query, a.MemberID, a.ActivityID, a.TypeID, a.Date, a.Description)
+	query := `SELECT id FROM ce_m_activity WHERE member_id = ? AND ce_activity_id = ? AND
+		ce_activity_type_id = ? AND activity_on = ? AND description = ? LIMIT 1`
 
-	err = ds.MySQL.Session.QueryRow(query).Scan(&dupId)
+	err = ds.MySQL.Session.QueryRow(query, a.MemberID, a.ActivityID, a.TypeID, a.Date, a.Description).Scan(&dupId)
 	if err == sql.ErrNoRows {
 		return dupId, nil
 	}
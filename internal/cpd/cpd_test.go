@@ -28,6 +28,7 @@ func TestCPD(t *testing.T) {
 		t.Run("testAddCPD", testAddCPD)
 		t.Run("testUpdateCPD", testUpdateCPD)
 		t.Run("testDuplicateOf", testDuplicateOf)
+		t.Run("testAddDuplicateOfQuotes", testAddDuplicateOfQuotes)
 		t.Run("testDelete", testDelete)
 	})
 }
@@ -188,6 +189,38 @@ func testDuplicateOf(t *testing.T) {
 	}
 }
 
+// test that a description with quotes is stored as is, and found as a duplicate
+func testAddDuplicateOfQuotes(t *testing.T) {
+	c := cpd.Input{
+		MemberID:    1,
+		ActivityID:  24,
+		TypeID:      25,
+		Date:        "2018-06-01",
+		Quantity:    1,
+		Description: `Attended "Heart Failure" workshop; O'Brien's session`,
+	}
+	id, err := cpd.Add(ds, c)
+	if err != nil {
+		t.Fatalf("cpd.Add() err = %s", err)
+	}
+
+	r, err := cpd.ByID(ds, id)
+	if err != nil {
+		t.Fatalf("cpd.ByID(%d) err = %s", id, err)
+	}
+	if r.Description != c.Description {
+		t.Errorf("cpd.ByID(%d).Description = %q, want %q", id, r.Description, c.Description)
+	}
+
+	got, err := cpd.DuplicateOf(ds, c)
+	if err != nil {
+		t.Fatalf("cpd.DuplicateOf() err = %s", err)
+	}
+	if got != id {
+		t.Errorf("cpd.DuplicateOf() = %d, want %d", got, id)
+	}
+}
+
 func testDelete(t *testing.T) {
 
 	// get a count before deleting
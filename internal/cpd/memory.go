@@ -0,0 +1,206 @@
+package cpd
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/cardiacsociety/web-services/internal/activity"
+)
+
+// Memory is an in-memory Repository for unit tests. As with Store, cpd is validated and takes its
+// credit per unit, and activity details, from the activities.
+type Memory struct {
+	mu          sync.Mutex
+	activities  activity.Repository
+	cpd         map[int]CPD
+	evaluations []MemberActivityReport
+	lastID      int
+}
+
+// NewMemory returns an empty Memory that looks up activities in ar
+func NewMemory(ar activity.Repository) *Memory {
+	return &Memory{activities: ar, cpd: map[int]CPD{}}
+}
+
+// AddEvaluation adds an evaluation period for a member. The activities are ignored.
+func (m *Memory) AddEvaluation(e MemberActivityReport) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.Activities = nil
+	m.evaluations = append(m.evaluations, e)
+}
+
+// ByID returns a cpd record
+func (m *Memory) ByID(id int) (CPD, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.cpd[id]
+	if !ok {
+		return c, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+// ByMemberID returns all of a member's cpd, newest first
+func (m *Memory) ByMemberID(memberID int) ([]CPD, error) {
+	return m.filter(func(c CPD) bool { return c.MemberID == memberID }), nil
+}
+
+// ByMemberPeriod returns the member's cpd with a date in the period, newest first
+func (m *Memory) ByMemberPeriod(memberID int, startDate, endDate string) ([]CPD, error) {
+	return m.filter(func(c CPD) bool {
+		return c.MemberID == memberID && c.Date >= startDate && c.Date <= endDate
+	}), nil
+}
+
+// Add validates and adds a cpd record, and returns the new id
+func (m *Memory) Add(a Input) (int, error) {
+	c, err := m.record(a)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastID++
+	c.ID = m.lastID
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	m.cpd[c.ID] = c
+	return c.ID, nil
+}
+
+// Update validates and replaces a cpd record
+func (m *Memory) Update(a Input) error {
+	c, err := m.record(a)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.cpd[a.ID]
+	if !ok {
+		return nil
+	}
+	c.ID = a.ID
+	c.MemberID = old.MemberID
+	c.CreatedAt = old.CreatedAt
+	c.UpdatedAt = time.Now()
+	m.cpd[c.ID] = c
+	return nil
+}
+
+// Delete removes a cpd record if it belongs to the member
+func (m *Memory) Delete(memberID, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.cpd[id]; ok && c.MemberID == memberID {
+		delete(m.cpd, id)
+	}
+	return nil
+}
+
+// DuplicateOf returns the id of a matching cpd record, or 0 if there is none
+func (m *Memory) DuplicateOf(a Input) (int, error) {
+	if err := validator.New().Struct(a); err != nil {
+		return 0, err
+	}
+	xc := m.filter(func(c CPD) bool {
+		return c.MemberID == a.MemberID && c.Activity.ID == a.ActivityID && c.Type.ID == a.TypeID &&
+			c.Date == a.Date && c.Description == a.Description
+	})
+	if len(xc) == 0 {
+		return 0, nil
+	}
+	return xc[0].ID, nil
+}
+
+// Evaluations returns the member's evaluation periods
+func (m *Memory) Evaluations(memberID int) ([]MemberActivityReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var es []MemberActivityReport
+	for _, e := range m.evaluations {
+		if e.MemberID == memberID {
+			es = append(es, e)
+		}
+	}
+	return es, nil
+}
+
+// Summary adds up the member's cpd for an activity in the period
+func (m *Memory) Summary(memberID, activityID int, startDate, endDate string) (Summary, error) {
+	var sm Summary
+	xc, _ := m.ByMemberPeriod(memberID, startDate, endDate)
+	for _, c := range xc {
+		if c.Activity.ID != activityID {
+			continue
+		}
+		sm.Units += c.CreditData.Quantity
+		sm.CreditPerUnit = c.CreditData.UnitCredit
+		sm.Credit += c.CreditData.Quantity * c.CreditData.UnitCredit
+	}
+	return sm, nil
+}
+
+// record converts the input to a cpd record, as it would be read back from the database
+func (m *Memory) record(a Input) (CPD, error) {
+
+	var c CPD
+	if err := validator.New().Struct(a); err != nil {
+		return c, err
+	}
+	uc, err := m.activities.CreditPerUnit(a.ActivityID)
+	if err != nil {
+		return c, err
+	}
+	act, err := m.activities.ByID(a.ActivityID)
+	if err != nil {
+		return c, err
+	}
+	xt, err := m.activities.Types(a.ActivityID)
+	if err != nil {
+		return c, err
+	}
+
+	c = CPD{
+		MemberID:    a.MemberID,
+		Date:        a.Date,
+		Credit:      a.Quantity * uc,
+		Description: a.Description,
+		Evidence:    a.Evidence,
+		Category:    activity.Category{ID: act.CategoryID, Name: act.CategoryName},
+		Activity:    act,
+		Type:        activity.Type{ID: a.TypeID},
+		CreditData:  activity.Credit{Quantity: a.Quantity, UnitName: act.UnitName, UnitCredit: uc},
+	}
+	for _, t := range xt {
+		if t.ID == a.TypeID {
+			c.Type.Name = t.Name
+		}
+	}
+	c.DateISO, _ = time.Parse("2006-01-02", a.Date)
+	return c, nil
+}
+
+// filter returns the cpd records that match, newest first
+func (m *Memory) filter(match func(CPD) bool) []CPD {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var xc []CPD
+	for _, c := range m.cpd {
+		if match(c) {
+			xc = append(xc, c)
+		}
+	}
+	sort.Slice(xc, func(i, j int) bool {
+		if xc[i].Date != xc[j].Date {
+			return xc[i].Date > xc[j].Date
+		}
+		return xc[i].ID > xc[j].ID
+	})
+	return xc
+}
@@ -45,50 +45,39 @@ type activityRecord struct {
 
 // MemberActivityReports generates evaluation period reports for a member.
 func MemberActivityReports(ds datastore.Datastore, memberID int) ([]MemberActivityReport, error) {
+	return Reports(NewStore(ds), activity.NewStore(ds), memberID)
+}
 
-	var es []MemberActivityReport
+// CurrentEvaluationPeriodReport returns a MemberActivityReport for the current evaluation period.
+func CurrentEvaluationPeriodReport(ds datastore.Datastore, memberID int) (MemberActivityReport, error) {
+	return CurrentReport(NewStore(ds), activity.NewStore(ds), memberID)
+}
 
-	query := `SELECT cme.id, cme.member_id, ce.name,
-	cme.cpd_points_required, cme.start_on, cme.end_on, cme.closed
-	FROM ce_m_evaluation cme
-	LEFT JOIN ce_evaluation ce ON cme.ce_evaluation_id = ce.id
-	WHERE member_id = ?`
+// Reports generates the evaluation period reports for a member, from the cpd and activity repositories
+func Reports(cr Repository, ar activity.Repository, memberID int) ([]MemberActivityReport, error) {
 
-	rows, err := ds.MySQL.Session.Query(query, memberID)
+	es, err := cr.Evaluations(memberID)
 	if err != nil {
 		return es, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		e := MemberActivityReport{}
-		rows.Scan(
-			&e.ID,
-			&e.MemberID,
-			&e.ReportName,
-			&e.CreditRequired,
-			&e.StartDate,
-			&e.EndDate,
-			&e.Closed,
-		)
-
-		err := e.generateActivitySummary(ds)
+
+	for i := range es {
+		err := es[i].generateActivitySummary(cr, ar)
 		if err != nil {
 			return es, err
 		}
-
-		es = append(es, e)
 	}
 
 	return es, nil
 }
 
-// CurrentEvaluationPeriodReport returns a MemberActivityReport for the current evaluation period.
-func CurrentEvaluationPeriodReport(ds datastore.Datastore, memberID int) (MemberActivityReport, error) {
+// CurrentReport returns the report for the member's current evaluation period, the last one that is
+// not closed.
+func CurrentReport(cr Repository, ar activity.Repository, memberID int) (MemberActivityReport, error) {
 
 	var me MemberActivityReport
 
-	xme, err := MemberActivityReports(ds, memberID)
+	xme, err := Reports(cr, ar, memberID)
 	if err != nil {
 		return me, err
 	}
@@ -102,10 +91,10 @@ func CurrentEvaluationPeriodReport(ds datastore.Datastore, memberID int) (Member
 	return me, nil
 }
 
-func (e *MemberActivityReport) generateActivitySummary(ds datastore.Datastore) error {
+func (e *MemberActivityReport) generateActivitySummary(cr Repository, ar activity.Repository) error {
 
 	// Need empty activities on the report, could not sort with JOIN in a single query as empty activities were omitted
-	xa, err := activity.All(ds)
+	xa, err := ar.All()
 	if err != nil {
 		return err
 	}
@@ -116,8 +105,11 @@ func (e *MemberActivityReport) generateActivitySummary(ds datastore.Datastore) e
 			ActivityName: a.Name,
 			MaxCredit:    a.MaxCredit,
 		}
-		ar.summary(ds, *e)
-		ar.fetchActivityRecords(ds, e.MemberID, e.StartDate, e.EndDate)
+		err := ar.summary(cr, *e)
+		if err != nil {
+			return err
+		}
+		ar.fetchActivityRecords(cr, e.MemberID, e.StartDate, e.EndDate)
 		e.Activities = append(e.Activities, ar)
 	}
 
@@ -127,28 +119,23 @@ func (e *MemberActivityReport) generateActivitySummary(ds datastore.Datastore) e
 }
 
 // summary fills in the details for one activity in a report
-func (a *activityReport) summary(ds datastore.Datastore, e MemberActivityReport) error {
-
-	query := Queries["select-cpd-summary-by-activity-id"]
-	rows := ds.MySQL.Session.QueryRow(query, e.StartDate, e.EndDate, e.MemberID, a.ActivityID)
-	err := rows.Scan(
-		&a.ActivityUnits,
-		&a.CreditPerUnit,
-		&a.CreditTotal,
-	)
+func (a *activityReport) summary(cr Repository, e MemberActivityReport) error {
+
+	sm, err := cr.Summary(e.MemberID, a.ActivityID, e.StartDate, e.EndDate)
 	if err != nil {
 		return err
 	}
+	a.ActivityUnits = sm.Units
+	a.CreditPerUnit = sm.CreditPerUnit
+	a.CreditTotal = sm.Credit
 
 	a.capCreditTotal()
 
 	return nil
 }
 
-func (a *activityReport) fetchActivityRecords(ds datastore.Datastore, memberID int, startDate, endDate string) {
-	clause := `WHERE member_id = %d AND cma.activity_on >= "%s" AND cma.activity_on <= "%s" ORDER BY cma.activity_on DESC`
-	clause = fmt.Sprintf(clause, memberID, startDate, endDate)
-	ma, err := Query(ds, clause)
+func (a *activityReport) fetchActivityRecords(cr Repository, memberID int, startDate, endDate string) {
+	ma, err := cr.ByMemberPeriod(memberID, startDate, endDate)
 	if err != nil {
 		fmt.Println(err)
 		return
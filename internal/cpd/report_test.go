@@ -0,0 +1,73 @@
+package cpd_test
+
+import (
+	"testing"
+
+	"github.com/cardiacsociety/web-services/internal/activity"
+	"github.com/cardiacsociety/web-services/internal/cpd"
+)
+
+// TestReports checks the report totals and caps with the in-memory repositories, so it does not need
+// the test databases
+func TestReports(t *testing.T) {
+
+	ar := activity.NewMemory(
+		activity.Activity{ID: 1, Name: "Meetings", UnitName: "hour", CreditPerUnit: 1.5, MaxCredit: 10},
+		activity.Activity{ID: 2, Name: "Reading", UnitName: "hour", CreditPerUnit: 2, MaxCredit: 50},
+	)
+	ar.AddTypes(1, activity.Type{ID: 11, Name: "Conference"})
+	ar.AddTypes(2, activity.Type{ID: 21, Name: "Journal"})
+
+	cr := cpd.NewMemory(ar)
+	cr.AddEvaluation(cpd.MemberActivityReport{ID: 1, MemberID: 7, StartDate: "2018-01-01", EndDate: "2018-12-31",
+		Closed: true, CreditRequired: 50})
+	cr.AddEvaluation(cpd.MemberActivityReport{ID: 2, MemberID: 7, StartDate: "2019-01-01", EndDate: "2019-12-31",
+		CreditRequired: 50})
+
+	for _, in := range []cpd.Input{
+		{MemberID: 7, ActivityID: 1, TypeID: 11, Date: "2019-03-01", Quantity: 8, Description: "Annual scientific meeting"},
+		{MemberID: 7, ActivityID: 2, TypeID: 21, Date: "2019-04-01", Quantity: 5, Description: "Heart, Lung and Circulation"},
+		{MemberID: 7, ActivityID: 2, TypeID: 21, Date: "2018-06-01", Quantity: 1, Description: "Heart, Lung and Circulation"},
+		{MemberID: 8, ActivityID: 2, TypeID: 21, Date: "2019-04-01", Quantity: 3, Description: "Another member"},
+	} {
+		if _, err := cr.Add(in); err != nil {
+			t.Fatalf("Add() err = %s", err)
+		}
+	}
+
+	xr, err := cpd.Reports(cr, ar, 7)
+	if err != nil {
+		t.Fatalf("Reports() err = %s", err)
+	}
+	if len(xr) != 2 {
+		t.Fatalf("Reports() count = %d, want 2", len(xr))
+	}
+	if xr[0].CreditObtained != 2 {
+		t.Errorf("Reports()[0].CreditObtained = %v, want 2", xr[0].CreditObtained)
+	}
+
+	r, err := cpd.CurrentReport(cr, ar, 7)
+	if err != nil {
+		t.Fatalf("CurrentReport() err = %s", err)
+	}
+	if r.ID != 2 {
+		t.Errorf("CurrentReport().ID = %d, want 2", r.ID)
+	}
+	cases := []struct {
+		total   float64
+		awarded float64
+	}{
+		{12, 10}, // capped at the max credit
+		{10, 10},
+	}
+	for i, c := range cases {
+		a := r.Activities[i]
+		if a.CreditTotal != c.total || a.CreditAwarded != c.awarded {
+			t.Errorf("CurrentReport() activity %d credit = %v, awarded %v, want %v, %v", a.ActivityID,
+				a.CreditTotal, a.CreditAwarded, c.total, c.awarded)
+		}
+	}
+	if r.CreditObtained != 20 {
+		t.Errorf("CurrentReport().CreditObtained = %v, want 20", r.CreditObtained)
+	}
+}
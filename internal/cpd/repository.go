@@ -0,0 +1,134 @@
+package cpd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Repository provides the cpd records and evaluation periods of members, so that the reports, and the
+// handlers that use them, can be tested with Memory instead of a database
+type Repository interface {
+	ByID(id int) (CPD, error)
+	ByMemberID(memberID int) ([]CPD, error)
+	// ByMemberPeriod returns the member's cpd with a date in the period, newest first
+	ByMemberPeriod(memberID int, startDate, endDate string) ([]CPD, error)
+	Add(a Input) (int, error)
+	Update(a Input) error
+	Delete(memberID, id int) error
+	DuplicateOf(a Input) (int, error)
+	// Evaluations returns the member's evaluation periods, as reports without the activities
+	Evaluations(memberID int) ([]MemberActivityReport, error)
+	// Summary adds up the member's cpd for an activity in the period
+	Summary(memberID, activityID int, startDate, endDate string) (Summary, error)
+}
+
+// Summary is the total of a member's cpd for an activity
+type Summary struct {
+	Units         float64
+	CreditPerUnit float64
+	Credit        float64
+}
+
+var (
+	_ Repository = Store{}
+	_ Repository = (*Memory)(nil)
+)
+
+// Store is the Repository for the MySQL database
+type Store struct {
+	ds datastore.Datastore
+}
+
+// NewStore returns a Repository for the MySQL database in ds
+func NewStore(ds datastore.Datastore) Store {
+	return Store{ds: ds}
+}
+
+// ByID fetches a cpd record
+func (s Store) ByID(id int) (CPD, error) {
+	return cpdByID(s.ds, id)
+}
+
+// ByMemberID fetches all of a member's cpd, newest first
+func (s Store) ByMemberID(memberID int) ([]CPD, error) {
+	return cpdByMemberID(s.ds, memberID)
+}
+
+// ByMemberPeriod fetches the member's cpd with a date in the period, newest first
+func (s Store) ByMemberPeriod(memberID int, startDate, endDate string) ([]CPD, error) {
+	clause := `WHERE member_id = %d AND cma.activity_on >= "%s" AND cma.activity_on <= "%s" ORDER BY cma.activity_on DESC`
+	return cpdQuery(s.ds, fmt.Sprintf(clause, memberID, startDate, endDate))
+}
+
+// Add inserts a cpd record and returns the new id
+func (s Store) Add(a Input) (int, error) {
+	return add(s.ds, a)
+}
+
+// Update updates a cpd record
+func (s Store) Update(a Input) error {
+	return update(s.ds, a)
+}
+
+// Delete deletes a cpd record that belongs to the member
+func (s Store) Delete(memberID, id int) error {
+	return remove(s.ds, memberID, id)
+}
+
+// DuplicateOf returns the id of a matching cpd record, or 0 if there is none
+func (s Store) DuplicateOf(a Input) (int, error) {
+	return duplicateOf(s.ds, a)
+}
+
+// Evaluations fetches the member's evaluation periods
+func (s Store) Evaluations(memberID int) ([]MemberActivityReport, error) {
+
+	var es []MemberActivityReport
+
+	query := `SELECT cme.id, cme.member_id, ce.name,
+	cme.cpd_points_required, cme.start_on, cme.end_on, cme.closed
+	FROM ce_m_evaluation cme
+	LEFT JOIN ce_evaluation ce ON cme.ce_evaluation_id = ce.id
+	WHERE member_id = ?`
+
+	rows, err := s.ds.MySQL.Session.Query(query, memberID)
+	if err != nil {
+		return es, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := MemberActivityReport{}
+		rows.Scan(
+			&e.ID,
+			&e.MemberID,
+			&e.ReportName,
+			&e.CreditRequired,
+			&e.StartDate,
+			&e.EndDate,
+			&e.Closed,
+		)
+		es = append(es, e)
+	}
+
+	return es, nil
+}
+
+// Summary adds up the member's active cpd for an activity in the period. The summary is zero if there
+// is no cpd.
+func (s Store) Summary(memberID, activityID int, startDate, endDate string) (Summary, error) {
+
+	var sm Summary
+	query := Queries["select-cpd-summary-by-activity-id"]
+	err := s.ds.MySQL.Session.QueryRow(query, startDate, endDate, memberID, activityID).Scan(
+		&sm.Units,
+		&sm.CreditPerUnit,
+		&sm.Credit,
+	)
+	if err == sql.ErrNoRows {
+		return sm, nil
+	}
+	return sm, err
+}
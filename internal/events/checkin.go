@@ -0,0 +1,267 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Check in error messages
+const (
+	ErrorTicket        = "ticket is not valid"
+	ErrorNotRegistered = "ticket is for a registration that is not confirmed"
+	ErrorScannedAt     = "scannedAt is not a time in RFC3339 format"
+	ErrorNoScannedAt   = "scannedAt is required for a scan that is synced"
+)
+
+// scanTimeFormat is the MySQL DATETIME format used for scan times
+const scanTimeFormat = "2006-01-02 15:04:05"
+
+// Ticket identifies a registration. It is encoded in the QR code on the ticket that is sent to the
+// member, and is signed so that the check in can be verified when the code is scanned at the event.
+type Ticket struct {
+	EventID        int `json:"eventId"`
+	RegistrationID int `json:"registrationId"`
+	MemberID       int `json:"memberId"`
+}
+
+// Scan is a scanned ticket. ScannedAt is the time of the scan in RFC3339 format, so that scans made
+// at an event without a connection can be synced later. If it is empty the time received is used,
+// except by SyncCheckIns, which requires it.
+type Scan struct {
+	Token     string `json:"token"`
+	ScannedAt string `json:"scannedAt"`
+	Device    string `json:"device"`
+}
+
+// CheckIn records a scan of a ticket. Duplicate is true if the registration had already been
+// checked in when the scan was received.
+type CheckIn struct {
+	ID             int    `json:"id" bson:"id"`
+	RegistrationID int    `json:"registrationId" bson:"registrationId"`
+	EventID        int    `json:"eventId" bson:"eventId"`
+	MemberID       int    `json:"memberId" bson:"memberId"`
+	DateCreated    string `json:"dateCreated" bson:"dateCreated"`
+	DateScanned    string `json:"dateScanned" bson:"dateScanned"`
+	Device         string `json:"device" bson:"device"`
+	Duplicate      bool   `json:"duplicate" bson:"duplicate"`
+}
+
+// CheckInResult is the outcome of one scan in a batch
+type CheckInResult struct {
+	Token   string   `json:"token"`
+	CheckIn *CheckIn `json:"checkIn,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// TicketKey returns the key used to sign tickets, from the env var MAPPCPD_TICKET_SIGNING_KEY. The key
+// is not shared with anything else, so it is an error if it is not set.
+func TicketKey() ([]byte, error) {
+	key := os.Getenv("MAPPCPD_TICKET_SIGNING_KEY")
+	if key == "" {
+		return nil, errors.New("env var MAPPCPD_TICKET_SIGNING_KEY is required to sign tickets")
+	}
+	return []byte(key), nil
+}
+
+// Ticket returns the ticket for a registration
+func (r Registration) Ticket() Ticket {
+	return Ticket{EventID: r.EventID, RegistrationID: r.ID, MemberID: r.MemberID}
+}
+
+// Token returns the signed ticket as a short string, suitable for a QR code, in the form
+// eventID.registrationID.memberID.signature
+func (t Ticket) Token(key []byte) string {
+	payload := fmt.Sprintf("%d.%d.%d", t.EventID, t.RegistrationID, t.MemberID)
+	return payload + "." + ticketSignature(payload, key)
+}
+
+// ParseTicket verifies the signature of a ticket token and returns the ticket
+func ParseTicket(token string, key []byte) (Ticket, error) {
+	var t Ticket
+	i := strings.LastIndex(token, ".")
+	if i < 0 || len(key) == 0 {
+		return t, errors.New(ErrorTicket)
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(ticketSignature(payload, key))) {
+		return t, errors.New(ErrorTicket)
+	}
+	xs := strings.Split(payload, ".")
+	if len(xs) != 3 {
+		return t, errors.New(ErrorTicket)
+	}
+	var xi [3]int
+	for i, s := range xs {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return t, errors.New(ErrorTicket)
+		}
+		xi[i] = n
+	}
+	return Ticket{EventID: xi[0], RegistrationID: xi[1], MemberID: xi[2]}, nil
+}
+
+// ticketSignature is a truncated HMAC, which keeps the QR code small
+func ticketSignature(payload string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("ticket:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// CheckInScan verifies a scanned ticket and records the check in. The first check in for a registration
+// records the member's attendance, along with any CPD for the event. Later scans are recorded as
+// duplicates. Syncing the same scan from the same device more than once returns the original check in.
+func CheckInScan(ds datastore.Datastore, s Scan, key []byte) (CheckIn, error) {
+
+	t, err := ParseTicket(s.Token, key)
+	if err != nil {
+		return CheckIn{}, err
+	}
+
+	scannedAt := time.Now()
+	if s.ScannedAt != "" {
+		scannedAt, err = time.Parse(time.RFC3339, s.ScannedAt)
+		if err != nil {
+			return CheckIn{}, errors.New(ErrorScannedAt)
+		}
+	}
+	at := scannedAt.In(time.Local).Format(scanTimeFormat)
+
+	r, err := RegistrationByID(ds, t.RegistrationID)
+	if err == sql.ErrNoRows || (err == nil && (r.EventID != t.EventID || r.MemberID != t.MemberID)) {
+		return CheckIn{}, errors.New(ErrorTicket)
+	}
+	if err != nil {
+		return CheckIn{}, err
+	}
+	if r.Status != StatusRegistered && r.Status != StatusAttended {
+		return CheckIn{}, errors.New(ErrorNotRegistered)
+	}
+
+	q := queries["select-checkin"] + " WHERE c.ce_event_registration_id = ? AND c.device = ? AND c.scanned_at = ?"
+	xc, err := executeCheckIn(ds, q, r.ID, s.Device, at)
+	if err != nil {
+		return CheckIn{}, err
+	}
+	if len(xc) > 0 {
+		return xc[0], nil
+	}
+
+	duplicate := r.DateCheckedIn != ""
+	res, err := ds.MySQL.Session.Exec(queries["insert-checkin"], r.ID, at, s.Device, duplicate)
+	if err != nil {
+		return CheckIn{}, errors.Wrap(err, "insert check in")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return CheckIn{}, err
+	}
+	if _, err := ds.MySQL.Session.Exec(queries["update-registration-checkin"], at, at, r.ID); err != nil {
+		return CheckIn{}, errors.Wrap(err, "update registration")
+	}
+	if !duplicate {
+		if _, err := MarkAttended(ds, r.EventID, r.MemberID); err != nil {
+			return CheckIn{}, errors.Wrap(err, "mark attended")
+		}
+	}
+
+	return CheckInByID(ds, int(id))
+}
+
+// SyncCheckIns records a batch of scans, such as those made by a device without a connection.
+// Scans are processed in the order they were made so that the earliest scan of each ticket is
+// the check in. A scan without a ScannedAt time cannot be put in order, and would be recorded as
+// made now, after scans that were really later, so it is rejected. A failure for one scan does not
+// prevent the others from being recorded, and the results are returned in the same order as the scans.
+func SyncCheckIns(ds datastore.Datastore, xs []Scan, key []byte) []CheckInResult {
+
+	order := make([]int, len(xs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, xs[order[i]].ScannedAt)
+		tj, _ := time.Parse(time.RFC3339, xs[order[j]].ScannedAt)
+		return ti.Before(tj)
+	})
+
+	results := make([]CheckInResult, len(xs))
+	for _, i := range order {
+		res := CheckInResult{Token: xs[i].Token}
+		if xs[i].ScannedAt == "" {
+			res.Error = ErrorNoScannedAt
+			results[i] = res
+			continue
+		}
+		c, err := CheckInScan(ds, xs[i], key)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.CheckIn = &c
+		}
+		results[i] = res
+	}
+	return results
+}
+
+// CheckInByID fetches a single CheckIn by ID
+func CheckInByID(ds datastore.Datastore, id int) (CheckIn, error) {
+	xc, err := executeCheckIn(ds, queries["select-checkin"]+" WHERE c.id = ?", id)
+	if err != nil {
+		return CheckIn{}, err
+	}
+	if len(xc) == 0 {
+		return CheckIn{}, sql.ErrNoRows
+	}
+	return xc[0], nil
+}
+
+// CheckIns fetches all of the scans for an event, in the order they were made
+func CheckIns(ds datastore.Datastore, eventID int) ([]CheckIn, error) {
+	q := queries["select-checkin"] + " WHERE r.ce_event_id = ? ORDER BY c.scanned_at ASC, c.id ASC"
+	return executeCheckIn(ds, q, eventID)
+}
+
+// executeCheckIn runs a check in query and scans the results into a []CheckIn
+func executeCheckIn(ds datastore.Datastore, query string, args ...interface{}) ([]CheckIn, error) {
+
+	var xc []CheckIn
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xc, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := CheckIn{}
+		err := rows.Scan(
+			&c.ID,
+			&c.RegistrationID,
+			&c.EventID,
+			&c.MemberID,
+			&c.DateCreated,
+			&c.DateScanned,
+			&c.Device,
+			&c.Duplicate,
+		)
+		if err != nil {
+			return xc, errors.Wrap(err, "failed to scan check in row")
+		}
+		xc = append(xc, c)
+	}
+
+	return xc, rows.Err()
+}
@@ -3,14 +3,29 @@ package events
 
 import (
 	"database/sql"
-	"log"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 	"github.com/pkg/errors"
 )
 
+// Error messages
+const (
+	ErrorIDNotNil   = "cannot insert an event row because Event.ID already has a value"
+	ErrorNoID       = "cannot update an event because Event.ID is not set"
+	ErrorNoName     = "Event.Name is empty"
+	ErrorNoLocation = "Event.Location is empty"
+	ErrorDateStart  = "Event.DateStart is missing or is not a date in the format YYYY-MM-DD"
+	ErrorDateEnd    = "Event.DateEnd is not a date in the format YYYY-MM-DD, or is before DateStart"
+	ErrorCapacity   = "Event.Capacity cannot be negative"
+	ErrorCPD        = "Event.ActivityTypeID and Event.CPDQuantity are required when Event.ActivityID is set"
+)
+
+// DateFormat is the format of the event start and end dates
+const DateFormat = "2006-01-02"
+
 // Event is a conference, workshop or some other calendar event that is relevant to CPD activity
 type Event struct {
 	ID          int    `json:"id" bson:"id"`
@@ -22,93 +37,78 @@ type Event struct {
 	Name        string `json:"name" bson:"name"`
 	Description string `json:"description" bson:"description"`
 	URL         string `json:"url" bson:"url"`
+
+	// Capacity is the maximum number of registrations, further registrations are waitlisted. Zero for no limit.
+	Capacity int `json:"capacity" bson:"capacity"`
+
+	// CPD recorded for members who attend the event. If ActivityID is zero attendance is recorded without CPD.
+	ActivityID     int     `json:"activityId" bson:"activityId"`
+	ActivityTypeID int     `json:"activityTypeId" bson:"activityTypeId"`
+	CPDQuantity    float64 `json:"cpdQuantity" bson:"cpdQuantity"`
+}
+
+// Filter specifies criteria for selecting events. From and To are dates in the format YYYY-MM-DD and
+// include events that start on or after From, and end on or before To. Location matches any part of
+// the event location.
+type Filter struct {
+	From     string
+	To       string
+	Location string
 }
 
 // ByID fetches a single Event by ID
 func ByID(ds datastore.Datastore, id int) (Event, error) {
+	xe, err := execute(ds, queries["select-event"]+" AND id = ?", id)
+	if err != nil {
+		return Event{}, err
+	}
+	if len(xe) == 0 {
+		return Event{}, sql.ErrNoRows
+	}
+	return xe[0], nil
+}
 
-	// Create Note value
-	e := Event{ID: id}
-
-	// Coalesce any NULL-able fields
-	q := `SELECT created_at, updated_at,
-		  COALESCE(start_on, ''),
-		  COALESCE(end_on, ''),
-		  COALESCE(location, ''),
-		  COALESCE(name, ''),
-		  COALESCE(description, ''),
-		  COALESCE(information_url, '')
-          FROM ce_event WHERE id = ?
-          ORDER BY start_on DESC`
-
-	err := ds.MySQL.Session.QueryRow(q, id).Scan(
-		&e.DateCreated,
-		&e.DateUpdated,
-		&e.DateStart,
-		&e.DateEnd,
-		&e.Location,
-		&e.Name,
-		&e.Description,
-		&e.URL,
-	)
-
-	return e, err
+// Query fetches the events matching the criteria in Filter, ordered by start date
+func Query(ds datastore.Datastore, f Filter) ([]Event, error) {
+	where, args := f.clauses()
+	q := queries["select-event"] + where + " ORDER BY start_on ASC, id ASC"
+	return execute(ds, q, args...)
+}
+
+// clauses returns the additional WHERE clauses and arguments specified by the Filter
+func (f Filter) clauses() (string, []interface{}) {
+	var xs []string
+	var args []interface{}
+	if f.From != "" {
+		xs = append(xs, "start_on >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		xs = append(xs, "COALESCE(end_on, start_on) <= ?")
+		args = append(args, f.To)
+	}
+	if f.Location != "" {
+		xs = append(xs, "location LIKE ?")
+		args = append(args, "%"+f.Location+"%")
+	}
+	if len(xs) == 0 {
+		return "", args
+	}
+	return " AND " + strings.Join(xs, " AND "), args
 }
 
 // ByDateRange returns Events that have a start date within the specified date range, including the start and end dates
 func ByDateRange(ds datastore.Datastore, start, end time.Time) ([]Event, error) {
 
-	var xe []Event
-
 	// MySQL DATE format
-	sd := start.Format("2006-01-02")
-	ed := end.Format("2006-01-02")
-
-	q := `SELECT id, created_at, updated_at,
-		  COALESCE(start_on, ''),
-		  COALESCE(end_on, ''),
-		  COALESCE(location, ''),
-		  COALESCE(name, ''),
-		  COALESCE(description, ''),
-		  COALESCE(information_url, '')
-		  FROM ce_event WHERE
-		  start_on >= ? AND end_on <= ?
-		  ORDER BY start_on DESC`
-
-	rows, err := ds.MySQL.Session.Query(q, sd, ed)
-	switch {
-	case err == sql.ErrNoRows:
-		return xe, nil
-	case err != nil:
-		msg := "ByDateRange() sql error"
-		return xe, errors.Wrap(err, msg)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
+	sd := start.Format(DateFormat)
+	ed := end.Format(DateFormat)
 
-		e := Event{}
-
-		err := rows.Scan(
-			&e.ID,
-			&e.DateCreated,
-			&e.DateUpdated,
-			&e.DateStart,
-			&e.DateEnd,
-			&e.Location,
-			&e.Name,
-			&e.Description,
-			&e.URL,
-		)
-		if err != nil {
-			msg := "ByDateRange() failed to scan row"
-			log.Println(msg, err)
-			return xe, errors.Wrap(err, msg)
-		}
-
-		xe = append(xe, e)
+	q := queries["select-event"] + " AND start_on >= ? AND end_on <= ? ORDER BY start_on DESC"
+	xe, err := execute(ds, q, sd, ed)
+	if err != nil {
+		return xe, errors.Wrap(err, "ByDateRange() sql error")
 	}
-
 	return xe, nil
 }
 
@@ -146,3 +146,132 @@ func Future(ds datastore.Datastore, days int) ([]Event, error) {
 
 	return DaysRange(ds, 0, days)
 }
+
+// InsertRow creates a new event row with fields from Event. A single day event may have no DateEnd,
+// in which case it is set to DateStart.
+func (e *Event) InsertRow(ds datastore.Datastore) error {
+	if e.ID > 0 {
+		return errors.New(ErrorIDNotNil)
+	}
+	if err := e.validate(); err != nil {
+		return err
+	}
+	res, err := ds.MySQL.Session.Exec(queries["insert-event"], e.args()...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(id)
+	return nil
+}
+
+// Update saves the fields of an existing event
+func (e *Event) Update(ds datastore.Datastore) error {
+	if e.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	if err := e.validate(); err != nil {
+		return err
+	}
+	args := append(e.args(), e.ID)
+	_, err := ds.MySQL.Session.Exec(queries["update-event"], args...)
+	return err
+}
+
+// Delete soft-deletes an event
+func (e *Event) Delete(ds datastore.Datastore) error {
+	if e.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	_, err := ds.MySQL.Session.Exec(queries["delete-event"], e.ID)
+	return err
+}
+
+// validate checks the required fields and dates
+func (e *Event) validate() error {
+	if e.Name == "" {
+		return errors.New(ErrorNoName)
+	}
+	if e.Location == "" {
+		return errors.New(ErrorNoLocation)
+	}
+	start, err := time.Parse(DateFormat, e.DateStart)
+	if err != nil {
+		return errors.New(ErrorDateStart)
+	}
+	if e.DateEnd == "" {
+		e.DateEnd = e.DateStart
+	}
+	end, err := time.Parse(DateFormat, e.DateEnd)
+	if err != nil || end.Before(start) {
+		return errors.New(ErrorDateEnd)
+	}
+	if e.Capacity < 0 {
+		return errors.New(ErrorCapacity)
+	}
+	if e.ActivityID > 0 && (e.ActivityTypeID == 0 || e.CPDQuantity <= 0) {
+		return errors.New(ErrorCPD)
+	}
+	return nil
+}
+
+// args returns the values for the insert and update queries
+func (e *Event) args() []interface{} {
+	var url sql.NullString
+	if e.URL != "" {
+		url = sql.NullString{String: e.URL, Valid: true}
+	}
+	return []interface{}{e.DateStart, e.DateEnd, e.Location, e.Name, e.Description, url,
+		nullInt(e.Capacity), nullInt(e.ActivityID), nullInt(e.ActivityTypeID), nullFloat(e.CPDQuantity)}
+}
+
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+func nullFloat(f float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f, Valid: f != 0}
+}
+
+// execute runs an event query and scans the results into an []Event
+func execute(ds datastore.Datastore, query string, args ...interface{}) ([]Event, error) {
+
+	var xe []Event
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xe, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		e := Event{}
+
+		err := rows.Scan(
+			&e.ID,
+			&e.DateCreated,
+			&e.DateUpdated,
+			&e.DateStart,
+			&e.DateEnd,
+			&e.Location,
+			&e.Name,
+			&e.Description,
+			&e.URL,
+			&e.Capacity,
+			&e.ActivityID,
+			&e.ActivityTypeID,
+			&e.CPDQuantity,
+		)
+		if err != nil {
+			return xe, errors.Wrap(err, "failed to scan event row")
+		}
+
+		xe = append(xe, e)
+	}
+
+	return xe, rows.Err()
+}
@@ -0,0 +1,454 @@
+package events_test
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/events"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/testdata"
+)
+
+var ds datastore.Datastore
+
+func TestEvents(t *testing.T) {
+
+	var teardown func()
+	ds, teardown = setup()
+	defer teardown()
+
+	t.Run("events", func(t *testing.T) {
+		t.Run("testPingDatabase", testPingDatabase)
+		t.Run("testByID", testByID)
+		t.Run("testQuery", testQuery)
+		t.Run("testInsertRow", testInsertRow)
+		t.Run("testInsertRowErrors", testInsertRowErrors)
+		t.Run("testUpdate", testUpdate)
+		t.Run("testDelete", testDelete)
+		t.Run("testRegister", testRegister)
+		t.Run("testCancel", testCancel)
+		t.Run("testMarkAttended", testMarkAttended)
+		t.Run("testMarkAttendedConcurrent", testMarkAttendedConcurrent)
+		t.Run("testCheckIn", testCheckIn)
+	})
+}
+
+func setup() (datastore.Datastore, func()) {
+	var db = testdata.NewDataStore()
+	err := db.SetupMySQL()
+	if err != nil {
+		log.Fatalf("SetupMySQL() err = %s", err)
+	}
+	return db.Store, func() {
+		err := db.TearDownMySQL()
+		if err != nil {
+			log.Fatalf("TearDownMySQL() err = %s", err)
+		}
+	}
+}
+
+func testPingDatabase(t *testing.T) {
+	err := ds.MySQL.Session.Ping()
+	if err != nil {
+		t.Fatalf("Ping() err = %s", err)
+	}
+}
+
+func testByID(t *testing.T) {
+	e, err := events.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("events.ByID(1) err = %s", err)
+	}
+	want := "CSANZ Annual Scientific Meeting"
+	if e.Name != want {
+		t.Errorf("Event.Name = %q, want %q", e.Name, want)
+	}
+	// soft deleted
+	_, err = events.ByID(ds, 4)
+	if err != sql.ErrNoRows {
+		t.Errorf("events.ByID(4) err = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func testQuery(t *testing.T) {
+	cases := []struct {
+		arg  events.Filter
+		want int // count
+	}{
+		{events.Filter{}, 3},
+		{events.Filter{From: "2019-06-01"}, 2},
+		{events.Filter{From: "2019-05-01", To: "2019-08-31"}, 2},
+		{events.Filter{To: "2019-08-10"}, 1},
+		{events.Filter{Location: "vic"}, 1},
+		{events.Filter{From: "2019-06-01", Location: "NZ"}, 1},
+	}
+	for _, c := range cases {
+		xe, err := events.Query(ds, c.arg)
+		if err != nil {
+			t.Fatalf("events.Query(%+v) err = %s", c.arg, err)
+		}
+		got := len(xe)
+		if got != c.want {
+			t.Errorf("events.Query(%+v) count = %d, want %d", c.arg, got, c.want)
+		}
+	}
+}
+
+func testInsertRow(t *testing.T) {
+	e := events.Event{
+		DateStart: "2020-02-14",
+		Location:  "Hobart, TAS",
+		Name:      "Heart Failure Symposium",
+	}
+	if err := e.InsertRow(ds); err != nil {
+		t.Fatalf("Event.InsertRow() err = %s", err)
+	}
+	got, err := events.ByID(ds, e.ID)
+	if err != nil {
+		t.Fatalf("events.ByID(%d) err = %s", e.ID, err)
+	}
+	// single day event
+	if got.DateEnd != "2020-02-14" {
+		t.Errorf("Event.DateEnd = %q, want %q", got.DateEnd, "2020-02-14")
+	}
+}
+
+func testInsertRowErrors(t *testing.T) {
+	cases := []struct {
+		arg  events.Event
+		want string
+	}{
+		{events.Event{ID: 1, Name: "n", Location: "l", DateStart: "2020-01-01"}, events.ErrorIDNotNil},
+		{events.Event{Location: "l", DateStart: "2020-01-01"}, events.ErrorNoName},
+		{events.Event{Name: "n", DateStart: "2020-01-01"}, events.ErrorNoLocation},
+		{events.Event{Name: "n", Location: "l", DateStart: "1/1/2020"}, events.ErrorDateStart},
+		{events.Event{Name: "n", Location: "l", DateStart: "2020-01-02", DateEnd: "2020-01-01"}, events.ErrorDateEnd},
+		{events.Event{Name: "n", Location: "l", DateStart: "2020-01-01", Capacity: -1}, events.ErrorCapacity},
+		{events.Event{Name: "n", Location: "l", DateStart: "2020-01-01", ActivityID: 20}, events.ErrorCPD},
+	}
+	for _, c := range cases {
+		err := c.arg.InsertRow(ds)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("Event.InsertRow() err = %v, want %s", err, c.want)
+		}
+	}
+}
+
+func testUpdate(t *testing.T) {
+	e, err := events.ByID(ds, 2)
+	if err != nil {
+		t.Fatalf("events.ByID(2) err = %s", err)
+	}
+	e.DateEnd = "2019-05-11"
+	e.URL = "https://example.com/echo"
+	if err := e.Update(ds); err != nil {
+		t.Fatalf("Event.Update() err = %s", err)
+	}
+	got, _ := events.ByID(ds, 2)
+	if got.DateEnd != e.DateEnd || got.URL != e.URL {
+		t.Errorf("Event after Update() = %+v, want DateEnd %s, URL %s", got, e.DateEnd, e.URL)
+	}
+}
+
+func testDelete(t *testing.T) {
+	e := events.Event{ID: 3}
+	if err := e.Delete(ds); err != nil {
+		t.Fatalf("Event.Delete() err = %s", err)
+	}
+	_, err := events.ByID(ds, 3)
+	if err != sql.ErrNoRows {
+		t.Errorf("events.ByID(3) after Delete() err = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+// event 1 has a capacity of 2
+func testRegister(t *testing.T) {
+	cases := []struct {
+		memberID int
+		want     string // status
+	}{
+		{1, events.StatusRegistered},
+		{2, events.StatusRegistered},
+		{3, events.StatusWaitlisted},
+		{4, events.StatusWaitlisted},
+		{1, events.StatusRegistered}, // registering again is harmless
+	}
+	for _, c := range cases {
+		r, err := events.Register(ds, 1, c.memberID)
+		if err != nil {
+			t.Fatalf("events.Register(1, %d) err = %s", c.memberID, err)
+		}
+		if r.Status != c.want {
+			t.Errorf("events.Register(1, %d) Status = %q, want %q", c.memberID, r.Status, c.want)
+		}
+	}
+	xr, err := events.Registrations(ds, 1)
+	if err != nil {
+		t.Fatalf("events.Registrations(1) err = %s", err)
+	}
+	if len(xr) != 4 {
+		t.Errorf("events.Registrations(1) count = %d, want 4", len(xr))
+	}
+	_, err = events.Register(ds, 4, 1) // soft deleted
+	if err == nil || err.Error() != events.ErrorNoEvent {
+		t.Errorf("events.Register(4, 1) err = %v, want %s", err, events.ErrorNoEvent)
+	}
+}
+
+// relies on the registrations from testRegister
+func testCancel(t *testing.T) {
+	r, err := events.MemberRegistration(ds, 1, 2)
+	if err != nil {
+		t.Fatalf("events.MemberRegistration(1, 2) err = %s", err)
+	}
+	if err := r.Cancel(ds); err != nil {
+		t.Fatalf("Registration.Cancel() err = %s", err)
+	}
+	if err := r.Cancel(ds); err == nil || err.Error() != events.ErrorAlreadyCancelled {
+		t.Errorf("Registration.Cancel() again err = %v, want %s", err, events.ErrorAlreadyCancelled)
+	}
+
+	// member 3 was first on the waitlist
+	want := map[int]string{
+		2: events.StatusCancelled,
+		3: events.StatusRegistered,
+		4: events.StatusWaitlisted,
+	}
+	for mid, status := range want {
+		r, err := events.MemberRegistration(ds, 1, mid)
+		if err != nil {
+			t.Fatalf("events.MemberRegistration(1, %d) err = %s", mid, err)
+		}
+		if r.Status != status {
+			t.Errorf("member %d Status = %q, want %q", mid, r.Status, status)
+		}
+	}
+
+	// re-joining goes to the back of the waitlist
+	r, err = events.Register(ds, 1, 2)
+	if err != nil {
+		t.Fatalf("events.Register(1, 2) err = %s", err)
+	}
+	if r.Status != events.StatusWaitlisted {
+		t.Errorf("events.Register(1, 2) Status = %q, want %q", r.Status, events.StatusWaitlisted)
+	}
+}
+
+func testMarkAttended(t *testing.T) {
+	before, err := cpd.ByMemberID(ds, 1)
+	if err != nil {
+		t.Fatalf("cpd.ByMemberID(1) err = %s", err)
+	}
+
+	// marking twice should record the cpd once
+	var r events.Registration
+	for i := 0; i < 2; i++ {
+		r, err = events.MarkAttended(ds, 1, 1)
+		if err != nil {
+			t.Fatalf("events.MarkAttended(1, 1) err = %s", err)
+		}
+	}
+	if r.Status != events.StatusAttended || r.DateAttended == "" {
+		t.Errorf("Registration = %+v, want status %q with DateAttended", r, events.StatusAttended)
+	}
+	if r.CPDID == 0 {
+		t.Fatalf("Registration.CPDID = 0, want the id of the cpd entry")
+	}
+
+	after, err := cpd.ByMemberID(ds, 1)
+	if err != nil {
+		t.Fatalf("cpd.ByMemberID(1) err = %s", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Errorf("cpd count = %d, want %d", len(after), len(before)+1)
+	}
+	c, err := cpd.ByID(ds, r.CPDID)
+	if err != nil {
+		t.Fatalf("cpd.ByID(%d) err = %s", r.CPDID, err)
+	}
+	if c.Date != "2019-08-08" || c.CreditData.Quantity != 6.5 {
+		t.Errorf("cpd Date, Quantity = %s, %v, want 2019-08-08, 6.5", c.Date, c.CreditData.Quantity)
+	}
+
+	// attended registrations cannot be cancelled
+	if err := r.Cancel(ds); err == nil || err.Error() != events.ErrorCancelAttended {
+		t.Errorf("Registration.Cancel() err = %v, want %s", err, events.ErrorCancelAttended)
+	}
+
+	xr := events.MarkAttendedBulk(ds, 2, []int{1, 999})
+	if xr[0].Error != "" || xr[0].CPDID != 0 {
+		t.Errorf("MarkAttendedBulk() member 1 = %+v, want no error and no cpd", xr[0])
+	}
+	if xr[1].Error != events.ErrorNoMember {
+		t.Errorf("MarkAttendedBulk() member 999 Error = %q, want %q", xr[1].Error, events.ErrorNoMember)
+	}
+}
+
+// attendance marked at the same time, eg by two scanning devices, should record the cpd once
+func testMarkAttendedConcurrent(t *testing.T) {
+	e, err := events.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("events.ByID(1) err = %s", err)
+	}
+	e.ID = 0
+	e.DateStart, e.DateEnd = "2019-09-05", "2019-09-05"
+	if err := e.InsertRow(ds); err != nil {
+		t.Fatalf("Event.InsertRow() err = %s", err)
+	}
+	before, err := cpd.ByMemberID(ds, 1)
+	if err != nil {
+		t.Fatalf("cpd.ByMemberID(1) err = %s", err)
+	}
+
+	n := 4
+	xr := make([]events.Registration, n)
+	xerr := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			xr[i], xerr[i] = events.MarkAttended(ds, e.ID, 1)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range xr {
+		if xerr[i] != nil {
+			t.Fatalf("events.MarkAttended(%d, 1) err = %s", e.ID, xerr[i])
+		}
+		if xr[i].CPDID == 0 || xr[i].CPDID != xr[0].CPDID {
+			t.Errorf("Registration.CPDID = %d, want %d for every call", xr[i].CPDID, xr[0].CPDID)
+		}
+	}
+	after, err := cpd.ByMemberID(ds, 1)
+	if err != nil {
+		t.Fatalf("cpd.ByMemberID(1) err = %s", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Errorf("cpd count = %d, want %d", len(after), len(before)+1)
+	}
+}
+
+// relies on the registrations from testRegister and testMarkAttended
+func testCheckIn(t *testing.T) {
+	key := []byte("test-key")
+
+	r, err := events.MemberRegistration(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("events.MemberRegistration(1, 1) err = %s", err)
+	}
+	token := r.Ticket().Token(key)
+
+	first := events.Scan{Token: token, ScannedAt: "2019-08-08T08:30:00Z", Device: "door-1"}
+	second := events.Scan{Token: token, ScannedAt: "2019-08-08T09:15:00Z", Device: "door-2"}
+
+	// the later scan is synced first
+	c2, err := events.CheckInScan(ds, second, key)
+	if err != nil {
+		t.Fatalf("events.CheckInScan() err = %s", err)
+	}
+	if c2.Duplicate {
+		t.Errorf("CheckIn.Duplicate = true for the first scan synced")
+	}
+	// a synced scan without a time cannot be ordered, and is not recorded
+	noTime := events.Scan{Token: token, Device: "door-3"}
+	xr := events.SyncCheckIns(ds, []events.Scan{first, second, noTime}, key)
+	if xr[0].Error != "" || !xr[0].CheckIn.Duplicate {
+		t.Errorf("SyncCheckIns() first scan = %+v, want a duplicate", xr[0])
+	}
+	if xr[1].Error != "" || xr[1].CheckIn.ID != c2.ID {
+		t.Errorf("SyncCheckIns() second scan = %+v, want the original check in id %d", xr[1], c2.ID)
+	}
+	if xr[2].Error != events.ErrorNoScannedAt || xr[2].CheckIn != nil {
+		t.Errorf("SyncCheckIns() scan without a time = %+v, want error %q", xr[2], events.ErrorNoScannedAt)
+	}
+
+	r, _ = events.MemberRegistration(ds, 1, 1)
+	if r.DateCheckedIn == "" || r.Status != events.StatusAttended {
+		t.Errorf("Registration = %+v, want status %q and DateCheckedIn set", r, events.StatusAttended)
+	}
+	xc, err := events.CheckIns(ds, 1)
+	if err != nil {
+		t.Fatalf("events.CheckIns(1) err = %s", err)
+	}
+	if len(xc) != 2 {
+		t.Errorf("events.CheckIns(1) count = %d, want 2", len(xc))
+	}
+
+	// waitlisted
+	w, err := events.MemberRegistration(ds, 1, 4)
+	if err != nil {
+		t.Fatalf("events.MemberRegistration(1, 4) err = %s", err)
+	}
+	_, err = events.CheckInScan(ds, events.Scan{Token: w.Ticket().Token(key)}, key)
+	if err == nil || err.Error() != events.ErrorNotRegistered {
+		t.Errorf("events.CheckInScan() waitlisted err = %v, want %s", err, events.ErrorNotRegistered)
+	}
+}
+
+func TestTicket(t *testing.T) {
+	key := []byte("test-key")
+	want := events.Ticket{EventID: 1, RegistrationID: 23, MemberID: 456}
+	token := want.Token(key)
+
+	got, err := events.ParseTicket(token, key)
+	if err != nil {
+		t.Fatalf("events.ParseTicket(%q) err = %s", token, err)
+	}
+	if got != want {
+		t.Errorf("events.ParseTicket() = %+v, want %+v", got, want)
+	}
+
+	cases := []struct {
+		token string
+		key   []byte
+	}{
+		{token, []byte("other-key")},
+		{strings.Replace(token, "1.23.", "1.24.", 1), key},
+		{"1.23.456", key},
+		{token, nil},
+	}
+	for _, c := range cases {
+		_, err := events.ParseTicket(c.token, c.key)
+		if err == nil || err.Error() != events.ErrorTicket {
+			t.Errorf("events.ParseTicket(%q) err = %v, want %s", c.token, err, events.ErrorTicket)
+		}
+	}
+}
+
+func TestTicketKey(t *testing.T) {
+	for _, v := range []string{"MAPPCPD_TICKET_SIGNING_KEY", "MAPPCPD_JWT_SIGNING_KEY"} {
+		defer os.Setenv(v, os.Getenv(v))
+	}
+	os.Setenv("MAPPCPD_JWT_SIGNING_KEY", "jwt-key")
+
+	os.Setenv("MAPPCPD_TICKET_SIGNING_KEY", "")
+	if _, err := events.TicketKey(); err == nil {
+		t.Errorf("events.TicketKey() with no MAPPCPD_TICKET_SIGNING_KEY err = nil, want an error")
+	}
+
+	os.Setenv("MAPPCPD_TICKET_SIGNING_KEY", "test-key")
+	key, err := events.TicketKey()
+	if err != nil || string(key) != "test-key" {
+		t.Errorf("events.TicketKey() = %q, %v, want %q, nil", key, err, "test-key")
+	}
+}
+
+func TestWriteTicket(t *testing.T) {
+	e := events.Event{ID: 1, Name: "Annual Scientific Meeting", Location: "Adelaide, SA", DateStart: "2019-08-08", DateEnd: "2019-08-11"}
+	r := events.Registration{ID: 23, EventID: 1, MemberID: 456, Status: events.StatusRegistered}
+	var b bytes.Buffer
+	if err := events.WriteTicket(&b, e, r, "Jane Citizen", []byte("test-key")); err != nil {
+		t.Fatalf("events.WriteTicket() err = %s", err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte("%PDF")) {
+		t.Errorf("events.WriteTicket() did not write a PDF")
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Calendar describes an iCalendar (RFC 5545) feed of events
+type Calendar struct {
+	// Name is displayed by calendar clients for a subscribed feed
+	Name string
+	// Domain is used to create a globally unique UID for each event, eg "csanz.edu.au"
+	Domain string
+}
+
+// WriteICal writes the events as an iCalendar document. Events are all-day events, so the
+// exclusive end date is the day after Event.DateEnd.
+func (c Calendar) WriteICal(w io.Writer, xe []Event) error {
+
+	var b bytes.Buffer
+
+	line(&b, "BEGIN:VCALENDAR")
+	line(&b, "VERSION:2.0")
+	line(&b, "PRODID:-//"+c.Domain+"//Events//EN")
+	line(&b, "CALSCALE:GREGORIAN")
+	line(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		line(&b, "X-WR-CALNAME:"+escape(c.Name))
+	}
+
+	for _, e := range xe {
+		start, err := time.Parse(DateFormat, e.DateStart)
+		if err != nil {
+			// events without a start date cannot be added to a calendar
+			continue
+		}
+		end, err := time.Parse(DateFormat, e.DateEnd)
+		if err != nil || end.Before(start) {
+			end = start
+		}
+		line(&b, "BEGIN:VEVENT")
+		line(&b, fmt.Sprintf("UID:event-%d@%s", e.ID, c.Domain))
+		line(&b, "DTSTAMP:"+stamp(e.DateUpdated))
+		line(&b, "DTSTART;VALUE=DATE:"+start.Format("20060102"))
+		line(&b, "DTEND;VALUE=DATE:"+end.AddDate(0, 0, 1).Format("20060102"))
+		line(&b, "SUMMARY:"+escape(e.Name))
+		if e.Location != "" {
+			line(&b, "LOCATION:"+escape(e.Location))
+		}
+		if e.Description != "" {
+			line(&b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.URL != "" {
+			line(&b, "URL:"+e.URL)
+		}
+		line(&b, "END:VEVENT")
+	}
+
+	line(&b, "END:VCALENDAR")
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// stamp converts a MySQL timestamp to an iCalendar UTC date-time, the server time is used if the
+// timestamp cannot be parsed
+func stamp(ts string) string {
+	t, err := time.Parse("2006-01-02 15:04:05", ts)
+	if err != nil {
+		t = time.Now()
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escape escapes text values as required by RFC 5545
+var escape = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", "",
+).Replace
+
+// line writes a content line terminated by CRLF. Lines longer than 75 octets are folded,
+// without splitting a multi-byte character.
+func line(b *bytes.Buffer, s string) {
+	n := 0
+	for _, r := range s {
+		l := len(string(r))
+		if n+l > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += l
+	}
+	b.WriteString("\r\n")
+}
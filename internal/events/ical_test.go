@@ -0,0 +1,59 @@
+package events_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cardiacsociety/web-services/internal/events"
+)
+
+func TestWriteICal(t *testing.T) {
+
+	xe := []events.Event{
+		{
+			ID:          1,
+			DateUpdated: "2019-01-12 10:30:00",
+			DateStart:   "2019-08-08",
+			DateEnd:     "2019-08-11",
+			Location:    "Adelaide Convention Centre, SA",
+			Name:        "Annual Scientific Meeting",
+			Description: "All members welcome; register early.\nLunch provided. " + strings.Repeat("x", 60),
+			URL:         "https://www.csanz.edu.au/asm",
+		},
+		{ID: 2, Name: "No start date"},
+	}
+
+	var b bytes.Buffer
+	c := events.Calendar{Name: "Society Events", Domain: "test.com"}
+	if err := c.WriteICal(&b, xe); err != nil {
+		t.Fatalf("Calendar.WriteICal() err = %s", err)
+	}
+	got := b.String()
+
+	want := []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Society Events\r\n",
+		"UID:event-1@test.com\r\n",
+		"DTSTAMP:20190112T103000Z\r\n",
+		"DTSTART;VALUE=DATE:20190808\r\n",
+		"DTEND;VALUE=DATE:20190812\r\n",
+		"LOCATION:Adelaide Convention Centre\\, SA\r\n",
+		"DESCRIPTION:All members welcome\\; register early.\\nLunch provided. x",
+		"x\r\n xxx",
+		"END:VCALENDAR\r\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("WriteICal() does not contain %q\n%s", w, got)
+		}
+	}
+	if strings.Count(got, "BEGIN:VEVENT") != 1 {
+		t.Errorf("WriteICal() should skip an event without a start date")
+	}
+	for _, l := range strings.Split(got, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("WriteICal() line length %d > 75: %s", len(l), l)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package events
+
+var queries = map[string]string{
+	"select-event":                     selectEvent,
+	"insert-event":                     insertEvent,
+	"update-event":                     updateEvent,
+	"delete-event":                     deleteEvent,
+	"select-event-capacity-for-update": selectEventCapacityForUpdate,
+	"select-registration":              selectRegistration,
+	"select-registration-for-update":   selectRegistrationForUpdate,
+	"select-attendance-for-update":     selectAttendanceForUpdate,
+	"select-next-waitlisted":           selectNextWaitlisted,
+	"count-registered":                 countRegistered,
+	"count-member":                     countMember,
+	"insert-registration":              insertRegistration,
+	"update-registration-status":       updateRegistrationStatus,
+	"update-registration-rejoin":       updateRegistrationRejoin,
+	"update-registration-attended":     updateRegistrationAttended,
+	"update-registration-cpd":          updateRegistrationCPD,
+	"update-registration-checkin":      updateRegistrationCheckIn,
+	"select-checkin":                   selectCheckIn,
+	"insert-checkin":                   insertCheckIn,
+}
+
+// Coalesce any NULL-able fields
+const selectEvent = `
+SELECT
+  id,
+  created_at,
+  COALESCE(updated_at, created_at),
+  COALESCE(start_on, ''),
+  COALESCE(end_on, ''),
+  COALESCE(location, ''),
+  COALESCE(name, ''),
+  COALESCE(description, ''),
+  COALESCE(information_url, ''),
+  COALESCE(capacity, 0),
+  COALESCE(ce_activity_id, 0),
+  COALESCE(ce_activity_type_id, 0),
+  COALESCE(cpd_quantity, 0)
+FROM ce_event
+WHERE active = 1`
+
+const insertEvent = `
+INSERT INTO ce_event (active, created_at, updated_at, start_on, end_on, location, name, description, information_url,
+  capacity, ce_activity_id, ce_activity_type_id, cpd_quantity)
+VALUES (1, NOW(), NOW(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const updateEvent = `
+UPDATE ce_event SET
+  updated_at = NOW(),
+  start_on = ?,
+  end_on = ?,
+  location = ?,
+  name = ?,
+  description = ?,
+  information_url = ?,
+  capacity = ?,
+  ce_activity_id = ?,
+  ce_activity_type_id = ?,
+  cpd_quantity = ?
+WHERE active = 1 AND id = ?`
+
+const deleteEvent = `UPDATE ce_event SET active = 0, updated_at = NOW() WHERE id = ?`
+
+const selectEventCapacityForUpdate = `SELECT COALESCE(capacity, 0) FROM ce_event WHERE active = 1 AND id = ? FOR UPDATE`
+
+const selectRegistration = `
+SELECT
+  id,
+  ce_event_id,
+  member_id,
+  created_at,
+  COALESCE(updated_at, created_at),
+  status,
+  COALESCE(attended_at, ''),
+  COALESCE(ce_m_activity_id, 0),
+  COALESCE(checked_in_at, '')
+FROM ce_event_registration`
+
+const selectRegistrationForUpdate = `
+SELECT id, status FROM ce_event_registration WHERE ce_event_id = ? AND member_id = ? FOR UPDATE`
+
+const selectAttendanceForUpdate = `
+SELECT id, COALESCE(ce_m_activity_id, 0) FROM ce_event_registration WHERE ce_event_id = ? AND member_id = ? FOR UPDATE`
+
+// the waitlist is ordered by registration time
+const selectNextWaitlisted = `
+SELECT id FROM ce_event_registration
+WHERE ce_event_id = ? AND status = 'waitlisted'
+ORDER BY created_at ASC, id ASC LIMIT 1 FOR UPDATE`
+
+// places are taken by members who are registered or have attended
+const countRegistered = `
+SELECT COUNT(*) FROM ce_event_registration WHERE ce_event_id = ? AND status IN ('registered', 'attended')`
+
+const countMember = `SELECT COUNT(*) FROM member WHERE id = ?`
+
+const insertRegistration = `
+INSERT INTO ce_event_registration (ce_event_id, member_id, created_at, updated_at, status)
+VALUES (?, ?, NOW(), NOW(), ?)`
+
+const updateRegistrationStatus = `UPDATE ce_event_registration SET status = ?, updated_at = NOW() WHERE id = ?`
+
+// a member who registers again after cancelling goes to the back of the waitlist
+const updateRegistrationRejoin = `
+UPDATE ce_event_registration SET status = ?, created_at = NOW(), updated_at = NOW() WHERE id = ?`
+
+const updateRegistrationAttended = `
+UPDATE ce_event_registration SET status = 'attended', attended_at = COALESCE(attended_at, NOW()), updated_at = NOW()
+WHERE id = ?`
+
+const updateRegistrationCPD = `UPDATE ce_event_registration SET ce_m_activity_id = ?, updated_at = NOW() WHERE id = ?`
+
+// the check in time is the earliest scan, which may be synced after a later one
+const updateRegistrationCheckIn = `
+UPDATE ce_event_registration SET checked_in_at = LEAST(COALESCE(checked_in_at, ?), ?), updated_at = NOW() WHERE id = ?`
+
+const selectCheckIn = `
+SELECT
+  c.id,
+  c.ce_event_registration_id,
+  r.ce_event_id,
+  r.member_id,
+  c.created_at,
+  c.scanned_at,
+  c.device,
+  c.duplicate
+FROM ce_event_checkin c
+  INNER JOIN ce_event_registration r ON c.ce_event_registration_id = r.id`
+
+const insertCheckIn = `
+INSERT INTO ce_event_checkin (ce_event_registration_id, created_at, scanned_at, device, duplicate)
+VALUES (?, NOW(), ?, ?, ?)`
@@ -0,0 +1,358 @@
+package events
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Registration status values
+const (
+	StatusRegistered = "registered"
+	StatusWaitlisted = "waitlisted"
+	StatusCancelled  = "cancelled"
+	StatusAttended   = "attended"
+)
+
+// Registration error messages
+const (
+	ErrorNoEvent          = "event does not exist"
+	ErrorNoMember         = "member does not exist"
+	ErrorNoRegistration   = "cannot cancel a registration because Registration.ID is not set"
+	ErrorCancelAttended   = "cannot cancel a registration for an event that has been attended"
+	ErrorAlreadyCancelled = "registration has already been cancelled"
+)
+
+// Registration is a member's place, or place on the waitlist, for an event
+type Registration struct {
+	ID           int    `json:"id" bson:"id"`
+	EventID      int    `json:"eventId" bson:"eventId"`
+	MemberID     int    `json:"memberId" bson:"memberId"`
+	DateCreated  string `json:"dateCreated" bson:"dateCreated"`
+	DateUpdated  string `json:"dateUpdated" bson:"dateUpdated"`
+	Status       string `json:"status" bson:"status"`
+	DateAttended string `json:"dateAttended" bson:"dateAttended"`
+
+	// CPDID is the id of the member activity recorded for attendance, if any
+	CPDID int `json:"cpdId" bson:"cpdId"`
+
+	// DateCheckedIn is the time of the earliest ticket scan, if any
+	DateCheckedIn string `json:"dateCheckedIn" bson:"dateCheckedIn"`
+}
+
+// AttendanceResult is the outcome of marking attendance for one member in a bulk update
+type AttendanceResult struct {
+	MemberID     int    `json:"memberId"`
+	Registration int    `json:"registrationId,omitempty"`
+	CPDID        int    `json:"cpdId,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Register registers a member for an event. If the event is at capacity the member is placed on the
+// waitlist. Registering again is harmless and returns the existing registration, and a member who
+// had cancelled is registered, or waitlisted, as if registering for the first time.
+func Register(ds datastore.Datastore, eventID, memberID int) (Registration, error) {
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return Registration{}, err
+	}
+	defer tx.Rollback()
+
+	// locking the event row serialises registrations so that the capacity cannot be exceeded
+	var capacity int
+	err = tx.QueryRow(queries["select-event-capacity-for-update"], eventID).Scan(&capacity)
+	if err == sql.ErrNoRows {
+		return Registration{}, errors.New(ErrorNoEvent)
+	}
+	if err != nil {
+		return Registration{}, errors.Wrap(err, "select event")
+	}
+
+	var id int
+	var status string
+	err = tx.QueryRow(queries["select-registration-for-update"], eventID, memberID).Scan(&id, &status)
+	if err != nil && err != sql.ErrNoRows {
+		return Registration{}, errors.Wrap(err, "select registration")
+	}
+	if id > 0 && status != StatusCancelled {
+		tx.Rollback()
+		return RegistrationByID(ds, id)
+	}
+
+	status = StatusRegistered
+	if capacity > 0 {
+		var n int
+		err = tx.QueryRow(queries["count-registered"], eventID).Scan(&n)
+		if err != nil {
+			return Registration{}, errors.Wrap(err, "count registrations")
+		}
+		if n >= capacity {
+			status = StatusWaitlisted
+		}
+	}
+
+	if id > 0 {
+		_, err = tx.Exec(queries["update-registration-rejoin"], status, id)
+	} else {
+		var res sql.Result
+		res, err = tx.Exec(queries["insert-registration"], eventID, memberID, status)
+		if err == nil {
+			var lid int64
+			lid, err = res.LastInsertId()
+			id = int(lid)
+		}
+	}
+	if err != nil {
+		return Registration{}, errors.Wrap(err, "save registration")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Registration{}, err
+	}
+	return RegistrationByID(ds, id)
+}
+
+// Cancel cancels a registration. If the member held a place, the member at the head of the waitlist
+// is moved into it. A registration cannot be cancelled once the event has been attended.
+func (r *Registration) Cancel(ds datastore.Datastore) error {
+	if r.ID == 0 {
+		return errors.New(ErrorNoRegistration)
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(queries["select-event-capacity-for-update"], r.EventID).Scan(&capacity)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.Wrap(err, "select event")
+	}
+
+	var id int
+	var status string
+	err = tx.QueryRow(queries["select-registration-for-update"], r.EventID, r.MemberID).Scan(&id, &status)
+	if err != nil {
+		return errors.Wrap(err, "select registration")
+	}
+	switch status {
+	case StatusAttended:
+		return errors.New(ErrorCancelAttended)
+	case StatusCancelled:
+		return errors.New(ErrorAlreadyCancelled)
+	}
+
+	if _, err := tx.Exec(queries["update-registration-status"], StatusCancelled, id); err != nil {
+		return errors.Wrap(err, "cancel registration")
+	}
+
+	if status == StatusRegistered {
+		var next int
+		err = tx.QueryRow(queries["select-next-waitlisted"], r.EventID).Scan(&next)
+		if err != nil && err != sql.ErrNoRows {
+			return errors.Wrap(err, "select waitlist")
+		}
+		if next > 0 {
+			if _, err := tx.Exec(queries["update-registration-status"], StatusRegistered, next); err != nil {
+				return errors.Wrap(err, "promote waitlisted registration")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.Status = StatusCancelled
+	return nil
+}
+
+// RegistrationByID fetches a single Registration by ID
+func RegistrationByID(ds datastore.Datastore, id int) (Registration, error) {
+	xr, err := executeRegistration(ds, queries["select-registration"]+" WHERE id = ?", id)
+	if err != nil {
+		return Registration{}, err
+	}
+	if len(xr) == 0 {
+		return Registration{}, sql.ErrNoRows
+	}
+	return xr[0], nil
+}
+
+// MemberRegistration fetches a member's registration for an event
+func MemberRegistration(ds datastore.Datastore, eventID, memberID int) (Registration, error) {
+	q := queries["select-registration"] + " WHERE ce_event_id = ? AND member_id = ?"
+	xr, err := executeRegistration(ds, q, eventID, memberID)
+	if err != nil {
+		return Registration{}, err
+	}
+	if len(xr) == 0 {
+		return Registration{}, sql.ErrNoRows
+	}
+	return xr[0], nil
+}
+
+// Registrations fetches all registrations for an event, in order of registration so that the
+// waitlisted registrations are listed in waitlist order
+func Registrations(ds datastore.Datastore, eventID int) ([]Registration, error) {
+	q := queries["select-registration"] + " WHERE ce_event_id = ? ORDER BY created_at ASC, id ASC"
+	return executeRegistration(ds, q, eventID)
+}
+
+// MemberRegistrations fetches all registrations for a member
+func MemberRegistrations(ds datastore.Datastore, memberID int) ([]Registration, error) {
+	q := queries["select-registration"] + " WHERE member_id = ? ORDER BY created_at DESC, id DESC"
+	return executeRegistration(ds, q, memberID)
+}
+
+// MarkAttended records a member's attendance at an event, creating the registration if the member did
+// not register beforehand. If the event specifies a CPD activity it is added to the member's CPD diary,
+// unless an identical entry already exists. Marking attendance more than once, even at the same time,
+// does not add more CPD.
+func MarkAttended(ds datastore.Datastore, eventID, memberID int) (Registration, error) {
+
+	e, err := ByID(ds, eventID)
+	if err == sql.ErrNoRows {
+		return Registration{}, errors.New(ErrorNoEvent)
+	}
+	if err != nil {
+		return Registration{}, err
+	}
+
+	var n int
+	err = ds.MySQL.Session.QueryRow(queries["count-member"], memberID).Scan(&n)
+	if err != nil {
+		return Registration{}, err
+	}
+	if n == 0 {
+		return Registration{}, errors.New(ErrorNoMember)
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return Registration{}, err
+	}
+	defer tx.Rollback()
+
+	// the event row is locked first, as in Register, and then the registration row, so that two scans, or a
+	// scan and a sync, cannot both find that the CPD has not been recorded and both record it
+	var capacity int
+	err = tx.QueryRow(queries["select-event-capacity-for-update"], eventID).Scan(&capacity)
+	if err == sql.ErrNoRows {
+		return Registration{}, errors.New(ErrorNoEvent)
+	}
+	if err != nil {
+		return Registration{}, errors.Wrap(err, "select event")
+	}
+
+	var id, cpdID int
+	err = tx.QueryRow(queries["select-attendance-for-update"], eventID, memberID).Scan(&id, &cpdID)
+	if err == sql.ErrNoRows {
+		res, err := tx.Exec(queries["insert-registration"], eventID, memberID, StatusAttended)
+		if err != nil {
+			return Registration{}, errors.Wrap(err, "insert registration")
+		}
+		lid, err := res.LastInsertId()
+		if err != nil {
+			return Registration{}, err
+		}
+		id = int(lid)
+	} else if err != nil {
+		return Registration{}, errors.Wrap(err, "select registration")
+	}
+
+	if _, err := tx.Exec(queries["update-registration-attended"], id); err != nil {
+		return Registration{}, errors.Wrap(err, "update registration")
+	}
+
+	// the CPD is recorded while the registration is locked. It is not part of the transaction, so if the
+	// transaction fails the entry is left in the diary, and is found by DuplicateOf on the next attempt.
+	if e.ActivityID > 0 && cpdID == 0 {
+		cpdID, err = e.recordCPD(ds, memberID)
+		if err != nil {
+			return Registration{}, errors.Wrap(err, "record cpd")
+		}
+		if _, err := tx.Exec(queries["update-registration-cpd"], cpdID, id); err != nil {
+			return Registration{}, errors.Wrap(err, "update registration")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Registration{}, err
+	}
+	return RegistrationByID(ds, id)
+}
+
+// MarkAttendedBulk records attendance for a list of members. A failure for one member does not prevent
+// the others from being recorded, and the outcome for each member is returned in the same order.
+func MarkAttendedBulk(ds datastore.Datastore, eventID int, memberIDs []int) []AttendanceResult {
+	var xr []AttendanceResult
+	for _, id := range memberIDs {
+		res := AttendanceResult{MemberID: id}
+		r, err := MarkAttended(ds, eventID, id)
+		if err != nil {
+			res.Error = err.Error()
+		}
+		res.Registration = r.ID
+		res.CPDID = r.CPDID
+		xr = append(xr, res)
+	}
+	return xr
+}
+
+// recordCPD adds the event's CPD activity for a member, and returns the id of the new member activity
+// or of an existing duplicate
+func (e Event) recordCPD(ds datastore.Datastore, memberID int) (int, error) {
+	a := cpd.Input{
+		MemberID:    memberID,
+		ActivityID:  e.ActivityID,
+		TypeID:      e.ActivityTypeID,
+		Date:        e.DateStart,
+		Quantity:    e.CPDQuantity,
+		Description: fmt.Sprintf("Attended %s", e.Name),
+	}
+	id, err := cpd.DuplicateOf(ds, a)
+	if err != nil || id > 0 {
+		return id, err
+	}
+	return cpd.Add(ds, a)
+}
+
+// executeRegistration runs a registration query and scans the results into a []Registration
+func executeRegistration(ds datastore.Datastore, query string, args ...interface{}) ([]Registration, error) {
+
+	var xr []Registration
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xr, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := Registration{}
+		err := rows.Scan(
+			&r.ID,
+			&r.EventID,
+			&r.MemberID,
+			&r.DateCreated,
+			&r.DateUpdated,
+			&r.Status,
+			&r.DateAttended,
+			&r.CPDID,
+			&r.DateCheckedIn,
+		)
+		if err != nil {
+			return xr, errors.Wrap(err, "failed to scan registration row")
+		}
+		xr = append(xr, r)
+	}
+
+	return xr, rows.Err()
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/cardiacsociety/web-services/internal/platform/qr"
+)
+
+// ticket layout, in mm
+const (
+	ticketMargin = 20
+	ticketQRSize = 70
+)
+
+// WriteTicket writes a PDF ticket for a registration to w. The ticket shows the event details and a
+// QR code of the signed ticket token, which is scanned to check in at the event.
+func WriteTicket(w io.Writer, e Event, r Registration, memberName string, key []byte) error {
+
+	token := r.Ticket().Token(key)
+	code, err := qr.Encode([]byte(token))
+	if err != nil {
+		return err
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Ticket - %s", e.Name), true)
+	pdf.SetAuthor("MappCPD PDF Generator", false)
+	pdf.SetMargins(ticketMargin, ticketMargin, ticketMargin)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // cp1252
+
+	pdf.SetFont("Arial", "B", 18)
+	pdf.MultiCell(0, 9, tr(e.Name), "", "L", false)
+	pdf.Ln(2)
+
+	pdf.SetFont("Arial", "", 12)
+	pdf.MultiCell(0, 7, tr(ticketDates(e)), "", "L", false)
+	pdf.MultiCell(0, 7, tr(e.Location), "", "L", false)
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.MultiCell(0, 7, tr(memberName), "", "L", false)
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(0, 6, fmt.Sprintf("Member ID: %d    Registration: %d", r.MemberID, r.ID), "", "L", false)
+	pdf.Ln(6)
+
+	// draw the modules as filled squares, leaving the quiet zone blank
+	n := float64(code.Size + 2*qr.QuietZone)
+	m := ticketQRSize / n
+	x0 := ticketMargin + qr.QuietZone*m
+	y0 := pdf.GetY() + qr.QuietZone*m
+	pdf.SetFillColor(0, 0, 0)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				pdf.Rect(x0+float64(x)*m, y0+float64(y)*m, m, m, "F")
+			}
+		}
+	}
+	pdf.SetY(y0 + float64(code.Size)*m + qr.QuietZone*m)
+
+	pdf.SetFont("Courier", "", 8)
+	pdf.MultiCell(0, 4, token, "", "L", false)
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(0, 6, "Please show this code at registration to check in. "+
+		"Attendance, and any CPD for the event, is recorded when the code is scanned.", "", "L", false)
+
+	return pdf.Output(w)
+}
+
+// ticketDates formats the event dates for display, eg 8 Aug 2019 - 11 Aug 2019
+func ticketDates(e Event) string {
+	start, err := time.Parse(DateFormat, e.DateStart)
+	if err != nil {
+		return e.DateStart
+	}
+	s := start.Format("2 Jan 2006")
+	if end, err := time.Parse(DateFormat, e.DateEnd); err == nil && end.After(start) {
+		s += " - " + end.Format("2 Jan 2006")
+	}
+	return s
+}
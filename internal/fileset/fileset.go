@@ -6,6 +6,7 @@ package fileset
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 	"github.com/pkg/errors"
@@ -38,6 +39,29 @@ func ResourceAttachment(ds datastore.Datastore) (FileSet, error) {
 	return get(ds, "ol_resource_file")
 }
 
+// All returns all of the active file sets, including those that are no longer current but may still hold files
+func All(ds datastore.Datastore) ([]FileSet, error) {
+
+	var xfs []FileSet
+
+	query := "SELECT id, entity_name, volume_name, set_path FROM fs_set WHERE active = 1 ORDER BY id"
+	rows, err := ds.MySQL.Session.Query(query)
+	if err != nil {
+		return xfs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fs FileSet
+		if err := rows.Scan(&fs.ID, &fs.Entity, &fs.Volume, &fs.Path); err != nil {
+			return xfs, err
+		}
+		xfs = append(xfs, fs)
+	}
+
+	return xfs, rows.Err()
+}
+
 // New returns a pointer to an initialised FileSet value. It receives the setPath, eg '/notes/' which is the base
 // path / pseudo path (S3) for all files stored in the set.
 func get(ds datastore.Datastore, entity string) (FileSet, error) {
@@ -81,3 +105,9 @@ func (fs FileSet) CheckFields() error {
 
 	return nil
 }
+
+// Key returns the full path to a file in the set, the 'key' in S3 parlance. Files are
+// stored below a path for the entity id, eg /cpd/123/filename.pdf
+func (fs FileSet) Key(entityID int, fileName string) string {
+	return fs.Path + strconv.Itoa(entityID) + "/" + fileName
+}
@@ -0,0 +1,127 @@
+package generic
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// FieldDiff is a field that has a different value in two documents. Field is the dotted path to the
+// field, as used in MongoDB queries, eg "contact.locations.0.city". Want or Got is nil when the field is
+// missing, or null, in that document.
+type FieldDiff struct {
+	Field string      `json:"field"`
+	Want  interface{} `json:"want"`
+	Got   interface{} `json:"got"`
+}
+
+func (fd FieldDiff) String() string {
+	return fmt.Sprintf("%s: want %v, got %v", fd.Field, fd.Want, fd.Got)
+}
+
+// DocDiff compares two values as they are stored in the document database, and returns the fields that
+// differ. Each value is converted to BSON first so that a struct can be compared with a document fetched
+// from the database - times are compared to the millisecond, fields are compared by their bson names and
+// a missing field is the same as a null one. Top-level fields named in ignore are not compared, eg "_id".
+func DocDiff(want, got interface{}, ignore ...string) ([]FieldDiff, error) {
+
+	wd, err := toDoc(want)
+	if err != nil {
+		return nil, fmt.Errorf("DocDiff() want err = %s", err)
+	}
+	gd, err := toDoc(got)
+	if err != nil {
+		return nil, fmt.Errorf("DocDiff() got err = %s", err)
+	}
+	for _, f := range ignore {
+		delete(wd, f)
+		delete(gd, f)
+	}
+
+	var xd []FieldDiff
+	diffValue(&xd, "", wd, gd)
+	return xd, nil
+}
+
+// toDoc converts a value to a map by way of BSON
+func toDoc(v interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if v == nil {
+		return doc, nil
+	}
+	b, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Unmarshal(b, &doc)
+	return doc, err
+}
+
+func diffValue(xd *[]FieldDiff, field string, want, got interface{}) {
+
+	switch w := want.(type) {
+	case bson.M:
+		if g, ok := got.(bson.M); ok {
+			diffDoc(xd, field, w, g)
+			return
+		}
+	case []interface{}:
+		if g, ok := got.([]interface{}); ok {
+			diffSlice(xd, field, w, g)
+			return
+		}
+	case time.Time:
+		if g, ok := got.(time.Time); ok && w.Equal(g) {
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		*xd = append(*xd, FieldDiff{Field: field, Want: want, Got: got})
+	}
+}
+
+func diffDoc(xd *[]FieldDiff, field string, want, got bson.M) {
+
+	keys := map[string]bool{}
+	for k := range want {
+		keys[k] = true
+	}
+	for k := range got {
+		keys[k] = true
+	}
+	var xk []string
+	for k := range keys {
+		xk = append(xk, k)
+	}
+	sort.Strings(xk)
+
+	for _, k := range xk {
+		diffValue(xd, path(field, k), want[k], got[k])
+	}
+}
+
+func diffSlice(xd *[]FieldDiff, field string, want, got []interface{}) {
+
+	for i := 0; i < len(want) || i < len(got); i++ {
+		f := path(field, fmt.Sprint(i))
+		switch {
+		case i >= len(got):
+			*xd = append(*xd, FieldDiff{Field: f, Want: want[i]})
+		case i >= len(want):
+			*xd = append(*xd, FieldDiff{Field: f, Got: got[i]})
+		default:
+			diffValue(xd, f, want[i], got[i])
+		}
+	}
+}
+
+func path(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
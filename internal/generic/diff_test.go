@@ -0,0 +1,73 @@
+package generic_test
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/cardiacsociety/web-services/internal/generic"
+)
+
+type location struct {
+	City string `bson:"city"`
+}
+
+type record struct {
+	OID       bson.ObjectId `bson:"_id,omitempty"`
+	ID        int           `bson:"id"`
+	UpdatedAt time.Time     `bson:"updatedAt"`
+	Name      string        `bson:"name"`
+	Tags      []string      `bson:"tags"`
+	Locations []location    `bson:"locations"`
+}
+
+func TestDocDiff(t *testing.T) {
+
+	updated := time.Date(2019, 3, 14, 9, 30, 0, 123456789, time.UTC)
+	want := record{
+		ID:        1,
+		UpdatedAt: updated,
+		Name:      "Michael",
+		Tags:      []string{"a", "b"},
+		Locations: []location{{City: "Sydney"}, {City: "Perth"}},
+	}
+
+	// a document as it is fetched from the database, with a different time zone and precision
+	doc := bson.M{
+		"_id":       bson.NewObjectId(),
+		"id":        1,
+		"updatedAt": updated.Truncate(time.Millisecond).In(time.FixedZone("AEST", 10*60*60)),
+		"name":      "Michael",
+		"tags":      []string{"a", "b"},
+		"locations": []bson.M{{"city": "Sydney"}, {"city": "Perth"}},
+	}
+	xd, err := generic.DocDiff(want, doc, "_id")
+	if err != nil {
+		t.Fatalf("DocDiff() err = %s", err)
+	}
+	if len(xd) > 0 {
+		t.Errorf("DocDiff() = %v, want no differences", xd)
+	}
+
+	doc["name"] = "Mike"
+	doc["tags"] = []string{"a"}
+	doc["locations"] = []bson.M{{"city": "Sydney"}, {"city": "Hobart"}}
+	doc["extra"] = true
+	xd, err = generic.DocDiff(want, doc, "_id")
+	if err != nil {
+		t.Fatalf("DocDiff() err = %s", err)
+	}
+	fields := []string{"extra", "locations.1.city", "name", "tags.1"}
+	if len(xd) != len(fields) {
+		t.Fatalf("DocDiff() = %v, want differences in %v", xd, fields)
+	}
+	for i, f := range fields {
+		if xd[i].Field != f {
+			t.Errorf("DocDiff()[%d].Field = %q, want %q", i, xd[i].Field, f)
+		}
+	}
+	if xd[2].Want != "Michael" || xd[2].Got != "Mike" {
+		t.Errorf("DocDiff() name = %v, want Michael and Mike", xd[2])
+	}
+}
@@ -67,11 +67,32 @@ func ByIDs(ds datastore.Datastore, invoiceIDs []int) ([]Invoice, error) {
 		return nil, err
 	}
 
+	err = attachMembers(ds, xi)
+	return xi, err
+}
+
+// attachMembers attaches the member to each invoice, fetching the members together rather than one at
+// a time
+func attachMembers(ds datastore.Datastore, xi []Invoice) error {
+
+	ids := make([]int, len(xi))
 	for i := range xi {
-		xi[i].attachMember(ds)
+		ids[i] = xi[i].MemberID
+	}
+	mm, err := member.MapByIDs(ds, ids)
+	if err != nil {
+		return err
 	}
 
-	return xi, err
+	for i := range xi {
+		m, ok := mm[xi[i].MemberID]
+		if !ok {
+			fmt.Printf("No member record with id %d for invoice id %d\n", xi[i].MemberID, xi[i].ID)
+			continue
+		}
+		xi[i].Member = m
+	}
+	return nil
 }
 
 func execute(ds datastore.Datastore, query string) ([]Invoice, error) {
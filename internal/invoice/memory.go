@@ -0,0 +1,72 @@
+package invoice
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/cardiacsociety/web-services/internal/member"
+)
+
+// Memory is an in-memory Repository for unit tests. As with Store, the member is attached to each
+// invoice, from the members.
+type Memory struct {
+	mu       sync.Mutex
+	members  member.Repository
+	invoices map[int]Invoice
+}
+
+// NewMemory returns a Memory holding the invoices, that looks up members in mr
+func NewMemory(mr member.Repository, xi ...Invoice) *Memory {
+	m := &Memory{members: mr, invoices: map[int]Invoice{}}
+	for _, i := range xi {
+		m.invoices[i.ID] = i
+	}
+	return m
+}
+
+// ByID returns an invoice, or sql.ErrNoRows
+func (m *Memory) ByID(invoiceID int) (Invoice, error) {
+	m.mu.Lock()
+	i, ok := m.invoices[invoiceID]
+	m.mu.Unlock()
+	if !ok {
+		return i, sql.ErrNoRows
+	}
+	m.attachMember(&i)
+	return i, nil
+}
+
+// ByIDs returns the invoices that exist, in the order of the ids, with the members fetched together as
+// for Store
+func (m *Memory) ByIDs(invoiceIDs []int) ([]Invoice, error) {
+
+	var xi []Invoice
+	var memberIDs []int
+	m.mu.Lock()
+	for _, id := range invoiceIDs {
+		if i, ok := m.invoices[id]; ok {
+			xi = append(xi, i)
+			memberIDs = append(memberIDs, i.MemberID)
+		}
+	}
+	m.mu.Unlock()
+
+	xm, err := m.members.ByIDs(memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	mm := map[int]member.Member{}
+	for _, v := range xm {
+		mm[v.ID] = v
+	}
+	for i := range xi {
+		xi[i].Member = mm[xi[i].MemberID]
+	}
+	return xi, nil
+}
+
+func (m *Memory) attachMember(i *Invoice) {
+	if mem, err := m.members.ByID(i.MemberID); err == nil {
+		i.Member = *mem
+	}
+}
@@ -0,0 +1,37 @@
+package invoice
+
+import (
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Repository provides the invoice records, so that code that needs them can be tested with Memory
+// instead of a database
+type Repository interface {
+	ByID(invoiceID int) (Invoice, error)
+	ByIDs(invoiceIDs []int) ([]Invoice, error)
+}
+
+var (
+	_ Repository = Store{}
+	_ Repository = (*Memory)(nil)
+)
+
+// Store is the Repository for the MySQL database
+type Store struct {
+	ds datastore.Datastore
+}
+
+// NewStore returns a Repository for the MySQL database in ds
+func NewStore(ds datastore.Datastore) Store {
+	return Store{ds: ds}
+}
+
+// ByID fetches an invoice, with the member
+func (s Store) ByID(invoiceID int) (Invoice, error) {
+	return ByID(s.ds, invoiceID)
+}
+
+// ByIDs fetches invoices, with their members
+func (s Store) ByIDs(invoiceIDs []int) ([]Invoice, error) {
+	return ByIDs(s.ds, invoiceIDs)
+}
@@ -2,8 +2,10 @@
 package issue
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/cardiacsociety/web-services/internal/note"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
@@ -18,6 +20,8 @@ const (
 	ErrorAssociation           = "association entity not specified"
 	ErrorAssociationID         = "association entity ID not specified"
 	ErrorAssociationEntity     = "association entity invalid"
+	ErrorNoID                  = "cannot update an issue because ID is not set"
+	ErrorNoAdminID             = "cannot assign an issue without an admin user id"
 )
 
 // Issue represents a workflow issue
@@ -37,10 +41,26 @@ type Issue struct {
 	Association   string // either "application" or "invoice"
 	AssociationID int    // the id of the associated application or invoice record
 
+	// AssignedTo is the id of the admin user responsible for the issue, 0 if unassigned
+	AssignedTo int
+
 	Type  Type
 	Notes []note.Note
 }
 
+// Filter specifies the criteria for selecting a list of issues. Zero values are ignored, so
+// an empty Filter selects all active issues. Resolved is a pointer so that "unresolved only"
+// can be distinguished from "don't care".
+type Filter struct {
+	TypeID        int
+	CategoryID    int
+	Resolved      *bool
+	MemberID      int
+	Association   string
+	AssociationID int
+	AssignedTo    int
+}
+
 // Type represents the sub-category of the issue, ie Category -> Type
 type Type struct {
 	ID          int
@@ -119,17 +139,92 @@ func (i *Issue) checkAssociatioData() error {
 	return nil
 }
 
-// ByID fetches an issue by id
+// ByID fetches an issue by id, whether or not it is active
 func ByID(ds datastore.Datastore, id int) (Issue, error) {
+	q := queries["select-issue-by-id"]
+	return scanIssue(ds.MySQL.Session.QueryRow(q, id))
+}
+
+// Query fetches a list of issues matching the criteria in Filter, most recent first
+func Query(ds datastore.Datastore, f Filter) ([]Issue, error) {
+	var xi []Issue
+
+	where, args := f.clauses()
+	q := queries["select-issue"] + where + " ORDER BY i.live_on DESC, i.id DESC"
+	rows, err := ds.MySQL.Session.Query(q, args...)
+	if err != nil {
+		return xi, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		i, err := scanIssue(rows)
+		if err != nil {
+			return xi, err
+		}
+		xi = append(xi, i)
+	}
+	return xi, rows.Err()
+}
+
+// ByAssignee fetches the open issues that are assigned to an admin user
+func ByAssignee(ds datastore.Datastore, adminID int) ([]Issue, error) {
+	resolved := false
+	return Query(ds, Filter{AssignedTo: adminID, Resolved: &resolved})
+}
+
+// clauses returns the additional WHERE clauses and arguments specified by the Filter, always
+// including the active issues only
+func (f Filter) clauses() (string, []interface{}) {
+	xs := []string{"i.active = 1"}
+	var args []interface{}
+	if f.TypeID > 0 {
+		xs = append(xs, "it.id = ?")
+		args = append(args, f.TypeID)
+	}
+	if f.CategoryID > 0 {
+		xs = append(xs, "ic.id = ?")
+		args = append(args, f.CategoryID)
+	}
+	if f.Resolved != nil {
+		xs = append(xs, "i.resolved = ?")
+		args = append(args, *f.Resolved)
+	}
+	if f.MemberID > 0 {
+		xs = append(xs, "ia.member_id = ?")
+		args = append(args, f.MemberID)
+	}
+	if f.Association != "" {
+		xs = append(xs, "ia.association = ?")
+		args = append(args, f.Association)
+	}
+	if f.AssociationID > 0 {
+		xs = append(xs, "ia.association_entity_id = ?")
+		args = append(args, f.AssociationID)
+	}
+	if f.AssignedTo > 0 {
+		xs = append(xs, "i.ad_user_id_assigned = ?")
+		args = append(args, f.AssignedTo)
+	}
+	return " AND " + strings.Join(xs, " AND "), args
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanIssue scans a single issue from a row selected with the select-issue query
+func scanIssue(row scanner) (Issue, error) {
 	i := Issue{}
 	var resolved, visible int // for converting 0/1 to bool
-	q := queries["select-issue-by-id"]
-	err := ds.MySQL.Session.QueryRow(q, id).Scan(
+	err := row.Scan(
 		&i.ID,
 		&resolved,
 		&visible,
 		&i.Description,
 		&i.Action,
+		&i.AssignedTo,
 		&i.MemberID,
 		&i.Association,
 		&i.AssociationID,
@@ -149,6 +244,78 @@ func ByID(ds datastore.Datastore, id int) (Issue, error) {
 	return i, err
 }
 
+// Assign sets the admin user responsible for the issue. The admin user must exist and be active.
+func (i *Issue) Assign(ds datastore.Datastore, adminID int) error {
+	switch {
+	case i.ID == 0:
+		return errors.New(ErrorNoID)
+	case adminID == 0:
+		return errors.New(ErrorNoAdminID)
+	}
+	var id int
+	err := ds.MySQL.Session.QueryRow(queries["select-admin-user-id"], adminID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no active admin user with id %d", adminID)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ds.MySQL.Session.Exec(queries["update-issue-assigned"], adminID, i.ID)
+	if err != nil {
+		return err
+	}
+	i.AssignedTo = adminID
+	return nil
+}
+
+// Resolve flags the issue as resolved
+func (i *Issue) Resolve(ds datastore.Datastore) error {
+	return i.setResolved(ds, true)
+}
+
+// Reopen flags a previously resolved issue as unresolved
+func (i *Issue) Reopen(ds datastore.Datastore) error {
+	return i.setResolved(ds, false)
+}
+
+func (i *Issue) setResolved(ds datastore.Datastore, resolved bool) error {
+	if i.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	_, err := ds.MySQL.Session.Exec(queries["update-issue-resolved"], resolved, i.ID)
+	if err != nil {
+		return err
+	}
+	i.Resolved = resolved
+	return nil
+}
+
+// SetNotes fetches the notes threaded on the issue and sets the Notes field
+func (i *Issue) SetNotes(ds datastore.Datastore) error {
+	xn, err := note.ByAssociation(ds, "issue", i.ID)
+	if err != nil {
+		return err
+	}
+	i.Notes = xn
+	return nil
+}
+
+// AddNote inserts a note associated with the issue, and with the issue's member if it has one,
+// and appends it to the Notes field.
+func (i *Issue) AddNote(ds datastore.Datastore, n note.Note) (note.Note, error) {
+	if i.ID == 0 {
+		return n, errors.New(ErrorNoID)
+	}
+	n.MemberID = i.MemberID
+	n.Association = "issue"
+	n.AssociationID = i.ID
+	if err := n.InsertRow(ds); err != nil {
+		return n, err
+	}
+	i.Notes = append(i.Notes, n)
+	return n, nil
+}
+
 // TypeByID fetches an issue type by id
 func TypeByID(ds datastore.Datastore, id int) (Type, error) {
 	t := Type{}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/cardiacsociety/web-services/internal/issue"
+	"github.com/cardiacsociety/web-services/internal/note"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 	"github.com/cardiacsociety/web-services/testdata"
 )
@@ -32,6 +33,13 @@ func TestIssue(t *testing.T) {
 		t.Run("testInsertRowErrorAssociationEntity", testInsertRowErrorAssociationEntity)
 		t.Run("testInsertRow", testInsertRow)
 		t.Run("testInsertRowWithAssociation", testInsertRowWithAssociation)
+		t.Run("testQuery", testQuery)
+		t.Run("testAssign", testAssign)
+		t.Run("testAssignErrorNoAdmin", testAssignErrorNoAdmin)
+		t.Run("testResolveReopen", testResolveReopen)
+		t.Run("testSetNotes", testSetNotes)
+		t.Run("testAddNote", testAddNote)
+		t.Run("testInactive", testInactive)
 	})
 }
 
@@ -269,6 +277,148 @@ func testInsertRowWithAssociation(t *testing.T) {
 	t.Log(toJSON(iss))
 }
 
+// test selecting issues with a Filter
+func testQuery(t *testing.T) {
+	resolved := false
+	cases := []struct {
+		arg  issue.Filter
+		want int // count
+	}{
+		{issue.Filter{MemberID: 502}, 1},
+		{issue.Filter{Association: "invoice", AssociationID: 1}, 1},
+		{issue.Filter{CategoryID: 5, Resolved: &resolved}, 2},
+		{issue.Filter{TypeID: 4, MemberID: 2}, 1},
+	}
+	for _, c := range cases {
+		xi, err := issue.Query(ds, c.arg)
+		if err != nil {
+			t.Fatalf("issue.Query(%+v) err = %s", c.arg, err)
+		}
+		got := len(xi)
+		if got != c.want {
+			t.Errorf("issue.Query(%+v) count = %d, want %d", c.arg, got, c.want)
+		}
+	}
+}
+
+// test assigning an issue to an admin user, and fetching issues by assignee
+func testAssign(t *testing.T) {
+	adminID := 1 // admin user id in test data
+	i, err := issue.ByID(ds, 2)
+	if err != nil {
+		t.Fatalf("issue.ByID(2) err = %s", err)
+	}
+	err = i.Assign(ds, adminID)
+	if err != nil {
+		t.Fatalf("Issue.Assign(%d) err = %s", adminID, err)
+	}
+	xi, err := issue.ByAssignee(ds, adminID)
+	if err != nil {
+		t.Fatalf("issue.ByAssignee(%d) err = %s", adminID, err)
+	}
+	if len(xi) != 1 || xi[0].ID != 2 {
+		t.Errorf("issue.ByAssignee(%d) = %v, want issue id 2 only", adminID, xi)
+	}
+}
+
+// test assigning an issue to an admin user that does not exist
+func testAssignErrorNoAdmin(t *testing.T) {
+	i, err := issue.ByID(ds, 2)
+	if err != nil {
+		t.Fatalf("issue.ByID(2) err = %s", err)
+	}
+	err = i.Assign(ds, 999)
+	if err == nil {
+		t.Errorf("Issue.Assign(999) err = nil, want error")
+	}
+}
+
+// test resolving and re-opening an issue
+func testResolveReopen(t *testing.T) {
+	i, err := issue.ByID(ds, 3)
+	if err != nil {
+		t.Fatalf("issue.ByID(3) err = %s", err)
+	}
+	if err := i.Resolve(ds); err != nil {
+		t.Fatalf("Issue.Resolve() err = %s", err)
+	}
+	i, _ = issue.ByID(ds, 3)
+	if !i.Resolved {
+		t.Errorf("Issue.Resolved = false after Resolve(), want true")
+	}
+	if err := i.Reopen(ds); err != nil {
+		t.Fatalf("Issue.Reopen() err = %s", err)
+	}
+	i, _ = issue.ByID(ds, 3)
+	if i.Resolved {
+		t.Errorf("Issue.Resolved = true after Reopen(), want false")
+	}
+}
+
+// test fetching the notes threaded on an issue
+func testSetNotes(t *testing.T) {
+	i, err := issue.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("issue.ByID(1) err = %s", err)
+	}
+	if err := i.SetNotes(ds); err != nil {
+		t.Fatalf("Issue.SetNotes() err = %s", err)
+	}
+	got := len(i.Notes)
+	want := 1
+	if got != want {
+		t.Errorf("Issue.Notes count = %d, want %d", got, want)
+	}
+}
+
+// test adding a note to an issue
+func testAddNote(t *testing.T) {
+	i, err := issue.ByID(ds, 3)
+	if err != nil {
+		t.Fatalf("issue.ByID(3) err = %s", err)
+	}
+	_, err = i.AddNote(ds, note.Note{TypeID: 10001, Content: "Followed up with member"})
+	if err != nil {
+		t.Fatalf("Issue.AddNote() err = %s", err)
+	}
+	i2, _ := issue.ByID(ds, 3)
+	if err := i2.SetNotes(ds); err != nil {
+		t.Fatalf("Issue.SetNotes() err = %s", err)
+	}
+	if len(i2.Notes) != 1 {
+		t.Fatalf("Issue.Notes count = %d, want 1", len(i2.Notes))
+	}
+	got := i2.Notes[0].MemberID
+	want := i.MemberID
+	if got != want {
+		t.Errorf("Note.MemberID = %d, want %d", got, want)
+	}
+}
+
+// test that an inactive issue is still fetched by id, but is left out of a query
+func testInactive(t *testing.T) {
+	_, err := ds.MySQL.Session.Exec("UPDATE wf_issue SET active = 0 WHERE id = 3")
+	if err != nil {
+		t.Fatalf("Exec() err = %s", err)
+	}
+	i, err := issue.ByID(ds, 3)
+	if err != nil {
+		t.Fatalf("issue.ByID(3) err = %s", err)
+	}
+	if i.ID != 3 {
+		t.Errorf("issue.ByID(3) ID = %d, want 3", i.ID)
+	}
+	xi, err := issue.Query(ds, issue.Filter{})
+	if err != nil {
+		t.Fatalf("issue.Query() err = %s", err)
+	}
+	for _, i := range xi {
+		if i.ID == 3 {
+			t.Errorf("issue.Query() includes inactive issue 3")
+		}
+	}
+}
+
 func toJSON(i interface{}) string {
 	xb, _ := json.MarshalIndent(i, "", " ")
 	return string(xb)
@@ -3,8 +3,12 @@ package issue
 var queries = map[string]string{
 	"insert-issue":             insertIssue,
 	"insert-issue-association": insertIssueAssociation,
+	"select-issue":             selectIssue,
 	"select-issue-by-id":       selectIssueByID,
-	"select-issue-type-by-id": selectIssueTypeByID,
+	"select-issue-type-by-id":  selectIssueTypeByID,
+	"select-admin-user-id":     selectAdminUserID,
+	"update-issue-assigned":    updateIssueAssigned,
+	"update-issue-resolved":    updateIssueResolved,
 }
 
 const insertIssue = `
@@ -32,9 +36,10 @@ SELECT
     i.member_visible AS VisibleToMember,
     i.description AS Description,
     i.required_action AS Action,
-    ia.member_id AS MemberID,
-    ia.association AS AssocEntity,
-    ia.association_entity_id AS AssocEntityID,
+    COALESCE(i.ad_user_id_assigned, 0) AS AssignedTo,
+    COALESCE(ia.member_id, 0) AS MemberID,
+    COALESCE(ia.association, '') AS AssocEntity,
+    COALESCE(ia.association_entity_id, 0) AS AssocEntityID,
     it.id AS IssueTypeID,
 	it.name AS IssueType,
 	it.Description as IssueTypeDescription,
@@ -70,3 +75,8 @@ WHERE
 
 const selectIssueTypeByID = selectIssueType + ` AND t.id = ?`
 
+const selectAdminUserID = `SELECT id FROM ad_user WHERE active = 1 AND id = ?`
+
+const updateIssueAssigned = `UPDATE wf_issue SET ad_user_id_assigned = ?, updated_at = NOW() WHERE id = ?`
+
+const updateIssueResolved = `UPDATE wf_issue SET resolved = ?, updated_at = NOW() WHERE id = ?`
@@ -0,0 +1,418 @@
+package member
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/date"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// loadBatch is the most ids in one IN list. Larger sets are loaded in batches, each with one query per
+// relation.
+const loadBatch = 500
+
+// loader builds the members for a set of ids with one query per relation, rather than the dozen
+// queries per member of the Set* methods
+type loader struct {
+	ds      datastore.Datastore
+	ids     []int
+	members map[int]*Member
+	// titled and statused record the members that have a current membership title and status
+	titled   map[int]bool
+	statused map[int]bool
+}
+
+// ByIDs returns the members with the ids, in the order of the ids, with one query per relation for every
+// 500 ids. Ids with no member record are left out, and duplicate ids are returned once.
+func ByIDs(ds datastore.Datastore, ids []int) ([]Member, error) {
+
+	var xm []Member
+	seen := map[int]bool{}
+	var batch []int
+	flush := func() error {
+		l := loader{ds: ds, ids: batch}
+		if err := l.load(); err != nil {
+			return err
+		}
+		for _, id := range batch {
+			if m, ok := l.members[id]; ok {
+				xm = append(xm, *m)
+			}
+		}
+		batch = nil
+		return nil
+	}
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		batch = append(batch, id)
+		if len(batch) == loadBatch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return xm, nil
+}
+
+// MapByIDs returns the members with the ids, by id, for code that looks up the member for each of a list
+// of records, such as the reports
+func MapByIDs(ds datastore.Datastore, ids []int) (map[int]Member, error) {
+	xm, err := ByIDs(ds, ids)
+	if err != nil {
+		return nil, err
+	}
+	mm := make(map[int]Member, len(xm))
+	for _, m := range xm {
+		mm[m.ID] = m
+	}
+	return mm, nil
+}
+
+func (l *loader) load() error {
+
+	if err := l.setMembers(); err != nil {
+		return err
+	}
+	if len(l.members) == 0 {
+		return nil
+	}
+
+	for _, f := range []func() error{
+		l.setContactLocations,
+		l.setMembershipTitles,
+		l.setMembershipStatuses,
+		l.setMembershipStatusHistory,
+		l.setQualifications,
+		l.setAccreditations,
+		l.setPositions,
+		l.setSpecialities,
+		l.setTags,
+	} {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	l.setMemberships()
+	return nil
+}
+
+// query runs a relation query for the ids and calls scan for each row. The query has a %s for the IN
+// list, and member_id as its first column.
+func (l *loader) query(name string, scan func(rows *sql.Rows) error) error {
+
+	args := make([]interface{}, len(l.ids))
+	for i, id := range l.ids {
+		args[i] = id
+	}
+	query := fmt.Sprintf(queries[name], "?"+strings.Repeat(", ?", len(l.ids)-1))
+
+	rows, err := l.ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return errors.Wrap(err, name+" query error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return errors.Wrap(err, name+" scan error")
+		}
+	}
+	return rows.Err()
+}
+
+func (l *loader) setMembers() error {
+
+	l.members = map[int]*Member{}
+	return l.query("select-members", func(rows *sql.Rows) error {
+
+		var m Member
+		var active int
+		var createdAt string
+		var updatedAt string
+		var middleNames string
+
+		err := rows.Scan(
+			&m.ID,
+			&active,
+			&createdAt,
+			&updatedAt,
+			&m.Title,
+			&m.Country,
+			&m.FirstName,
+			&middleNames,
+			&m.LastName,
+			&m.PostNominal,
+			&m.QualificationsOther,
+			&m.Gender,
+			&m.DateOfBirth,
+			&m.DateOfEntry,
+			&m.Contact.EmailPrimary,
+			&m.Contact.EmailSecondary,
+			&m.Contact.Mobile,
+			&m.JournalNumber,
+			&m.BpayNumber,
+			&m.Contact.Directory,
+			&m.Contact.Consent,
+		)
+		if err != nil {
+			return err
+		}
+
+		// Note - this is soft-delete active NOT membership active
+		m.Active = active == 1
+
+		m.CreatedAt, err = date.StringToTime(createdAt)
+		if err != nil {
+			return errors.Wrap(err, "Error converting createdAt to Time")
+		}
+		m.UpdatedAt, err = date.StringToTime(updatedAt)
+		if err != nil {
+			return errors.Wrap(err, "Error converting updatedAt to Time")
+		}
+
+		if len(middleNames) > 0 {
+			m.MiddleNames = strings.Split(middleNames, " ")
+		}
+
+		m.Memberships = []Membership{{
+			OrgID:   "csanz",
+			OrgCode: "CSANZ",
+			OrgName: "Cardiac Society of Australia and New Zealand",
+		}}
+		l.members[m.ID] = &m
+		return nil
+	})
+}
+
+func (l *loader) setContactLocations() error {
+	return l.query("select-members-contact-locations", func(rows *sql.Rows) error {
+
+		var id int
+		var loc Location
+		var address string
+
+		err := rows.Scan(
+			&id,
+			&loc.Description,
+			&address,
+			&loc.City,
+			&loc.State,
+			&loc.Postcode,
+			&loc.Country,
+			&loc.Phone,
+			&loc.Fax,
+			&loc.Email,
+			&loc.URL,
+			&loc.Preference,
+		)
+		if err != nil {
+			return err
+		}
+
+		// split address string into array of lines
+		for _, a := range strings.Split(address, "\n") {
+			if len(a) > 0 {
+				loc.Address = append(loc.Address, a)
+			}
+		}
+
+		if m, ok := l.members[id]; ok {
+			m.Contact.Locations = append(m.Contact.Locations, loc)
+		}
+		return nil
+	})
+}
+
+// setMembershipTitles sets the current title, the rows are newest first so the first for a member is used
+func (l *loader) setMembershipTitles() error {
+
+	l.titled = map[int]bool{}
+	return l.query("select-members-title", func(rows *sql.Rows) error {
+		var id int
+		var t string
+		if err := rows.Scan(&id, &t); err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok && !l.titled[id] {
+			m.Memberships[0].Title = t
+			l.titled[id] = true
+		}
+		return nil
+	})
+}
+
+// setMembershipStatuses sets the current status, the rows are newest first so the first for a member is
+// used
+func (l *loader) setMembershipStatuses() error {
+
+	l.statused = map[int]bool{}
+	return l.query("select-members-status", func(rows *sql.Rows) error {
+		var id int
+		var s string
+		if err := rows.Scan(&id, &s); err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok && !l.statused[id] {
+			m.Memberships[0].Status = s
+			l.statused[id] = true
+		}
+		return nil
+	})
+}
+
+func (l *loader) setMembershipStatusHistory() error {
+	return l.query("select-members-status-history", func(rows *sql.Rows) error {
+		var id int
+		t := MembershipStatus{}
+		err := rows.Scan(
+			&id,
+			&t.Date,
+			&t.Name,
+			&t.Description,
+			&t.Comment,
+		)
+		if err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok {
+			m.Memberships[0].StatusHistory = append(m.Memberships[0].StatusHistory, t)
+		}
+		return nil
+	})
+}
+
+// setMemberships removes the default membership from members without a current title and status, as
+// SetMembershipTitle and SetMembershipStatus do
+func (l *loader) setMemberships() {
+	for id, m := range l.members {
+		if !l.titled[id] || !l.statused[id] {
+			m.Memberships = []Membership{}
+		}
+	}
+}
+
+func (l *loader) setQualifications() error {
+	return l.query("select-members-qualifications", func(rows *sql.Rows) error {
+
+		var id int
+		var q Qualification
+		var year string
+
+		err := rows.Scan(
+			&id,
+			&q.Code,
+			&q.Name,
+			&q.Description,
+			&year,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(year) > 0 {
+			q.Year, err = strconv.Atoi(year)
+			if err != nil {
+				return errors.Wrap(err, "could not convert year to integer")
+			}
+		}
+
+		if m, ok := l.members[id]; ok {
+			m.Qualifications = append(m.Qualifications, q)
+		}
+		return nil
+	})
+}
+
+func (l *loader) setAccreditations() error {
+	return l.query("select-members-accreditations", func(rows *sql.Rows) error {
+		var id int
+		var a Accreditation
+		err := rows.Scan(
+			&id,
+			&a.Code,
+			&a.Name,
+			&a.Description,
+			&a.Start,
+			&a.End,
+		)
+		if err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok {
+			m.Accreditations = append(m.Accreditations, a)
+		}
+		return nil
+	})
+}
+
+func (l *loader) setPositions() error {
+	return l.query("select-members-positions", func(rows *sql.Rows) error {
+		var id int
+		p := Position{}
+		err := rows.Scan(
+			&id,
+			&p.OrgCode,
+			&p.OrgName,
+			&p.Code,
+			&p.Name,
+			&p.Description,
+			&p.Start,
+			&p.End,
+		)
+		if err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok {
+			m.Positions = append(m.Positions, p)
+		}
+		return nil
+	})
+}
+
+func (l *loader) setSpecialities() error {
+	return l.query("select-members-specialities", func(rows *sql.Rows) error {
+		var id int
+		s := Speciality{}
+		err := rows.Scan(
+			&id,
+			&s.Name,
+			&s.Description,
+			&s.Start,
+		)
+		if err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok {
+			m.Specialities = append(m.Specialities, s)
+		}
+		return nil
+	})
+}
+
+func (l *loader) setTags() error {
+	return l.query("select-members-tags", func(rows *sql.Rows) error {
+		var id int
+		var t string
+		if err := rows.Scan(&id, &t); err != nil {
+			return err
+		}
+		if m, ok := l.members[id]; ok {
+			m.Tags = append(m.Tags, t)
+		}
+		return nil
+	})
+}
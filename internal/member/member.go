@@ -11,8 +11,9 @@ import (
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/cardiacsociety/web-services/internal/cpd"
-	"github.com/cardiacsociety/web-services/internal/date"
+	"github.com/cardiacsociety/web-services/internal/generic"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 	"github.com/cardiacsociety/web-services/internal/qualification"
 	"github.com/pkg/errors"
 )
@@ -594,143 +595,113 @@ func (m *Member) Sync(ds datastore.Datastore) error {
 	return m.SaveDocDB(ds)
 }
 
-// ValueByID returns a Member value rather than a pointer
-func ValueByID(ds datastore.Datastore, id int) (Member, error) {
+// SyncByID fetches the member from MySQL and saves it to the document database. If the member has been
+// deleted the document is removed, and removed is true. An inactive member is saved with active false.
+func SyncByID(ds datastore.Datastore, id int) (removed bool, err error) {
 	m, err := ByID(ds, id)
-	return *m, err
-}
-
-// ByID returns a pointer to a populated Member value
-func ByID(ds datastore.Datastore, id int) (*Member, error) {
-
-	m := Member{ID: id}
-
-	query := queries["select-member"]
-
-	var active int
-	var createdAt string
-	var updatedAt string
-	var middleNames string
-
-	err := ds.MySQL.Session.QueryRow(query, id).Scan(
-		&active,
-		&createdAt,
-		&updatedAt,
-		&m.FirstName,
-		&middleNames,
-		&m.LastName,
-		&m.PostNominal,
-		&m.QualificationsOther,
-		&m.Gender,
-		&m.DateOfBirth,
-		&m.DateOfEntry,
-		&m.Contact.EmailPrimary,
-		&m.Contact.EmailSecondary,
-		&m.Contact.Mobile,
-		&m.JournalNumber,
-		&m.BpayNumber,
-		&m.Contact.Directory,
-		&m.Contact.Consent,
-	)
-
-	if err == sql.ErrNoRows {
-		return &m, errors.Wrap(err, "No member record with that id")
+	if errors.Cause(err) == sql.ErrNoRows {
+		return RemoveDoc(ds, id)
 	}
 	if err != nil {
-		return &m, errors.Wrap(err, "SQL error")
+		return false, err
 	}
+	return false, m.Sync(ds)
+}
 
-	// Note - this is soft-delete active NOT membership active
-	if active == 1 {
-		m.Active = true
-	}
+// SyncByIDs fetches the members with ByIDs and saves them to the document database, so that a batch of
+// members needs one query per relation. The documents of members that have been deleted are removed, and
+// their ids returned.
+func SyncByIDs(ds datastore.Datastore, ids []int) (removed []int, err error) {
 
-	m.CreatedAt, err = date.StringToTime(createdAt)
+	mm, err := MapByIDs(ds, ids)
 	if err != nil {
-		return &m, errors.Wrap(err, "Error converting createdAt to Time")
+		return nil, err
 	}
-	m.UpdatedAt, err = date.StringToTime(updatedAt)
-	if err != nil {
-		return &m, errors.Wrap(err, "Error converting updatedAt to Time")
+	for _, id := range ids {
+		m, ok := mm[id]
+		if !ok {
+			r, err := RemoveDoc(ds, id)
+			if err != nil {
+				return removed, errors.Wrapf(err, "SyncByIDs id %d", id)
+			}
+			if r {
+				removed = append(removed, id)
+			}
+			continue
+		}
+		if err := m.Sync(ds); err != nil {
+			return removed, errors.Wrapf(err, "SyncByIDs id %d", id)
+		}
 	}
+	return removed, nil
+}
 
-	err = m.SetHonorific(ds)
+// RemoveDoc removes the member document, removed is false if there was no document
+func RemoveDoc(ds datastore.Datastore, id int) (removed bool, err error) {
+	mc, err := ds.MongoDB.MembersCollection()
 	if err != nil {
-		return &m, errors.Wrap(err, "SetHonorific")
+		return false, errors.Wrap(err, "RemoveDoc could not get member collection")
 	}
-
-	err = m.SetCountry(ds)
+	info, err := mc.RemoveAll(bson.M{"id": id})
 	if err != nil {
-		return &m, errors.Wrap(err, "SetCountry")
+		return false, errors.Wrap(err, "RemoveDoc remove error")
 	}
+	return info.Removed > 0, nil
+}
 
-	if len(middleNames) > 0 {
-		xmn := strings.Split(middleNames, " ")
-		for _, mn := range xmn {
-			m.MiddleNames = append(m.MiddleNames, mn)
-		}
-	}
+// VerifyDoc compares the member document with the member fetched from MySQL, and returns the fields that
+// differ. A missing document, or a document for a member that has been deleted, is reported as a
+// difference in the id field.
+func VerifyDoc(ds datastore.Datastore, id int) ([]generic.FieldDiff, error) {
 
-	err = m.SetContactLocations(ds)
-	if err != nil {
-		return &m, errors.Wrap(err, "SetContactLocations")
+	want, err := ByID(ds, id)
+	if errors.Cause(err) == sql.ErrNoRows {
+		want, err = nil, nil
 	}
-
-	err = m.SetMemberships()
 	if err != nil {
-		return &m, errors.Wrap(err, "SetMemberships")
-	}
-	for i := range m.Memberships {
-		err = m.SetMembershipTitle(ds, i)
-		if err != nil {
-			return &m, errors.Wrap(err, "SetMembershipTitle")
-		}
-		//
-		//err = m.SetMembershipTitleHistory(ds, i)
-		//if err != nil {
-		//	return &m, errors.Wrap(err, "SetMembershipTitleHistory")
-		//}
+		return nil, err
 	}
 
-	for i := range m.Memberships {
-
-		err = m.SetMembershipStatus(ds, i)
-		if err != nil {
-			return &m, errors.Wrap(err, "SetMembershipStatus")
-		}
-
-		err = m.SetMembershipStatusHistory(ds, i)
-		if err != nil {
-			return &m, errors.Wrap(err, "SetMembershipStatusHistory")
+	xm, err := SearchDocDB(ds, bson.M{"id": id})
+	if err == mgo.ErrNotFound {
+		if want == nil {
+			return nil, nil
 		}
+		return []generic.FieldDiff{{Field: "id", Want: id}}, nil
 	}
-
-	err = m.SetQualifications(ds)
 	if err != nil {
-		return &m, errors.Wrap(err, "SetQualifications")
+		return nil, errors.Wrap(err, "VerifyDoc")
 	}
-
-	err = m.SetAccreditations(ds)
-	if err != nil {
-		return &m, errors.Wrap(err, "SetAccreditations")
+	if want == nil {
+		return []generic.FieldDiff{{Field: "id", Got: id}}, nil
 	}
 
-	err = m.SetPositions(ds)
-	if err != nil {
-		return &m, errors.Wrap(err, "SetPositions")
-	}
+	return generic.DocDiff(want, xm[0], "_id")
+}
 
-	err = m.SetSpecialities(ds)
-	if err != nil {
-		return &m, errors.Wrap(err, "SetSpecialities")
-	}
+// ValueByID returns a Member value rather than a pointer
+func ValueByID(ds datastore.Datastore, id int) (Member, error) {
+	m, err := ByID(ds, id)
+	return *m, err
+}
+
+// ByID returns a pointer to a populated Member value. It uses the same loader as ByIDs, so that a member
+// is the same whichever is used to fetch it.
+func ByID(ds datastore.Datastore, id int) (*Member, error) {
 
-	err = m.SetTags(ds)
+	m := Member{ID: id}
+
+	l := loader{ds: ds, ids: []int{id}}
+	err := l.load()
 	if err != nil {
-		return &m, errors.Wrap(err, "SetTags")
+		return &m, errors.Wrap(err, "SQL error")
+	}
+	mp, ok := l.members[id]
+	if !ok {
+		return &m, errors.Wrap(sql.ErrNoRows, "No member record with that id")
 	}
 
-	return &m, nil
+	return mp, nil
 }
 
 // SearchDocDB searches the Member collection using the specified query
@@ -759,20 +730,19 @@ func SearchDocDB(ds datastore.Datastore, query bson.M) ([]Member, error) {
 // soft-deleting their subcription(s)
 func (m *Member)Lapse(ds datastore.Datastore) error {
 
-	// This creates new status of lapsed, and sets others to current = 0
-	sr := StatusRow{
-		StatusID: lapsedStatusID,
-		Current: true, 
-	}
-	if err := sr.insert(ds, m.ID); err != nil {
-		return err
-	}
+	return outbox.Transact(ds.MySQL.Session, outbox.Member, outbox.Upsert, func(tx *sql.Tx) (int, error) {
 
-	// De-activate all financial subscriptions
-	_, err := ds.MySQL.Session.Exec(queries["update-member-deactivate-subscriptions"], m.ID)
-	if err != nil {
-		return err
-	}
+		// This creates new status of lapsed, and sets others to current = 0
+		sr := StatusRow{
+			StatusID: lapsedStatusID,
+			Current:  true,
+		}
+		if err := sr.insert(tx, m.ID); err != nil {
+			return m.ID, err
+		}
 
-	return nil
-}
\ No newline at end of file
+		// De-activate all financial subscriptions
+		_, err := tx.Exec(queries["update-member-deactivate-subscriptions"], m.ID)
+		return m.ID, err
+	})
+}
@@ -1,6 +1,7 @@
 package member
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"github.com/cardiacsociety/web-services/internal/issue"
 	"github.com/cardiacsociety/web-services/internal/note"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 )
 
 // Foreign Key values for creating required record relationships
@@ -149,81 +151,96 @@ func (r *Row) Insert(ds datastore.Datastore) error {
 	// gender stored as 'M' or 'F', so capitalise first letter of gender string
 	r.Gender = strings.ToUpper(string(strings.TrimSpace(r.Gender)[0]))
 
-	res, err := ds.MySQL.Session.Exec(queries["insert-member-row"],
-		r.RoleID,
-		r.NamePrefixID,
-		r.CountryID,
-		consentDirectory,
-		consentContact,
-		r.DateOfBirth,
-		r.Gender,
-		r.FirstName,
-		r.MiddleNames,
-		r.LastName,
-		r.PostNominal,
-		r.Mobile,
-		r.PrimaryEmail)
+	// The member and related rows are saved with the change event in one transaction. The note and
+	// issue are not part of the member record so are added afterwards.
+	err := outbox.Transact(ds.MySQL.Session, outbox.Member, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		res, err := tx.Exec(queries["insert-member-row"],
+			r.RoleID,
+			r.NamePrefixID,
+			r.CountryID,
+			consentDirectory,
+			consentContact,
+			r.DateOfBirth,
+			r.Gender,
+			r.FirstName,
+			r.MiddleNames,
+			r.LastName,
+			r.PostNominal,
+			r.Mobile,
+			r.PrimaryEmail)
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("LastInsertID() err = %s", err)
+		}
+		r.ID = int(id) // from int64
+		return r.ID, r.insertRelated(tx)
+	})
 	if err != nil {
+		r.ID = 0
 		return err
 	}
-	id, err := res.LastInsertId()
+
+	err = r.insertFileNote(ds)
 	if err != nil {
-		return fmt.Errorf("LastInsertID() err = %s", err)
+		return fmt.Errorf("insertFileNote() err = %s", err)
 	}
-	r.ID = int(id) // from int64
 
-	err = r.insertQualifications(ds)
+	err = r.insertIssue(ds)
+	if err != nil {
+		return fmt.Errorf("insertIssue() err = %s", err)
+	}
+
+	return nil
+}
+
+// insertRelated inserts the rows related to the member
+func (r *Row) insertRelated(tx *sql.Tx) error {
+
+	err := r.insertQualifications(tx)
 	if err != nil {
 		return fmt.Errorf("insertQualifications() err = %s", err)
 	}
 
-	err = r.insertPositions(ds)
+	err = r.insertPositions(tx)
 	if err != nil {
 		return fmt.Errorf("insertPositions() err = %s", err)
 	}
 
-	err = r.insertSpecialities(ds)
+	err = r.insertSpecialities(tx)
 	if err != nil {
 		return fmt.Errorf("insertSpecialities() err = %s", err)
 	}
 
-	err = r.insertAccreditations(ds)
+	err = r.insertAccreditations(tx)
 	if err != nil {
 		return fmt.Errorf("insertAccreditations() err = %s", err)
 	}
 
-	err = r.insertTags(ds)
+	err = r.insertTags(tx)
 	if err != nil {
 		return fmt.Errorf("insertTags() err = %s", err)
 	}
 
-	err = r.insertContacts(ds)
+	err = r.insertContacts(tx)
 	if err != nil {
 		return fmt.Errorf("insertContacts() err = %s", err)
 	}
 
-	err = r.insertApplication(ds)
+	err = r.insertApplication(tx)
 	if err != nil {
 		return fmt.Errorf("insertApplication() err = %s", err)
 	}
 
-	err = r.insertFileNote(ds)
-	if err != nil {
-		return fmt.Errorf("insertFileNote() err = %s", err)
-	}
-
-	err = r.insertIssue(ds)
-	if err != nil {
-		return fmt.Errorf("insertIssue() err = %s", err)
-	}
-
 	return nil
 }
 
 // insertQualifications inserts the member qualifications present in the Row value
-func (r *Row) insertQualifications(ds datastore.Datastore) error {
+func (r *Row) insertQualifications(tx *sql.Tx) error {
 	for _, q := range r.Qualifications {
-		err := q.insert(ds, r.ID)
+		err := q.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -232,9 +249,9 @@ func (r *Row) insertQualifications(ds datastore.Datastore) error {
 }
 
 // insertPositions inserts the member positions present in the Row value
-func (r *Row) insertPositions(ds datastore.Datastore) error {
+func (r *Row) insertPositions(tx *sql.Tx) error {
 	for _, p := range r.Positions {
-		err := p.insert(ds, r.ID)
+		err := p.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -243,9 +260,9 @@ func (r *Row) insertPositions(ds datastore.Datastore) error {
 }
 
 // insertSpecialities inserts the member specialities present in the Row value
-func (r *Row) insertSpecialities(ds datastore.Datastore) error {
+func (r *Row) insertSpecialities(tx *sql.Tx) error {
 	for _, s := range r.Specialities {
-		err := s.insert(ds, r.ID)
+		err := s.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -254,9 +271,9 @@ func (r *Row) insertSpecialities(ds datastore.Datastore) error {
 }
 
 // insertAccreditations inserts the member accreditations present in the Row value
-func (r *Row) insertAccreditations(ds datastore.Datastore) error {
+func (r *Row) insertAccreditations(tx *sql.Tx) error {
 	for _, a := range r.Accreditations {
-		err := a.insert(ds, r.ID)
+		err := a.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -265,9 +282,9 @@ func (r *Row) insertAccreditations(ds datastore.Datastore) error {
 }
 
 // insertTags inserts the member tags present in the Row value
-func (r *Row) insertTags(ds datastore.Datastore) error {
+func (r *Row) insertTags(tx *sql.Tx) error {
 	for _, t := range r.Tags {
-		err := t.insert(ds, r.ID)
+		err := t.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -277,8 +294,8 @@ func (r *Row) insertTags(ds datastore.Datastore) error {
 
 // insertApplication creates an application record for the member and sets the
 // Application ID on success.
-func (r *Row) insertApplication(ds datastore.Datastore) error {
-	id, err := r.Application.insert(ds, r.ID)
+func (r *Row) insertApplication(tx *sql.Tx) error {
+	id, err := r.Application.insert(tx, r.ID)
 	if err != nil {
 		return err
 	}
@@ -287,9 +304,9 @@ func (r *Row) insertApplication(ds datastore.Datastore) error {
 }
 
 // insertContacts inserts the member contact rows
-func (r *Row) insertContacts(ds datastore.Datastore) error {
+func (r *Row) insertContacts(tx *sql.Tx) error {
 	for _, c := range r.Contacts {
-		err := c.insert(ds, r.ID)
+		err := c.insert(tx, r.ID)
 		if err != nil {
 			return err
 		}
@@ -341,8 +358,8 @@ func (r *Row) insertIssue(ds datastore.Datastore) error {
 }
 
 // insert a member qualification row in the junction table
-func (qr QualificationRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-qualification-row"],
+func (qr QualificationRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-qualification-row"],
 		memberID,
 		qr.QualificationID,
 		qr.OrganisationID,
@@ -353,8 +370,8 @@ func (qr QualificationRow) insert(ds datastore.Datastore, memberID int) error {
 }
 
 // insert a member position row in the junction table
-func (pr PositionRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-position-row"],
+func (pr PositionRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-position-row"],
 		memberID,
 		pr.PositionID,
 		pr.OrganisationID,
@@ -363,8 +380,8 @@ func (pr PositionRow) insert(ds datastore.Datastore, memberID int) error {
 }
 
 // insert a member speciality row in the junction table
-func (sr SpecialityRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-speciality-row"],
+func (sr SpecialityRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-speciality-row"],
 		memberID,
 		sr.SpecialityID,
 		sr.Preference,
@@ -373,8 +390,8 @@ func (sr SpecialityRow) insert(ds datastore.Datastore, memberID int) error {
 }
 
 // insert a member accreditation row in the junction table
-func (ar AccreditationRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-accreditation-row"],
+func (ar AccreditationRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-accreditation-row"],
 		memberID,
 		ar.AccreditationID,
 		ar.StartDate,
@@ -384,16 +401,16 @@ func (ar AccreditationRow) insert(ds datastore.Datastore, memberID int) error {
 }
 
 // insert a member tag row in the junction table
-func (tr TagRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-tag-row"],
+func (tr TagRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-tag-row"],
 		memberID,
 		tr.TagID)
 	return err
 }
 
 // insert methods creates a new application record, returns id on success
-func (ar ApplicationRow) insert(ds datastore.Datastore, memberID int) (int, error) {
-	res, err := ds.MySQL.Session.Exec(queries["insert-member-application-row"],
+func (ar ApplicationRow) insert(tx *sql.Tx, memberID int) (int, error) {
+	res, err := tx.Exec(queries["insert-member-application-row"],
 		memberID,
 		ar.NominatorID,
 		ar.SeconderID,
@@ -406,8 +423,8 @@ func (ar ApplicationRow) insert(ds datastore.Datastore, memberID int) (int, erro
 	return int(id), err
 }
 
-func (cr ContactRow) insert(ds datastore.Datastore, memberID int) error {
-	_, err := ds.MySQL.Session.Exec(queries["insert-member-contact-row"],
+func (cr ContactRow) insert(tx *sql.Tx, memberID int) error {
+	_, err := tx.Exec(queries["insert-member-contact-row"],
 		memberID,
 		cr.TypeID,
 		cr.CountryID,
@@ -427,7 +444,7 @@ func (cr ContactRow) insert(ds datastore.Datastore, memberID int) error {
 
 // insert a member status row and, if it is set to current, ensure it is the
 // only record with current = 1
-func (sr StatusRow) insert(ds datastore.Datastore, memberID int) error {
+func (sr StatusRow) insert(tx *sql.Tx, memberID int) error {
 
 	sr.MemberID = memberID
 
@@ -436,7 +453,7 @@ func (sr StatusRow) insert(ds datastore.Datastore, memberID int) error {
 	if sr.Current {
 		current = 1
 	}
-	res, err := ds.MySQL.Session.Exec(queries["insert-member-status-row"],
+	res, err := tx.Exec(queries["insert-member-status-row"],
 		memberID,
 		sr.StatusID,
 		current,
@@ -455,7 +472,7 @@ func (sr StatusRow) insert(ds datastore.Datastore, memberID int) error {
 	// If true also need to set current = 0 for all other status
 	// records for the member - can only have one status at a time.
 	if sr.Current {
-		_, err := ds.MySQL.Session.Exec(queries["update-member-current-status"], sr.ID, memberID)
+		_, err := tx.Exec(queries["update-member-current-status"], sr.ID, memberID)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package member_test
 
 import (
 	"log"
+	"reflect"
 	"testing"
 
 	"github.com/cardiacsociety/web-services/internal/member"
@@ -24,9 +25,97 @@ func TestMemberRow(t *testing.T) {
 	t.Run("member_row", func(t *testing.T) {
 		t.Run("testInsertRow", testInsertRow)
 		t.Run("testInsertRowJSON", testInsertRowJSON)
+		t.Run("testByIDs", testByIDs)
 	})
 }
 
+// testByIDs checks the members loaded in bulk by ByIDs against the same members built with the Set*
+// methods, one query per relation per member. It runs after the inserts so there are several members with
+// relations to group, and member 1 is given another position so the position ids are not in member order.
+func testByIDs(t *testing.T) {
+
+	query := `INSERT INTO mp_m_position (member_id, mp_position_id, organisation_id, active, created_at, updated_at, start_on)
+	VALUES (1, 1, 14, 1, NOW(), NOW(), '2019-01-01')`
+	_, err := ds2.MySQL.Session.Exec(query)
+	if err != nil {
+		t.Fatalf("Exec() err = %s", err)
+	}
+
+	xm, err := member.ByIDs(ds2, []int{3, 999999, 1, 2, 1})
+	if err != nil {
+		t.Fatalf("member.ByIDs() err = %s", err)
+	}
+	var ids []int
+	for _, m := range xm {
+		ids = append(ids, m.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{3, 1, 2}) {
+		t.Fatalf("member.ByIDs() ids = %v, want [3 1 2]", ids)
+	}
+
+	for _, got := range xm {
+		want := memberBySetters(t, got)
+		if len(want.Positions) == 0 {
+			t.Errorf("member %d has no positions to compare", got.ID)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("member.ByIDs() member %d = %+v, want %+v", got.ID, got, want)
+		}
+	}
+}
+
+// memberBySetters builds the member from the member record fields of m, and the Set* methods for the rest
+func memberBySetters(t *testing.T, m member.Member) member.Member {
+
+	want := member.Member{
+		ID:                  m.ID,
+		Active:              m.Active,
+		CreatedAt:           m.CreatedAt,
+		UpdatedAt:           m.UpdatedAt,
+		FirstName:           m.FirstName,
+		MiddleNames:         m.MiddleNames,
+		LastName:            m.LastName,
+		PostNominal:         m.PostNominal,
+		QualificationsOther: m.QualificationsOther,
+		Gender:              m.Gender,
+		DateOfBirth:         m.DateOfBirth,
+		DateOfEntry:         m.DateOfEntry,
+		JournalNumber:       m.JournalNumber,
+		BpayNumber:          m.BpayNumber,
+		Contact: member.Contact{
+			EmailPrimary:   m.Contact.EmailPrimary,
+			EmailSecondary: m.Contact.EmailSecondary,
+			Mobile:         m.Contact.Mobile,
+			Directory:      m.Contact.Directory,
+			Consent:        m.Contact.Consent,
+		},
+	}
+
+	check := func(name string, err error) {
+		if err != nil {
+			t.Fatalf("member %d %s() err = %s", m.ID, name, err)
+		}
+	}
+	check("SetHonorific", want.SetHonorific(ds2))
+	check("SetCountry", want.SetCountry(ds2))
+	check("SetContactLocations", want.SetContactLocations(ds2))
+	check("SetMemberships", want.SetMemberships())
+	for i := range want.Memberships {
+		check("SetMembershipTitle", want.SetMembershipTitle(ds2, i))
+	}
+	for i := range want.Memberships {
+		check("SetMembershipStatus", want.SetMembershipStatus(ds2, i))
+		check("SetMembershipStatusHistory", want.SetMembershipStatusHistory(ds2, i))
+	}
+	check("SetQualifications", want.SetQualifications(ds2))
+	check("SetAccreditations", want.SetAccreditations(ds2))
+	check("SetPositions", want.SetPositions(ds2))
+	check("SetSpecialities", want.SetSpecialities(ds2))
+	check("SetTags", want.SetTags(ds2))
+
+	return want
+}
+
 func setup2() (datastore.Datastore, func()) {
 	var db = testdata.NewDataStore()
 	err := db.SetupMySQL()
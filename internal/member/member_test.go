@@ -9,6 +9,7 @@ import (
 
 	"github.com/cardiacsociety/web-services/internal/member"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 	"github.com/cardiacsociety/web-services/testdata"
 	"github.com/matryer/is"
 	"gopkg.in/mgo.v2/bson"
@@ -207,6 +208,21 @@ func testLapse(t *testing.T) {
 	if err != nil {
 		t.Fatalf("member.Lapse() err = %s", err)
 	}
+
+	// the change event is recorded with the change
+	xe, err := outbox.After(ds, 0, 1000, outbox.Member)
+	if err != nil {
+		t.Fatalf("outbox.After() err = %s", err)
+	}
+	var found bool
+	for _, e := range xe {
+		if e.AggregateID == 1 && e.Action == outbox.Upsert {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("outbox.After() no event for member id 1")
+	}
 }
 
 func printJSON(m member.Member) {
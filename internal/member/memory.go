@@ -0,0 +1,68 @@
+package member
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Memory is an in-memory Repository for unit tests. The members are the MySQL records, and the docs
+// are kept separately so that a test can check what was saved.
+type Memory struct {
+	mu      sync.Mutex
+	members map[int]Member
+	Docs    map[int]Member
+}
+
+// NewMemory returns a Memory holding the members
+func NewMemory(xm ...Member) *Memory {
+	m := &Memory{members: map[int]Member{}, Docs: map[int]Member{}}
+	for _, v := range xm {
+		m.members[v.ID] = v
+	}
+	return m
+}
+
+// ByID returns a copy of a member, or an error with the cause sql.ErrNoRows, as for Store
+func (m *Memory) ByID(id int) (*Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.members[id]
+	if !ok {
+		return nil, errors.Wrap(sql.ErrNoRows, "ByID")
+	}
+	return &v, nil
+}
+
+// ByIDs returns copies of the members that exist, in the order of the ids, and each member once
+func (m *Memory) ByIDs(ids []int) ([]Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var xm []Member
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if v, ok := m.members[id]; ok && !seen[id] {
+			xm = append(xm, v)
+			seen[id] = true
+		}
+	}
+	return xm, nil
+}
+
+// SaveDoc saves a copy of the member to Docs
+func (m *Memory) SaveDoc(v *Member) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Docs[v.ID] = *v
+	return nil
+}
+
+// RemoveDoc removes the member from Docs
+func (m *Memory) RemoveDoc(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.Docs[id]
+	delete(m.Docs, id)
+	return ok, nil
+}
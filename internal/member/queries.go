@@ -10,7 +10,16 @@ var queries = map[string]string{
 	"insert-member-application-row":          insertMemberApplicationRow,
 	"insert-member-contact-row":              insertMemberContactRow,
 	"insert-member-status-row":               insertMemberStatusRow,
-	"select-member":                          selectMember,
+	"select-members":                         selectMembers,
+	"select-members-contact-locations":       selectMembersContactLocations,
+	"select-members-title":                   selectMembersTitle,
+	"select-members-status":                  selectMembersStatus,
+	"select-members-status-history":          selectMembersStatusHistory,
+	"select-members-qualifications":          selectMembersQualifications,
+	"select-members-accreditations":          selectMembersAccreditations,
+	"select-members-positions":               selectMembersPositions,
+	"select-members-specialities":            selectMembersSpecialities,
+	"select-members-tags":                    selectMembersTags,
 	"select-member-honorific":                selectMemberHonorific,
 	"select-member-country":                  selectMemberCountry,
 	"select-member-contact-locations":        selectMemberContactLocations,
@@ -146,30 +155,6 @@ INSERT INTO ms_m_status(
 ) 
 VALUES (?, ?, ?, NOW(), ?)`
 
-const selectMember = `SELECT 
-	active,
-    created_at as CreatedAt,
-    updated_at as UpdatedAt,
-    COALESCE(first_name, '') as FirstName,  
-    COALESCE(middle_names, '') as MiddleNames,
-    COALESCE(last_name, '') as LastName,
-    COALESCE(suffix, '') as PostNom,
-    COALESCE(qualifications_other, '') as QualificationsOther, 
-    COALESCE(gender, '') as Gender,
-    COALESCE(date_of_birth, '') as DateOfBirth,
-    COALESCE(date_of_entry, '') as DateOfEntry,
-    COALESCE(primary_email, '') as Email,
-    COALESCE(secondary_email, '') as Email2,
-    COALESCE(mobile_phone, '') as Mobile,
-    COALESCE(journal_number, '') as JournalNumber,
-    COALESCE(bpay_number, '') as BpayNumber,
-    consent_directory as ConsentDirectory,
-    consent_contact as ConsentContact
-FROM
-    member
-WHERE
-    id = ?`
-
 const selectMemberHonorific = `SELECT
 	COALESCE(a.name, '') FROM a_name_prefix a
 	RIGHT JOIN member m ON m.a_name_prefix_id = a.id
@@ -201,7 +186,7 @@ FROM
 WHERE
     mpmc.member_id = ?
 GROUP BY mpmc.id
-ORDER BY mpct.order ASC`
+ORDER BY mpct.order ASC, mpmc.id ASC`
 
 const selectMembershipTitle = `SELECT 
     COALESCE(mt.name, '')
@@ -262,7 +247,7 @@ FROM
     mp_qualification mq ON mmq.mp_qualification_id = mq.id
 WHERE
     mmq.member_id = ?
-ORDER BY year DESC`
+ORDER BY year DESC, mmq.id ASC`
 
 const selectMemberAccreditations = `SELECT 
     COALESCE(ma.short_name, ''),
@@ -276,7 +261,7 @@ FROM
     mp_accreditation ma ON mma.mp_accreditation_id = ma.id
 WHERE
     mma.member_id = ?
-ORDER BY mma.start_on DESC`
+ORDER BY mma.start_on DESC, mma.id ASC`
 
 const selectMemberPositions = `SELECT 
     COALESCE(organisation.short_name, ''),
@@ -293,7 +278,8 @@ FROM
         LEFT JOIN
     organisation ON mmp.organisation_id = organisation.id
 WHERE
-    mmp.member_id = ?`
+    mmp.member_id = ?
+ORDER BY mmp.id ASC`
 
 const selectMemberSpecialities = `SELECT 
     COALESCE(s.name, ''),
@@ -305,7 +291,7 @@ FROM
     mp_speciality s ON ms.mp_speciality_id = s.id
 WHERE
     ms.member_id = ?
-ORDER BY ms.preference ASC`
+ORDER BY ms.preference ASC, ms.id ASC`
 
 const selectMemberTags = `SELECT 
     COALESCE(t.name, '') as Tag
@@ -314,7 +300,174 @@ FROM
         LEFT JOIN
     mp_tag t ON mt.mp_tag_id = t.id
 WHERE
-    mt.member_id = ?`
+    mt.member_id = ?
+ORDER BY mt.id ASC`
+
+// The select-members queries fetch a relation for a set of members, with member_id as the first column.
+// The %s is replaced with the placeholders for the ids.
+
+const selectMembers = `SELECT 
+    m.id,
+    m.active,
+    m.created_at as CreatedAt,
+    m.updated_at as UpdatedAt,
+    COALESCE(a.name, '') as Honorific,
+    COALESCE(c.name, '') as Country,
+    COALESCE(m.first_name, '') as FirstName,  
+    COALESCE(m.middle_names, '') as MiddleNames,
+    COALESCE(m.last_name, '') as LastName,
+    COALESCE(m.suffix, '') as PostNom,
+    COALESCE(m.qualifications_other, '') as QualificationsOther, 
+    COALESCE(m.gender, '') as Gender,
+    COALESCE(m.date_of_birth, '') as DateOfBirth,
+    COALESCE(m.date_of_entry, '') as DateOfEntry,
+    COALESCE(m.primary_email, '') as Email,
+    COALESCE(m.secondary_email, '') as Email2,
+    COALESCE(m.mobile_phone, '') as Mobile,
+    COALESCE(m.journal_number, '') as JournalNumber,
+    COALESCE(m.bpay_number, '') as BpayNumber,
+    m.consent_directory as ConsentDirectory,
+    m.consent_contact as ConsentContact
+FROM
+    member m
+        LEFT JOIN
+    a_name_prefix a ON m.a_name_prefix_id = a.id
+        LEFT JOIN
+    country c ON m.country_id = c.id
+WHERE
+    m.id IN (%s)`
+
+const selectMembersContactLocations = `SELECT 
+    mpmc.member_id,
+    COALESCE(mpct.name, ''),
+    CONCAT(COALESCE(mpmc.address1, ''), '\n', COALESCE(mpmc.address2, ''), '\n', COALESCE(mpmc.address3, '')),
+    COALESCE(mpmc.locality, ''),
+    COALESCE(mpmc.state, ''),
+    COALESCE(mpmc.postcode, ''),
+    COALESCE(country.name, ''),
+    COALESCE(mpmc.phone, ''),
+    COALESCE(mpmc.fax, ''),
+    COALESCE(mpmc.email, ''),
+    COALESCE(mpmc.web, ''),
+    COALESCE(mpct.order, '')
+FROM
+    mp_m_contact mpmc
+        LEFT JOIN
+    mp_contact_type mpct ON mpmc.mp_contact_type_id = mpct.id
+        LEFT JOIN
+    country ON mpmc.country_id = country.id
+WHERE
+    mpmc.member_id IN (%s)
+GROUP BY mpmc.id
+ORDER BY mpct.order ASC, mpmc.id ASC`
+
+const selectMembersTitle = `SELECT 
+    mmt.member_id,
+    COALESCE(mt.name, '')
+FROM
+    ms_title mt
+        INNER JOIN
+    ms_m_title mmt ON mt.id = mmt.ms_title_id
+WHERE
+	current = 1 AND mmt.member_id IN (%s)
+ORDER BY mmt.id DESC`
+
+const selectMembersStatus = `SELECT 
+    mms.member_id,
+    COALESCE(ms.name, '')
+FROM
+    ms_status ms
+        INNER JOIN
+    ms_m_status mms ON ms.id = mms.ms_status_id
+WHERE
+	current = 1 AND mms.member_id IN (%s)
+ORDER BY mms.id DESC`
+
+const selectMembersStatusHistory = `SELECT
+    mms.member_id,
+	mms.created_at as Date,
+    COALESCE(ms.name, ''),
+    COALESCE(ms.description, ''),
+    COALESCE(mms.comment, '')
+FROM
+    ms_status ms
+        INNER JOIN
+    ms_m_status mms ON ms.id = mms.ms_status_id
+WHERE
+    mms.member_id IN (%s)
+ORDER BY mms.id DESC`
+
+const selectMembersQualifications = `SELECT 
+    mmq.member_id,
+    COALESCE(mq.short_name, ''),
+    COALESCE(mq.name, ''),
+    COALESCE(mq.description, ''),
+    COALESCE(mmq.year, '')
+FROM
+    mp_m_qualification mmq
+        LEFT JOIN
+    mp_qualification mq ON mmq.mp_qualification_id = mq.id
+WHERE
+    mmq.member_id IN (%s)
+ORDER BY year DESC, mmq.id ASC`
+
+const selectMembersAccreditations = `SELECT 
+    mma.member_id,
+    COALESCE(ma.short_name, ''),
+    COALESCE(ma.name, ''),
+    COALESCE(ma.description, ''),
+    COALESCE(mma.start_on, ''),
+    COALESCE(mma.end_on, '')
+FROM
+    mp_m_accreditation mma
+        LEFT JOIN
+    mp_accreditation ma ON mma.mp_accreditation_id = ma.id
+WHERE
+    mma.member_id IN (%s)
+ORDER BY mma.start_on DESC, mma.id ASC`
+
+const selectMembersPositions = `SELECT 
+    mmp.member_id,
+    COALESCE(organisation.short_name, ''),
+    COALESCE(organisation.name, ''),
+    COALESCE(mp.short_name, ''),
+    COALESCE(mp.name, ''),
+    COALESCE(mp.description, ''),
+    COALESCE(mmp.start_on, ''),
+    COALESCE(mmp.end_on, '')
+FROM
+    mp_m_position mmp
+        LEFT JOIN
+    mp_position mp ON mmp.mp_position_id = mp.id
+        LEFT JOIN
+    organisation ON mmp.organisation_id = organisation.id
+WHERE
+    mmp.member_id IN (%s)
+ORDER BY mmp.id ASC`
+
+const selectMembersSpecialities = `SELECT 
+    ms.member_id,
+    COALESCE(s.name, ''),
+    COALESCE(s.description, ''),
+    COALESCE(ms.start_on, '')
+FROM
+    mp_m_speciality ms
+        LEFT JOIN
+    mp_speciality s ON ms.mp_speciality_id = s.id
+WHERE
+    ms.member_id IN (%s)
+ORDER BY ms.preference ASC, ms.id ASC`
+
+const selectMembersTags = `SELECT 
+    mt.member_id,
+    COALESCE(t.name, '') as Tag
+FROM
+    mp_m_tag mt
+        LEFT JOIN
+    mp_tag t ON mt.mp_tag_id = t.id
+WHERE
+    mt.member_id IN (%s)
+ORDER BY mt.id ASC`
 
 // ensure only ONE member status record is current
 const updateMemberCurrentStatus = `
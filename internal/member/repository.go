@@ -0,0 +1,51 @@
+package member
+
+import (
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Repository provides the member records, so that code that needs them can be tested with Memory
+// instead of a database
+type Repository interface {
+	ByID(id int) (*Member, error)
+	// ByIDs returns the members that exist, in the order of the ids
+	ByIDs(ids []int) ([]Member, error)
+	SaveDoc(m *Member) error
+	RemoveDoc(id int) (bool, error)
+}
+
+var (
+	_ Repository = Store{}
+	_ Repository = (*Memory)(nil)
+)
+
+// Store is the Repository for the MySQL and document databases - members are read from MySQL, and saved
+// to the document database
+type Store struct {
+	ds datastore.Datastore
+}
+
+// NewStore returns a Repository for the databases in ds
+func NewStore(ds datastore.Datastore) Store {
+	return Store{ds: ds}
+}
+
+// ByID fetches a member from MySQL
+func (s Store) ByID(id int) (*Member, error) {
+	return ByID(s.ds, id)
+}
+
+// ByIDs fetches members from MySQL with one query per relation
+func (s Store) ByIDs(ids []int) ([]Member, error) {
+	return ByIDs(s.ds, ids)
+}
+
+// SaveDoc upserts the member doc
+func (s Store) SaveDoc(m *Member) error {
+	return m.SaveDocDB(s.ds)
+}
+
+// RemoveDoc removes the member doc, removed is false if there was no doc
+func (s Store) RemoveDoc(id int) (bool, error) {
+	return RemoveDoc(s.ds, id)
+}
@@ -0,0 +1,388 @@
+package module
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+)
+
+// Authoring error messages
+const (
+	ErrorIDNotNil        = "cannot insert a module because Module.ID already has a value"
+	ErrorNoID            = "cannot update a module because Module.ID is not set"
+	ErrorNoName          = "Module.Name is empty"
+	ErrorDuration        = "Module.DurationMinutes cannot be negative"
+	ErrorNoSections      = "a module must have at least one section before it can be published"
+	ErrorSectionIDNotNil = "cannot insert a section because Section.ID already has a value"
+	ErrorSectionNoID     = "cannot update a section because Section.ID is not set"
+	ErrorSectionSummary  = "Section.Summary is empty"
+	ErrorSectionOrder    = "the section ids must be the sections of the module, each listed once"
+	ErrorNoResource      = "resource does not exist"
+)
+
+// Resource is a resource in the library that is listed with a module
+type Resource struct {
+	ID          int    `json:"id" bson:"id"`
+	Sequence    int    `json:"sequence" bson:"sequence"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	URL         string `json:"url" bson:"url"`
+	ShortURL    string `json:"shortUrl" bson:"shortUrl"`
+}
+
+// InsertRow creates a new module from the values in Module. A new module is unpublished, so it
+// is not available to members until it is published.
+func (m *Module) InsertRow(ds datastore.Datastore) error {
+	if m.ID > 0 {
+		return errors.New(ErrorIDNotNil)
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	var id int
+	err := outbox.Transact(ds.MySQL.Session, outbox.Module, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		res, err := tx.Exec(queries["insert-module"], m.Name, m.Description, m.Objective,
+			m.Instruction, m.DurationMinutes)
+		if err != nil {
+			return 0, err
+		}
+		id64, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(queries["update-module-original"], id64); err != nil {
+			return 0, err
+		}
+		id = int(id64)
+		return id, nil
+	})
+	if err != nil {
+		return err
+	}
+	m.ID = id
+	return nil
+}
+
+// Update saves the values in Module. Sections and resources are updated separately. If the module is
+// published the document is also updated.
+func (m *Module) Update(ds datastore.Datastore) error {
+	if m.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	err := execWithEvent(ds, m.ID, outbox.Upsert, queries["update-module"], m.Name, m.Description,
+		m.Objective, m.Instruction, m.DurationMinutes, m.ID)
+	if err != nil {
+		return err
+	}
+	return m.refresh(ds)
+}
+
+// Publish makes the module available to members, and saves the document immediately rather than
+// waiting for the next sync
+func (m *Module) Publish(ds datastore.Datastore) error {
+	if m.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	xs, err := Sections(ds, m.ID)
+	if err != nil {
+		return err
+	}
+	if len(xs) == 0 {
+		return errors.New(ErrorNoSections)
+	}
+	if err := execWithEvent(ds, m.ID, outbox.Upsert, queries["publish-module"], m.ID); err != nil {
+		return err
+	}
+	return m.refresh(ds)
+}
+
+// Unpublish withdraws the module from members, and removes the document. Members who have
+// already started the module can still finish it.
+func (m *Module) Unpublish(ds datastore.Datastore) error {
+	if m.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	if err := execWithEvent(ds, m.ID, outbox.Delete, queries["unpublish-module"], m.ID); err != nil {
+		return err
+	}
+	if err := m.refresh(ds); err != nil {
+		return err
+	}
+	return m.DeleteDoc(ds)
+}
+
+// refresh fetches the module again and, if it is published, saves the document
+func (m *Module) refresh(ds datastore.Datastore) error {
+	m2, err := ByID(ds, m.ID)
+	if err != nil {
+		return err
+	}
+	*m = *m2
+	if !m.Published {
+		return nil
+	}
+	return m.SaveDoc(ds)
+}
+
+// validate checks the required fields
+func (m *Module) validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return errors.New(ErrorNoName)
+	}
+	if m.DurationMinutes < 0 {
+		return errors.New(ErrorDuration)
+	}
+	return nil
+}
+
+// DeleteDoc removes the Module doc from MongoDB, if it is there
+func (m *Module) DeleteDoc(ds datastore.Datastore) error {
+	_, err := RemoveDoc(ds, m.ID)
+	return err
+}
+
+// RemoveDoc removes the module document, removed is false if there was no document
+func RemoveDoc(ds datastore.Datastore, id int) (removed bool, err error) {
+	mc, err := ds.MongoDB.ModulesCollection()
+	if err != nil {
+		return false, errors.Wrap(err, "module.RemoveDoc()")
+	}
+	info, err := mc.RemoveAll(map[string]int{"id": id})
+	if err != nil {
+		return false, errors.Wrap(err, "module.RemoveDoc()")
+	}
+	return info.Removed > 0, nil
+}
+
+// SectionByID fetches a single section
+func SectionByID(ds datastore.Datastore, id int) (Section, error) {
+	xs, err := executeSection(ds, queries["select-section"], id)
+	if err != nil {
+		return Section{}, err
+	}
+	if len(xs) == 0 {
+		return Section{}, sql.ErrNoRows
+	}
+	return xs[0], nil
+}
+
+// InsertRow adds a section to Section.ModuleID. A Sequence of 0 places the section after the existing
+// sections.
+func (s *Section) InsertRow(ds datastore.Datastore) error {
+	if s.ID > 0 {
+		return errors.New(ErrorSectionIDNotNil)
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if _, err := ByID(ds, s.ModuleID); err == sql.ErrNoRows {
+		return errors.New(ErrorNoModule)
+	} else if err != nil {
+		return err
+	}
+	if s.Sequence == 0 {
+		xs, err := Sections(ds, s.ModuleID)
+		if err != nil {
+			return err
+		}
+		s.Sequence = 1
+		if len(xs) > 0 {
+			s.Sequence = xs[len(xs)-1].Sequence + 1
+		}
+	}
+	var id int64
+	err := outbox.Transact(ds.MySQL.Session, outbox.Module, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		res, err := tx.Exec(queries["insert-section"], s.ModuleID, s.Sequence, s.Summary, nullString(s.Content))
+		if err != nil {
+			return 0, err
+		}
+		id, err = res.LastInsertId()
+		return s.ModuleID, err
+	})
+	if err != nil {
+		return err
+	}
+	s.ID = int(id)
+	s.Type = "info"
+	return nil
+}
+
+// Update saves the values in Section
+func (s *Section) Update(ds datastore.Datastore) error {
+	if s.ID == 0 {
+		return errors.New(ErrorSectionNoID)
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if err := s.setModuleID(ds); err != nil {
+		return err
+	}
+	return execWithEvent(ds, s.ModuleID, outbox.Upsert, queries["update-section"], s.Sequence, s.Summary,
+		nullString(s.Content), s.ID)
+}
+
+// Delete removes a section from the module
+func (s *Section) Delete(ds datastore.Datastore) error {
+	if s.ID == 0 {
+		return errors.New(ErrorSectionNoID)
+	}
+	if err := s.setModuleID(ds); err != nil {
+		return err
+	}
+	return execWithEvent(ds, s.ModuleID, outbox.Upsert, queries["delete-section"], s.ID)
+}
+
+// setModuleID fetches the module id of the section if it is not set, so that the change event can be
+// recorded against the module
+func (s *Section) setModuleID(ds datastore.Datastore) error {
+	if s.ModuleID > 0 {
+		return nil
+	}
+	s2, err := SectionByID(ds, s.ID)
+	if err != nil {
+		return err
+	}
+	s.ModuleID = s2.ModuleID
+	return nil
+}
+
+// validate checks the required fields
+func (s *Section) validate() error {
+	if strings.TrimSpace(s.Summary) == "" {
+		return errors.New(ErrorSectionSummary)
+	}
+	return nil
+}
+
+// OrderSections sets the order of the sections of a module. The ids must include every section of
+// the module.
+func OrderSections(ds datastore.Datastore, moduleID int, ids []int) error {
+
+	xs, err := Sections(ds, moduleID)
+	if err != nil {
+		return err
+	}
+	seen := map[int]bool{}
+	for _, s := range xs {
+		seen[s.ID] = false
+	}
+	for _, id := range ids {
+		done, ok := seen[id]
+		if !ok || done {
+			return errors.New(ErrorSectionOrder)
+		}
+		seen[id] = true
+	}
+	if len(ids) != len(xs) {
+		return errors.New(ErrorSectionOrder)
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for i, id := range ids {
+		if _, err := tx.Exec(queries["update-section-sequence"], i+1, id); err != nil {
+			return errors.Wrap(err, "update section")
+		}
+	}
+	if err := outbox.Emit(tx, outbox.Module, moduleID, outbox.Upsert); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Resources fetches the resources listed with a module, in order
+func Resources(ds datastore.Datastore, moduleID int) ([]Resource, error) {
+
+	xr := []Resource{}
+
+	rows, err := ds.MySQL.Session.Query(queries["select-resources"], moduleID)
+	if err != nil {
+		return xr, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := Resource{}
+		if err := rows.Scan(&r.ID, &r.Sequence, &r.Name, &r.Description, &r.URL, &r.ShortURL); err != nil {
+			return xr, errors.Wrap(err, "failed to scan resource row")
+		}
+		xr = append(xr, r)
+	}
+
+	return xr, rows.Err()
+}
+
+// SetResources replaces the resources listed with a module with the resources ids, in order
+func SetResources(ds datastore.Datastore, moduleID int, ids []int) error {
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(queries["delete-resources"], moduleID); err != nil {
+		return errors.Wrap(err, "delete resources")
+	}
+	for i, id := range ids {
+		var n int
+		if err := tx.QueryRow(queries["count-resource"], id).Scan(&n); err != nil {
+			return errors.Wrap(err, "select resource")
+		}
+		if n == 0 {
+			return errors.New(ErrorNoResource)
+		}
+		if _, err := tx.Exec(queries["upsert-resource"], moduleID, id, i+1); err != nil {
+			return errors.Wrap(err, "save resource")
+		}
+	}
+	if err := outbox.Emit(tx, outbox.Module, moduleID, outbox.Upsert); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execWithEvent runs the query, and records a change event for the module in the same transaction
+func execWithEvent(ds datastore.Datastore, moduleID int, action string, query string, args ...interface{}) error {
+	return outbox.Transact(ds.MySQL.Session, outbox.Module, action, func(tx *sql.Tx) (int, error) {
+		_, err := tx.Exec(query, args...)
+		return moduleID, err
+	})
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// executeSection runs a section query and scans the results into a []Section
+func executeSection(ds datastore.Datastore, query string, args ...interface{}) ([]Section, error) {
+
+	var xs []Section
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := Section{}
+		if err := rows.Scan(&s.ID, &s.ModuleID, &s.Sequence, &s.Type, &s.Summary, &s.Content); err != nil {
+			return xs, errors.Wrap(err, "failed to scan section row")
+		}
+		xs = append(xs, s)
+	}
+
+	return xs, rows.Err()
+}
@@ -0,0 +1,370 @@
+package module
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/activity"
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+)
+
+// Enrolment error messages
+const (
+	ErrorNoModule        = "module does not exist"
+	ErrorFinished        = "module has already been finished"
+	ErrorNoSection       = "section is not part of the module"
+	ErrorIncomplete      = "all sections must be completed before the module can be finished"
+	ErrorNoActivityType  = "module CPD activity has no activity types"
+	ErrorNegativeElapsed = "elapsed seconds cannot be negative"
+)
+
+// Enrolment is a member's attempt at a module. A member resumes an unfinished enrolment rather than
+// starting again, and can start a new enrolment once a module has been finished.
+type Enrolment struct {
+	ID                int       `json:"id" bson:"id"`
+	ModuleID          int       `json:"moduleId" bson:"moduleId"`
+	ModuleName        string    `json:"moduleName" bson:"moduleName"`
+	MemberID          int       `json:"memberId" bson:"memberId"`
+	DateStarted       string    `json:"dateStarted" bson:"dateStarted"`
+	DateUpdated       string    `json:"dateUpdated" bson:"dateUpdated"`
+	DateFinished      string    `json:"dateFinished" bson:"dateFinished"`
+	SectionsTotal     int       `json:"sectionsTotal" bson:"sectionsTotal"`
+	SectionsCompleted int       `json:"sectionsCompleted" bson:"sectionsCompleted"`
+	Passed            bool      `json:"passed" bson:"passed"`
+	Sections          []Section `json:"sections,omitempty" bson:"sections,omitempty"`
+
+	// CPDID is the id of the member activity recorded when the module was finished, if any
+	CPDID int `json:"cpdId" bson:"cpdId"`
+}
+
+// Section is a part of a module, stored as a slide. Completed and the fields that follow it
+// are only set in the context of an Enrolment.
+type Section struct {
+	ID             int    `json:"id" bson:"id"`
+	ModuleID       int    `json:"moduleId" bson:"moduleId"`
+	Sequence       int    `json:"sequence" bson:"sequence"`
+	Type           string `json:"type" bson:"type"`
+	Summary        string `json:"summary" bson:"summary"`
+	Content        string `json:"content" bson:"content"`
+	Completed      bool   `json:"completed" bson:"completed"`
+	DateCompleted  string `json:"dateCompleted,omitempty" bson:"dateCompleted,omitempty"`
+	ElapsedSeconds int    `json:"elapsedSeconds" bson:"elapsedSeconds"`
+}
+
+// Finished is true if the module was finished in this enrolment
+func (e Enrolment) Finished() bool {
+	return e.DateFinished != ""
+}
+
+// Progress is the fraction of sections completed, from 0 to 1
+func (e Enrolment) Progress() float64 {
+	if e.SectionsTotal == 0 {
+		return 0
+	}
+	return float64(e.SectionsCompleted) / float64(e.SectionsTotal)
+}
+
+// Start enrols a member in a published module, or returns their unfinished enrolment so the module can
+// be resumed
+func Start(ds datastore.Datastore, moduleID, memberID int) (Enrolment, error) {
+
+	m, err := ByID(ds, moduleID)
+	if err == sql.ErrNoRows || (err == nil && !m.Published) {
+		return Enrolment{}, errors.New(ErrorNoModule)
+	}
+	if err != nil {
+		return Enrolment{}, err
+	}
+
+	q := queries["select-enrolment"] + ` AND mm.ol_module_id = ? AND mm.member_id = ? AND mm.module_completed_at IS NULL
+		ORDER BY mm.id DESC LIMIT 1`
+	xe, err := executeEnrolment(ds, q, moduleID, memberID)
+	if err != nil {
+		return Enrolment{}, err
+	}
+	if len(xe) > 0 {
+		return EnrolmentByID(ds, xe[0].ID)
+	}
+
+	res, err := ds.MySQL.Session.Exec(queries["insert-enrolment"], memberID, moduleID)
+	if err != nil {
+		return Enrolment{}, errors.Wrap(err, "insert enrolment")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Enrolment{}, err
+	}
+	if err := execWithEvent(ds, moduleID, outbox.Upsert, queries["increment-started"], moduleID); err != nil {
+		return Enrolment{}, errors.Wrap(err, "update module")
+	}
+
+	return EnrolmentByID(ds, int(id))
+}
+
+// EnrolmentByID fetches an Enrolment, including the sections of the module and the member's progress
+func EnrolmentByID(ds datastore.Datastore, id int) (Enrolment, error) {
+
+	xe, err := executeEnrolment(ds, queries["select-enrolment"]+" AND mm.id = ?", id)
+	if err != nil {
+		return Enrolment{}, err
+	}
+	if len(xe) == 0 {
+		return Enrolment{}, sql.ErrNoRows
+	}
+	e := xe[0]
+
+	e.Sections, err = Sections(ds, e.ModuleID)
+	if err != nil {
+		return e, err
+	}
+
+	rows, err := ds.MySQL.Session.Query(queries["select-section-progress"], e.ID)
+	if err != nil {
+		return e, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sectionID, seconds int
+		var date string
+		if err := rows.Scan(&sectionID, &date, &seconds); err != nil {
+			return e, errors.Wrap(err, "failed to scan section progress row")
+		}
+		for i := range e.Sections {
+			if e.Sections[i].ID == sectionID {
+				e.Sections[i].Completed = true
+				e.Sections[i].DateCompleted = date
+				e.Sections[i].ElapsedSeconds = seconds
+			}
+		}
+	}
+
+	return e, rows.Err()
+}
+
+// MemberEnrolments fetches a member's module history, most recent first. Sections are not included.
+func MemberEnrolments(ds datastore.Datastore, memberID int) ([]Enrolment, error) {
+	q := queries["select-enrolment"] + " AND mm.member_id = ? ORDER BY mm.created_at DESC, mm.id DESC"
+	return executeEnrolment(ds, q, memberID)
+}
+
+// Sections fetches the sections of a module, in order
+func Sections(ds datastore.Datastore, moduleID int) ([]Section, error) {
+	return executeSection(ds, queries["select-sections"], moduleID)
+}
+
+// CompleteSection records the completion of a section, and adds to the time spent on it. Completing a
+// section again only adds the time.
+func (e *Enrolment) CompleteSection(ds datastore.Datastore, sectionID, elapsedSeconds int) error {
+
+	if e.Finished() {
+		return errors.New(ErrorFinished)
+	}
+	if elapsedSeconds < 0 {
+		return errors.New(ErrorNegativeElapsed)
+	}
+	xs, err := Sections(ds, e.ModuleID)
+	if err != nil {
+		return err
+	}
+	var ok bool
+	for _, s := range xs {
+		ok = ok || s.ID == sectionID
+	}
+	if !ok {
+		return errors.New(ErrorNoSection)
+	}
+
+	var id int
+	err = ds.MySQL.Session.QueryRow(queries["select-enrolment-section"], e.ID, sectionID).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = ds.MySQL.Session.Exec(queries["insert-enrolment-section"], e.ID, sectionID, elapsedSeconds)
+	case err == nil:
+		_, err = ds.MySQL.Session.Exec(queries["update-enrolment-section"], elapsedSeconds, id)
+	}
+	if err != nil {
+		return errors.Wrap(err, "save section")
+	}
+	if _, err := ds.MySQL.Session.Exec(queries["touch-enrolment"], e.ID); err != nil {
+		return errors.Wrap(err, "update enrolment")
+	}
+
+	*e, err = EnrolmentByID(ds, e.ID)
+	return err
+}
+
+// Finish marks the module as finished, once all sections are complete and, if the module has a quiz, an
+// attempt has been submitted. If the module has a CPD activity configured an entry is added to the member's
+// CPD diary, with a quantity from the module's DurationMinutes. CPD that is allocated on pass is only
+// recorded if an attempt passed. If recording the CPD fails the module is still finished, and calling
+// Finish again retries the CPD.
+func (e *Enrolment) Finish(ds datastore.Datastore) error {
+
+	if e.Finished() {
+		if e.CPDID > 0 {
+			return errors.New(ErrorFinished)
+		}
+		if err := e.saveCPD(ds); err != nil {
+			return err
+		}
+		if e.CPDID == 0 {
+			return errors.New(ErrorFinished)
+		}
+		return nil
+	}
+	if e.SectionsCompleted < e.SectionsTotal {
+		return errors.New(ErrorIncomplete)
+	}
+	xq, err := Questions(ds, e.ModuleID)
+	if err != nil {
+		return err
+	}
+	if len(xq) > 0 {
+		var submitted, passed int
+		if err := ds.MySQL.Session.QueryRow(queries["select-enrolment-passed"], e.ID).Scan(&submitted, &passed); err != nil {
+			return errors.Wrap(err, "select attempts")
+		}
+		if submitted == 0 {
+			return errors.New(ErrorNoAttempt)
+		}
+		e.Passed = passed > 0
+	}
+
+	res, err := ds.MySQL.Session.Exec(queries["finish-enrolment"], e.ID)
+	if err != nil {
+		return errors.Wrap(err, "finish enrolment")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errors.New(ErrorFinished)
+	}
+	if err := execWithEvent(ds, e.ModuleID, outbox.Upsert, queries["increment-finished"], e.ModuleID); err != nil {
+		return errors.Wrap(err, "update module")
+	}
+
+	*e, err = EnrolmentByID(ds, e.ID)
+	if err != nil {
+		return err
+	}
+	return e.saveCPD(ds)
+}
+
+// saveCPD records the CPD for a finished enrolment, and sets the enrolment's CPDID
+func (e *Enrolment) saveCPD(ds datastore.Datastore) error {
+
+	cpdID, err := e.recordCPD(ds)
+	if err != nil {
+		return errors.Wrap(err, "record cpd")
+	}
+	if cpdID == 0 {
+		return nil
+	}
+	if _, err := ds.MySQL.Session.Exec(queries["update-enrolment-cpd"], cpdID, e.ID); err != nil {
+		return errors.Wrap(err, "update enrolment")
+	}
+	e.CPDID = cpdID
+	return nil
+}
+
+// recordCPD adds the module's CPD activity to the member's diary, dated the day the module was finished,
+// and returns the id of the new member activity, or of an existing duplicate, so a retry does not add it
+// twice. It returns 0 if CPD is not allocated for the module.
+func (e Enrolment) recordCPD(ds datastore.Datastore) (int, error) {
+
+	var activityID, typeID int
+	var allocate, onPass bool
+	err := ds.MySQL.Session.QueryRow(queries["select-module-cpd"], e.ModuleID).Scan(&activityID, &typeID, &allocate, &onPass)
+	if err == sql.ErrNoRows || (err == nil && !allocate) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if onPass && !e.Passed {
+		return 0, nil
+	}
+
+	m, err := ByID(ds, e.ModuleID)
+	if err != nil {
+		return 0, err
+	}
+	a, err := activity.ByID(ds, activityID)
+	if err != nil {
+		return 0, err
+	}
+	if typeID == 0 {
+		xt, err := activity.Types(ds, activityID)
+		if err != nil {
+			return 0, err
+		}
+		if len(xt) == 0 {
+			return 0, errors.New(ErrorNoActivityType)
+		}
+		typeID = xt[0].ID
+	}
+
+	// activities measured in hours are credited for the duration of the module, others once per module
+	quantity := 1.0
+	if a.UnitName == "hours" && m.DurationMinutes > 0 {
+		quantity = math.Round(float64(m.DurationMinutes)/60*100) / 100
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if len(e.DateFinished) >= len(date) {
+		date = e.DateFinished[:len(date)]
+	}
+
+	in := cpd.Input{
+		MemberID:    e.MemberID,
+		ActivityID:  activityID,
+		TypeID:      typeID,
+		Date:        date,
+		Quantity:    quantity,
+		Description: fmt.Sprintf("Completed online module: %s", m.Name),
+	}
+	id, err := cpd.DuplicateOf(ds, in)
+	if err != nil || id > 0 {
+		return id, err
+	}
+	return cpd.Add(ds, in)
+}
+
+// executeEnrolment runs an enrolment query and scans the results into an []Enrolment
+func executeEnrolment(ds datastore.Datastore, query string, args ...interface{}) ([]Enrolment, error) {
+
+	var xe []Enrolment
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xe, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := Enrolment{}
+		err := rows.Scan(
+			&e.ID,
+			&e.ModuleID,
+			&e.ModuleName,
+			&e.MemberID,
+			&e.DateStarted,
+			&e.DateUpdated,
+			&e.DateFinished,
+			&e.CPDID,
+			&e.SectionsTotal,
+			&e.SectionsCompleted,
+			&e.Passed,
+		)
+		if err != nil {
+			return xe, errors.Wrap(err, "failed to scan enrolment row")
+		}
+		xe = append(xe, e)
+	}
+
+	return xe, rows.Err()
+}
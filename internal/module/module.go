@@ -1,13 +1,17 @@
 package module
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 
+	"github.com/cardiacsociety/web-services/internal/generic"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 	"github.com/cardiacsociety/web-services/internal/utility"
 )
@@ -21,10 +25,15 @@ type Module struct {
 	PublishedAt     time.Time     `json:"publishedAt" bson:"publishedAt"`
 	Name            string        `json:"name" bson:"name"`
 	Description     string        `json:"description" bson:"description"`
+	Objective       string        `json:"objective" bson:"objective"`
+	Instruction     string        `json:"instruction" bson:"instruction"`
 	DurationMinutes int           `json:"durationMinutes" bson:"durationMinutes"`
 	Started         int           `json:"started" bson:"started"`
 	Finished        int           `json:"finished" bson:"finished"`
 	Current         bool          `json:"current" bson:"current"`
+	Published       bool          `json:"published" bson:"published"`
+	Sections        []Section     `json:"sections" bson:"sections"`
+	Resources       []Resource    `json:"resources" bson:"resources"`
 }
 
 // ByID fetches a module by id, from the MySQL db
@@ -41,7 +50,10 @@ func ByID(ds datastore.Datastore, id int) (*Module, error) {
 	olm.published_at,
 	COALESCE(olm.name, ''),
 	COALESCE(olm.description, ''),
-	olm.started, olm.finished, olm.current
+	COALESCE(olm.objective, ''),
+	COALESCE(olm.instruction, ''),
+	olm.estimated_total_mins,
+	olm.started, olm.finished, olm.current, olm.published
 	FROM ol_module olm
 	WHERE active = 1 AND
 	olm.id = ?`
@@ -57,13 +69,25 @@ func ByID(ds datastore.Datastore, id int) (*Module, error) {
 		&publishedAt,
 		&m.Name,
 		&m.Description,
+		&m.Objective,
+		&m.Instruction,
+		&m.DurationMinutes,
 		&m.Started,
 		&m.Finished,
 		&m.Current,
+		&m.Published,
 	)
 	if err != nil {
 		return &m, err
 	}
+	m.Sections, err = Sections(ds, id)
+	if err != nil {
+		return &m, err
+	}
+	m.Resources, err = Resources(ds, id)
+	if err != nil {
+		return &m, err
+	}
 	// Convert MySQL date time strings to time.Time
 	m.CreatedAt, _ = utility.DateTime(createdAt)
 	m.UpdatedAt, _ = utility.DateTime(updatedAt)
@@ -233,6 +257,49 @@ func (m *Module) Sync(ds datastore.Datastore) error {
 	return m.SaveDoc(ds)
 }
 
+// SyncByID fetches the module from MySQL and saves it to the document database. The document is
+// removed if the module is not published, or has been deleted, and removed is true.
+func SyncByID(ds datastore.Datastore, id int) (removed bool, err error) {
+	m, err := ByID(ds, id)
+	if errors.Cause(err) == sql.ErrNoRows || (err == nil && !m.Published) {
+		return RemoveDoc(ds, id)
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, m.Sync(ds)
+}
+
+// VerifyDoc compares the module document with the module fetched from MySQL, and returns the fields that
+// differ. A missing document for a published module, or a document for a module that is not published or
+// has been deleted, is reported as a difference in the id field.
+func VerifyDoc(ds datastore.Datastore, id int) ([]generic.FieldDiff, error) {
+
+	want, err := ByID(ds, id)
+	if errors.Cause(err) == sql.ErrNoRows || (err == nil && !want.Published) {
+		want, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	got, err := DocModulesOne(ds, bson.M{"id": id})
+	if err == mgo.ErrNotFound {
+		if want == nil {
+			return nil, nil
+		}
+		return []generic.FieldDiff{{Field: "id", Want: id}}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "VerifyDoc")
+	}
+	if want == nil {
+		return []generic.FieldDiff{{Field: "id", Got: id}}, nil
+	}
+
+	return generic.DocDiff(want, got, "_id")
+}
+
 // SaveDoc upserts Module doc to MongoDB
 func (m *Module) SaveDoc(ds datastore.Datastore) error {
 
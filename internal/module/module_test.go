@@ -0,0 +1,508 @@
+package module_test
+
+import (
+	"log"
+	"testing"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+
+	"github.com/cardiacsociety/web-services/internal/cpd"
+	"github.com/cardiacsociety/web-services/internal/module"
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/testdata"
+)
+
+var ds datastore.Datastore
+
+func TestModule(t *testing.T) {
+
+	var teardown func()
+	ds, teardown = setup()
+	defer teardown()
+
+	t.Run("module", func(t *testing.T) {
+		t.Run("testPingDatabase", testPingDatabase)
+		t.Run("testByID", testByID)
+		t.Run("testStart", testStart)
+		t.Run("testCompleteSection", testCompleteSection)
+		t.Run("testFinish", testFinish)
+		t.Run("testMemberEnrolments", testMemberEnrolments)
+		t.Run("testQuiz", testQuiz)
+		t.Run("testItemDifficulty", testItemDifficulty)
+		t.Run("testQuestions", testQuestions)
+		t.Run("testAuthoring", testAuthoring)
+	})
+}
+
+func setup() (datastore.Datastore, func()) {
+	var db = testdata.NewDataStore()
+	err := db.SetupMySQL()
+	if err != nil {
+		log.Fatalf("SetupMySQL() err = %s", err)
+	}
+	err = db.SetupMongoDB()
+	if err != nil {
+		log.Fatalf("SetupMongoDB() err = %s", err)
+	}
+	return db.Store, func() {
+		err := db.TearDownMySQL()
+		if err != nil {
+			log.Fatalf("TearDownMySQL() err = %s", err)
+		}
+		err = db.TearDownMongoDB()
+		if err != nil {
+			log.Fatalf("TearDownMongoDB() err = %s", err)
+		}
+	}
+}
+
+func testPingDatabase(t *testing.T) {
+	err := ds.MySQL.Session.Ping()
+	if err != nil {
+		t.Fatalf("Ping() err = %s", err)
+	}
+}
+
+func testByID(t *testing.T) {
+	m, err := module.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("module.ByID(1) err = %s", err)
+	}
+	if m.Name != "Atrial Fibrillation Update" || m.DurationMinutes != 90 {
+		t.Errorf("Module Name, DurationMinutes = %q, %d, want %q, 90", m.Name, m.DurationMinutes, "Atrial Fibrillation Update")
+	}
+	if len(m.Sections) != 2 || len(m.Resources) != 2 || m.Resources[0].ID != 6576 {
+		t.Errorf("Module has %d sections and resources %+v, want 2 sections and resource 6576 first", len(m.Sections), m.Resources)
+	}
+}
+
+func testStart(t *testing.T) {
+	e, err := module.Start(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("module.Start(1, 1) err = %s", err)
+	}
+	if e.SectionsTotal != 2 || e.SectionsCompleted != 0 || len(e.Sections) != 2 {
+		t.Errorf("Enrolment = %+v, want 2 sections with none completed", e)
+	}
+
+	// an unfinished enrolment is resumed
+	e2, err := module.Start(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("module.Start(1, 1) err = %s", err)
+	}
+	if e2.ID != e.ID {
+		t.Errorf("module.Start(1, 1) resumed ID = %d, want %d", e2.ID, e.ID)
+	}
+
+	var started int
+	if err := ds.MySQL.Session.QueryRow("SELECT started FROM ol_module WHERE id = 1").Scan(&started); err != nil {
+		t.Fatalf("select started err = %s", err)
+	}
+	if started != 1 {
+		t.Errorf("ol_module.started = %d, want 1", started)
+	}
+
+	_, err = module.Start(ds, 999, 1)
+	if err == nil || err.Error() != module.ErrorNoModule {
+		t.Errorf("module.Start(999, 1) err = %v, want %s", err, module.ErrorNoModule)
+	}
+}
+
+// relies on the enrolment from testStart
+func testCompleteSection(t *testing.T) {
+	e, err := module.Start(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("module.Start(1, 1) err = %s", err)
+	}
+
+	// slide 3 is inactive
+	err = e.CompleteSection(ds, 3, 10)
+	if err == nil || err.Error() != module.ErrorNoSection {
+		t.Errorf("Enrolment.CompleteSection(3) err = %v, want %s", err, module.ErrorNoSection)
+	}
+
+	// completing again adds the time
+	for _, secs := range []int{60, 30} {
+		if err := e.CompleteSection(ds, 1, secs); err != nil {
+			t.Fatalf("Enrolment.CompleteSection(1) err = %s", err)
+		}
+	}
+	if e.SectionsCompleted != 1 {
+		t.Errorf("Enrolment.SectionsCompleted = %d, want 1", e.SectionsCompleted)
+	}
+	if got := e.Progress(); got != 0.5 {
+		t.Errorf("Enrolment.Progress() = %v, want 0.5", got)
+	}
+	if !e.Sections[0].Completed || e.Sections[0].ElapsedSeconds != 90 {
+		t.Errorf("Sections[0] = %+v, want completed with 90 elapsed seconds", e.Sections[0])
+	}
+}
+
+// relies on the enrolment from testCompleteSection
+func testFinish(t *testing.T) {
+	e, err := module.Start(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("module.Start(1, 1) err = %s", err)
+	}
+
+	err = e.Finish(ds)
+	if err == nil || err.Error() != module.ErrorIncomplete {
+		t.Errorf("Enrolment.Finish() err = %v, want %s", err, module.ErrorIncomplete)
+	}
+
+	if err := e.CompleteSection(ds, 2, 120); err != nil {
+		t.Fatalf("Enrolment.CompleteSection(2) err = %s", err)
+	}
+	if err := e.Finish(ds); err != nil {
+		t.Fatalf("Enrolment.Finish() err = %s", err)
+	}
+	if !e.Finished() || e.CPDID == 0 {
+		t.Fatalf("Enrolment = %+v, want finished with a CPDID", e)
+	}
+
+	c, err := cpd.ByID(ds, e.CPDID)
+	if err != nil {
+		t.Fatalf("cpd.ByID(%d) err = %s", e.CPDID, err)
+	}
+	if c.Activity.ID != 24 || c.Type.ID != 35 || c.CreditData.Quantity != 1.5 {
+		t.Errorf("cpd Activity, Type, Quantity = %d, %d, %v, want 24, 35, 1.5",
+			c.Activity.ID, c.Type.ID, c.CreditData.Quantity)
+	}
+
+	// if the cpd was not recorded when the module was finished, finishing again records it once
+	cpdID := e.CPDID
+	if _, err := ds.MySQL.Session.Exec(`UPDATE ol_m_module SET ce_m_activity_id = NULL WHERE id = ?`, e.ID); err != nil {
+		t.Fatalf("clear ce_m_activity_id err = %s", err)
+	}
+	e, err = module.EnrolmentByID(ds, e.ID)
+	if err != nil {
+		t.Fatalf("module.EnrolmentByID(%d) err = %s", e.ID, err)
+	}
+	if err := e.Finish(ds); err != nil {
+		t.Fatalf("Enrolment.Finish() retry err = %s", err)
+	}
+	if e.CPDID != cpdID {
+		t.Errorf("Enrolment.CPDID after retry = %d, want %d", e.CPDID, cpdID)
+	}
+
+	err = e.Finish(ds)
+	if err == nil || err.Error() != module.ErrorFinished {
+		t.Errorf("Enrolment.Finish() err = %v, want %s", err, module.ErrorFinished)
+	}
+	err = e.CompleteSection(ds, 1, 10)
+	if err == nil || err.Error() != module.ErrorFinished {
+		t.Errorf("Enrolment.CompleteSection() err = %v, want %s", err, module.ErrorFinished)
+	}
+
+	// a finished module can be started again
+	e2, err := module.Start(ds, 1, 1)
+	if err != nil {
+		t.Fatalf("module.Start(1, 1) err = %s", err)
+	}
+	if e2.ID == e.ID || e2.Finished() {
+		t.Errorf("module.Start(1, 1) = %+v, want a new enrolment", e2)
+	}
+}
+
+// relies on the enrolments from testFinish
+func testMemberEnrolments(t *testing.T) {
+	xe, err := module.MemberEnrolments(ds, 1)
+	if err != nil {
+		t.Fatalf("module.MemberEnrolments(1) err = %s", err)
+	}
+	if len(xe) != 2 {
+		t.Fatalf("module.MemberEnrolments(1) count = %d, want 2", len(xe))
+	}
+	if xe[0].Finished() || !xe[1].Finished() {
+		t.Errorf("module.MemberEnrolments(1) want the unfinished enrolment first")
+	}
+}
+
+// correct and incorrect answers for the question bank of module 2
+var (
+	rightAnswers = map[int][]int{5: {1}, 6: {4, 5, 6}, 7: {8}, 8: {11}}
+	wrongAnswers = map[int][]int{5: {2}, 6: {7}, 7: {9}, 8: {12}}
+)
+
+func answers(a module.Attempt, m map[int][]int) []module.Answer {
+	var xa []module.Answer
+	for _, q := range a.Questions {
+		xa = append(xa, module.Answer{QuestionID: q.ID, OptionIDs: m[q.ID]})
+	}
+	return xa
+}
+
+func testQuiz(t *testing.T) {
+	e, err := module.Start(ds, 2, 1)
+	if err != nil {
+		t.Fatalf("module.Start(2, 1) err = %s", err)
+	}
+	if e.SectionsTotal != 1 {
+		t.Errorf("Enrolment.SectionsTotal = %d, want 1 as question slides are not sections", e.SectionsTotal)
+	}
+	if err := e.CompleteSection(ds, 4, 30); err != nil {
+		t.Fatalf("Enrolment.CompleteSection(4) err = %s", err)
+	}
+	err = e.Finish(ds)
+	if err == nil || err.Error() != module.ErrorNoAttempt {
+		t.Errorf("Enrolment.Finish() err = %v, want %s", err, module.ErrorNoAttempt)
+	}
+
+	a, err := e.StartAttempt(ds)
+	if err != nil {
+		t.Fatalf("Enrolment.StartAttempt() err = %s", err)
+	}
+	if len(a.Questions) != 3 {
+		t.Fatalf("Attempt.Questions count = %d, want 3", len(a.Questions))
+	}
+	for _, q := range a.Questions {
+		for _, o := range q.Options {
+			if o.Correct || o.Value > 0 {
+				t.Errorf("Attempt in progress shows the correct options for question %d", q.ID)
+			}
+		}
+	}
+	resumed, err := e.StartAttempt(ds)
+	if err != nil {
+		t.Fatalf("Enrolment.StartAttempt() err = %s", err)
+	}
+	if resumed.ID != a.ID {
+		t.Errorf("Enrolment.StartAttempt() resumed ID = %d, want %d", resumed.ID, a.ID)
+	}
+
+	err = a.Submit(ds, []module.Answer{{QuestionID: 999, OptionIDs: []int{1}}})
+	if err == nil || err.Error() != module.ErrorAnswerQuestion {
+		t.Errorf("Attempt.Submit() err = %v, want %s", err, module.ErrorAnswerQuestion)
+	}
+	if err := a.Submit(ds, answers(a, wrongAnswers)); err != nil {
+		t.Fatalf("Attempt.Submit() err = %s", err)
+	}
+	if !a.Finished() || a.Passed || a.Score != 0 || a.MaxScore == 0 {
+		t.Errorf("Attempt = %+v, want submitted and failed with a score of 0", a)
+	}
+	err = a.Submit(ds, answers(a, rightAnswers))
+	if err == nil || err.Error() != module.ErrorSubmitted {
+		t.Errorf("Attempt.Submit() err = %v, want %s", err, module.ErrorSubmitted)
+	}
+
+	e, err = module.EnrolmentByID(ds, e.ID)
+	if err != nil {
+		t.Fatalf("module.EnrolmentByID(%d) err = %s", e.ID, err)
+	}
+	a, err = e.StartAttempt(ds)
+	if err != nil {
+		t.Fatalf("Enrolment.StartAttempt() second attempt err = %s", err)
+	}
+	if err := a.Submit(ds, answers(a, rightAnswers)); err != nil {
+		t.Fatalf("Attempt.Submit() err = %s", err)
+	}
+	if !a.Passed || a.Percentage != 100 {
+		t.Errorf("Attempt Passed, Percentage = %v, %v, want true, 100", a.Passed, a.Percentage)
+	}
+
+	e, err = module.EnrolmentByID(ds, e.ID)
+	if err != nil {
+		t.Fatalf("module.EnrolmentByID(%d) err = %s", e.ID, err)
+	}
+	_, err = e.StartAttempt(ds)
+	if err == nil || err.Error() != module.ErrorPassed {
+		t.Errorf("Enrolment.StartAttempt() err = %v, want %s", err, module.ErrorPassed)
+	}
+	if err := e.Finish(ds); err != nil {
+		t.Fatalf("Enrolment.Finish() err = %s", err)
+	}
+	if e.CPDID == 0 {
+		t.Errorf("Enrolment.CPDID = 0, want cpd recorded after a pass")
+	}
+
+	// the attempt limit is 2, with no reset
+	e, err = module.Start(ds, 2, 1)
+	if err != nil {
+		t.Fatalf("module.Start(2, 1) err = %s", err)
+	}
+	_, err = e.StartAttempt(ds)
+	if err == nil || err.Error() != module.ErrorAttemptLimit {
+		t.Errorf("Enrolment.StartAttempt() err = %v, want %s", err, module.ErrorAttemptLimit)
+	}
+}
+
+// relies on the attempts from testQuiz
+func testItemDifficulty(t *testing.T) {
+	xs, err := module.ItemDifficulty(ds, 2)
+	if err != nil {
+		t.Fatalf("module.ItemDifficulty(2) err = %s", err)
+	}
+	if len(xs) != 4 {
+		t.Fatalf("module.ItemDifficulty(2) count = %d, want 4", len(xs))
+	}
+	var attempts, correct int
+	for _, s := range xs {
+		attempts += s.Attempts
+		correct += s.Correct
+		if s.QuestionID == 8 && len(s.Options) != 3 {
+			t.Errorf("ItemStats.Options count for question 8 = %d, want 3", len(s.Options))
+		}
+	}
+	if attempts != 6 || correct != 3 {
+		t.Errorf("ItemStats attempts, correct = %d, %d, want 6, 3", attempts, correct)
+	}
+}
+
+func testQuestions(t *testing.T) {
+	q := module.Question{
+		ModuleID: 1,
+		Type:     module.QuestionSingle,
+		Question: "Which drug is used for rate control?",
+		Options:  []module.Option{{Text: "Metoprolol", Correct: true}, {Text: "Amiodarone", Correct: true}},
+	}
+	err := q.InsertRow(ds)
+	if err == nil || err.Error() != module.ErrorQuestionCorrect {
+		t.Errorf("Question.InsertRow() err = %v, want %s", err, module.ErrorQuestionCorrect)
+	}
+	q.Options[1].Correct = false
+	if err := q.InsertRow(ds); err != nil {
+		t.Fatalf("Question.InsertRow() err = %s", err)
+	}
+
+	q.Type = module.QuestionMultiple
+	q.Options = append(q.Options, module.Option{Text: "Diltiazem", Value: 2})
+	if err := q.Update(ds); err != nil {
+		t.Fatalf("Question.Update() err = %s", err)
+	}
+	xq, err := module.Questions(ds, 1)
+	if err != nil {
+		t.Fatalf("module.Questions(1) err = %s", err)
+	}
+	if len(xq) != 1 || xq[0].Type != module.QuestionMultiple || len(xq[0].Options) != 3 {
+		t.Fatalf("module.Questions(1) = %+v, want one multiple choice question with 3 options", xq)
+	}
+	if !xq[0].Options[2].Correct || xq[0].Options[2].Value != 2 {
+		t.Errorf("Option = %+v, want correct with a value of 2", xq[0].Options[2])
+	}
+
+	if err := q.Delete(ds); err != nil {
+		t.Fatalf("Question.Delete() err = %s", err)
+	}
+	xq, err = module.Questions(ds, 1)
+	if err != nil {
+		t.Fatalf("module.Questions(1) err = %s", err)
+	}
+	if len(xq) != 0 {
+		t.Errorf("module.Questions(1) count = %d, want 0", len(xq))
+	}
+}
+
+func TestQuestionMark(t *testing.T) {
+	q := module.Question{
+		Type: module.QuestionMultiple,
+		Options: []module.Option{
+			{ID: 1, Value: 1, Penalty: 1},
+			{ID: 2, Value: 2},
+			{ID: 3, Penalty: 1},
+			{ID: 4},
+		},
+	}
+	cases := []struct {
+		selected []int
+		score    int
+		correct  bool
+	}{
+		{[]int{1, 2}, 3, true},
+		{[]int{2}, 1, false},       // missed option 1
+		{[]int{1, 2, 3}, 2, false}, // incorrect option 3
+		{[]int{1, 2, 4}, 3, false}, // no penalty for option 4
+		{[]int{3}, 0, false},       // never less than 0
+		{nil, 0, false},
+	}
+	for _, c := range cases {
+		score, max, correct := q.Mark(c.selected)
+		if score != c.score || max != 3 || correct != c.correct {
+			t.Errorf("Question.Mark(%v) = %d, %d, %v, want %d, 3, %v", c.selected, score, max, correct, c.score, c.correct)
+		}
+	}
+}
+
+func testAuthoring(t *testing.T) {
+	m := module.Module{Description: "Heart failure management."}
+	err := m.InsertRow(ds)
+	if err == nil || err.Error() != module.ErrorNoName {
+		t.Errorf("Module.InsertRow() err = %v, want %s", err, module.ErrorNoName)
+	}
+	m.Name = "Heart Failure"
+	m.DurationMinutes = 45
+	if err := m.InsertRow(ds); err != nil {
+		t.Fatalf("Module.InsertRow() err = %s", err)
+	}
+
+	// unpublished modules cannot be started
+	_, err = module.Start(ds, m.ID, 1)
+	if err == nil || err.Error() != module.ErrorNoModule {
+		t.Errorf("module.Start(%d, 1) err = %v, want %s", m.ID, err, module.ErrorNoModule)
+	}
+	err = m.Publish(ds)
+	if err == nil || err.Error() != module.ErrorNoSections {
+		t.Errorf("Module.Publish() err = %v, want %s", err, module.ErrorNoSections)
+	}
+
+	var xs []module.Section
+	for _, summary := range []string{"Diagnosis", "Treatment"} {
+		s := module.Section{ModuleID: m.ID, Summary: summary}
+		if err := s.InsertRow(ds); err != nil {
+			t.Fatalf("Section.InsertRow() err = %s", err)
+		}
+		xs = append(xs, s)
+	}
+	if xs[1].Sequence != 2 {
+		t.Errorf("Section.Sequence = %d, want 2", xs[1].Sequence)
+	}
+	err = module.OrderSections(ds, m.ID, []int{xs[1].ID})
+	if err == nil || err.Error() != module.ErrorSectionOrder {
+		t.Errorf("module.OrderSections() err = %v, want %s", err, module.ErrorSectionOrder)
+	}
+	if err := module.OrderSections(ds, m.ID, []int{xs[1].ID, xs[0].ID}); err != nil {
+		t.Fatalf("module.OrderSections() err = %s", err)
+	}
+
+	err = module.SetResources(ds, m.ID, []int{6578, 999})
+	if err == nil || err.Error() != module.ErrorNoResource {
+		t.Errorf("module.SetResources() err = %v, want %s", err, module.ErrorNoResource)
+	}
+	if err := module.SetResources(ds, m.ID, []int{6578, 6576}); err != nil {
+		t.Fatalf("module.SetResources() err = %s", err)
+	}
+
+	if err := m.Publish(ds); err != nil {
+		t.Fatalf("Module.Publish() err = %s", err)
+	}
+	doc, err := module.DocModulesOne(ds, bson.M{"id": m.ID})
+	if err != nil {
+		t.Fatalf("module.DocModulesOne(%d) err = %s", m.ID, err)
+	}
+	if !doc.Published || len(doc.Sections) != 2 || doc.Sections[0].Summary != "Treatment" {
+		t.Errorf("Module doc = %+v, want published with the Treatment section first", doc)
+	}
+	if len(doc.Resources) != 2 || doc.Resources[0].ID != 6578 {
+		t.Errorf("Module doc resources = %+v, want resource 6578 first", doc.Resources)
+	}
+
+	m.Name = "Heart Failure Update"
+	if err := m.Update(ds); err != nil {
+		t.Fatalf("Module.Update() err = %s", err)
+	}
+	doc, err = module.DocModulesOne(ds, bson.M{"id": m.ID})
+	if err != nil {
+		t.Fatalf("module.DocModulesOne(%d) err = %s", m.ID, err)
+	}
+	if doc.Name != m.Name {
+		t.Errorf("Module doc Name = %q, want %q", doc.Name, m.Name)
+	}
+
+	if err := m.Unpublish(ds); err != nil {
+		t.Fatalf("Module.Unpublish() err = %s", err)
+	}
+	_, err = module.DocModulesOne(ds, bson.M{"id": m.ID})
+	if err != mgo.ErrNotFound {
+		t.Errorf("module.DocModulesOne(%d) err = %v, want %v", m.ID, err, mgo.ErrNotFound)
+	}
+}
@@ -0,0 +1,307 @@
+package module
+
+var queries = map[string]string{
+	"select-enrolment":         selectEnrolment,
+	"insert-enrolment":         insertEnrolment,
+	"finish-enrolment":         finishEnrolment,
+	"update-enrolment-cpd":     updateEnrolmentCPD,
+	"touch-enrolment":          touchEnrolment,
+	"increment-started":        incrementStarted,
+	"increment-finished":       incrementFinished,
+	"select-sections":          selectSections,
+	"select-section-progress":  selectSectionProgress,
+	"select-enrolment-section": selectEnrolmentSection,
+	"insert-enrolment-section": insertEnrolmentSection,
+	"update-enrolment-section": updateEnrolmentSection,
+	"select-module-cpd":        selectModuleCPD,
+	"select-enrolment-passed":  selectEnrolmentPassed,
+
+	"select-quiz":             selectQuiz,
+	"update-quiz":             updateQuiz,
+	"select-questions":        selectQuestions,
+	"select-options":          selectOptions,
+	"insert-question":         insertQuestion,
+	"update-question":         updateQuestion,
+	"delete-question":         deleteQuestion,
+	"insert-option":           insertOption,
+	"delete-options":          deleteOptions,
+	"select-attempt":          selectAttempt,
+	"select-attempt-question": selectAttemptQuestion,
+	"select-attempt-options":  selectAttemptOptions,
+	"select-attempt-answers":  selectAttemptAnswers,
+	"count-attempts":          countAttempts,
+	"insert-attempt":          insertAttempt,
+	"insert-attempt-question": insertAttemptQuestion,
+	"insert-attempt-option":   insertAttemptOption,
+	"score-attempt-question":  scoreAttemptQuestion,
+	"submit-attempt":          submitAttempt,
+	"select-item-stats":       selectItemStats,
+	"select-option-stats":     selectOptionStats,
+
+	"insert-module":           insertModule,
+	"update-module-original":  updateModuleOriginal,
+	"update-module":           updateModule,
+	"publish-module":          publishModule,
+	"unpublish-module":        unpublishModule,
+	"select-section":          selectSection,
+	"insert-section":          insertSection,
+	"update-section":          updateSection,
+	"update-section-sequence": updateSectionSequence,
+	"delete-section":          deleteSection,
+	"select-resources":        selectResources,
+	"count-resource":          countResource,
+	"delete-resources":        deleteResources,
+	"upsert-resource":         upsertResource,
+}
+
+// sections are the active info slides of the module, question slides make up the quiz
+const selectEnrolment = `
+SELECT
+  mm.id,
+  mm.ol_module_id,
+  m.name,
+  mm.member_id,
+  mm.created_at,
+  COALESCE(mm.updated_at, mm.created_at),
+  COALESCE(mm.module_completed_at, ''),
+  COALESCE(mm.ce_m_activity_id, 0),
+  (SELECT COUNT(*) FROM ol_slide s WHERE s.active = 1 AND s.type = 'info' AND s.ol_module_id = mm.ol_module_id),
+  (SELECT COUNT(DISTINCT ms.ol_slide_id) FROM ol_m_module_slide ms
+    INNER JOIN ol_slide s ON ms.ol_slide_id = s.id AND s.active = 1 AND s.type = 'info'
+    WHERE ms.active = 1 AND ms.ol_m_module_id = mm.id),
+  (SELECT COUNT(*) > 0 FROM ol_m_module_attempt a WHERE a.active = 1 AND a.passed = 1 AND a.ol_m_module_id = mm.id)
+FROM ol_m_module mm
+  INNER JOIN ol_module m ON mm.ol_module_id = m.id
+WHERE mm.active = 1`
+
+const insertEnrolment = `
+INSERT INTO ol_m_module (member_id, ol_module_id, active, created_at, updated_at) VALUES (?, ?, 1, NOW(), NOW())`
+
+// the condition on module_completed_at ensures a module is only finished once
+const finishEnrolment = `
+UPDATE ol_m_module
+SET slides_completed_at = COALESCE(slides_completed_at, NOW()), module_completed_at = NOW(), updated_at = NOW()
+WHERE id = ? AND module_completed_at IS NULL`
+
+const updateEnrolmentCPD = `UPDATE ol_m_module SET ce_m_activity_id = ? WHERE id = ?`
+
+const touchEnrolment = `UPDATE ol_m_module SET updated_at = NOW() WHERE id = ?`
+
+const incrementStarted = `UPDATE ol_module SET started = started + 1 WHERE id = ?`
+
+const incrementFinished = `UPDATE ol_module SET finished = finished + 1 WHERE id = ?`
+
+const selectSections = `
+SELECT id, ol_module_id, sequence, type, summary, COALESCE(content, '')
+FROM ol_slide
+WHERE active = 1 AND type = 'info' AND ol_module_id = ?
+ORDER BY sequence ASC, id ASC`
+
+const selectSectionProgress = `
+SELECT ol_slide_id, COALESCE(updated_at, created_at), elapsed_time_seconds
+FROM ol_m_module_slide
+WHERE active = 1 AND ol_m_module_id = ?`
+
+const selectEnrolmentSection = `
+SELECT id FROM ol_m_module_slide WHERE active = 1 AND ol_m_module_id = ? AND ol_slide_id = ? LIMIT 1`
+
+const insertEnrolmentSection = `
+INSERT INTO ol_m_module_slide (ol_m_module_id, ol_slide_id, active, created_at, updated_at, elapsed_time_seconds)
+VALUES (?, ?, 1, NOW(), NOW(), ?)`
+
+const updateEnrolmentSection = `
+UPDATE ol_m_module_slide SET elapsed_time_seconds = elapsed_time_seconds + ?, updated_at = NOW() WHERE id = ?`
+
+const selectModuleCPD = `
+SELECT ce_activity_id, COALESCE(ce_activity_type_id, 0), allocate_points, allocate_on_pass
+FROM ol_module_cpd
+WHERE active = 1 AND ol_module_id = ?`
+
+// returns the number of submitted and passed attempts for an enrolment
+const selectEnrolmentPassed = `
+SELECT COUNT(submitted_at), COALESCE(SUM(passed), 0)
+FROM ol_m_module_attempt
+WHERE active = 1 AND ol_m_module_id = ?`
+
+const selectQuiz = `
+SELECT id, COALESCE(pass_percentage, 0), quiz_question_count, attempt_limit, attempt_limit_reset_days
+FROM ol_module
+WHERE active = 1 AND id = ?`
+
+const updateQuiz = `
+UPDATE ol_module
+SET pass_percentage = ?, quiz_question_count = ?, attempt_limit = ?, attempt_limit_reset_days = ?, updated_at = NOW()
+WHERE id = ?`
+
+const selectQuestions = `
+SELECT id, ol_module_id, sequence, COALESCE(question_type, ''), summary, COALESCE(content, '')
+FROM ol_slide
+WHERE active = 1 AND type = 'question'`
+
+// options are selected with the questions, see Questions()
+const selectOptions = `
+SELECT o.id, o.ol_slide_id, o.sequence, o.option, COALESCE(o.explanation, ''), o.positive_value, o.negative_value
+FROM ol_option o
+  INNER JOIN ol_slide s ON o.ol_slide_id = s.id
+WHERE o.active = 1 AND s.active = 1 AND s.type = 'question'`
+
+const insertQuestion = `
+INSERT INTO ol_slide (ol_module_id, active, created_at, updated_at, sequence, type, summary, content, question_type)
+VALUES (?, 1, NOW(), NOW(), ?, 'question', ?, ?, ?)`
+
+const updateQuestion = `
+UPDATE ol_slide SET sequence = ?, summary = ?, content = ?, question_type = ?, updated_at = NOW()
+WHERE id = ? AND type = 'question'`
+
+const deleteQuestion = `UPDATE ol_slide SET active = 0, updated_at = NOW() WHERE id = ? AND type = 'question'`
+
+const insertOption = `
+INSERT INTO ol_option (ol_slide_id, active, created_at, updated_at, sequence, positive_value, negative_value, ` + "`option`" + `, explanation)
+VALUES (?, 1, NOW(), NOW(), ?, ?, ?, ?, ?)`
+
+// options are replaced when a question is updated, the old ones are kept for the attempts that refer to them
+const deleteOptions = `UPDATE ol_option SET active = 0, updated_at = NOW() WHERE active = 1 AND ol_slide_id = ?`
+
+const selectAttempt = `
+SELECT
+  a.id,
+  a.ol_m_module_id,
+  mm.ol_module_id,
+  mm.member_id,
+  a.created_at,
+  COALESCE(a.submitted_at, ''),
+  a.score,
+  a.max_score,
+  COALESCE(a.pass_percentage, 0),
+  a.passed
+FROM ol_m_module_attempt a
+  INNER JOIN ol_m_module mm ON a.ol_m_module_id = mm.id
+WHERE a.active = 1`
+
+// the question slide may have been deleted since the attempt was started
+const selectAttemptQuestion = `
+SELECT aq.id, s.id, s.ol_module_id, aq.sequence, COALESCE(s.question_type, ''), s.summary, COALESCE(s.content, ''),
+  aq.option_order, aq.score, aq.max_score, aq.correct
+FROM ol_m_module_attempt_question aq
+  INNER JOIN ol_slide s ON aq.ol_slide_id = s.id
+WHERE aq.active = 1 AND aq.ol_m_module_attempt_id = ?
+ORDER BY aq.sequence ASC`
+
+// options are those drawn for the attempt, regardless of any changes to the question since
+const selectAttemptOptions = `
+SELECT o.id, o.ol_slide_id, o.sequence, o.option, COALESCE(o.explanation, ''), o.positive_value, o.negative_value
+FROM ol_m_module_attempt_question aq
+  INNER JOIN ol_option o ON o.ol_slide_id = aq.ol_slide_id AND FIND_IN_SET(o.id, aq.option_order) > 0
+WHERE aq.active = 1 AND aq.ol_m_module_attempt_id = ?`
+
+const selectAttemptAnswers = `
+SELECT aq.ol_slide_id, ao.ol_option_id
+FROM ol_m_module_attempt_option ao
+  INNER JOIN ol_m_module_attempt_question aq ON ao.ol_m_module_attempt_question_id = aq.id
+WHERE aq.active = 1 AND aq.ol_m_module_attempt_id = ?
+ORDER BY ao.id ASC`
+
+// attempts by a member at a module, since the number of days ago, or all attempts if days is 0
+const countAttempts = `
+SELECT COUNT(*)
+FROM ol_m_module_attempt a
+  INNER JOIN ol_m_module mm ON a.ol_m_module_id = mm.id
+WHERE a.active = 1 AND mm.active = 1 AND mm.member_id = ? AND mm.ol_module_id = ?
+  AND (? = 0 OR a.created_at > NOW() - INTERVAL ? DAY)`
+
+const insertAttempt = `
+INSERT INTO ol_m_module_attempt (ol_m_module_id, active, created_at, updated_at, pass_percentage)
+VALUES (?, 1, NOW(), NOW(), ?)`
+
+const insertAttemptQuestion = `
+INSERT INTO ol_m_module_attempt_question (ol_m_module_attempt_id, ol_slide_id, active, created_at, updated_at, sequence, option_order)
+VALUES (?, ?, 1, NOW(), NOW(), ?, ?)`
+
+const insertAttemptOption = `
+INSERT INTO ol_m_module_attempt_option (ol_m_module_attempt_question_id, ol_option_id, created_at) VALUES (?, ?, NOW())`
+
+const scoreAttemptQuestion = `
+UPDATE ol_m_module_attempt_question SET score = ?, max_score = ?, correct = ?, updated_at = NOW() WHERE id = ?`
+
+// the condition on submitted_at ensures an attempt is only scored once
+const submitAttempt = `
+UPDATE ol_m_module_attempt SET submitted_at = NOW(), updated_at = NOW(), score = ?, max_score = ?, passed = ?
+WHERE id = ? AND submitted_at IS NULL`
+
+// item statistics are from submitted attempts only
+const selectItemStats = `
+SELECT
+  s.id,
+  s.sequence,
+  COALESCE(s.question_type, ''),
+  s.summary,
+  COUNT(a.id),
+  COALESCE(SUM(IF(a.id IS NULL, 0, aq.correct)), 0),
+  COALESCE(SUM(IF(a.id IS NULL, 0, aq.score)), 0),
+  COALESCE(SUM(IF(a.id IS NULL, 0, aq.max_score)), 0)
+FROM ol_slide s
+  LEFT JOIN ol_m_module_attempt_question aq ON aq.ol_slide_id = s.id AND aq.active = 1
+  LEFT JOIN ol_m_module_attempt a ON aq.ol_m_module_attempt_id = a.id AND a.active = 1 AND a.submitted_at IS NOT NULL
+WHERE s.active = 1 AND s.type = 'question' AND s.ol_module_id = ?
+GROUP BY s.id, s.sequence, s.question_type, s.summary
+ORDER BY s.sequence ASC, s.id ASC`
+
+const selectOptionStats = `
+SELECT o.id, o.ol_slide_id, o.option, o.positive_value, COUNT(ao.id)
+FROM ol_option o
+  INNER JOIN ol_slide s ON o.ol_slide_id = s.id
+  LEFT JOIN ol_m_module_attempt_option ao ON ao.ol_option_id = o.id
+WHERE o.active = 1 AND s.active = 1 AND s.type = 'question' AND s.ol_module_id = ?
+GROUP BY o.id, o.ol_slide_id, o.option, o.positive_value, o.sequence
+ORDER BY o.sequence ASC, o.id ASC`
+
+// modules are created unpublished, as the first revision
+const insertModule = `
+INSERT INTO ol_module (ol_module_id_original, active, current, revision, created_at, updated_at, published_at,
+  name, description, objective, instruction, estimated_total_mins, published)
+VALUES (0, 1, 1, 1, NOW(), NOW(), NOW(), ?, ?, ?, ?, ?, 0)`
+
+const updateModuleOriginal = `UPDATE ol_module SET ol_module_id_original = id WHERE id = ?`
+
+const updateModule = `
+UPDATE ol_module
+SET name = ?, description = ?, objective = ?, instruction = ?, estimated_total_mins = ?, updated_at = NOW()
+WHERE active = 1 AND id = ?`
+
+const publishModule = `
+UPDATE ol_module SET published = 1, published_at = NOW(), updated_at = NOW() WHERE active = 1 AND id = ?`
+
+const unpublishModule = `UPDATE ol_module SET published = 0, updated_at = NOW() WHERE active = 1 AND id = ?`
+
+const selectSection = `
+SELECT id, ol_module_id, sequence, type, summary, COALESCE(content, '')
+FROM ol_slide
+WHERE active = 1 AND type = 'info' AND id = ?`
+
+const insertSection = `
+INSERT INTO ol_slide (ol_module_id, active, created_at, updated_at, sequence, type, summary, content)
+VALUES (?, 1, NOW(), NOW(), ?, 'info', ?, ?)`
+
+const updateSection = `
+UPDATE ol_slide SET sequence = ?, summary = ?, content = ?, updated_at = NOW() WHERE id = ? AND type = 'info'`
+
+const updateSectionSequence = `UPDATE ol_slide SET sequence = ?, updated_at = NOW() WHERE id = ?`
+
+const deleteSection = `UPDATE ol_slide SET active = 0, updated_at = NOW() WHERE id = ? AND type = 'info'`
+
+const selectResources = `
+SELECT r.id, mr.sequence, r.name, r.description, r.resource_url, COALESCE(r.short_url, '')
+FROM ol_module_resource mr
+  INNER JOIN ol_resource r ON mr.ol_resource_id = r.id AND r.active = 1
+WHERE mr.active = 1 AND mr.ol_module_id = ?
+ORDER BY mr.sequence ASC, mr.id ASC`
+
+const countResource = `SELECT COUNT(*) FROM ol_resource WHERE active = 1 AND id = ?`
+
+const deleteResources = `UPDATE ol_module_resource SET active = 0, updated_at = NOW() WHERE ol_module_id = ?`
+
+// a resource that was previously removed from the module is restored
+const upsertResource = `
+INSERT INTO ol_module_resource (ol_module_id, ol_resource_id, active, created_at, updated_at, sequence)
+VALUES (?, ?, 1, NOW(), NOW(), ?)
+ON DUPLICATE KEY UPDATE active = 1, sequence = VALUES(sequence), updated_at = NOW()`
@@ -0,0 +1,776 @@
+package module
+
+import (
+	"database/sql"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Question types
+const (
+	QuestionSingle   = "single"
+	QuestionMultiple = "multiple"
+)
+
+// Quiz error messages
+const (
+	ErrorQuizSettings     = "Quiz.PassPercentage must be 0 to 100, and the other settings cannot be negative"
+	ErrorQuestionIDNotNil = "cannot insert a question because Question.ID already has a value"
+	ErrorQuestionNoID     = "cannot update a question because Question.ID is not set"
+	ErrorQuestionText     = "Question.Question is empty"
+	ErrorQuestionType     = "Question.Type must be single or multiple"
+	ErrorQuestionOptions  = "Question.Options must have at least two options, each with text"
+	ErrorQuestionCorrect  = "a single choice question must have exactly one correct option, and a multiple choice question at least one"
+	ErrorNoQuiz           = "module does not have a quiz"
+	ErrorNoAttempt        = "the quiz must be attempted before the module can be finished"
+	ErrorPassed           = "the quiz has already been passed"
+	ErrorAttemptLimit     = "no more attempts are allowed for this module"
+	ErrorSubmitted        = "attempt has already been submitted"
+	ErrorAnswerQuestion   = "answer is for a question that is not part of the attempt"
+	ErrorAnswerOption     = "answer includes an option that is not part of the question"
+	ErrorAnswerSingle     = "only one option can be selected for a single choice question"
+)
+
+// Quiz holds the assessment settings for a module. The questions are the question slides of the module,
+// and each attempt is a random selection of QuestionCount questions, or all of them if it is 0. An attempt
+// passes if it scores at least PassPercentage, and any submitted attempt passes if PassPercentage is 0.
+// A member can make AttemptLimit attempts every AttemptLimitResetDays, with 0 meaning no limit, and
+// no reset, respectively.
+type Quiz struct {
+	ModuleID              int        `json:"moduleId" bson:"moduleId"`
+	PassPercentage        int        `json:"passPercentage" bson:"passPercentage"`
+	QuestionCount         int        `json:"questionCount" bson:"questionCount"`
+	AttemptLimit          int        `json:"attemptLimit" bson:"attemptLimit"`
+	AttemptLimitResetDays int        `json:"attemptLimitResetDays" bson:"attemptLimitResetDays"`
+	Questions             []Question `json:"questions" bson:"questions"`
+}
+
+// Question is a single or multiple choice question in the question bank for a module
+type Question struct {
+	ID       int      `json:"id" bson:"id"`
+	ModuleID int      `json:"moduleId" bson:"moduleId"`
+	Sequence int      `json:"sequence" bson:"sequence"`
+	Type     string   `json:"type" bson:"type"`
+	Question string   `json:"question" bson:"question"`
+	Content  string   `json:"content" bson:"content"`
+	Options  []Option `json:"options" bson:"options"`
+}
+
+// Option is a possible answer to a question. Selecting a correct option adds its Value to the score
+// for the question. Penalty is deducted for selecting an incorrect option, or for not selecting a
+// correct one. The score for a question is never less than 0.
+type Option struct {
+	ID          int    `json:"id" bson:"id"`
+	QuestionID  int    `json:"questionId" bson:"questionId"`
+	Sequence    int    `json:"sequence" bson:"sequence"`
+	Text        string `json:"text" bson:"text"`
+	Explanation string `json:"explanation,omitempty" bson:"explanation,omitempty"`
+	Correct     bool   `json:"correct,omitempty" bson:"correct,omitempty"`
+	Value       int    `json:"value,omitempty" bson:"value,omitempty"`
+	Penalty     int    `json:"penalty,omitempty" bson:"penalty,omitempty"`
+}
+
+// Attempt is a member's attempt at the quiz for a module, during an Enrolment. The answers, scores and
+// the correct options are only included once the attempt has been submitted.
+type Attempt struct {
+	ID             int               `json:"id" bson:"id"`
+	EnrolmentID    int               `json:"enrolmentId" bson:"enrolmentId"`
+	ModuleID       int               `json:"moduleId" bson:"moduleId"`
+	MemberID       int               `json:"memberId" bson:"memberId"`
+	DateStarted    string            `json:"dateStarted" bson:"dateStarted"`
+	DateSubmitted  string            `json:"dateSubmitted" bson:"dateSubmitted"`
+	Score          int               `json:"score" bson:"score"`
+	MaxScore       int               `json:"maxScore" bson:"maxScore"`
+	Percentage     float64           `json:"percentage" bson:"percentage"`
+	PassPercentage int               `json:"passPercentage" bson:"passPercentage"`
+	Passed         bool              `json:"passed" bson:"passed"`
+	Questions      []AttemptQuestion `json:"questions,omitempty" bson:"questions,omitempty"`
+}
+
+// AttemptQuestion is a question drawn for an attempt, with the options in the order they are shown
+type AttemptQuestion struct {
+	Question
+	Selected []int `json:"selected" bson:"selected"`
+	Score    int   `json:"score" bson:"score"`
+	MaxScore int   `json:"maxScore" bson:"maxScore"`
+	Correct  bool  `json:"correct" bson:"correct"`
+
+	// attemptQuestionID identifies the question within the attempt
+	attemptQuestionID int
+}
+
+// Answer is the options selected for a question in an attempt
+type Answer struct {
+	QuestionID int   `json:"questionId"`
+	OptionIDs  []int `json:"optionIds"`
+}
+
+// ItemStats describes how a question has performed in submitted attempts. Difficulty is the proportion
+// of attempts that answered the question correctly, so a lower value is a harder question.
+type ItemStats struct {
+	QuestionID int           `json:"questionId"`
+	Sequence   int           `json:"sequence"`
+	Type       string        `json:"type"`
+	Question   string        `json:"question"`
+	Attempts   int           `json:"attempts"`
+	Correct    int           `json:"correct"`
+	Difficulty float64       `json:"difficulty"`
+	MeanScore  float64       `json:"meanScore"`
+	Options    []OptionStats `json:"options"`
+}
+
+// OptionStats is the number of times an option was selected, and the proportion of attempts at the
+// question that selected it
+type OptionStats struct {
+	OptionID int     `json:"optionId"`
+	Text     string  `json:"text"`
+	Correct  bool    `json:"correct"`
+	Selected int     `json:"selected"`
+	Rate     float64 `json:"rate"`
+}
+
+// Finished is true once the attempt has been submitted and scored
+func (a Attempt) Finished() bool {
+	return a.DateSubmitted != ""
+}
+
+// QuizByModuleID fetches the quiz settings and question bank for a module
+func QuizByModuleID(ds datastore.Datastore, moduleID int) (Quiz, error) {
+	q := Quiz{}
+	err := ds.MySQL.Session.QueryRow(queries["select-quiz"], moduleID).Scan(
+		&q.ModuleID,
+		&q.PassPercentage,
+		&q.QuestionCount,
+		&q.AttemptLimit,
+		&q.AttemptLimitResetDays,
+	)
+	if err != nil {
+		return q, err
+	}
+	q.Questions, err = Questions(ds, moduleID)
+	return q, err
+}
+
+// Update saves the quiz settings. The questions are not changed.
+func (q *Quiz) Update(ds datastore.Datastore) error {
+	if q.PassPercentage < 0 || q.PassPercentage > 100 || q.QuestionCount < 0 || q.AttemptLimit < 0 ||
+		q.AttemptLimitResetDays < 0 {
+		return errors.New(ErrorQuizSettings)
+	}
+	pass := sql.NullInt64{Int64: int64(q.PassPercentage), Valid: q.PassPercentage > 0}
+	_, err := ds.MySQL.Session.Exec(queries["update-quiz"], pass, q.QuestionCount, q.AttemptLimit,
+		q.AttemptLimitResetDays, q.ModuleID)
+	return err
+}
+
+// Questions fetches the question bank for a module, in order
+func Questions(ds datastore.Datastore, moduleID int) ([]Question, error) {
+	q := queries["select-questions"] + " AND ol_module_id = ? ORDER BY sequence ASC, id ASC"
+	xq, err := executeQuestion(ds, q, moduleID)
+	if err != nil {
+		return xq, err
+	}
+	xo, err := executeOption(ds, queries["select-options"]+" AND s.ol_module_id = ? ORDER BY o.sequence ASC, o.id ASC", moduleID)
+	if err != nil {
+		return xq, err
+	}
+	for i := range xq {
+		for _, o := range xo {
+			if o.QuestionID == xq[i].ID {
+				xq[i].Options = append(xq[i].Options, o)
+			}
+		}
+	}
+	return xq, nil
+}
+
+// QuestionByID fetches a question, with its options
+func QuestionByID(ds datastore.Datastore, id int) (Question, error) {
+	xq, err := executeQuestion(ds, queries["select-questions"]+" AND id = ?", id)
+	if err != nil {
+		return Question{}, err
+	}
+	if len(xq) == 0 {
+		return Question{}, sql.ErrNoRows
+	}
+	q := xq[0]
+	q.Options, err = executeOption(ds, queries["select-options"]+" AND s.id = ? ORDER BY o.sequence ASC, o.id ASC", id)
+	return q, err
+}
+
+// InsertRow adds a question, and its options, to the question bank for Question.ModuleID
+func (q *Question) InsertRow(ds datastore.Datastore) error {
+	if q.ID > 0 {
+		return errors.New(ErrorQuestionIDNotNil)
+	}
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(queries["insert-question"], q.ModuleID, q.Sequence, q.Question, q.Content, q.Type)
+	if err != nil {
+		return errors.Wrap(err, "insert question")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := q.insertOptions(tx, int(id)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	q.ID = int(id)
+	return nil
+}
+
+// Update saves a question and replaces its options. The previous options are retained for the
+// attempts that have already been made.
+func (q *Question) Update(ds datastore.Datastore) error {
+	if q.ID == 0 {
+		return errors.New(ErrorQuestionNoID)
+	}
+	if err := q.validate(); err != nil {
+		return err
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(queries["update-question"], q.Sequence, q.Question, q.Content, q.Type, q.ID); err != nil {
+		return errors.Wrap(err, "update question")
+	}
+	if _, err := tx.Exec(queries["delete-options"], q.ID); err != nil {
+		return errors.Wrap(err, "delete options")
+	}
+	if err := q.insertOptions(tx, q.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a question from the question bank
+func (q *Question) Delete(ds datastore.Datastore) error {
+	if q.ID == 0 {
+		return errors.New(ErrorQuestionNoID)
+	}
+	_, err := ds.MySQL.Session.Exec(queries["delete-question"], q.ID)
+	return err
+}
+
+// insertOptions adds the options for question id, in order
+func (q *Question) insertOptions(tx *sql.Tx, id int) error {
+	for i, o := range q.Options {
+		var explanation sql.NullString
+		if o.Explanation != "" {
+			explanation = sql.NullString{String: o.Explanation, Valid: true}
+		}
+		_, err := tx.Exec(queries["insert-option"], id, i+1, o.Value, o.Penalty, o.Text, explanation)
+		if err != nil {
+			return errors.Wrap(err, "insert option")
+		}
+	}
+	return nil
+}
+
+// validate checks the question and options. An option marked as Correct without a Value is given a
+// Value of 1, and an option with a Value is correct.
+func (q *Question) validate() error {
+	if strings.TrimSpace(q.Question) == "" {
+		return errors.New(ErrorQuestionText)
+	}
+	if q.Type != QuestionSingle && q.Type != QuestionMultiple {
+		return errors.New(ErrorQuestionType)
+	}
+	if len(q.Options) < 2 {
+		return errors.New(ErrorQuestionOptions)
+	}
+	var correct int
+	for i := range q.Options {
+		o := &q.Options[i]
+		if strings.TrimSpace(o.Text) == "" || o.Value < 0 || o.Penalty < 0 {
+			return errors.New(ErrorQuestionOptions)
+		}
+		if o.Correct && o.Value == 0 {
+			o.Value = 1
+		}
+		o.Correct = o.Value > 0
+		if o.Correct {
+			correct++
+		}
+	}
+	if correct == 0 || (q.Type == QuestionSingle && correct > 1) {
+		return errors.New(ErrorQuestionCorrect)
+	}
+	return nil
+}
+
+// Mark returns the score for the selected options, the highest possible score, and whether the
+// selection was exactly the correct options
+func (q Question) Mark(selected []int) (score, max int, correct bool) {
+	chosen := map[int]bool{}
+	for _, id := range selected {
+		chosen[id] = true
+	}
+	correct = true
+	for _, o := range q.Options {
+		max += o.Value
+		switch {
+		case o.Value > 0 && chosen[o.ID]:
+			score += o.Value
+		case o.Value > 0:
+			score -= o.Penalty
+			correct = false
+		case chosen[o.ID]:
+			score -= o.Penalty
+			correct = false
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score, max, correct
+}
+
+// StartAttempt starts an attempt at the module quiz, or returns the attempt in progress. The questions
+// are drawn at random from the question bank and the options are shuffled.
+func (e Enrolment) StartAttempt(ds datastore.Datastore) (Attempt, error) {
+
+	if e.Finished() {
+		return Attempt{}, errors.New(ErrorFinished)
+	}
+	if e.Passed {
+		return Attempt{}, errors.New(ErrorPassed)
+	}
+	quiz, err := QuizByModuleID(ds, e.ModuleID)
+	if err == sql.ErrNoRows {
+		return Attempt{}, errors.New(ErrorNoModule)
+	}
+	if err != nil {
+		return Attempt{}, err
+	}
+	if len(quiz.Questions) == 0 {
+		return Attempt{}, errors.New(ErrorNoQuiz)
+	}
+
+	q := queries["select-attempt"] + " AND a.ol_m_module_id = ? AND a.submitted_at IS NULL ORDER BY a.id DESC LIMIT 1"
+	xa, err := executeAttempt(ds, q, e.ID)
+	if err != nil {
+		return Attempt{}, err
+	}
+	if len(xa) > 0 {
+		return AttemptByID(ds, xa[0].ID)
+	}
+
+	if quiz.AttemptLimit > 0 {
+		var n int
+		days := quiz.AttemptLimitResetDays
+		err := ds.MySQL.Session.QueryRow(queries["count-attempts"], e.MemberID, e.ModuleID, days, days).Scan(&n)
+		if err != nil {
+			return Attempt{}, errors.Wrap(err, "count attempts")
+		}
+		if n >= quiz.AttemptLimit {
+			return Attempt{}, errors.New(ErrorAttemptLimit)
+		}
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return Attempt{}, err
+	}
+	defer tx.Rollback()
+
+	pass := sql.NullInt64{Int64: int64(quiz.PassPercentage), Valid: quiz.PassPercentage > 0}
+	res, err := tx.Exec(queries["insert-attempt"], e.ID, pass)
+	if err != nil {
+		return Attempt{}, errors.Wrap(err, "insert attempt")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i, question := range draw(r, quiz.Questions, quiz.QuestionCount) {
+		xs := make([]string, len(question.Options))
+		for j, o := range question.Options {
+			xs[j] = strconv.Itoa(o.ID)
+		}
+		_, err := tx.Exec(queries["insert-attempt-question"], id, question.ID, i+1, strings.Join(xs, ","))
+		if err != nil {
+			return Attempt{}, errors.Wrap(err, "insert attempt question")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Attempt{}, err
+	}
+	return AttemptByID(ds, int(id))
+}
+
+// draw returns n questions selected at random, or all of the questions if n is 0, with the options of
+// each question shuffled
+func draw(r *rand.Rand, bank []Question, n int) []Question {
+	if n <= 0 || n > len(bank) {
+		n = len(bank)
+	}
+	xq := make([]Question, len(bank))
+	copy(xq, bank)
+	r.Shuffle(len(xq), func(i, j int) { xq[i], xq[j] = xq[j], xq[i] })
+	xq = xq[:n]
+	for i := range xq {
+		xo := make([]Option, len(xq[i].Options))
+		copy(xo, xq[i].Options)
+		r.Shuffle(len(xo), func(i, j int) { xo[i], xo[j] = xo[j], xo[i] })
+		xq[i].Options = xo
+	}
+	return xq
+}
+
+// Attempts fetches the attempts made during an enrolment, without the questions
+func Attempts(ds datastore.Datastore, enrolmentID int) ([]Attempt, error) {
+	q := queries["select-attempt"] + " AND a.ol_m_module_id = ? ORDER BY a.id ASC"
+	return executeAttempt(ds, q, enrolmentID)
+}
+
+// AttemptByID fetches an attempt, with its questions. The answers and correct options are only
+// included if the attempt has been submitted.
+func AttemptByID(ds datastore.Datastore, id int) (Attempt, error) {
+
+	xa, err := executeAttempt(ds, queries["select-attempt"]+" AND a.id = ?", id)
+	if err != nil {
+		return Attempt{}, err
+	}
+	if len(xa) == 0 {
+		return Attempt{}, sql.ErrNoRows
+	}
+	a := xa[0]
+
+	a.Questions, err = attemptQuestions(ds, a.ID)
+	if err != nil {
+		return a, err
+	}
+	if a.Finished() {
+		return a, nil
+	}
+	for i := range a.Questions {
+		for j := range a.Questions[i].Options {
+			o := &a.Questions[i].Options[j]
+			o.Explanation, o.Correct, o.Value, o.Penalty = "", false, 0, 0
+		}
+	}
+	return a, nil
+}
+
+// attemptQuestions fetches the questions drawn for an attempt, with their options in the order shown,
+// and the options selected
+func attemptQuestions(ds datastore.Datastore, attemptID int) ([]AttemptQuestion, error) {
+
+	var xq []AttemptQuestion
+	order := map[int][]int{}
+
+	rows, err := ds.MySQL.Session.Query(queries["select-attempt-question"], attemptID)
+	if err != nil {
+		return xq, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var options string
+		q := AttemptQuestion{}
+		err := rows.Scan(&q.attemptQuestionID, &q.ID, &q.ModuleID, &q.Sequence, &q.Type, &q.Question.Question, &q.Content,
+			&options, &q.Score, &q.MaxScore, &q.Correct)
+		if err != nil {
+			return xq, errors.Wrap(err, "failed to scan attempt question row")
+		}
+		for _, s := range strings.Split(options, ",") {
+			n, _ := strconv.Atoi(s)
+			order[q.ID] = append(order[q.ID], n)
+		}
+		q.Selected = []int{}
+		xq = append(xq, q)
+	}
+	if err := rows.Err(); err != nil {
+		return xq, err
+	}
+
+	xo, err := executeOption(ds, queries["select-attempt-options"], attemptID)
+	if err != nil {
+		return xq, err
+	}
+	for i := range xq {
+		for _, id := range order[xq[i].ID] {
+			for _, o := range xo {
+				if o.ID == id {
+					xq[i].Options = append(xq[i].Options, o)
+				}
+			}
+		}
+	}
+
+	answers, err := ds.MySQL.Session.Query(queries["select-attempt-answers"], attemptID)
+	if err != nil {
+		return xq, err
+	}
+	defer answers.Close()
+	for answers.Next() {
+		var questionID, optionID int
+		if err := answers.Scan(&questionID, &optionID); err != nil {
+			return xq, errors.Wrap(err, "failed to scan answer row")
+		}
+		for i := range xq {
+			if xq[i].ID == questionID {
+				xq[i].Selected = append(xq[i].Selected, optionID)
+			}
+		}
+	}
+
+	return xq, answers.Err()
+}
+
+// Submit scores the answers for an attempt. Questions without an answer are scored as if no options
+// were selected.
+func (a *Attempt) Submit(ds datastore.Datastore, answers []Answer) error {
+
+	if a.Finished() {
+		return errors.New(ErrorSubmitted)
+	}
+
+	// fetch the questions again as the scoring values are not included in an attempt in progress
+	xq, err := attemptQuestions(ds, a.ID)
+	if err != nil {
+		return err
+	}
+	selected := map[int][]int{}
+	for _, ans := range answers {
+		var q *AttemptQuestion
+		for i := range xq {
+			if xq[i].ID == ans.QuestionID {
+				q = &xq[i]
+			}
+		}
+		if q == nil {
+			return errors.New(ErrorAnswerQuestion)
+		}
+		for _, id := range ans.OptionIDs {
+			var ok bool
+			for _, o := range q.Options {
+				ok = ok || o.ID == id
+			}
+			if !ok {
+				return errors.New(ErrorAnswerOption)
+			}
+		}
+		if q.Type == QuestionSingle && len(ans.OptionIDs) > 1 {
+			return errors.New(ErrorAnswerSingle)
+		}
+		selected[q.ID] = ans.OptionIDs
+	}
+
+	tx, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var score, max int
+	for _, q := range xq {
+		s, m, correct := q.Mark(selected[q.ID])
+		score += s
+		max += m
+		if _, err := tx.Exec(queries["score-attempt-question"], s, m, correct, q.attemptQuestionID); err != nil {
+			return errors.Wrap(err, "score attempt question")
+		}
+		for _, optionID := range selected[q.ID] {
+			if _, err := tx.Exec(queries["insert-attempt-option"], q.attemptQuestionID, optionID); err != nil {
+				return errors.Wrap(err, "insert answer")
+			}
+		}
+	}
+
+	passed := a.PassPercentage == 0 || percentage(score, max) >= float64(a.PassPercentage)
+	res, err := tx.Exec(queries["submit-attempt"], score, max, passed, a.ID)
+	if err != nil {
+		return errors.Wrap(err, "submit attempt")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errors.New(ErrorSubmitted)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	*a, err = AttemptByID(ds, a.ID)
+	return err
+}
+
+// percentage is score as a percentage of max, to 2 decimal places. A quiz with nothing to score is 100%.
+func percentage(score, max int) float64 {
+	if max == 0 {
+		return 100
+	}
+	return math.Round(float64(score)/float64(max)*10000) / 100
+}
+
+// ItemDifficulty reports on the questions in the question bank for a module, from the submitted attempts.
+// The questions are ordered by difficulty, hardest first.
+func ItemDifficulty(ds datastore.Datastore, moduleID int) ([]ItemStats, error) {
+
+	var xs []ItemStats
+
+	rows, err := ds.MySQL.Session.Query(queries["select-item-stats"], moduleID)
+	if err != nil {
+		return xs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s := ItemStats{Options: []OptionStats{}}
+		var score, max int
+		err := rows.Scan(&s.QuestionID, &s.Sequence, &s.Type, &s.Question, &s.Attempts, &s.Correct, &score, &max)
+		if err != nil {
+			return xs, errors.Wrap(err, "failed to scan item stats row")
+		}
+		if s.Attempts > 0 {
+			s.Difficulty = math.Round(float64(s.Correct)/float64(s.Attempts)*100) / 100
+			s.MeanScore = math.Round(percentage(score, max)) / 100
+		}
+		xs = append(xs, s)
+	}
+	if err := rows.Err(); err != nil {
+		return xs, err
+	}
+
+	options, err := ds.MySQL.Session.Query(queries["select-option-stats"], moduleID)
+	if err != nil {
+		return xs, err
+	}
+	defer options.Close()
+	for options.Next() {
+		var questionID, value int
+		o := OptionStats{}
+		if err := options.Scan(&o.OptionID, &questionID, &o.Text, &value, &o.Selected); err != nil {
+			return xs, errors.Wrap(err, "failed to scan option stats row")
+		}
+		o.Correct = value > 0
+		for i := range xs {
+			if xs[i].QuestionID == questionID {
+				if xs[i].Attempts > 0 {
+					o.Rate = math.Round(float64(o.Selected)/float64(xs[i].Attempts)*100) / 100
+				}
+				xs[i].Options = append(xs[i].Options, o)
+			}
+		}
+	}
+	if err := options.Err(); err != nil {
+		return xs, err
+	}
+
+	// questions that have not been attempted go last
+	sort.SliceStable(xs, func(i, j int) bool {
+		if (xs[i].Attempts == 0) != (xs[j].Attempts == 0) {
+			return xs[j].Attempts == 0
+		}
+		return xs[i].Difficulty < xs[j].Difficulty
+	})
+	return xs, nil
+}
+
+// executeQuestion runs a question query and scans the results into a []Question, without options
+func executeQuestion(ds datastore.Datastore, query string, args ...interface{}) ([]Question, error) {
+
+	var xq []Question
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xq, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		q := Question{}
+		if err := rows.Scan(&q.ID, &q.ModuleID, &q.Sequence, &q.Type, &q.Question, &q.Content); err != nil {
+			return xq, errors.Wrap(err, "failed to scan question row")
+		}
+		xq = append(xq, q)
+	}
+
+	return xq, rows.Err()
+}
+
+// executeOption runs an option query and scans the results into an []Option
+func executeOption(ds datastore.Datastore, query string, args ...interface{}) ([]Option, error) {
+
+	var xo []Option
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xo, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o := Option{}
+		err := rows.Scan(&o.ID, &o.QuestionID, &o.Sequence, &o.Text, &o.Explanation, &o.Value, &o.Penalty)
+		if err != nil {
+			return xo, errors.Wrap(err, "failed to scan option row")
+		}
+		o.Correct = o.Value > 0
+		xo = append(xo, o)
+	}
+
+	return xo, rows.Err()
+}
+
+// executeAttempt runs an attempt query and scans the results into an []Attempt, without questions
+func executeAttempt(ds datastore.Datastore, query string, args ...interface{}) ([]Attempt, error) {
+
+	var xa []Attempt
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xa, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := Attempt{}
+		err := rows.Scan(
+			&a.ID,
+			&a.EnrolmentID,
+			&a.ModuleID,
+			&a.MemberID,
+			&a.DateStarted,
+			&a.DateSubmitted,
+			&a.Score,
+			&a.MaxScore,
+			&a.PassPercentage,
+			&a.Passed,
+		)
+		if err != nil {
+			return xa, errors.Wrap(err, "failed to scan attempt row")
+		}
+		if a.Finished() {
+			a.Percentage = percentage(a.Score, a.MaxScore)
+		}
+		xa = append(xa, a)
+	}
+
+	return xa, rows.Err()
+}
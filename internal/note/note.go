@@ -4,7 +4,10 @@ package note
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/cardiacsociety/web-services/internal/attachments"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
 )
 
@@ -17,6 +20,8 @@ const (
 	ErrorAssociation       = "association entity not specified"
 	ErrorAssociationID     = "association entity ID not specified"
 	ErrorAssociationEntity = "association entity invalid"
+	ErrorNoID              = "cannot update a note because Note.ID is not set"
+	ErrorTypeID            = "note type id is not valid"
 )
 
 // Note represents a record of a comment, document or anything else. A Note is always linked to a member
@@ -35,11 +40,33 @@ type Note struct {
 	Attachments   []Attachment `json:"attachments" bson:"attachments"`
 }
 
-// Attachment is a file linked to a note
+// Type is a category of note, eg General, Call, Email
+type Type struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	System      bool   `json:"system"`
+}
+
+// Filter specifies the criteria for searching notes. Text is split into terms and each term
+// must be found in either the note content or the name of one of its attachments. From and To
+// are inclusive dates (YYYY-MM-DD) compared with the note's effective date. Zero values are ignored.
+type Filter struct {
+	Text     string
+	MemberID int
+	TypeID   int
+	From     string
+	To       string
+}
+
+// Attachment is a file linked to a note. Files are not publicly accessible so URL is a short-lived
+// signed URL, generated each time the note is fetched.
 type Attachment struct {
-	ID   int    `json:"id" bson:"id"`
-	Name string `json:"name" bson:"name"`
-	URL  string `json:"url" bson:"url"`
+	ID     int    `json:"id" bson:"id"`
+	Name   string `json:"name" bson:"name"`
+	URL    string `json:"url" bson:"url"`
+	Volume string `json:"-" bson:"-"`
+	Key    string `json:"-" bson:"-"`
 }
 
 // InsertRow creates a new note row with fields from Note
@@ -47,14 +74,23 @@ func (n *Note) InsertRow(ds datastore.Datastore) error {
 	switch {
 	case n.ID > 0:
 		return errors.New(ErrorIDNotNil)
-	case n.MemberID == 0:
+	case n.MemberID == 0 && n.Association != "issue":
+		// a note on a global issue is the only note without a member
 		return errors.New(ErrorNoMemberID)
 	case n.TypeID == 0:
 		return errors.New(ErrorNoTypeID)
 	case n.Content == "":
 		return errors.New(ErrorNoContent)
 	}
-	res, err := ds.MySQL.Session.Exec(queries["insert-note"], n.TypeID, n.Content)
+	err := n.checkType(ds)
+	if err != nil {
+		return err
+	}
+	err = n.checkAssociationData()
+	if err != nil {
+		return err
+	}
+	res, err := ds.MySQL.Session.Exec(queries["insert-note"], n.TypeID, NullString(n.DateEffective), n.Content)
 	if err != nil {
 		return err
 	}
@@ -66,14 +102,10 @@ func (n *Note) InsertRow(ds datastore.Datastore) error {
 
 	// Notes differ from issues in that they always require an associated
 	// record in wf_note_association. That is, they must always be associated with
-	// at least a member id. The member id has already been checked (above).
-	err = n.checkAssociationData()
-	if err != nil {
-		return err
-	}
+	// at least a member id. The member id and association have already been checked (above).
 	_, err = ds.MySQL.Session.Exec(queries["insert-note-association"],
 		n.ID,
-		n.MemberID,
+		NullInt(n.MemberID),
 		NullInt(n.AssociationID),
 		NullString(n.Association),
 	)
@@ -84,8 +116,8 @@ func (n *Note) InsertRow(ds datastore.Datastore) error {
 	return nil
 }
 
-// checkAssociatioData verifies fields required to associate an issue with other data. 
-// To associate a Note record with another entity requires the entity name as a string, 
+// checkAssociatioData verifies fields required to associate an issue with other data.
+// To associate a Note record with another entity requires the entity name as a string,
 // ie 'application' or 'issue', as well as the id of the record from that entity table.
 func (n *Note) checkAssociationData() error {
 	// no association
@@ -105,6 +137,80 @@ func (n *Note) checkAssociationData() error {
 	return nil
 }
 
+// Update saves the type, content, effective date and association of an existing note. The
+// member to which the note belongs cannot be changed.
+func (n *Note) Update(ds datastore.Datastore) error {
+	switch {
+	case n.ID == 0:
+		return errors.New(ErrorNoID)
+	case n.TypeID == 0:
+		return errors.New(ErrorNoTypeID)
+	case n.Content == "":
+		return errors.New(ErrorNoContent)
+	}
+	err := n.checkType(ds)
+	if err != nil {
+		return err
+	}
+	err = n.checkAssociationData()
+	if err != nil {
+		return err
+	}
+	_, err = ds.MySQL.Session.Exec(queries["update-note"], n.TypeID, NullString(n.DateEffective), n.Content, n.ID)
+	if err != nil {
+		return err
+	}
+	_, err = ds.MySQL.Session.Exec(queries["update-note-association"], NullInt(n.AssociationID), NullString(n.Association), n.ID)
+	return err
+}
+
+// Delete soft-deletes a note and its association
+func (n *Note) Delete(ds datastore.Datastore) error {
+	if n.ID == 0 {
+		return errors.New(ErrorNoID)
+	}
+	_, err := ds.MySQL.Session.Exec(queries["delete-note"], n.ID)
+	if err != nil {
+		return err
+	}
+	_, err = ds.MySQL.Session.Exec(queries["delete-note-association"], n.ID)
+	return err
+}
+
+// checkType verifies that TypeID identifies an active note type
+func (n *Note) checkType(ds datastore.Datastore) error {
+	_, err := TypeByID(ds, n.TypeID)
+	if err == sql.ErrNoRows {
+		return errors.New(ErrorTypeID)
+	}
+	return err
+}
+
+// TypeByID fetches an active note type
+func TypeByID(ds datastore.Datastore, id int) (Type, error) {
+	var t Type
+	err := ds.MySQL.Session.QueryRow(queries["select-note-type-by-id"], id).Scan(&t.ID, &t.Name, &t.Description, &t.System)
+	return t, err
+}
+
+// Types fetches all of the active note types
+func Types(ds datastore.Datastore) ([]Type, error) {
+	var xt []Type
+	rows, err := ds.MySQL.Session.Query(queries["select-note-types"])
+	if err != nil {
+		return xt, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t Type
+		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.System); err != nil {
+			return xt, err
+		}
+		xt = append(xt, t)
+	}
+	return xt, rows.Err()
+}
+
 // NullString allows an empty string value (nil) to be set to NULL in the database
 func NullString(s string) sql.NullString {
 	if len(s) == 0 {
@@ -158,16 +264,69 @@ func ByID(ds datastore.Datastore, id int) (Note, error) {
 		n.AssociationID = int(associationID.Int64)
 	}
 
-	n.Attachments, err = attachments(ds, n.ID)
+	n.Attachments, err = noteAttachments(ds, n.ID)
 
 	return n, err
 }
 
 // ByMemberID fetches all the notes linked to a Member from the specified datastore
 func ByMemberID(ds datastore.Datastore, memberID int) ([]Note, error) {
-	var xn []Note
 	q := queries["select-notes-by-member-id"] + " ORDER BY wn.effective_on DESC"
-	rows, err := ds.MySQL.Session.Query(q, memberID)
+	return execute(ds, q, memberID)
+}
+
+// ByAssociation fetches all the notes associated with a record in another entity,
+// for example, the notes threaded on an issue: ByAssociation(ds, "issue", 3)
+func ByAssociation(ds datastore.Datastore, association string, associationID int) ([]Note, error) {
+	q := queries["select-notes-by-association"] + " ORDER BY wn.effective_on ASC, wn.id ASC"
+	return execute(ds, q, association, associationID)
+}
+
+// Search fetches the notes matching the criteria in Filter, most recent first
+func Search(ds datastore.Datastore, f Filter) ([]Note, error) {
+	where, args := f.clauses()
+	q := queries["select-note"] + where + " ORDER BY wn.effective_on DESC, wn.id DESC"
+	return execute(ds, q, args...)
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// clauses returns the additional WHERE clauses and arguments specified by the Filter
+func (f Filter) clauses() (string, []interface{}) {
+	var xs []string
+	var args []interface{}
+	for _, term := range strings.Fields(f.Text) {
+		like := "%" + likeEscaper.Replace(term) + "%"
+		xs = append(xs, fmt.Sprintf("(wn.note LIKE ? ESCAPE '\\\\' OR wn.id IN (%s))", queries["select-note-ids-by-attachment-name"]))
+		args = append(args, like, like)
+	}
+	if f.MemberID > 0 {
+		xs = append(xs, "m.id = ?")
+		args = append(args, f.MemberID)
+	}
+	if f.TypeID > 0 {
+		xs = append(xs, "wnt.id = ?")
+		args = append(args, f.TypeID)
+	}
+	if f.From != "" {
+		xs = append(xs, "wn.effective_on >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		xs = append(xs, "wn.effective_on <= ?")
+		args = append(args, f.To)
+	}
+	if len(xs) == 0 {
+		return "", args
+	}
+	return " AND " + strings.Join(xs, " AND "), args
+}
+
+// execute runs a note query and scans the results into a []Note
+func execute(ds datastore.Datastore, query string, args ...interface{}) ([]Note, error) {
+	var xn []Note
+	rows, err := ds.MySQL.Session.Query(query, args...)
 	if err != nil {
 		return xn, err
 	}
@@ -205,7 +364,7 @@ func ByMemberID(ds datastore.Datastore, memberID int) ([]Note, error) {
 			n.AssociationID = int(associationID.Int64)
 		}
 
-		n.Attachments, err = attachments(ds, n.ID)
+		n.Attachments, err = noteAttachments(ds, n.ID)
 		if err != nil {
 			return xn, nil
 		}
@@ -214,24 +373,24 @@ func ByMemberID(ds datastore.Datastore, memberID int) ([]Note, error) {
 	return xn, nil
 }
 
-func attachments(ds datastore.Datastore, noteID int) ([]Attachment, error) {
+// noteAttachments fetches the attachments for a note from the attachments package, which finds the key
+// each file is stored at and signs the URL
+func noteAttachments(ds datastore.Datastore, noteID int) ([]Attachment, error) {
 
 	var xa []Attachment
 
-	query := queries["select-attachments"] + " WHERE wa.wf_note_id = ?"
-	rows, err := ds.MySQL.Session.Query(query, noteID)
+	xna, err := attachments.ByEntity(ds, "wf_attachment", noteID)
 	if err != nil {
 		return xa, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		a := Attachment{}
-		err := rows.Scan(&a.ID, &a.Name, &a.URL)
-		if err != nil {
-			return xa, err
-		}
-		xa = append(xa, a)
+	for _, na := range xna {
+		xa = append(xa, Attachment{
+			ID:     na.ID,
+			Name:   na.CleanFilename,
+			URL:    na.URL,
+			Volume: na.FileSet.Volume,
+			Key:    na.Key(),
+		})
 	}
 
 	return xa, nil
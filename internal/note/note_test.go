@@ -1,11 +1,14 @@
 package note_test
 
 import (
+	"database/sql"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/cardiacsociety/web-services/internal/note"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 	"github.com/cardiacsociety/web-services/testdata"
 )
 
@@ -29,6 +32,12 @@ func TestNote(t *testing.T) {
 		t.Run("testInsertRowErrorNoContent", testInsertRowErrorNoContent)
 		t.Run("testInsertRow", testInsertRow)
 		t.Run("testInsertRowAssociation", testInsertRowAssociation)
+		t.Run("testInsertRowErrorTypeID", testInsertRowErrorTypeID)
+		t.Run("testInsertRowErrorAssociationEntity", testInsertRowErrorAssociationEntity)
+		t.Run("testTypes", testTypes)
+		t.Run("testUpdate", testUpdate)
+		t.Run("testDelete", testDelete)
+		t.Run("testSearch", testSearch)
 	})
 }
 
@@ -106,25 +115,35 @@ func testMemberNote(t *testing.T) {
 	}
 }
 
+// attachment urls are signed by the storage backend so check the url up to the signature query. The
+// file may be stored at the legacy key, with the attachment id, or with the clean file name.
 func testNoteFirstAttachmentUrl(t *testing.T) {
 	cases := []struct {
-		arg  int    // note id
-		want string // file url
+		key  string // where the file is stored
+		want string // file url prefix
 	}{
-		{1, "https://cdn.test.com/note/1/1-filename.ext"},
+		{"/note/1/1-filename.ext", "https://files.test.com/test-volume/note/1/1-filename.ext?"},
+		{"/note/1/filename.ext", "https://files.test.com/test-volume/note/1/filename.ext?"},
 	}
 
 	for _, c := range cases {
-		n, err := note.ByID(ds, c.arg)
+		sds := ds
+		st := storage.NewMemory("https://files.test.com", "key")
+		if err := st.Put("test-volume", c.key, strings.NewReader("content"), "text/plain"); err != nil {
+			t.Fatalf("Memory.Put() err = %s", err)
+		}
+		sds.Storage = st
+
+		n, err := note.ByID(sds, 1)
 		if err != nil {
-			t.Errorf("note.ByID(%d) err = %s", c.arg, err)
+			t.Errorf("note.ByID(1) err = %s", err)
 		}
 		var got string
 		if len(n.Attachments) > 0 {
 			got = n.Attachments[0].URL
 		}
-		if got != c.want {
-			t.Errorf("Note.Attachments[0].URL = %s, want %s", got, c.want)
+		if !strings.HasPrefix(got, c.want) {
+			t.Errorf("Note.Attachments[0].URL = %s, want prefix %s", got, c.want)
 		}
 	}
 }
@@ -238,3 +257,124 @@ func testInsertRowAssociation(t *testing.T) {
 		t.Errorf("note.Association = %q, want %q", got, want)
 	}
 }
+
+// test an attempt to insert a note row with a TypeID that does not exist
+func testInsertRowErrorTypeID(t *testing.T) {
+	n := note.Note{
+		TypeID:   123,
+		MemberID: 1,
+		Content:  "This is the note content",
+	}
+	err := n.InsertRow(ds)
+	if err == nil {
+		t.Fatalf("Note.InsertRow() err = nil, want %q", note.ErrorTypeID)
+	}
+	got := err.Error()
+	want := note.ErrorTypeID
+	if got != want {
+		t.Errorf("Note.InsertRow() err = %q, want %q", got, want)
+	}
+}
+
+// test an attempt to insert a note row with an invalid association
+func testInsertRowErrorAssociationEntity(t *testing.T) {
+	n := note.Note{
+		TypeID:        10001,
+		MemberID:      1,
+		Content:       "This is the note content",
+		Association:   "invoice",
+		AssociationID: 1,
+	}
+	err := n.InsertRow(ds)
+	if err == nil {
+		t.Fatalf("Note.InsertRow() err = nil, want %q", note.ErrorAssociationEntity)
+	}
+	got := err.Error()
+	want := note.ErrorAssociationEntity
+	if got != want {
+		t.Errorf("Note.InsertRow() err = %q, want %q", got, want)
+	}
+}
+
+func testTypes(t *testing.T) {
+	xt, err := note.Types(ds)
+	if err != nil {
+		t.Fatalf("note.Types() err = %s", err)
+	}
+	got := len(xt)
+	want := 21
+	if got != want {
+		t.Errorf("note.Types() count = %d, want %d", got, want)
+	}
+}
+
+// test updating the content and type of a note
+func testUpdate(t *testing.T) {
+	n, err := note.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("note.ByID(1) err = %s", err)
+	}
+	n.TypeID = 10003
+	n.Content = "Updated note content"
+	err = n.Update(ds)
+	if err != nil {
+		t.Fatalf("Note.Update() err = %s", err)
+	}
+	n2, err := note.ByID(ds, 1)
+	if err != nil {
+		t.Fatalf("note.ByID(1) err = %s", err)
+	}
+	if n2.Content != n.Content {
+		t.Errorf("Note.Content = %q, want %q", n2.Content, n.Content)
+	}
+	if n2.Type != "Call" {
+		t.Errorf("Note.Type = %q, want %q", n2.Type, "Call")
+	}
+}
+
+// test deleting a note
+func testDelete(t *testing.T) {
+	n := note.Note{
+		TypeID:   10001,
+		MemberID: 1,
+		Content:  "This note will be deleted",
+	}
+	err := n.InsertRow(ds)
+	if err != nil {
+		t.Fatalf("Note.InsertRow() err = %s", err)
+	}
+	err = n.Delete(ds)
+	if err != nil {
+		t.Fatalf("Note.Delete() err = %s", err)
+	}
+	_, err = note.ByID(ds, n.ID)
+	if err != sql.ErrNoRows {
+		t.Errorf("note.ByID(%d) err = %v, want %v", n.ID, err, sql.ErrNoRows)
+	}
+}
+
+// test searching notes by content, attachment name, member, type and date
+func testSearch(t *testing.T) {
+	cases := []struct {
+		arg  note.Filter
+		want int // count
+	}{
+		{note.Filter{Text: "filename"}, 1},
+		{note.Filter{Text: "issue raised", MemberID: 1}, 2},
+		{note.Filter{TypeID: 1, MemberID: 1}, 2},
+		{note.Filter{MemberID: 1, From: "2015-01-01", To: "2015-12-31"}, 3},
+		{note.Filter{Text: "nothingwillmatchthis"}, 0},
+		{note.Filter{Text: "%"}, 0},
+		{note.Filter{Text: "Issue_raised"}, 0},
+	}
+	for _, c := range cases {
+		xn, err := note.Search(ds, c.arg)
+		if err != nil {
+			t.Fatalf("note.Search(%+v) err = %s", c.arg, err)
+		}
+		got := len(xn)
+		if got != c.want {
+			t.Errorf("note.Search(%+v) count = %d, want %d", c.arg, got, c.want)
+		}
+	}
+}
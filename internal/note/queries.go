@@ -2,12 +2,19 @@ package note
 
 // Queries is a map containing common queries for the package
 var queries = map[string]string{
-	"select-note":               selectNote,
-	"select-note-by-id":         selectNoteByID,
-	"select-notes-by-member-id": selectNotesByMemberID,
-	"select-attachments":        selectAttachments,
-	"insert-note":               insertNote,
-	"insert-note-association":   insertNoteAssociation,
+	"select-note":                        selectNote,
+	"select-note-by-id":                  selectNoteByID,
+	"select-notes-by-member-id":          selectNotesByMemberID,
+	"select-notes-by-association":        selectNotesByAssociation,
+	"insert-note":                        insertNote,
+	"insert-note-association":            insertNoteAssociation,
+	"update-note":                        updateNote,
+	"update-note-association":            updateNoteAssociation,
+	"delete-note":                        deleteNote,
+	"delete-note-association":            deleteNoteAssociation,
+	"select-note-type-by-id":             selectNoteTypeByID,
+	"select-note-types":                  selectNoteTypes,
+	"select-note-ids-by-attachment-name": selectNoteIDsByAttachmentName,
 }
 
 const selectNote = `
@@ -17,7 +24,7 @@ SELECT
   wnt.id                        AS TypeID,
   COALESCE(wna.association, '') AS Association,
   wna.association_entity_id     AS AssociationID,
-  m.id                          AS MemberID,
+  COALESCE(m.id, 0)             AS MemberID,
   wn.created_at                 AS CreatedAt,
   wn.updated_at                 AS UpdatedAt,
   wn.effective_on               AS Date,
@@ -32,14 +39,7 @@ const selectNoteByID = selectNote + ` AND wn.id = ?`
 
 const selectNotesByMemberID = selectNote + ` AND m.id = ?`
 
-const selectAttachments = `
-SELECT
-  wa.id AS ID,
-  wa.clean_filename AS FileName,
-  CONCAT(u.base_url, s.set_path, wa.wf_note_id, "/", wa.id, "-", wa.clean_filename) AS FileUrl
-FROM wf_attachment wa
-  LEFT JOIN fs_set s ON wa.fs_set_id = s.id
-  LEFT JOIN fs_url u ON s.id = u.fs_set_id`
+const selectNotesByAssociation = selectNote + ` AND wna.association = ? AND wna.association_entity_id = ?`
 
 const insertNote = `
 INSERT INTO wf_note (
@@ -47,7 +47,7 @@ INSERT INTO wf_note (
 	updated_at, 
 	effective_on, 
 	note 
-) VALUES (?, NOW(), NOW(), ?)`
+) VALUES (?, NOW(), COALESCE(?, NOW()), ?)`
 
 const insertNoteAssociation = `
 INSERT INTO wf_note_association (
@@ -57,3 +57,38 @@ INSERT INTO wf_note_association (
 	updated_at, 
 	association
 ) VALUES (?, ?, ?, NOW(), ?)`
+
+const updateNote = `
+UPDATE wf_note SET 
+	wf_note_type_id = ?, 
+	effective_on = COALESCE(?, effective_on), 
+	note = ?, 
+	updated_at = NOW() 
+WHERE id = ?`
+
+const updateNoteAssociation = `
+UPDATE wf_note_association SET 
+	association_entity_id = ?, 
+	association = ?, 
+	updated_at = NOW() 
+WHERE wf_note_id = ?`
+
+const deleteNote = `UPDATE wf_note SET active = 0, updated_at = NOW() WHERE id = ?`
+
+const deleteNoteAssociation = `UPDATE wf_note_association SET active = 0, updated_at = NOW() WHERE wf_note_id = ?`
+
+const selectNoteType = `
+SELECT
+  id          AS ID,
+  name        AS Name,
+  description AS Description,
+  system      AS System
+FROM wf_note_type
+WHERE active = 1`
+
+const selectNoteTypeByID = selectNoteType + ` AND id = ?`
+
+const selectNoteTypes = selectNoteType + ` ORDER BY name`
+
+const selectNoteIDsByAttachmentName = `
+SELECT wf_note_id FROM wf_attachment WHERE active = 1 AND clean_filename LIKE ? ESCAPE '\\'`
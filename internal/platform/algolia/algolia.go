@@ -0,0 +1,150 @@
+// Package algolia is the Algolia backend for the search indexes. It is kept out of the search package so
+// that services that only use the local backend do not depend on the Algolia client.
+package algolia
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+)
+
+// EnvVars are required for the Algolia backend
+var EnvVars = []string{
+	"MAPPCPD_ALGOLIA_APP_ID",
+	"MAPPCPD_ALGOLIA_API_KEY",
+}
+
+// maxBatchCount is the most objects sent in a single request
+const maxBatchCount = 1000
+
+// ensure Index satisfies the interface
+var _ search.Indexer = (*Index)(nil)
+
+// Index is a search.Indexer for an Algolia application
+type Index struct {
+	client algoliasearch.Client
+}
+
+// New returns a pointer to an Index for the Algolia application, the api key must have write access
+func New(appID, apiKey string) *Index {
+	return &Index{client: algoliasearch.NewClient(appID, apiKey)}
+}
+
+// Save adds the objects to the index, in batches
+func (a *Index) Save(index string, objects []search.Object) error {
+	return populate(a.client.InitIndex(index), objects)
+}
+
+// Rebuild updates an index without interruption to any queries that may be in progress. It makes an empty
+// copy of the original index with the same settings, and then populates the temporary index with fresh data.
+// Once that is done the temporary index is moved to replace the original.
+func (a *Index) Rebuild(index string, objects []search.Object) error {
+
+	tempIndexName := index + "_TEMP_COPY"
+	_, err := a.client.ScopedCopyIndex(index, tempIndexName, []string{"settings", "synonyms"})
+	if err != nil {
+		return errors.New("Could not create temporary index for " + index + "-" + err.Error())
+	}
+	tempIndex := a.client.InitIndex(tempIndexName)
+
+	err = populate(tempIndex, objects)
+	if err != nil {
+		return errors.New("Error populating index -" + err.Error())
+	}
+
+	_, err = a.client.MoveIndex(tempIndexName, index)
+	if err != nil {
+		return errors.New("Error moving temp index to target - " + err.Error())
+	}
+
+	_, err = tempIndex.Delete()
+	if err != nil {
+		return errors.New("Error deleting temp index - " + err.Error())
+	}
+
+	return nil
+}
+
+// Delete removes the objects with top-level fields equal to the filter values. At least one filter is
+// required, so that an index cannot be emptied by mistake.
+func (a *Index) Delete(index string, filters map[string]interface{}) error {
+	if len(filters) == 0 {
+		return errors.New("a filter is required to delete objects")
+	}
+	_, err := a.client.InitIndex(index).DeleteBy(algoliasearch.Map{"filters": filterString(filters)})
+	return err
+}
+
+// Search queries the index. Filters are converted to Algolia filters joined by AND.
+func (a *Index) Search(index string, q search.Query) (search.Result, error) {
+
+	params := algoliasearch.Map{"page": q.Page}
+	if q.HitsPerPage > 0 {
+		params["hitsPerPage"] = q.HitsPerPage
+	}
+	if filters := filterString(q.Filters); filters != "" {
+		params["filters"] = filters
+	}
+
+	res, err := a.client.InitIndex(index).Search(q.Text, params)
+	if err != nil {
+		return search.Result{}, err
+	}
+
+	r := search.Result{
+		Hits:        []search.Object{},
+		NbHits:      res.NbHits,
+		Page:        res.Page,
+		NbPages:     res.NbPages,
+		HitsPerPage: res.HitsPerPage,
+	}
+	for _, h := range res.Hits {
+		r.Hits = append(r.Hits, search.Object(h))
+	}
+
+	return r, nil
+}
+
+// filterString converts filters to an Algolia filter expression. Numbers use a numeric comparison
+// and other values a facet filter.
+func filterString(filters map[string]interface{}) string {
+	var xs []string
+	for k, v := range filters {
+		switch v.(type) {
+		case int, int64, float64:
+			xs = append(xs, fmt.Sprintf("%s=%v", k, v))
+		default:
+			xs = append(xs, fmt.Sprintf("%s:%q", k, fmt.Sprint(v)))
+		}
+	}
+	sort.Strings(xs)
+	return strings.Join(xs, " AND ")
+}
+
+// populate adds the objects to the index in batches
+func populate(index algoliasearch.Index, objects []search.Object) error {
+
+	for start := 0; start < len(objects); start += maxBatchCount {
+		end := start + maxBatchCount
+		if end > len(objects) {
+			end = len(objects)
+		}
+		xo := make([]algoliasearch.Object, 0, end-start)
+		for _, o := range objects[start:end] {
+			xo = append(xo, algoliasearch.Object(o))
+		}
+		batch, err := index.AddObjects(xo)
+		if err != nil {
+			return err
+		}
+		log.Println("Algolia batch TaskID", batch.TaskID, "- count", len(batch.ObjectIDs))
+	}
+
+	return nil
+}
@@ -8,13 +8,20 @@ import (
 	"github.com/pkg/errors"
 
 	cache "github.com/patrickmn/go-cache"
+
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
 )
 
-// Datastore contains connections to the various databases
+// Datastore contains connections to the various databases, and to file storage. Storage is
+// only required by services that handle files, and Search by services that query a local search
+// index, so they are not set by FromEnv().
 type Datastore struct {
 	MySQL   MySQLConnection
 	MongoDB MongoDBConnection
 	Cache   *cache.Cache
+	Storage storage.Storage
+	Search  search.Indexer
 }
 
 // New returns a pointer to a Datastore
@@ -22,7 +29,7 @@ func New() *Datastore {
 
 	// Cache is used to store results of 'background' jobs
 	//c := cache.New(5*time.Minute, 10*time.Minute)
-        c := cache.New(25*time.Minute, 30*time.Minute)
+	c := cache.New(25*time.Minute, 30*time.Minute)
 
 	return &Datastore{
 		Cache: c,
@@ -0,0 +1,327 @@
+/*
+	Package migrate applies ordered schema migrations to the MySQL database.
+
+	Each migration is a pair of files in the migrations directory, named with a version number and a
+	description - 0002_event_registration.up.sql and 0002_event_registration.down.sql. Applied migrations
+	are recorded in the schema_migration table with a checksum of the up file, so a migration that has been
+	changed after it was applied is reported rather than silently skipped.
+
+	MySQL commits schema changes as they are made, so a migration that fails part way is not recorded as
+	applied and the statements that ran before the failure must be reversed by hand.
+*/
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// table records the applied migrations
+const table = "schema_migration"
+
+// baseline is the version of the initial schema. It has no down statements and is never reversed, as that
+// would drop every table.
+const baseline = 1
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migration (
+  version INT NOT NULL COMMENT 'The migration version',
+  name VARCHAR(255) NOT NULL COMMENT 'The migration description, from the file name',
+  checksum CHAR(64) NOT NULL COMMENT 'sha256 of the up file when it was applied',
+  applied_at DATETIME NOT NULL COMMENT 'When the migration was applied',
+  PRIMARY KEY (version))
+  ENGINE = InnoDB
+  COMMENT = 'The schema migrations that have been applied to the database.'`
+
+// fileName matches the migration files, eg 0001_initial_schema.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a change to the schema, and the change that reverses it
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Statements splits the sql into statements, each ending with a semicolon at the end of a line
+func Statements(sql string) []string {
+
+	var xs []string
+	var b strings.Builder
+	for _, l := range strings.Split(sql, "\n") {
+		t := strings.TrimSpace(l)
+		if b.Len() == 0 && (t == "" || strings.HasPrefix(t, "--")) {
+			continue
+		}
+		b.WriteString(l)
+		b.WriteString("\n")
+		if strings.HasSuffix(t, ";") {
+			xs = append(xs, strings.TrimSpace(b.String()))
+			b.Reset()
+		}
+	}
+	if s := strings.TrimSpace(b.String()); s != "" {
+		xs = append(xs, s)
+	}
+	return xs
+}
+
+// Load reads the migrations in dir, in version order. Each version must have an up and a down file, and
+// the versions must start at 1 with no gaps. The down file for the baseline version 1 may be empty.
+func Load(dir string) ([]Migration, error) {
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate.Load()")
+	}
+
+	mm := map[int]*Migration{}
+	for _, f := range files {
+		p := fileName.FindStringSubmatch(f.Name())
+		if p == nil {
+			continue
+		}
+		v, _ := strconv.Atoi(p[1])
+		m, ok := mm[v]
+		if !ok {
+			m = &Migration{Version: v, Name: p[2]}
+			mm[v] = m
+		}
+		if m.Name != p[2] {
+			return nil, fmt.Errorf("migrate.Load() version %d has files named %s and %s", v, m.Name, p[2])
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "migrate.Load()")
+		}
+		if p[3] == "up" {
+			m.Up = string(b)
+			sum := sha256.Sum256(b)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	xm := make([]Migration, 0, len(mm))
+	for _, m := range mm {
+		xm = append(xm, *m)
+	}
+	sort.Slice(xm, func(i, j int) bool { return xm[i].Version < xm[j].Version })
+
+	for i, m := range xm {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migrate.Load() version %d is missing", i+1)
+		}
+		if len(Statements(m.Up)) == 0 || (len(Statements(m.Down)) == 0 && m.Version != baseline) {
+			return nil, fmt.Errorf("migrate.Load() version %d (%s) needs an up and a down file", m.Version, m.Name)
+		}
+	}
+	return xm, nil
+}
+
+// State is a migration and whether it has been applied
+type State struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Changed is set when the up file no longer matches the checksum recorded when it was applied
+	Changed bool
+}
+
+// Status returns the state of each migration, in version order. A version recorded in the database
+// that is not in xm is an error, as the database is newer than the migrations.
+func Status(ds datastore.Datastore, xm []Migration) ([]State, error) {
+
+	applied, err := appliedMigrations(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	xs := make([]State, len(xm))
+	for i, m := range xm {
+		xs[i].Migration = m
+		a, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
+		xs[i].Applied = true
+		xs[i].AppliedAt = a.AppliedAt
+		xs[i].Changed = a.Checksum != m.Checksum
+		delete(applied, m.Version)
+	}
+	for v := range applied {
+		return nil, fmt.Errorf("migrate.Status() version %d is applied but there is no migration file", v)
+	}
+	return xs, nil
+}
+
+// Pending returns the migrations that Up would apply, in order. It is an error if an applied migration
+// has changed, or if a migration has been added below one that is already applied.
+func Pending(ds datastore.Datastore, xm []Migration) ([]Migration, error) {
+
+	xs, err := checkedStatus(ds, xm)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range xs {
+		if !s.Applied {
+			pending = append(pending, s.Migration)
+			continue
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("migrate.Pending() version %d is not applied but %d is", pending[0].Version, s.Version)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order, and returns those that were applied. It stops at the first
+// migration that fails.
+func Up(ds datastore.Datastore, xm []Migration) ([]Migration, error) {
+
+	pending, err := Pending(ds, xm)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ds.MySQL.Session.Exec(createTable); err != nil {
+		return nil, errors.Wrap(err, "migrate.Up() create table")
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		if err := run(ds, m, m.Up); err != nil {
+			return done, errors.Wrap(err, "migrate.Up()")
+		}
+		_, err := ds.MySQL.Session.Exec(`INSERT INTO schema_migration (version, name, checksum, applied_at)
+			VALUES (?, ?, ?, NOW())`, m.Version, m.Name, m.Checksum)
+		if err != nil {
+			return done, errors.Wrapf(err, "migrate.Up() record version %d", m.Version)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Last returns the last n applied migrations, newest first, which are those that Down would reverse. It
+// is an error if they would include the baseline version.
+func Last(ds datastore.Datastore, xm []Migration, n int) ([]Migration, error) {
+
+	xs, err := checkedStatus(ds, xm)
+	if err != nil {
+		return nil, err
+	}
+	return last(xs, n)
+}
+
+// Down reverses the last n applied migrations, newest first, and returns those that were reversed. It
+// will not reverse the baseline version, so nothing is reversed if n reaches it.
+func Down(ds datastore.Datastore, xm []Migration, n int) ([]Migration, error) {
+
+	last, err := Last(ds, xm, n)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range last {
+		if err := run(ds, m, m.Down); err != nil {
+			return done, errors.Wrap(err, "migrate.Down()")
+		}
+		_, err := ds.MySQL.Session.Exec(`DELETE FROM schema_migration WHERE version = ?`, m.Version)
+		if err != nil {
+			return done, errors.Wrapf(err, "migrate.Down() remove version %d", m.Version)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// checkedStatus returns the status, or an error if an applied migration has changed
+func checkedStatus(ds datastore.Datastore, xm []Migration) ([]State, error) {
+
+	xs, err := Status(ds, xm)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range xs {
+		if s.Changed {
+			return nil, fmt.Errorf("version %d (%s) has changed since it was applied", s.Version, s.Name)
+		}
+	}
+	return xs, nil
+}
+
+// last returns the last n applied migrations in xs, newest first, or an error if the baseline is one
+// of them
+func last(xs []State, n int) ([]Migration, error) {
+	var xm []Migration
+	for i := len(xs) - 1; i >= 0 && len(xm) < n; i-- {
+		if !xs[i].Applied {
+			continue
+		}
+		if xs[i].Version == baseline {
+			return nil, fmt.Errorf("migrate.Last() version %d (%s) is the baseline and cannot be reversed", xs[i].Version, xs[i].Name)
+		}
+		xm = append(xm, xs[i].Migration)
+	}
+	return xm, nil
+}
+
+// run executes each statement in the sql
+func run(ds datastore.Datastore, m Migration, sql string) error {
+	for i, s := range Statements(sql) {
+		if _, err := ds.MySQL.Session.Exec(s); err != nil {
+			return errors.Wrapf(err, "version %d (%s) statement %d", m.Version, m.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the rows in the migrations table by version. There are none if the table
+// does not exist yet.
+func appliedMigrations(ds datastore.Datastore) (map[int]State, error) {
+
+	applied := map[int]State{}
+
+	var n int
+	err := ds.MySQL.Session.QueryRow(`SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?`, table).Scan(&n)
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate.appliedMigrations()")
+	}
+	if n == 0 {
+		return applied, nil
+	}
+
+	rows, err := ds.MySQL.Session.Query(`SELECT version, name, checksum, applied_at FROM schema_migration`)
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate.appliedMigrations()")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s State
+		var at string
+		if err := rows.Scan(&s.Version, &s.Name, &s.Checksum, &at); err != nil {
+			return nil, errors.Wrap(err, "migrate.appliedMigrations()")
+		}
+		s.AppliedAt, _ = time.ParseInLocation("2006-01-02 15:04:05", at, time.Local)
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
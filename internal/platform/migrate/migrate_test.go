@@ -0,0 +1,130 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatements(t *testing.T) {
+
+	sql := "-- A comment\n\nCREATE TABLE `a` (\n  `id` INT COMMENT 'x; y')\n  ENGINE = InnoDB;\n\nDROP TABLE `b`;\nSELECT 1"
+	want := []string{
+		"CREATE TABLE `a` (\n  `id` INT COMMENT 'x; y')\n  ENGINE = InnoDB;",
+		"DROP TABLE `b`;",
+		"SELECT 1",
+	}
+	got := Statements(sql)
+	if len(got) != len(want) {
+		t.Fatalf("Statements() count = %d, want %d - %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Statements()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+
+	cases := []struct {
+		files   []string
+		empty   string // a file with no statements
+		wantErr bool
+	}{
+		{[]string{"0002_b.up.sql", "0002_b.down.sql", "0001_a.up.sql", "0001_a.down.sql", "README.md"}, "", false},
+		{[]string{"0001_a.up.sql", "0001_a.down.sql", "0002_b.up.sql", "0002_b.down.sql"}, "0001_a.down.sql", false}, // baseline
+		{[]string{"0001_a.up.sql", "0001_a.down.sql", "0002_b.up.sql", "0002_b.down.sql"}, "0002_b.down.sql", true},  // empty down
+		{[]string{"0001_a.up.sql", "0001_a.down.sql", "0003_c.up.sql", "0003_c.down.sql"}, "", true},                 // gap
+		{[]string{"0001_a.up.sql", "0001_a.down.sql", "0002_b.up.sql"}, "", true},                                    // no down
+		{[]string{"0001_a.up.sql", "0001_b.down.sql"}, "", true},                                                     // names differ
+	}
+
+	for i, c := range cases {
+		dir, err := ioutil.TempDir("", "migrate")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		for _, f := range c.files {
+			sql := "SELECT '" + f + "';\n"
+			if f == c.empty {
+				sql = "-- no statements\n"
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, f), []byte(sql), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		xm, err := Load(dir)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("Load() case %d err = nil, want an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Load() case %d err = %s", i, err)
+		}
+		if len(xm) != 2 || xm[0].Name != "a" || xm[1].Version != 2 {
+			t.Errorf("Load() case %d = %v, want versions 1 and 2 in order", i, xm)
+		}
+		if xm[0].Checksum == "" || xm[0].Checksum == xm[1].Checksum {
+			t.Errorf("Load() case %d checksums = %q and %q, want distinct checksums", i, xm[0].Checksum, xm[1].Checksum)
+		}
+	}
+}
+
+func TestLast(t *testing.T) {
+
+	xs := []State{
+		{Migration: Migration{Version: 1, Name: "a"}, Applied: true},
+		{Migration: Migration{Version: 2, Name: "b"}, Applied: true},
+		{Migration: Migration{Version: 3, Name: "c"}, Applied: true},
+		{Migration: Migration{Version: 4, Name: "d"}},
+	}
+
+	cases := []struct {
+		n       int
+		want    []int // versions
+		wantErr bool
+	}{
+		{1, []int{3}, false},
+		{2, []int{3, 2}, false},
+		{3, nil, true}, // baseline
+		{9, nil, true},
+	}
+
+	for _, c := range cases {
+		xm, err := last(xs, c.n)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("last(%d) err = nil, want an error", c.n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("last(%d) err = %s", c.n, err)
+		}
+		if len(xm) != len(c.want) {
+			t.Fatalf("last(%d) count = %d, want %d", c.n, len(xm), len(c.want))
+		}
+		for i, v := range c.want {
+			if xm[i].Version != v {
+				t.Errorf("last(%d)[%d] version = %d, want %d", c.n, i, xm[i].Version, v)
+			}
+		}
+	}
+}
+
+// TestLoadMigrations checks that the migrations in the repo load, so a misnamed file fails the build
+func TestLoadMigrations(t *testing.T) {
+	xm, err := Load("../../../migrations")
+	if err != nil {
+		t.Fatalf("Load() err = %s", err)
+	}
+	if len(xm) == 0 {
+		t.Errorf("Load() found no migrations")
+	}
+}
@@ -0,0 +1,90 @@
+package outbox_test
+
+import (
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/cardiacsociety/web-services/testdata"
+)
+
+func TestConsumer(t *testing.T) {
+
+	ds, teardown := setup()
+	defer teardown()
+
+	c := outbox.Consumer{Name: "outbox_test", Aggregates: []string{outbox.Member}}
+	var got []int
+	fn := func(xe []outbox.Event) error {
+		got = append(got, outbox.IDs(xe, outbox.Member)...)
+		return nil
+	}
+
+	// read any events added by the test data
+	for {
+		n, err := c.Next(ds, fn)
+		if err != nil {
+			t.Fatalf("Consumer.Next() err = %s", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	got = nil
+
+	// two transactions add events, and the one with the later id commits first
+	tx1, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		t.Fatalf("Begin() err = %s", err)
+	}
+	defer tx1.Rollback()
+	if err := outbox.Emit(tx1, outbox.Member, 1, outbox.Upsert); err != nil {
+		t.Fatalf("outbox.Emit() err = %s", err)
+	}
+	tx2, err := ds.MySQL.Session.Begin()
+	if err != nil {
+		t.Fatalf("Begin() err = %s", err)
+	}
+	if err := outbox.Emit(tx2, outbox.Member, 2, outbox.Upsert); err != nil {
+		t.Fatalf("outbox.Emit() err = %s", err)
+	}
+	if err := tx2.Commit(); err != nil {
+		t.Fatalf("Commit() err = %s", err)
+	}
+
+	// the checkpoint must not move past the event that has not been committed
+	n, err := c.Next(ds, fn)
+	if err != nil {
+		t.Fatalf("Consumer.Next() err = %s", err)
+	}
+	if n != 0 || len(got) != 0 {
+		t.Errorf("Consumer.Next() = %d events for members %v, want none before the first commit", n, got)
+	}
+
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit() err = %s", err)
+	}
+	n, err = c.Next(ds, fn)
+	if err != nil {
+		t.Fatalf("Consumer.Next() err = %s", err)
+	}
+	if n != 2 || !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Consumer.Next() = %d events for members %v, want 2 for members [1 2]", n, got)
+	}
+}
+
+func setup() (datastore.Datastore, func()) {
+	var db = testdata.NewDataStore()
+	err := db.SetupMySQL()
+	if err != nil {
+		log.Fatalf("SetupMySQL() err = %s", err)
+	}
+	return db.Store, func() {
+		err := db.TearDownMySQL()
+		if err != nil {
+			log.Fatalf("TearDownMySQL() err = %s", err)
+		}
+	}
+}
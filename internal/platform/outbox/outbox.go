@@ -0,0 +1,296 @@
+/*
+	Package outbox records change events for the member, resource and module records.
+
+	Writes to those records add an event to the outbox_event table in the same transaction as the
+	change, see Transact. Consumers such as syncr and algr read the events in order from a checkpoint,
+	so that changes are passed on within seconds rather than waiting for the next scheduled run. An event only identifies the record that
+	changed - consumers fetch the current record, so events for the same record can be collapsed.
+*/
+package outbox
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/utility"
+)
+
+// Aggregates that emit change events
+const (
+	Member   = "member"
+	Resource = "resource"
+	Module   = "module"
+)
+
+// Actions
+const (
+	Upsert = "upsert"
+	Delete = "delete"
+)
+
+// DefaultBatchSize is the number of events fetched by a consumer when Consumer.BatchSize is not set
+const DefaultBatchSize = 500
+
+// DefaultLag is how long a consumer waits for a missing event id to be committed when Consumer.Lag is
+// not set. It should be longer than the longest transaction that adds an event.
+const DefaultLag = 30 * time.Second
+
+// Event is a change to a single record
+type Event struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Aggregate   string    `json:"aggregate"`
+	AggregateID int       `json:"aggregateId"`
+	Action      string    `json:"action"`
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx, so that an event can be added in the same transaction
+// as the change
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Emit adds an event to the outbox
+func Emit(ex Execer, aggregate string, id int, action string) error {
+	if id == 0 {
+		return errors.New("outbox.Emit() aggregate id is not set")
+	}
+	_, err := ex.Exec(`INSERT INTO outbox_event (created_at, aggregate, aggregate_id, action)
+		VALUES (NOW(), ?, ?, ?)`, aggregate, id, action)
+	return errors.Wrap(err, "outbox.Emit()")
+}
+
+// Transact runs fn in a transaction and adds an event for the record with the id returned by fn, in the
+// same transaction, so that the event is recorded if, and only if, the change is
+func Transact(db *sql.DB, aggregate, action string, fn func(tx *sql.Tx) (int, error)) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "outbox.Transact()")
+	}
+	defer tx.Rollback()
+
+	id, err := fn(tx)
+	if err != nil {
+		return err
+	}
+	if err := Emit(tx, aggregate, id, action); err != nil {
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "outbox.Transact()")
+}
+
+// After fetches up to limit events with an id greater than id, oldest first. The aggregates are
+// optional, and limit the events to those aggregates.
+func After(ds datastore.Datastore, id int64, limit int, aggregates ...string) ([]Event, error) {
+
+	query := `SELECT id, created_at, aggregate, aggregate_id, action FROM outbox_event WHERE id > ?`
+	args := []interface{}{id}
+	if len(aggregates) > 0 {
+		query += ` AND aggregate IN (?` + strings.Repeat(", ?", len(aggregates)-1) + `)`
+		for _, a := range aggregates {
+			args = append(args, a)
+		}
+	}
+	query += ` ORDER BY id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "outbox.After()")
+	}
+	defer rows.Close()
+
+	var xe []Event
+	for rows.Next() {
+		var e Event
+		var createdAt string
+		if err := rows.Scan(&e.ID, &createdAt, &e.Aggregate, &e.AggregateID, &e.Action); err != nil {
+			return nil, errors.Wrap(err, "outbox.After()")
+		}
+		e.CreatedAt, _ = utility.DateTime(createdAt)
+		xe = append(xe, e)
+	}
+	return xe, rows.Err()
+}
+
+// Collapse removes all but the last event for each record, keeping the order of the last events
+func Collapse(xe []Event) []Event {
+
+	type key struct {
+		aggregate string
+		id        int
+	}
+	last := map[key]int{}
+	for i, e := range xe {
+		last[key{e.Aggregate, e.AggregateID}] = i
+	}
+
+	var res []Event
+	for i, e := range xe {
+		if last[key{e.Aggregate, e.AggregateID}] == i {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// IDs returns the aggregate ids of the events for an aggregate
+func IDs(xe []Event, aggregate string) []int {
+	var xi []int
+	seen := map[int]bool{}
+	for _, e := range xe {
+		if e.Aggregate == aggregate && !seen[e.AggregateID] {
+			xi = append(xi, e.AggregateID)
+			seen[e.AggregateID] = true
+		}
+	}
+	return xi
+}
+
+// Checkpoint returns the id of the last event processed by the named consumer, 0 if the consumer has
+// not processed any events
+func Checkpoint(ds datastore.Datastore, consumer string) (int64, error) {
+	var id int64
+	err := ds.MySQL.Session.QueryRow(`SELECT last_event_id FROM outbox_consumer WHERE name = ?`,
+		consumer).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, errors.Wrap(err, "outbox.Checkpoint()")
+}
+
+// SetCheckpoint records the id of the last event processed by the named consumer
+func SetCheckpoint(ds datastore.Datastore, consumer string, id int64) error {
+	_, err := ds.MySQL.Session.Exec(`INSERT INTO outbox_consumer (name, last_event_id, updated_at)
+		VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE last_event_id = VALUES(last_event_id), updated_at = NOW()`,
+		consumer, id)
+	return errors.Wrap(err, "outbox.SetCheckpoint()")
+}
+
+// Purge removes events created before t that have been processed by every consumer, and returns
+// the number of events removed
+func Purge(ds datastore.Datastore, t time.Time) (int64, error) {
+	res, err := ds.MySQL.Session.Exec(`DELETE FROM outbox_event WHERE created_at < ?
+		AND id <= (SELECT COALESCE(MIN(last_event_id), 0) FROM outbox_consumer)`,
+		t.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, errors.Wrap(err, "outbox.Purge()")
+	}
+	return res.RowsAffected()
+}
+
+// Consumer reads the outbox from a checkpoint that is saved under its name. Lag is how long to wait for a
+// missing event id, see Contiguous.
+type Consumer struct {
+	Name       string
+	Aggregates []string
+	BatchSize  int
+	Lag        time.Duration
+}
+
+// Next calls fn with the next batch of events after the checkpoint, collapsed so there is one event
+// per record, and moves the checkpoint past the batch if fn returns nil. The batch ends at a gap in the
+// event ids that may still be filled, see Contiguous. It returns the number of events read from the
+// outbox, which is 0 when the consumer has caught up.
+func (c Consumer) Next(ds datastore.Datastore, fn func([]Event) error) (int, error) {
+
+	if c.Name == "" {
+		return 0, errors.New("outbox.Consumer.Next() consumer name is not set")
+	}
+	size := c.BatchSize
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	lag := c.Lag
+	if lag <= 0 {
+		lag = DefaultLag
+	}
+
+	id, err := Checkpoint(ds, c.Name)
+	if err != nil {
+		return 0, err
+	}
+	// events for every aggregate are read so that a gap in the ids is not mistaken for another aggregate
+	xe, err := After(ds, id, size)
+	if err != nil || len(xe) == 0 {
+		return 0, err
+	}
+	var now string
+	if err := ds.MySQL.Session.QueryRow(`SELECT NOW()`).Scan(&now); err != nil {
+		return 0, errors.Wrap(err, "outbox.Consumer.Next()")
+	}
+	t, _ := utility.DateTime(now)
+	xe = Contiguous(xe, id, t, lag)
+	if len(xe) == 0 {
+		return 0, nil
+	}
+
+	if xa := aggregateEvents(xe, c.Aggregates); len(xa) > 0 {
+		if err := fn(Collapse(xa)); err != nil {
+			return 0, err
+		}
+	}
+	return len(xe), SetCheckpoint(ds, c.Name, xe[len(xe)-1].ID)
+}
+
+// Contiguous returns the events, which follow the event with the id after, up to the first gap in the
+// ids that may still be filled. Ids are allocated when an event is added, but an event can only be read
+// once its transaction commits, and transactions do not always commit in id order. Moving a checkpoint
+// past a missing id would lose that event, so it is waited for until the event after it is older than
+// lag, at the time now, after which it is taken to be from a transaction that was rolled back.
+func Contiguous(xe []Event, after int64, now time.Time, lag time.Duration) []Event {
+	for i, e := range xe {
+		if e.ID != after+1 && now.Sub(e.CreatedAt) < lag {
+			return xe[:i]
+		}
+		after = e.ID
+	}
+	return xe
+}
+
+// aggregateEvents returns the events for the aggregates, or all of the events if there are none
+func aggregateEvents(xe []Event, aggregates []string) []Event {
+	if len(aggregates) == 0 {
+		return xe
+	}
+	var res []Event
+	for _, e := range xe {
+		for _, a := range aggregates {
+			if e.Aggregate == a {
+				res = append(res, e)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// Run calls Next until stop is closed. It carries on without waiting while there is a backlog, and
+// waits for interval when the consumer has caught up or a batch fails. A failed batch is logged and
+// retried, as the checkpoint has not moved.
+func (c Consumer) Run(ds datastore.Datastore, interval time.Duration, stop <-chan struct{}, fn func([]Event) error) {
+
+	for {
+		n, err := c.Next(ds, fn)
+		if err != nil {
+			log.Printf("Consumer %s error processing change events, will retry - %s", c.Name, err)
+		}
+
+		wait := interval
+		if n > 0 && err == nil {
+			wait = 0
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
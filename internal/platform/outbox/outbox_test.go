@@ -0,0 +1,74 @@
+package outbox_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+)
+
+var events = []outbox.Event{
+	{ID: 1, Aggregate: outbox.Member, AggregateID: 7821, Action: outbox.Upsert},
+	{ID: 2, Aggregate: outbox.Resource, AggregateID: 6576, Action: outbox.Upsert},
+	{ID: 3, Aggregate: outbox.Member, AggregateID: 502, Action: outbox.Upsert},
+	{ID: 4, Aggregate: outbox.Resource, AggregateID: 6576, Action: outbox.Delete},
+	{ID: 5, Aggregate: outbox.Module, AggregateID: 7821, Action: outbox.Upsert},
+	{ID: 6, Aggregate: outbox.Member, AggregateID: 7821, Action: outbox.Upsert},
+}
+
+func TestCollapse(t *testing.T) {
+	var got []int64
+	for _, e := range outbox.Collapse(events) {
+		got = append(got, e.ID)
+	}
+	want := []int64{3, 4, 5, 6}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Collapse() ids = %v, want %v", got, want)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	cases := []struct {
+		aggregate string
+		want      []int
+	}{
+		{outbox.Member, []int{7821, 502}},
+		{outbox.Resource, []int{6576}},
+		{outbox.Module, []int{7821}},
+		{"other", nil},
+	}
+	for _, c := range cases {
+		got := outbox.IDs(events, c.aggregate)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("IDs(%s) = %v, want %v", c.aggregate, got, c.want)
+		}
+	}
+}
+
+func TestContiguous(t *testing.T) {
+	now := time.Date(2019, 8, 8, 10, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Second)
+	old := now.Add(-time.Minute)
+
+	cases := []struct {
+		after int64
+		xe    []outbox.Event
+		want  []int64 // ids
+	}{
+		{0, []outbox.Event{{ID: 1, CreatedAt: recent}, {ID: 2, CreatedAt: recent}}, []int64{1, 2}},
+		{0, []outbox.Event{{ID: 1, CreatedAt: recent}, {ID: 3, CreatedAt: recent}}, []int64{1}}, // 2 may not have committed
+		{0, []outbox.Event{{ID: 1, CreatedAt: old}, {ID: 3, CreatedAt: old}}, []int64{1, 3}},    // 2 was rolled back
+		{5, []outbox.Event{{ID: 7, CreatedAt: recent}}, nil},                                    // 6 may not have committed
+		{5, []outbox.Event{{ID: 6, CreatedAt: old}, {ID: 8, CreatedAt: old}, {ID: 10, CreatedAt: recent}}, []int64{6, 8}},
+	}
+	for i, c := range cases {
+		var got []int64
+		for _, e := range outbox.Contiguous(c.xe, c.after, now, 30*time.Second) {
+			got = append(got, e.ID)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Contiguous() case %d ids = %v, want %v", i, got, c.want)
+		}
+	}
+}
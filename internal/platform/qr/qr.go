@@ -0,0 +1,503 @@
+/*
+	Package qr encodes short byte strings as QR codes, for tickets and other codes that are scanned from
+	a screen or print out. It supports byte mode with error correction level M (~15% recovery) in
+	versions 1 to 10, which is enough for up to 213 bytes.
+*/
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// MaxLen is the maximum number of bytes that can be encoded
+const MaxLen = 213
+
+// QuietZone is the width of the blank border, in modules, required around a QR code
+const QuietZone = 4
+
+// ErrTooLong is returned when the data does not fit in the largest supported version
+var ErrTooLong = errors.New("data is too long to encode as a QR code")
+
+// Code is a QR code symbol. Modules are addressed by x (column) and y (row) from the top left.
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+}
+
+// Black reports whether the module at x, y is dark. Coordinates outside the symbol, such as
+// the quiet zone, are light.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+// Image returns the code as an image with scale pixels per module, including the quiet zone
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	n := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, n, n))
+	for py := 0; py < n; py++ {
+		for px := 0; px < n; px++ {
+			v := color.Gray{Y: 255}
+			if c.Black(px/scale-QuietZone, py/scale-QuietZone) {
+				v = color.Gray{Y: 0}
+			}
+			img.SetGray(px, py, v)
+		}
+	}
+	return img
+}
+
+// blocks describes the error correction structure of a version at level M
+type blocks struct {
+	ecLen   int   // error correction codewords per block
+	dataLen []int // data codewords in each block
+}
+
+func (b blocks) dataTotal() int {
+	var n int
+	for _, l := range b.dataLen {
+		n += l
+	}
+	return n
+}
+
+// versions 1 to 10 at error correction level M, from ISO/IEC 18004 table 9
+var versions = []blocks{
+	{},
+	{10, []int{16}},
+	{16, []int{28}},
+	{26, []int{44}},
+	{18, []int{32, 32}},
+	{24, []int{43, 43}},
+	{16, []int{27, 27, 27, 27}},
+	{18, []int{31, 31, 31, 31}},
+	{22, []int{38, 38, 39, 39}},
+	{22, []int{36, 36, 36, 37, 37}},
+	{26, []int{43, 43, 43, 43, 44}},
+}
+
+// alignment pattern centre coordinates for versions 1 to 10
+var alignment = [][]int{
+	{}, {}, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+// Encode returns the smallest QR code that holds data
+func Encode(data []byte) (*Code, error) {
+	for v := 1; v < len(versions); v++ {
+		if len(data) <= capacity(v) {
+			return encode(data, v), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// capacity is the number of bytes that fit in a version, after the mode indicator and character count
+func capacity(version int) int {
+	return (versions[version].dataTotal()*8 - 4 - countBits(version)) / 8
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+func encode(data []byte, version int) *Code {
+
+	size := version*4 + 17
+	c := &Code{Version: version, Size: size, modules: grid(size)}
+	fn := grid(size) // function modules, which are not masked and do not hold data
+	c.drawFunctionPatterns(fn)
+
+	cw := codewords(data, version)
+	c.drawCodewords(fn, cw)
+
+	best, penalty := 0, -1
+	for m := 0; m < 8; m++ {
+		c.applyMask(fn, m)
+		c.drawFormat(fn, m)
+		if p := c.penalty(); penalty < 0 || p < penalty {
+			best, penalty = m, p
+		}
+		c.applyMask(fn, m) // masking is reversible
+	}
+	c.applyMask(fn, best)
+	c.drawFormat(fn, best)
+
+	return c
+}
+
+func grid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+// codewords builds the data bit stream, then splits it into blocks, adds the error correction
+// codewords to each block and interleaves the result
+func codewords(data []byte, version int) []byte {
+
+	b := versions[version]
+	total := b.dataTotal()
+
+	var bb bitBuffer
+	bb.append(0x4, 4) // byte mode
+	bb.append(len(data), countBits(version))
+	for _, d := range data {
+		bb.append(int(d), 8)
+	}
+	// terminator, then pad to a byte boundary
+	if n := total*8 - bb.len(); n < 4 {
+		bb.append(0, n)
+	} else {
+		bb.append(0, 4)
+	}
+	if n := bb.len() % 8; n > 0 {
+		bb.append(0, 8-n)
+	}
+	xb := bb.bytes()
+	for pad := 0xEC; len(xb) < total; pad ^= 0xEC ^ 0x11 {
+		xb = append(xb, byte(pad))
+	}
+
+	divisor := rsDivisor(b.ecLen)
+	var dataBlocks, ecBlocks [][]byte
+	for _, l := range b.dataLen {
+		dataBlocks = append(dataBlocks, xb[:l])
+		ecBlocks = append(ecBlocks, rsRemainder(xb[:l], divisor))
+		xb = xb[l:]
+	}
+
+	var out []byte
+	longest := b.dataLen[len(b.dataLen)-1]
+	for i := 0; i < longest; i++ {
+		for _, blk := range dataBlocks {
+			if i < len(blk) {
+				out = append(out, blk[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecLen; i++ {
+		for _, blk := range ecBlocks {
+			out = append(out, blk[i])
+		}
+	}
+	return out
+}
+
+// set marks a function module
+func (c *Code) set(fn [][]bool, x, y int, dark bool) {
+	c.modules[y][x] = dark
+	fn[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(fn [][]bool) {
+
+	size := c.Size
+
+	// timing patterns
+	for i := 0; i < size; i++ {
+		c.set(fn, 6, i, i%2 == 0)
+		c.set(fn, i, 6, i%2 == 0)
+	}
+
+	// finder patterns, including the separators
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.set(fn, x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	// alignment patterns, except where they would overlap the finder patterns
+	pos := alignment[c.Version]
+	for i, x := range pos {
+		for j, y := range pos {
+			last := len(pos) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(fn, x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas, which are drawn for each mask, and the dark module
+	c.drawFormat(fn, 0)
+	c.set(fn, 8, size-8, true)
+
+	if c.Version >= 7 {
+		bits := bch(c.Version, 12, 0x1F25)
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 == 1
+			a, b := size-11+i%3, i/3
+			c.set(fn, a, b, dark)
+			c.set(fn, b, a, dark)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information for level M and the mask
+func (c *Code) drawFormat(fn [][]bool, mask int) {
+
+	size := c.Size
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(fn, 8, i, bit(i))
+	}
+	c.set(fn, 8, 7, bit(6))
+	c.set(fn, 8, 8, bit(7))
+	c.set(fn, 7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(fn, 14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(fn, size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(fn, 8, size-15+i, bit(i))
+	}
+}
+
+// formatBits returns the 15 bit format information for level M (indicator 00) and the mask
+func formatBits(mask int) int {
+	return bch(mask, 10, 0x537) ^ 0x5412
+}
+
+// bch appends the BCH error correction bits for data, generated by poly of degree n
+func bch(data, n, poly int) int {
+	rem := data
+	for i := 0; i < n; i++ {
+		rem = rem<<1 ^ (rem>>uint(n-1)&1)*poly
+	}
+	return data<<uint(n) | rem
+}
+
+// drawCodewords places the codewords in the two module wide columns that zigzag up and
+// down from the bottom right corner, skipping the function modules
+func (c *Code) drawCodewords(fn [][]bool, cw []byte) {
+	size := c.Size
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if fn[y][x] || i >= len(cw)*8 {
+					continue
+				}
+				c.modules[y][x] = cw[i/8]>>uint(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the mask pattern
+func (c *Code) applyMask(fn [][]bool, mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if fn[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules used to choose a mask, lower is better
+func (c *Code) penalty() int {
+
+	size := c.Size
+	var p, dark int
+
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= size; i++ {
+			if i < size && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				p += run - 2
+			}
+			run = 1
+		}
+		// finder-like patterns, 1:1:3:1:1 with four light modules on one side
+		pattern := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= size; i++ {
+			match := true
+			for k, v := range pattern {
+				if get(i+k) != v {
+					match = false
+					break
+				}
+			}
+			if match && (lightRun(get, i-4, i, size) || lightRun(get, i+7, i+11, size)) {
+				p += 40
+			}
+		}
+	}
+
+	for y := 0; y < size; y++ {
+		line(func(i int) bool { return c.modules[y][i] })
+	}
+	for x := 0; x < size; x++ {
+		line(func(i int) bool { return c.modules[i][x] })
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			v := c.modules[y][x]
+			if v {
+				dark++
+			}
+			if x < size-1 && y < size-1 && v == c.modules[y][x+1] && v == c.modules[y+1][x] && v == c.modules[y+1][x+1] {
+				p += 3
+			}
+		}
+	}
+
+	percent := dark * 100 / (size * size)
+	p += abs(percent-50) / 5 * 10
+
+	return p
+}
+
+// lightRun reports whether the modules from i to j (exclusive) are light, treating modules
+// outside the symbol as light
+func lightRun(get func(i int) bool, i, j, size int) bool {
+	for ; i < j; i++ {
+		if i >= 0 && i < size && get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// bitBuffer accumulates the data bit stream
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, v>>uint(i)&1 == 1)
+	}
+}
+
+func (bb bitBuffer) len() int {
+	return len(bb)
+}
+
+func (bb bitBuffer) bytes() []byte {
+	xb := make([]byte, (len(bb)+7)/8)
+	for i, b := range bb {
+		if b {
+			xb[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return xb
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the degree, without the leading term
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords for data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,166 @@
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+// HELLO WORLD at version 1-M, from the worked example at thonky.com/qr-code-tutorial
+func TestRSRemainder(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	got := rsRemainder(data, rsDivisor(10))
+	if !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder() = %v, want %v", got, want)
+	}
+}
+
+// level M format information from ISO/IEC 18004 annex C
+func TestFormatBits(t *testing.T) {
+	want := []int{
+		0x5412, // 101010000010010
+		0x5125, // 101000100100101
+		0x5E7C, // 101111001111100
+		0x5B4B, // 101101101001011
+		0x45F9, // 100010111111001
+		0x40CE, // 100000011001110
+		0x4F97, // 100111110010111
+		0x4AA0, // 100101010100000
+	}
+	for mask, w := range want {
+		if got := formatBits(mask); got != w {
+			t.Errorf("formatBits(%d) = %015b, want %015b", mask, got, w)
+		}
+	}
+	if got, want := bch(7, 12, 0x1F25), 0x07C94; got != want {
+		t.Errorf("version 7 information = %018b, want %018b", got, want)
+	}
+}
+
+// the modules that are not function patterns should hold exactly the codewords, plus remainder bits
+func TestDataModules(t *testing.T) {
+	remainder := []int{0, 0, 7, 7, 7, 7, 7, 0, 0, 0, 0}
+	for v := 1; v < len(versions); v++ {
+		size := v*4 + 17
+		c := &Code{Version: v, Size: size, modules: grid(size)}
+		fn := grid(size)
+		c.drawFunctionPatterns(fn)
+		var n int
+		for y := range fn {
+			for x := range fn[y] {
+				if !fn[y][x] {
+					n++
+				}
+			}
+		}
+		b := versions[v]
+		want := (b.dataTotal()+b.ecLen*len(b.dataLen))*8 + remainder[v]
+		if n != want {
+			t.Errorf("version %d data modules = %d, want %d", v, n, want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+
+	cases := []struct {
+		len  int
+		want int // version
+	}{
+		{14, 1},
+		{15, 2},
+		{42, 3},
+		{107, 7},
+		{MaxLen, 10},
+	}
+	for _, c := range cases {
+		code, err := Encode(bytes.Repeat([]byte("a"), c.len))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes) err = %s", c.len, err)
+		}
+		if code.Version != c.want {
+			t.Errorf("Encode(%d bytes) version = %d, want %d", c.len, code.Version, c.want)
+		}
+		if !code.Black(0, 0) || code.Black(7, 7) || !code.Black(8, code.Size-8) {
+			t.Errorf("Encode(%d bytes) finder, separator or dark module is wrong", c.len)
+		}
+		if code.Black(-1, 0) || code.Black(code.Size, 0) {
+			t.Errorf("Encode(%d bytes) quiet zone should be light", c.len)
+		}
+	}
+
+	if _, err := Encode(make([]byte, MaxLen+1)); err != ErrTooLong {
+		t.Errorf("Encode(%d bytes) err = %v, want %v", MaxLen+1, err, ErrTooLong)
+	}
+}
+
+// decoding the format information and reversing the placement and mask should return the data
+func TestRoundTrip(t *testing.T) {
+
+	data := []byte("12.345.6789.abcdefghijklmnopqrstuv")
+	c, err := Encode(data)
+	if err != nil {
+		t.Fatalf("Encode() err = %s", err)
+	}
+
+	var format int
+	for i := 0; i <= 5; i++ {
+		format |= btoi(c.Black(8, i)) << uint(i)
+	}
+	format |= btoi(c.Black(8, 7))<<6 | btoi(c.Black(8, 8))<<7 | btoi(c.Black(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		format |= btoi(c.Black(14-i, 8)) << uint(i)
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format information %015b is not valid for level M", format)
+	}
+
+	fn := grid(c.Size)
+	(&Code{Version: c.Version, Size: c.Size, modules: grid(c.Size)}).drawFunctionPatterns(fn)
+	c.applyMask(fn, mask)
+
+	// version 3 has a single block, so the data codewords come first
+	var bb bitBuffer
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if !fn[y][right-j] {
+					bb = append(bb, c.Black(right-j, y))
+				}
+			}
+		}
+	}
+	xb := bb.bytes()
+	if xb[0]>>4 != 0x4 {
+		t.Fatalf("mode indicator = %x, want 4", xb[0]>>4)
+	}
+	n := int(xb[0]&0x0F)<<4 | int(xb[1]>>4)
+	got := make([]byte, n)
+	for i := range got {
+		got[i] = xb[i+1]<<4 | xb[i+2]>>4
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("decoded %q, want %q", got, data)
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
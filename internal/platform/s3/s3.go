@@ -1,36 +1,173 @@
 package s3
 
 import (
+	"io"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/34South/envr"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
 )
 
-func init() {
-	envr.New("mappcpd-attachments", []string{
-		"AWS_ACCESS_KEY_ID",
-		"AWS_SECRET_ACCESS_KEY",
-		"AWS_REGION",
-	}).Auto()
+// EnvVars are the env vars required by the aws package to access S3
+var EnvVars = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_REGION",
 }
 
-// PutRequest issues a signed URL that allows for a PUT to an Amazon S3 bucket. It receives the
-// key (full path to file including file name', and the name of the bucket.
-// The aws package ASSUMES the presence of AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY env vars so they have been
-// added to init() above. AWS_REGION was added by me.
-func PutRequest(key, bucket string) (string, error) {
+// Object describes an object in an Amazon S3 bucket
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
 
-	sess := session.Must(session.NewSession())
-	svc := s3.New(sess, aws.NewConfig().WithRegion(os.Getenv("AWS_REGION")))
-	req, _ := svc.PutObjectRequest(&s3.PutObjectInput{
-		Bucket: aws.String(bucket),
+// Store provides access to files (objects) in Amazon S3 buckets. The volume passed to each of
+// the methods is the name of the bucket, and key is the full path to the file, including the file name.
+type Store struct {
+	sess   *session.Session
+	region string
+}
+
+// New returns a pointer to a Store for the specified region. The aws package ASSUMES the presence of
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY env vars.
+func New(region string) (*Store, error) {
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, errors.Wrap(err, "Error creating AWS session")
+	}
+	return &Store{sess: sess, region: region}, nil
+}
+
+func (s *Store) svc() *s3.S3 {
+	return s3.New(s.sess)
+}
+
+// PutURL issues a signed URL that allows for a PUT to an Amazon S3 bucket
+func (s *Store) PutURL(volume, key string, ttl time.Duration) (string, error) {
+	req, _ := s.svc().PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(volume),
 		Key:    aws.String(key),
-		//Body:   strings.NewReader("EXPECTED CONTENTS"),
 	})
+	return req.Presign(ttl)
+}
+
+// GetURL issues a signed URL that allows for a GET of an object in an Amazon S3 bucket
+func (s *Store) GetURL(volume, key string, ttl time.Duration) (string, error) {
+	req, _ := s.svc().GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(volume),
+		Key:    aws.String(key),
+	})
+	return req.Presign(ttl)
+}
 
-	return req.Presign(15 * time.Minute)
+// Put uploads the content read from r to an Amazon S3 bucket
+func (s *Store) Put(volume, key string, r io.Reader, contentType string) error {
+	_, err := s3manager.NewUploader(s.sess).Upload(&s3manager.UploadInput{
+		Bucket:      aws.String(volume),
+		Key:         aws.String(key),
+		Body:        r,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// Get returns a reader for an object in an Amazon S3 bucket, the caller must close it
+func (s *Store) Get(volume, key string) (io.ReadCloser, error) {
+	out, err := s.svc().GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(volume),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// Exists checks for the presence of an object in an Amazon S3 bucket
+func (s *Store) Exists(volume, key string) (bool, error) {
+	_, err := s.svc().HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(volume),
+		Key:    aws.String(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes an object from an Amazon S3 bucket
+func (s *Store) Delete(volume, key string) error {
+	_, err := s.svc().DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(volume),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// List returns the objects in an Amazon S3 bucket with keys that start with prefix. Object keys in S3
+// have no leading slash - the SDK removes it from the request path for the other methods - so it is
+// removed from prefix, and added to the keys that are returned, to match the keys of a file set.
+func (s *Store) List(volume, prefix string) ([]Object, error) {
+	var xo []Object
+	in := &s3.ListObjectsV2Input{
+		Bucket: aws.String(volume),
+		Prefix: aws.String(strings.TrimLeft(prefix, "/")),
+	}
+	err := s.svc().ListObjectsV2Pages(in, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			xo = append(xo, Object{
+				Key:      "/" + aws.StringValue(o.Key),
+				Size:     aws.Int64Value(o.Size),
+				Modified: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	return xo, err
+}
+
+// MakePrivate blocks all public access to an Amazon S3 bucket, and resets the bucket ACL to private.
+// Objects in the bucket can then only be accessed with credentials or a signed URL.
+func (s *Store) MakePrivate(bucket string) error {
+	_, err := s.svc().PutPublicAccessBlock(&s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(bucket),
+		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error blocking public access")
+	}
+	_, err = s.svc().PutBucketAcl(&s3.PutBucketAclInput{
+		Bucket: aws.String(bucket),
+		ACL:    aws.String(s3.BucketCannedACLPrivate),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error setting private acl")
+	}
+	return nil
+}
+
+// PutRequest issues a signed URL that allows for a PUT to an Amazon S3 bucket. It receives the
+// key (full path to file including file name', and the name of the bucket.
+// Deprecated: use a Store, or a storage.Storage, so that the storage backend can be configured.
+func PutRequest(key, bucket string) (string, error) {
+	s, err := New(os.Getenv("AWS_REGION"))
+	if err != nil {
+		return "", err
+	}
+	return s.PutURL(bucket, key, 15*time.Minute)
 }
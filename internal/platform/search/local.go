@@ -0,0 +1,362 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// fieldWeights rank matches in some fields above matches in others
+var fieldWeights = map[string]int{"name": 3, "title": 3, "code": 2, "keywords": 2}
+
+// validIndexName is a name that is safe to use as a file name
+var validIndexName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Local is an Indexer that keeps each index in a JSON file below Dir. Indexes are held in memory
+// and searched by scanning every object, which is fine for the size of the indexes in development
+// and tests. An index is reloaded when the file is changed by another process, such as algr.
+type Local struct {
+	Dir string
+
+	mu      sync.Mutex
+	indexes map[string]*localIndex
+}
+
+// localIndex is an index loaded from a file
+type localIndex struct {
+	modTime time.Time
+	ids     []string
+	docs    map[string]localDoc
+}
+
+// localDoc is an object with the weighted terms from its text fields
+type localDoc struct {
+	object Object
+	terms  map[string]int
+}
+
+// NewLocal returns a pointer to a Local index rooted at dir, which is created if it does not exist
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Local{Dir: dir, indexes: map[string]*localIndex{}}, nil
+}
+
+// Save adds the objects to the index, replacing objects with the same objectID
+func (l *Local) Save(index string, objects []Object) error {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	li, err := l.load(index)
+	if err != nil {
+		return err
+	}
+	xo, err := normalise(objects)
+	if err != nil {
+		return err
+	}
+	for _, o := range xo {
+		li.put(o)
+	}
+	return l.write(index, li)
+}
+
+// Rebuild replaces all of the objects in the index. The new index is written to a temporary file that
+// is then renamed, so searches see either the old or the new index.
+func (l *Local) Rebuild(index string, objects []Object) error {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !validIndexName.MatchString(index) {
+		return fmt.Errorf("invalid index name %q", index)
+	}
+	xo, err := normalise(objects)
+	if err != nil {
+		return err
+	}
+	li := &localIndex{docs: map[string]localDoc{}}
+	for _, o := range xo {
+		li.put(o)
+	}
+	return l.write(index, li)
+}
+
+// Delete removes the objects with top-level fields equal to the filter values. At least one filter is
+// required, so that an index cannot be emptied by mistake.
+func (l *Local) Delete(index string, filters map[string]interface{}) error {
+
+	if len(filters) == 0 {
+		return errors.New("a filter is required to delete objects")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	li, err := l.load(index)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, id := range li.ids {
+		if li.docs[id].matches(filters) {
+			delete(li.docs, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == len(li.ids) {
+		return nil
+	}
+	li.ids = ids
+	return l.write(index, li)
+}
+
+// Search finds the objects that contain every word in the query text, the last word may be the start of
+// a word so that results can be shown as the user types. Results are ordered by the number of matches,
+// weighted by field, and then by the order the objects were added to the index.
+func (l *Local) Search(index string, q Query) (Result, error) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	li, err := l.load(index)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{Hits: []Object{}, Page: q.Page, HitsPerPage: q.HitsPerPage}
+	if res.HitsPerPage < 1 {
+		res.HitsPerPage = DefaultHitsPerPage
+	}
+	if res.Page < 0 {
+		res.Page = 0
+	}
+
+	words := tokenize(q.Text)
+	type hit struct {
+		id    string
+		score int
+		seq   int
+	}
+	var hits []hit
+	for i, id := range li.ids {
+		d := li.docs[id]
+		if !d.matches(q.Filters) {
+			continue
+		}
+		score, ok := d.score(words)
+		if !ok {
+			continue
+		}
+		hits = append(hits, hit{id, score, i})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].seq < hits[j].seq
+	})
+
+	res.NbHits = len(hits)
+	res.NbPages = (len(hits) + res.HitsPerPage - 1) / res.HitsPerPage
+	for i := res.Page * res.HitsPerPage; i < len(hits) && i < (res.Page+1)*res.HitsPerPage; i++ {
+		res.Hits = append(res.Hits, li.docs[hits[i].id].object)
+	}
+
+	return res, nil
+}
+
+// filePath returns the file for an index
+func (l *Local) filePath(index string) string {
+	return filepath.Join(l.Dir, index+".json")
+}
+
+// load returns the index, reading the file if it has changed since it was last read. An index that
+// has no file is empty. The caller must hold l.mu.
+func (l *Local) load(index string) (*localIndex, error) {
+
+	if !validIndexName.MatchString(index) {
+		return nil, fmt.Errorf("invalid index name %q", index)
+	}
+	if l.indexes == nil {
+		l.indexes = map[string]*localIndex{}
+	}
+
+	fi, err := os.Stat(l.filePath(index))
+	if os.IsNotExist(err) {
+		li := &localIndex{docs: map[string]localDoc{}}
+		l.indexes[index] = li
+		return li, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if li, ok := l.indexes[index]; ok && li.modTime.Equal(fi.ModTime()) {
+		return li, nil
+	}
+
+	xb, err := ioutil.ReadFile(l.filePath(index))
+	if err != nil {
+		return nil, err
+	}
+	xo, err := decode(xb)
+	if err != nil {
+		return nil, errors.Wrapf(err, "index %s", index)
+	}
+	li := &localIndex{modTime: fi.ModTime(), docs: map[string]localDoc{}}
+	for _, o := range xo {
+		li.put(o)
+	}
+	l.indexes[index] = li
+	return li, nil
+}
+
+// write saves the index to its file. The caller must hold l.mu.
+func (l *Local) write(index string, li *localIndex) error {
+
+	xo := make([]Object, 0, len(li.ids))
+	for _, id := range li.ids {
+		xo = append(xo, li.docs[id].object)
+	}
+	xb, err := json.Marshal(xo)
+	if err != nil {
+		return err
+	}
+
+	tmp := l.filePath(index) + ".tmp"
+	if err := ioutil.WriteFile(tmp, xb, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.filePath(index)); err != nil {
+		return err
+	}
+	fi, err := os.Stat(l.filePath(index))
+	if err != nil {
+		return err
+	}
+	li.modTime = fi.ModTime()
+	l.indexes[index] = li
+	return nil
+}
+
+// put adds or replaces an object in the index
+func (li *localIndex) put(o Object) {
+	id := objectID(o, len(li.ids))
+	o["objectID"] = id
+	if _, ok := li.docs[id]; !ok {
+		li.ids = append(li.ids, id)
+	}
+	d := localDoc{object: o, terms: map[string]int{}}
+	for k, v := range o {
+		if k == "objectID" || k == "_id" {
+			continue
+		}
+		w := fieldWeights[k]
+		if w == 0 {
+			w = 1
+		}
+		addTerms(d.terms, v, w)
+	}
+	li.docs[id] = d
+}
+
+// objectID is the objectID of the object, or the id if there is no objectID. Objects without either
+// are numbered in the order they are added.
+func objectID(o Object, n int) string {
+	for _, k := range []string{"objectID", "id"} {
+		if v, ok := o[k]; ok && v != nil && fmt.Sprint(v) != "" {
+			return fmt.Sprint(v)
+		}
+	}
+	return fmt.Sprintf("local-%d", n+1)
+}
+
+// addTerms adds the words in the string values of v to terms, with weight w
+func addTerms(terms map[string]int, v interface{}, w int) {
+	switch v := v.(type) {
+	case string:
+		for _, t := range tokenize(v) {
+			terms[t] += w
+		}
+	case []interface{}:
+		for _, x := range v {
+			addTerms(terms, x, w)
+		}
+	case map[string]interface{}:
+		for _, x := range v {
+			addTerms(terms, x, w)
+		}
+	}
+}
+
+// matches is true if the top-level fields of the object have the filter values
+func (d localDoc) matches(filters map[string]interface{}) bool {
+	for k, v := range filters {
+		if fmt.Sprint(d.object[k]) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// score is the weighted number of matches for the words, ok is false if any word does not match. The
+// last word matches the start of a term.
+func (d localDoc) score(words []string) (score int, ok bool) {
+	for i, w := range words {
+		n := d.terms[w]
+		if i == len(words)-1 {
+			n = 0
+			for t, c := range d.terms {
+				if strings.HasPrefix(t, w) {
+					n += c
+				}
+			}
+		}
+		if n == 0 {
+			return 0, false
+		}
+		score += n
+	}
+	return score, true
+}
+
+// tokenize splits text into lower case words
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalise round trips the objects through JSON so that they are stored and returned the same way
+// whether or not they have been read from the file
+func normalise(objects []Object) ([]Object, error) {
+	xb, err := json.Marshal(objects)
+	if err != nil {
+		return nil, err
+	}
+	return decode(xb)
+}
+
+// decode unmarshals a JSON array of objects, keeping numbers as json.Number so that large ids are
+// not formatted as floats
+func decode(xb []byte) ([]Object, error) {
+	var xo []Object
+	d := json.NewDecoder(bytes.NewReader(xb))
+	d.UseNumber()
+	err := d.Decode(&xo)
+	return xo, err
+}
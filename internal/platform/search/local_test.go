@@ -0,0 +1,185 @@
+package search_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/search"
+)
+
+var resources = []search.Object{
+	{"objectID": "a1", "id": 6576, "active": true, "type": "Article (journal)",
+		"name":     "Gavage of Fecal Samples From Patients With Colorectal Cancer",
+		"keywords": []string{"Carcinogenesis", "Colon Cancer"}},
+	{"objectID": "a2", "id": 6577, "active": true, "type": "Article (journal)",
+		"name":        "NETSstudy: development of a Hirschsprung's disease core outcome set",
+		"description": "A core outcome set for cancer and other diseases"},
+	{"objectID": "a3", "id": 2000, "active": false, "type": "Video (web)",
+		"name":        "Risk factor management as the fourth pillar of AF management",
+		"publishedAt": time.Date(2016, 8, 5, 0, 0, 0, 0, time.UTC)},
+}
+
+func TestLocal(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "search_test")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() err = %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := search.NewLocal(dir)
+	if err != nil {
+		t.Fatalf("search.NewLocal() err = %s", err)
+	}
+	if err := l.Rebuild("resources", resources); err != nil {
+		t.Fatalf("Local.Rebuild() err = %s", err)
+	}
+
+	t.Run("testSearch", func(t *testing.T) { testSearch(t, l) })
+	t.Run("testSave", func(t *testing.T) { testSave(t, l) })
+	t.Run("testDelete", func(t *testing.T) { testDelete(t, l) })
+	t.Run("testReload", func(t *testing.T) { testReload(t, l, dir) })
+	t.Run("testInvalidIndex", func(t *testing.T) { testInvalidIndex(t, l) })
+}
+
+func testSearch(t *testing.T, l *search.Local) {
+	cases := []struct {
+		q   search.Query
+		ids []string
+	}{
+		{search.Query{}, []string{"a1", "a2", "a3"}},
+		{search.Query{Text: "cancer"}, []string{"a1", "a2"}}, // match in name and keywords ranks first
+		{search.Query{Text: "CORE outcome"}, []string{"a2"}},
+		{search.Query{Text: "management pil"}, []string{"a3"}}, // last word is a prefix
+		{search.Query{Text: "pil management"}, []string{}},     // other words are not
+		{search.Query{Text: "article", Filters: map[string]interface{}{"active": "true"}}, []string{"a1", "a2"}},
+		{search.Query{Filters: map[string]interface{}{"id": 2000}}, []string{"a3"}},
+		{search.Query{HitsPerPage: 2, Page: 1}, []string{"a3"}},
+		{search.Query{Text: "nothing"}, []string{}},
+	}
+	for _, c := range cases {
+		res, err := l.Search("resources", c.q)
+		if err != nil {
+			t.Fatalf("Local.Search(%+v) err = %s", c.q, err)
+		}
+		var got []string
+		for _, h := range res.Hits {
+			got = append(got, h["objectID"].(string))
+		}
+		if len(got) != len(c.ids) {
+			t.Fatalf("Local.Search(%+v) ids = %v, want %v", c.q, got, c.ids)
+		}
+		for i := range got {
+			if got[i] != c.ids[i] {
+				t.Errorf("Local.Search(%+v) ids = %v, want %v", c.q, got, c.ids)
+			}
+		}
+	}
+
+	res, err := l.Search("resources", search.Query{HitsPerPage: 2})
+	if err != nil {
+		t.Fatalf("Local.Search() err = %s", err)
+	}
+	if res.NbHits != 3 || res.NbPages != 2 {
+		t.Errorf("Local.Search() NbHits = %d, NbPages = %d, want 3, 2", res.NbHits, res.NbPages)
+	}
+}
+
+func testSave(t *testing.T, l *search.Local) {
+
+	err := l.Save("resources", []search.Object{
+		{"objectID": "a2", "id": 6577, "name": "Replaced"},
+		{"id": 10012, "name": "Cardiac arrest and cerebral blood flow"},
+	})
+	if err != nil {
+		t.Fatalf("Local.Save() err = %s", err)
+	}
+
+	res, err := l.Search("resources", search.Query{})
+	if err != nil {
+		t.Fatalf("Local.Search() err = %s", err)
+	}
+	if res.NbHits != 4 {
+		t.Errorf("Local.Search() NbHits = %d, want 4", res.NbHits)
+	}
+
+	res, _ = l.Search("resources", search.Query{Text: "replaced"})
+	if res.NbHits != 1 || res.Hits[0]["objectID"] != "a2" {
+		t.Errorf("Local.Search(replaced) = %v, want a2", res.Hits)
+	}
+
+	// objects without an objectID use the id
+	res, _ = l.Search("resources", search.Query{Text: "cardiac"})
+	if res.NbHits != 1 || res.Hits[0]["objectID"] != "10012" {
+		t.Errorf("Local.Search(cardiac) = %v, want objectID 10012", res.Hits)
+	}
+}
+
+func testDelete(t *testing.T, l *search.Local) {
+
+	if err := l.Delete("resources", nil); err == nil {
+		t.Errorf("Local.Delete() with no filters err = nil, want an error")
+	}
+	if err := l.Delete("resources", map[string]interface{}{"id": 10012}); err != nil {
+		t.Fatalf("Local.Delete() err = %s", err)
+	}
+	// nothing to delete
+	if err := l.Delete("resources", map[string]interface{}{"id": 10012}); err != nil {
+		t.Fatalf("Local.Delete() err = %s", err)
+	}
+
+	res, _ := l.Search("resources", search.Query{})
+	if res.NbHits != 3 {
+		t.Errorf("Local.Search() after delete NbHits = %d, want 3", res.NbHits)
+	}
+	res, _ = l.Search("resources", search.Query{Text: "cardiac"})
+	if res.NbHits != 0 {
+		t.Errorf("Local.Search(cardiac) after delete NbHits = %d, want 0", res.NbHits)
+	}
+}
+
+func testReload(t *testing.T, l *search.Local, dir string) {
+
+	// a second Local, as in another process, sees the index and the changes made to it
+	l2, err := search.NewLocal(dir)
+	if err != nil {
+		t.Fatalf("search.NewLocal() err = %s", err)
+	}
+	res, err := l2.Search("resources", search.Query{})
+	if err != nil {
+		t.Fatalf("Local.Search() err = %s", err)
+	}
+	if res.NbHits != 3 {
+		t.Errorf("Local.Search() NbHits = %d, want 3", res.NbHits)
+	}
+
+	// change the file time so the change is seen even if the clock has not moved on
+	if err := l.Rebuild("resources", resources[:1]); err != nil {
+		t.Fatalf("Local.Rebuild() err = %s", err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(dir+"/resources.json", later, later)
+
+	res, _ = l2.Search("resources", search.Query{})
+	if res.NbHits != 1 {
+		t.Errorf("Local.Search() after rebuild NbHits = %d, want 1", res.NbHits)
+	}
+
+	res, _ = l2.Search("empty", search.Query{})
+	if res.NbHits != 0 {
+		t.Errorf("Local.Search() of a missing index NbHits = %d, want 0", res.NbHits)
+	}
+}
+
+func testInvalidIndex(t *testing.T, l *search.Local) {
+	for _, index := range []string{"", "../resources", "a/b"} {
+		if _, err := l.Search(index, search.Query{}); err == nil {
+			t.Errorf("Local.Search(%q) err = nil, want an error", index)
+		}
+		if err := l.Save(index, resources); err == nil {
+			t.Errorf("Local.Save(%q) err = nil, want an error", index)
+		}
+	}
+}
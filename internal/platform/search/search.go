@@ -0,0 +1,75 @@
+/*
+	Package search provides a common interface to the search index backends.
+
+	Indexes are built by algr. Algolia is used in production and is queried directly by the
+	client applications. The local backend is an embedded engine that stores indexes on the local
+	file system, so that indexes can be built and searched in development and tests without an
+	Algolia account.
+*/
+package search
+
+import (
+	"fmt"
+	"os"
+)
+
+// Object is a single record in an index. The "objectID" field identifies the object, if it is not
+// set the backend assigns an id.
+type Object = map[string]interface{}
+
+// Query is a full text search of an index. Filters select objects with top-level fields equal to
+// the values. Page numbers start at 0, as they do for Algolia.
+type Query struct {
+	Text        string                 `json:"query"`
+	Filters     map[string]interface{} `json:"filters"`
+	Page        int                    `json:"page"`
+	HitsPerPage int                    `json:"hitsPerPage"`
+}
+
+// Result is a page of search results
+type Result struct {
+	Hits        []Object `json:"hits"`
+	NbHits      int      `json:"nbHits"`
+	Page        int      `json:"page"`
+	NbPages     int      `json:"nbPages"`
+	HitsPerPage int      `json:"hitsPerPage"`
+}
+
+// DefaultHitsPerPage is the page size when the query does not set one
+const DefaultHitsPerPage = 20
+
+// Indexer is implemented by each of the search backends
+type Indexer interface {
+	// Save adds the objects to the index, replacing objects with the same objectID
+	Save(index string, objects []Object) error
+	// Rebuild replaces all of the objects in the index, without interrupting searches in progress
+	Rebuild(index string, objects []Object) error
+	// Delete removes the objects with top-level fields equal to the filter values
+	Delete(index string, filters map[string]interface{}) error
+	// Search queries the index
+	Search(index string, q Query) (Result, error)
+}
+
+// ensure the backends satisfy the interface
+var _ Indexer = (*Local)(nil)
+
+// Backend names for the MAPPCPD_SEARCH env var
+const (
+	BackendAlgolia = "algolia"
+	BackendLocal   = "local"
+)
+
+// FromEnv returns the local backend if the MAPPCPD_SEARCH env var is "local", with the indexes stored
+// below MAPPCPD_SEARCH_DIR. For any other backend it returns nil, as those are queried directly by
+// the client applications.
+func FromEnv() (Indexer, error) {
+
+	if os.Getenv("MAPPCPD_SEARCH") != BackendLocal {
+		return nil, nil
+	}
+	dir := os.Getenv("MAPPCPD_SEARCH_DIR")
+	if dir == "" {
+		return nil, fmt.Errorf("env var MAPPCPD_SEARCH_DIR is required for the %s search backend", BackendLocal)
+	}
+	return NewLocal(dir)
+}
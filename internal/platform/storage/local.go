@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local is a Storage on the local file system. Each volume is a directory below Dir.
+type Local struct {
+	signer
+	Dir string
+}
+
+// NewLocal returns a pointer to a Local store rooted at dir, which is created if it does not
+// exist. Signed URLs are issued relative to baseURL and signed with key.
+func NewLocal(dir, baseURL, key string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Local{
+		signer: signer{baseURL: baseURL, key: []byte(key)},
+		Dir:    dir,
+	}, nil
+}
+
+// filePath returns the file system path for a volume and key
+func (l *Local) filePath(volume, key string) (string, error) {
+	if err := checkVolume(volume); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Dir, volume, filepath.FromSlash(CleanKey(key))), nil
+}
+
+// PutURL issues a signed URL that allows for a PUT to the store
+func (l *Local) PutURL(volume, key string, ttl time.Duration) (string, error) {
+	return l.url("PUT", volume, key, ttl)
+}
+
+// GetURL issues a signed URL that allows for a GET from the store
+func (l *Local) GetURL(volume, key string, ttl time.Duration) (string, error) {
+	return l.url("GET", volume, key, ttl)
+}
+
+// Put stores the content read from r
+func (l *Local) Put(volume, key string, r io.Reader, contentType string) error {
+	fp, err := l.filePath(volume, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(fp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Get returns a reader for a stored file
+func (l *Local) Get(volume, key string) (io.ReadCloser, error) {
+	fp, err := l.filePath(volume, key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fp)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Exists checks if a file is stored
+func (l *Local) Exists(volume, key string) (bool, error) {
+	fp, err := l.filePath(volume, key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(fp)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete removes a stored file, it is not an error if the file does not exist
+func (l *Local) Delete(volume, key string) error {
+	fp, err := l.filePath(volume, key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(fp)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List returns the files in a volume with keys that start with prefix
+func (l *Local) List(volume, prefix string) ([]Object, error) {
+	root, err := l.filePath(volume, "/")
+	if err != nil {
+		return nil, err
+	}
+	var xo []Object
+	err = filepath.Walk(root, func(fp string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, fp)
+		if err != nil {
+			return err
+		}
+		key := "/" + filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			xo = append(xo, Object{Key: key, Size: fi.Size(), Modified: fi.ModTime()})
+		}
+		return nil
+	})
+	return xo, err
+}
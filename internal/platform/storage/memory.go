@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Storage, for tests and local development. Files are lost when the
+// process exits.
+type Memory struct {
+	signer
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data     []byte
+	modified time.Time
+}
+
+// NewMemory returns a pointer to an empty Memory store. Signed URLs are issued relative to
+// baseURL and signed with key.
+func NewMemory(baseURL, key string) *Memory {
+	return &Memory{
+		signer: signer{baseURL: baseURL, key: []byte(key)},
+		files:  map[string]memoryFile{},
+	}
+}
+
+func memoryKey(volume, key string) string {
+	return volume + CleanKey(key)
+}
+
+// PutURL issues a signed URL that allows for a PUT to the store
+func (m *Memory) PutURL(volume, key string, ttl time.Duration) (string, error) {
+	return m.url("PUT", volume, key, ttl)
+}
+
+// GetURL issues a signed URL that allows for a GET from the store
+func (m *Memory) GetURL(volume, key string, ttl time.Duration) (string, error) {
+	return m.url("GET", volume, key, ttl)
+}
+
+// Put stores the content read from r
+func (m *Memory) Put(volume, key string, r io.Reader, contentType string) error {
+	if err := checkVolume(volume); err != nil {
+		return err
+	}
+	xb, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[memoryKey(volume, key)] = memoryFile{data: xb, modified: time.Now()}
+	return nil
+}
+
+// Get returns a reader for a stored file
+func (m *Memory) Get(volume, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.files[memoryKey(volume, key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// Exists checks if a file is stored
+func (m *Memory) Exists(volume, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.files[memoryKey(volume, key)]
+	return ok, nil
+}
+
+// Delete removes a stored file, it is not an error if the file does not exist
+func (m *Memory) Delete(volume, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, memoryKey(volume, key))
+	return nil
+}
+
+// Keys returns the sorted keys of all files stored in a volume
+func (m *Memory) Keys(volume string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var xs []string
+	for k := range m.files {
+		if strings.HasPrefix(k, volume+"/") {
+			xs = append(xs, strings.TrimPrefix(k, volume))
+		}
+	}
+	sort.Strings(xs)
+	return xs
+}
+
+// List returns the files in a volume with keys that start with prefix
+func (m *Memory) List(volume, prefix string) ([]Object, error) {
+	if err := checkVolume(volume); err != nil {
+		return nil, err
+	}
+	var xo []Object
+	for _, k := range m.Keys(volume) {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		m.mu.RLock()
+		f, ok := m.files[volume+k]
+		m.mu.RUnlock()
+		if ok {
+			xo = append(xo, Object{Key: k, Size: int64(len(f.data)), Modified: f.modified})
+		}
+	}
+	return xo, nil
+}
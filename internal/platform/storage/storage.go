@@ -0,0 +1,218 @@
+/*
+	Package storage provides a common interface to the file storage backends.
+
+	Files are addressed by volume (bucket) and key (full path to the file, including file name),
+	as described by a fileset.FileSet. Amazon S3 is used in production, and a local file system
+	or in-memory store can be used for development and tests so that AWS credentials are not required.
+*/
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cardiacsociety/web-services/internal/platform/s3"
+)
+
+// Storage is implemented by each of the file storage backends
+type Storage interface {
+	// PutURL issues a signed URL that allows the client to upload a file with a PUT request
+	PutURL(volume, key string, ttl time.Duration) (string, error)
+	// GetURL issues a signed URL that allows the client to download a file
+	GetURL(volume, key string, ttl time.Duration) (string, error)
+	// Put stores the content read from r
+	Put(volume, key string, r io.Reader, contentType string) error
+	// Get returns a reader for a stored file, the caller must close it
+	Get(volume, key string) (io.ReadCloser, error)
+	// Exists checks if a file is stored
+	Exists(volume, key string) (bool, error)
+	// Delete removes a stored file
+	Delete(volume, key string) error
+	// List returns the files in a volume with keys that start with prefix
+	List(volume, prefix string) ([]s3.Object, error)
+}
+
+// Object describes a stored file, as returned by List
+type Object = s3.Object
+
+// ensure the backends satisfy the interface
+var (
+	_ Storage = (*s3.Store)(nil)
+	_ Storage = (*Local)(nil)
+	_ Storage = (*Memory)(nil)
+)
+
+// Backend names for the MAPPCPD_STORAGE env var
+const (
+	BackendS3     = "s3"
+	BackendLocal  = "local"
+	BackendMemory = "memory"
+)
+
+// DefaultTTL is the lifetime of signed URLs
+const DefaultTTL = 15 * time.Minute
+
+// FilesPath is the path at which the web server serves signed URLs for the local and memory backends
+const FilesPath = "/v1/files"
+
+// ErrNotFound is returned when a file is not present in the local or memory store
+var ErrNotFound = errors.New("file not found in storage")
+
+// FromEnv returns the Storage backend specified by the MAPPCPD_STORAGE env var, which may be
+// "s3" (default), "local" or "memory". The local backend stores files below MAPPCPD_STORAGE_DIR.
+// Signed URLs for the local and memory backends point to MAPPCPD_API_URL and are signed with
+// MAPPCPD_STORAGE_SIGNING_KEY, which is required for those backends.
+func FromEnv() (Storage, error) {
+
+	backend := os.Getenv("MAPPCPD_STORAGE")
+
+	key := os.Getenv("MAPPCPD_STORAGE_SIGNING_KEY")
+	if key == "" && (backend == BackendLocal || backend == BackendMemory) {
+		return nil, fmt.Errorf("env var MAPPCPD_STORAGE_SIGNING_KEY is required for the %s storage backend", backend)
+	}
+	baseURL := strings.TrimRight(os.Getenv("MAPPCPD_API_URL"), "/") + FilesPath
+
+	switch backend {
+	case "", BackendS3:
+		for _, v := range s3.EnvVars {
+			if os.Getenv(v) == "" {
+				return nil, fmt.Errorf("env var %s is required for the %s storage backend", v, BackendS3)
+			}
+		}
+		return s3.New(os.Getenv("AWS_REGION"))
+	case BackendLocal:
+		dir := os.Getenv("MAPPCPD_STORAGE_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("env var MAPPCPD_STORAGE_DIR is required for the %s storage backend", BackendLocal)
+		}
+		return NewLocal(dir, baseURL, key)
+	case BackendMemory:
+		return NewMemory(baseURL, key), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// signer issues and verifies signed URLs for backends that are served by the web server
+type signer struct {
+	baseURL string
+	key     []byte
+}
+
+// url returns a signed URL for the specified http method, volume and key
+func (s signer) url(method, volume, key string, ttl time.Duration) (string, error) {
+	if err := checkVolume(volume); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", s.signature(method, volume, CleanKey(key), expires))
+	return s.baseURL + "/" + volume + CleanKey(key) + "?" + q.Encode(), nil
+}
+
+// verify checks the expiry and signature of a request made with a signed URL,
+// and returns the volume and key from the URL path
+func (s signer) verify(r *http.Request, prefix string) (volume, key string, err error) {
+	p := strings.TrimPrefix(r.URL.Path, prefix)
+	p = strings.TrimPrefix(p, "/")
+	i := strings.Index(p, "/")
+	if i < 1 {
+		return "", "", errors.New("url path should contain a volume and key")
+	}
+	volume, key = p[:i], CleanKey(p[i:])
+
+	expires := r.URL.Query().Get("expires")
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", "", errors.New("missing or malformed expires")
+	}
+	if time.Now().Unix() > exp {
+		return "", "", errors.New("signed url has expired")
+	}
+	want := s.signature(r.Method, volume, key, expires)
+	if !hmac.Equal([]byte(want), []byte(r.URL.Query().Get("signature"))) {
+		return "", "", errors.New("invalid signature")
+	}
+	return volume, key, nil
+}
+
+func (s signer) signature(method, volume, key, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s%s\n%s", method, volume, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves GET and PUT requests for the signed URLs issued by the local and memory backends.
+// The prefix is the path at which the handler is mounted and is removed before locating the file.
+func Handler(st Storage, prefix string) http.Handler {
+
+	var sg signer
+	switch v := st.(type) {
+	case *Local:
+		sg = v.signer
+	case *Memory:
+		sg = v.signer
+	default:
+		return http.NotFoundHandler()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		volume, key, err := sg.verify(r, prefix)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			defer r.Body.Close()
+			if err := st.Put(volume, key, r.Body, r.Header.Get("Content-Type")); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case http.MethodGet:
+			rc, err := st.Get(volume, key)
+			if err == ErrNotFound {
+				http.NotFound(w, r)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer rc.Close()
+			io.Copy(w, rc)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// CleanKey normalises a key so that it always has a leading slash and cannot refer to a parent path. Keys
+// listed from S3 have no leading slash, so they are compared with file set keys in this form.
+func CleanKey(key string) string {
+	return path.Clean("/" + key)
+}
+
+// checkVolume ensures a volume name can be used safely as a single path segment
+func checkVolume(volume string) error {
+	if volume == "" || volume == "." || volume == ".." || strings.ContainsAny(volume, `/\`) {
+		return fmt.Errorf("invalid volume name %q", volume)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/storage"
+)
+
+func TestStorage(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "storage_test")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() err = %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	local, err := storage.NewLocal(dir, "http://localhost/v1/files", "secret")
+	if err != nil {
+		t.Fatalf("storage.NewLocal() err = %s", err)
+	}
+
+	backends := map[string]storage.Storage{
+		"memory": storage.NewMemory("http://localhost/v1/files", "secret"),
+		"local":  local,
+	}
+
+	for name, st := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Run("testPutGet", func(t *testing.T) { testPutGet(t, st) })
+			t.Run("testDelete", func(t *testing.T) { testDelete(t, st) })
+			t.Run("testList", func(t *testing.T) { testList(t, st) })
+			t.Run("testKeyTraversal", func(t *testing.T) { testKeyTraversal(t, st) })
+			t.Run("testInvalidVolume", func(t *testing.T) { testInvalidVolume(t, st) })
+			t.Run("testSignedURLs", func(t *testing.T) { testSignedURLs(t, st) })
+			t.Run("testSignedURLErrors", func(t *testing.T) { testSignedURLErrors(t, st) })
+		})
+	}
+}
+
+func testPutGet(t *testing.T, st storage.Storage) {
+	err := st.Put("test-volume", "/cpd/1/file.txt", strings.NewReader("hello"), "text/plain")
+	if err != nil {
+		t.Fatalf("Put() err = %s", err)
+	}
+	ok, err := st.Exists("test-volume", "/cpd/1/file.txt")
+	if err != nil || !ok {
+		t.Fatalf("Exists() = %v, %v, want true, nil", ok, err)
+	}
+	rc, err := st.Get("test-volume", "/cpd/1/file.txt")
+	if err != nil {
+		t.Fatalf("Get() err = %s", err)
+	}
+	defer rc.Close()
+	xb, _ := ioutil.ReadAll(rc)
+	got := string(xb)
+	want := "hello"
+	if got != want {
+		t.Errorf("Get() content = %q, want %q", got, want)
+	}
+}
+
+func testDelete(t *testing.T, st storage.Storage) {
+	st.Put("test-volume", "/cpd/2/file.txt", strings.NewReader("hello"), "text/plain")
+	if err := st.Delete("test-volume", "/cpd/2/file.txt"); err != nil {
+		t.Fatalf("Delete() err = %s", err)
+	}
+	ok, _ := st.Exists("test-volume", "/cpd/2/file.txt")
+	if ok {
+		t.Errorf("Exists() after Delete() = true, want false")
+	}
+	_, err := st.Get("test-volume", "/cpd/2/file.txt")
+	if err != storage.ErrNotFound {
+		t.Errorf("Get() after Delete() err = %v, want %v", err, storage.ErrNotFound)
+	}
+	// deleting a file that does not exist is not an error
+	if err := st.Delete("test-volume", "/cpd/2/file.txt"); err != nil {
+		t.Errorf("Delete() missing file err = %s, want nil", err)
+	}
+}
+
+func testList(t *testing.T, st storage.Storage) {
+	st.Put("list-volume", "/cpd/1/a.txt", strings.NewReader("a"), "text/plain")
+	st.Put("list-volume", "/cpd/2/b.txt", strings.NewReader("bb"), "text/plain")
+	st.Put("list-volume", "/note/1/c.txt", strings.NewReader("c"), "text/plain")
+
+	xo, err := st.List("list-volume", "/cpd/")
+	if err != nil {
+		t.Fatalf("List() err = %s", err)
+	}
+	var got []string
+	for _, o := range xo {
+		got = append(got, o.Key)
+	}
+	want := "/cpd/1/a.txt,/cpd/2/b.txt"
+	if strings.Join(got, ",") != want {
+		t.Errorf("List() keys = %v, want %s", got, want)
+	}
+	if len(xo) == 2 && (xo[1].Size != 2 || xo[1].Modified.IsZero()) {
+		t.Errorf("List() object = %+v, want size 2 and modified time", xo[1])
+	}
+
+	xo, err = st.List("empty-volume", "/")
+	if err != nil || len(xo) != 0 {
+		t.Errorf("List() empty volume = %v, %v, want no objects and nil", xo, err)
+	}
+}
+
+// a key cannot be used to escape the volume
+func testKeyTraversal(t *testing.T, st storage.Storage) {
+	st.Put("test-volume", "/../../escape.txt", strings.NewReader("hello"), "text/plain")
+	ok, _ := st.Exists("test-volume", "/escape.txt")
+	if !ok {
+		t.Errorf("Exists(/escape.txt) = false, want key to be cleaned to the volume root")
+	}
+}
+
+func testInvalidVolume(t *testing.T, st storage.Storage) {
+	for _, v := range []string{"", "..", "a/b"} {
+		err := st.Put(v, "/file.txt", strings.NewReader("hello"), "text/plain")
+		if err == nil {
+			t.Errorf("Put(%q) err = nil, want error", v)
+		}
+	}
+}
+
+// test an upload and download using signed urls served by storage.Handler
+func testSignedURLs(t *testing.T, st storage.Storage) {
+	srv := httptest.NewServer(storage.Handler(st, storage.FilesPath))
+	defer srv.Close()
+
+	putURL, err := st.PutURL("test-volume", "/note/3/signed.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("PutURL() err = %s", err)
+	}
+	putURL = strings.Replace(putURL, "http://localhost", srv.URL, 1)
+	req, _ := http.NewRequest("PUT", putURL, strings.NewReader("signed content"))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT err = %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", res.StatusCode, http.StatusOK)
+	}
+
+	getURL, err := st.GetURL("test-volume", "/note/3/signed.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("GetURL() err = %s", err)
+	}
+	getURL = strings.Replace(getURL, "http://localhost", srv.URL, 1)
+	res, err = http.Get(getURL)
+	if err != nil {
+		t.Fatalf("GET err = %s", err)
+	}
+	defer res.Body.Close()
+	xb, _ := ioutil.ReadAll(res.Body)
+	if string(xb) != "signed content" {
+		t.Errorf("GET content = %q, want %q", string(xb), "signed content")
+	}
+}
+
+func testSignedURLErrors(t *testing.T, st storage.Storage) {
+	srv := httptest.NewServer(storage.Handler(st, storage.FilesPath))
+	defer srv.Close()
+
+	expired, _ := st.GetURL("test-volume", "/cpd/1/file.txt", -time.Minute)
+	putURL, _ := st.PutURL("test-volume", "/cpd/1/file.txt", time.Minute) // signed for PUT, not GET
+	tampered, _ := st.GetURL("test-volume", "/cpd/1/file.txt", time.Minute)
+	tampered = strings.Replace(tampered, "/cpd/1/", "/cpd/9/", 1)
+
+	for _, u := range []string{expired, putURL, tampered} {
+		u = strings.Replace(u, "http://localhost", srv.URL, 1)
+		res, err := http.Get(u)
+		if err != nil {
+			t.Fatalf("GET err = %s", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusForbidden {
+			t.Errorf("GET %s status = %d, want %d", u, res.StatusCode, http.StatusForbidden)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+
+	for _, v := range []string{"MAPPCPD_STORAGE", "MAPPCPD_STORAGE_SIGNING_KEY", "MAPPCPD_JWT_SIGNING_KEY"} {
+		defer os.Setenv(v, os.Getenv(v))
+	}
+	os.Setenv("MAPPCPD_STORAGE", storage.BackendMemory)
+	os.Setenv("MAPPCPD_JWT_SIGNING_KEY", "jwt-secret")
+
+	// the jwt key is not used in place of the storage key
+	os.Setenv("MAPPCPD_STORAGE_SIGNING_KEY", "")
+	if _, err := storage.FromEnv(); err == nil {
+		t.Errorf("storage.FromEnv() with no MAPPCPD_STORAGE_SIGNING_KEY err = nil, want an error")
+	}
+
+	os.Setenv("MAPPCPD_STORAGE_SIGNING_KEY", "secret")
+	if _, err := storage.FromEnv(); err != nil {
+		t.Errorf("storage.FromEnv() err = %s", err)
+	}
+}
@@ -33,6 +33,16 @@ func ExcelReport(ds datastore.Datastore, positions []Position) (*excelize.File,
 		"Comment",
 	})
 
+	// fetch all of the members at once
+	var ids []int
+	for _, p := range positions {
+		ids = append(ids, p.MemberID)
+	}
+	members, err := member.MapByIDs(ds, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	// data rows
 	for _, p := range positions {
 
@@ -52,9 +62,9 @@ func ExcelReport(ds datastore.Datastore, positions []Position) (*excelize.File,
 		// Get member record so we can access contact location
 		var address = []string{"", "", ""}
 		var mail member.Location
-		m, err := member.ByID(ds, p.MemberID)
-		if err != nil {
-			f.AddError(m.ID, "Error fetching member record: "+err.Error())
+		m, ok := members[p.MemberID]
+		if !ok {
+			f.AddError(p.MemberID, "Error fetching member record: no member record with that id")
 		} else {
 			// ContactLocationByType returns an empty struct and an error if not found
 			// so can ignore error and write an empty cell
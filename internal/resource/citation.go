@@ -0,0 +1,432 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Citation formats
+const (
+	CiteBibTeX  = "bibtex"
+	CiteRIS     = "ris"
+	CiteCSLJSON = "csl-json"
+	CiteAPA     = "apa"
+)
+
+// ErrorCiteFormat is returned for an unknown citation format
+const ErrorCiteFormat = "citation format must be one of bibtex, ris, csl-json or apa"
+
+// citeContentTypes are the content types of the citation formats
+var citeContentTypes = map[string]string{
+	CiteBibTeX:  "application/x-bibtex; charset=utf-8",
+	CiteRIS:     "application/x-research-info-systems; charset=utf-8",
+	CiteCSLJSON: "application/vnd.citationstyles.csl+json; charset=utf-8",
+	CiteAPA:     "text/plain; charset=utf-8",
+}
+
+// citeExtensions are the file extensions of the citation formats
+var citeExtensions = map[string]string{
+	CiteBibTeX:  "bib",
+	CiteRIS:     "ris",
+	CiteCSLJSON: "json",
+	CiteAPA:     "txt",
+}
+
+// Author is an author of a resource
+type Author struct {
+	Family string `json:"family"`
+	Given  string `json:"given"`
+}
+
+// Citation holds the typed fields needed to cite a resource. Journal articles from pubmed have most of
+// these in Resource.Attributes, other resources are cited as web pages.
+type Citation struct {
+	ID            int      `json:"id"`
+	Article       bool     `json:"article"`
+	Title         string   `json:"title"`
+	Authors       []Author `json:"authors"`
+	Journal       string   `json:"journal"`
+	JournalAbbrev string   `json:"journalAbbrev"`
+	Volume        string   `json:"volume"`
+	Issue         string   `json:"issue"`
+	Pages         string   `json:"pages"`
+	Year          int      `json:"year"`
+	Month         int      `json:"month"`
+	Day           int      `json:"day"`
+	DOI           string   `json:"doi"`
+	PMID          string   `json:"pmid"`
+	URL           string   `json:"url"`
+}
+
+// Citation extracts the citation fields from the resource. Authors are stored by pubmedr as
+// "LastName Initials", eg "Wong SH".
+func (r Resource) Citation() Citation {
+
+	c := Citation{
+		ID:    r.ID,
+		Title: strings.TrimSpace(r.Name),
+		Year:  r.PubDate.Year,
+		Month: r.PubDate.Month,
+		Day:   r.PubDate.Day,
+		URL:   r.ResourceURL,
+	}
+
+	attr := func(k string) string {
+		s, _ := r.Attributes[k].(string)
+		return strings.TrimSpace(s)
+	}
+	c.Journal = attr("sourceName")
+	c.JournalAbbrev = attr("sourceNameAbbrev")
+	c.Volume = attr("sourceVolume")
+	c.Issue = attr("sourceIssue")
+	c.Pages = attr("sourcePages")
+	if strings.EqualFold(attr("source"), "pubmed") {
+		c.PMID = attr("sourceId")
+	}
+	c.Article = c.Journal != ""
+
+	// pubmed dates often have no day, which is stored as the 1st, so use the descriptive date to tell
+	if pd := attr("sourcePubDate"); pd != "" && len(strings.Fields(pd)) < 3 {
+		c.Day = 0
+	}
+
+	for _, p := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/"} {
+		if strings.HasPrefix(r.ResourceURL, p) {
+			c.DOI = strings.TrimPrefix(r.ResourceURL, p)
+		}
+	}
+
+	if xa, ok := r.Attributes["authors"].([]interface{}); ok {
+		for _, v := range xa {
+			s, _ := v.(string)
+			if a, ok := parseAuthor(s); ok {
+				c.Authors = append(c.Authors, a)
+			}
+		}
+	}
+	if xa, ok := r.Attributes["authors"].([]string); ok {
+		for _, s := range xa {
+			if a, ok := parseAuthor(s); ok {
+				c.Authors = append(c.Authors, a)
+			}
+		}
+	}
+
+	return c
+}
+
+// parseAuthor splits a "LastName Initials" author name
+func parseAuthor(s string) (Author, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Author{}, false
+	}
+	i := strings.LastIndex(s, " ")
+	if i < 0 {
+		return Author{Family: s}, true
+	}
+	return Author{Family: s[:i], Given: s[i+1:]}, true
+}
+
+// initials formats initials such as "SH" as "S. H."
+func (a Author) initials() string {
+	var xs []string
+	for _, r := range a.Given {
+		if unicode.IsLetter(r) {
+			xs = append(xs, string(r)+".")
+		}
+	}
+	return strings.Join(xs, " ")
+}
+
+// Cite formats the citations, and returns the content type and a file name for the format
+func Cite(xc []Citation, format string) (body []byte, contentType, fileName string, err error) {
+
+	contentType, ok := citeContentTypes[format]
+	if !ok {
+		return nil, "", "", errors.New(ErrorCiteFormat)
+	}
+	fileName = "citations." + citeExtensions[format]
+	if len(xc) == 1 {
+		fileName = fmt.Sprintf("resource-%d.%s", xc[0].ID, citeExtensions[format])
+	}
+
+	var b bytes.Buffer
+	switch format {
+	case CiteBibTeX:
+		keys := map[string]int{}
+		for i, c := range xc {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(c.BibTeX(c.bibKey(keys)))
+		}
+	case CiteRIS:
+		for _, c := range xc {
+			b.WriteString(c.RIS())
+		}
+	case CiteCSLJSON:
+		items := []map[string]interface{}{}
+		for _, c := range xc {
+			items = append(items, c.CSL())
+		}
+		xb, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return nil, "", "", err
+		}
+		b.Write(xb)
+		b.WriteString("\n")
+	case CiteAPA:
+		for _, c := range xc {
+			b.WriteString(c.APA())
+			b.WriteString("\n")
+		}
+	}
+
+	return b.Bytes(), contentType, fileName, nil
+}
+
+// bibKey returns a citation key such as "Wong2017", with a suffix if the key has already been used
+func (c Citation) bibKey(used map[string]int) string {
+	k := fmt.Sprintf("resource%d", c.ID)
+	if len(c.Authors) > 0 && c.Year > 0 {
+		k = ""
+		for _, r := range c.Authors[0].Family {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				k += string(r)
+			}
+		}
+		k += strconv.Itoa(c.Year)
+	}
+	used[k]++
+	if n := used[k]; n > 1 {
+		k += string(rune('a' + n - 1))
+	}
+	return k
+}
+
+// BibTeX formats the citation as a BibTeX entry
+func (c Citation) BibTeX(key string) string {
+
+	var b strings.Builder
+	field := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, "  %s = {%s},\n", k, v)
+		}
+	}
+
+	entry := "misc"
+	if c.Article {
+		entry = "article"
+	}
+	fmt.Fprintf(&b, "@%s{%s,\n", entry, key)
+
+	var xa []string
+	for _, a := range c.Authors {
+		n := bibEscape(a.Family)
+		if a.Given != "" {
+			n += ", " + a.initials()
+		}
+		xa = append(xa, n)
+	}
+	field("author", strings.Join(xa, " and "))
+	field("title", bibEscape(c.Title))
+	field("journal", bibEscape(c.Journal))
+	if c.Year > 0 {
+		field("year", strconv.Itoa(c.Year))
+	}
+	if c.Month > 0 && c.Month <= 12 {
+		field("month", strings.ToLower(monthAbbrev[c.Month-1]))
+	}
+	field("volume", bibEscape(c.Volume))
+	field("number", bibEscape(c.Issue))
+	field("pages", bibEscape(strings.Replace(c.Pages, "-", "--", 1)))
+	field("doi", bibEscape(c.DOI))
+	field("pmid", c.PMID)
+	if c.Article {
+		field("url", c.URL)
+	} else if c.URL != "" {
+		field("howpublished", `\url{`+c.URL+`}`)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// bibEscape escapes the characters that have a special meaning in BibTeX
+func bibEscape(s string) string {
+	return strings.NewReplacer(`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`,
+		"$", `\$`, "#", `\#`, "_", `\_`).Replace(s)
+}
+
+// RIS formats the citation as a RIS record
+func (c Citation) RIS() string {
+
+	var b strings.Builder
+	tag := func(k, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, "%s  - %s\r\n", k, v)
+		}
+	}
+
+	if c.Article {
+		tag("TY", "JOUR")
+	} else {
+		tag("TY", "ELEC")
+	}
+	for _, a := range c.Authors {
+		n := a.Family
+		if a.Given != "" {
+			n += ", " + a.initials()
+		}
+		tag("AU", n)
+	}
+	tag("TI", c.Title)
+	tag("T2", c.Journal)
+	tag("J2", c.JournalAbbrev)
+	if c.Year > 0 {
+		tag("PY", strconv.Itoa(c.Year))
+		da := fmt.Sprintf("%d/", c.Year)
+		if c.Month > 0 {
+			da += fmt.Sprintf("%02d", c.Month)
+		}
+		da += "/"
+		if c.Day > 0 {
+			da += fmt.Sprintf("%02d", c.Day)
+		}
+		tag("DA", da+"/")
+	}
+	tag("VL", c.Volume)
+	tag("IS", c.Issue)
+	if c.Pages != "" {
+		xp := strings.SplitN(c.Pages, "-", 2)
+		tag("SP", xp[0])
+		if len(xp) == 2 {
+			tag("EP", xp[1])
+		}
+	}
+	tag("DO", c.DOI)
+	tag("AN", c.PMID)
+	tag("UR", c.URL)
+	b.WriteString("ER  - \r\n")
+
+	return b.String()
+}
+
+// CSL formats the citation as a CSL-JSON item
+func (c Citation) CSL() map[string]interface{} {
+
+	item := map[string]interface{}{
+		"id":    strconv.Itoa(c.ID),
+		"type":  "webpage",
+		"title": c.Title,
+	}
+	if c.Article {
+		item["type"] = "article-journal"
+	}
+	set := func(k, v string) {
+		if v != "" {
+			item[k] = v
+		}
+	}
+	set("container-title", c.Journal)
+	set("container-title-short", c.JournalAbbrev)
+	set("volume", c.Volume)
+	set("issue", c.Issue)
+	set("page", c.Pages)
+	set("DOI", c.DOI)
+	set("PMID", c.PMID)
+	set("URL", c.URL)
+
+	if len(c.Authors) > 0 {
+		item["author"] = c.Authors
+	}
+	if c.Year > 0 {
+		dp := []int{c.Year}
+		if c.Month > 0 {
+			dp = append(dp, c.Month)
+			if c.Day > 0 {
+				dp = append(dp, c.Day)
+			}
+		}
+		item["issued"] = map[string]interface{}{"date-parts": [][]int{dp}}
+	}
+
+	return item
+}
+
+// APA formats the citation in APA (7th edition) style, as plain text
+func (c Citation) APA() string {
+
+	// Up to 20 authors are listed, after that the first 19, an ellipsis and the last
+	var xa []string
+	for _, a := range c.Authors {
+		n := a.Family
+		if a.Given != "" {
+			n += ", " + a.initials()
+		}
+		xa = append(xa, n)
+	}
+	var authors string
+	switch {
+	case len(xa) == 0:
+	case len(xa) == 1:
+		authors = xa[0]
+	case len(xa) <= 20:
+		authors = strings.Join(xa[:len(xa)-1], ", ") + ", & " + xa[len(xa)-1]
+	default:
+		authors = strings.Join(xa[:19], ", ") + ", . . . " + xa[len(xa)-1]
+	}
+
+	date := "(n.d.)."
+	if c.Year > 0 {
+		date = fmt.Sprintf("(%d).", c.Year)
+	}
+	title := strings.TrimSuffix(c.Title, ".") + "."
+
+	// Without authors the title moves to the author position
+	var b strings.Builder
+	if authors != "" {
+		fmt.Fprintf(&b, "%s %s %s", sentenceEnd(authors), date, title)
+	} else {
+		fmt.Fprintf(&b, "%s %s", title, date)
+	}
+
+	if c.Journal != "" {
+		fmt.Fprintf(&b, " %s", c.Journal)
+		if c.Volume != "" {
+			fmt.Fprintf(&b, ", %s", c.Volume)
+			if c.Issue != "" {
+				fmt.Fprintf(&b, "(%s)", c.Issue)
+			}
+		}
+		if c.Pages != "" {
+			fmt.Fprintf(&b, ", %s", strings.Replace(c.Pages, "-", "–", 1))
+		}
+		b.WriteString(".")
+	}
+
+	if c.DOI != "" {
+		fmt.Fprintf(&b, " https://doi.org/%s", c.DOI)
+	} else if c.URL != "" {
+		fmt.Fprintf(&b, " %s", c.URL)
+	}
+
+	return b.String()
+}
+
+// sentenceEnd adds a full stop, unless the string already ends with one
+func sentenceEnd(s string) string {
+	if strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
+
+var monthAbbrev = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
@@ -0,0 +1,105 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+	"github.com/matryer/is"
+)
+
+// article is unmarshaled from JSON, as it is when fetched from the database
+func article(t *testing.T) resource.Resource {
+	r := resource.Resource{
+		ID:          6576,
+		Name:        "Gavage of Fecal Samples From Patients With Colorectal Cancer Promotes Intestinal Carcinogenesis.",
+		ResourceURL: "https://doi.org/10.1053/j.gastro.2017.08.022",
+		PubDate:     resource.PubDate{Year: 2017, Month: 12, Day: 1},
+	}
+	attributes := `{"source":"Pubmed","sourceId":"28823860","authors":["Wong SH","Zhao L","Zhang X"],
+	"sourceName":"Gastroenterology","sourceNameAbbrev":"Gastroenterology","sourcePubDate":"2017 Dec",
+	"sourceVolume":"153","sourceIssue":"6","sourcePages":"1621-1633.e6"}`
+	if err := json.Unmarshal([]byte(attributes), &r.Attributes); err != nil {
+		t.Fatalf("json.Unmarshal() err = %s", err)
+	}
+	return r
+}
+
+func TestCitation(t *testing.T) {
+	is := is.New(t)
+
+	c := article(t).Citation()
+	is.True(c.Article)
+	is.Equal(c.Journal, "Gastroenterology")
+	is.Equal(c.DOI, "10.1053/j.gastro.2017.08.022")
+	is.Equal(c.PMID, "28823860")
+	is.Equal(c.Day, 0) // no day in the pubmed date
+	is.Equal(c.Authors, []resource.Author{{"Wong", "SH"}, {"Zhao", "L"}, {"Zhang", "X"}})
+
+	video := resource.Resource{
+		ID:          2000,
+		Name:        "Risk factor management as the fourth pillar of AF management",
+		ResourceURL: "https://webcast.gigtv.com.au/Mediasite/Play/bb4663e0c3b64cc58f200064bb6c03db1d",
+		PubDate:     resource.PubDate{Year: 2016, Month: 8, Day: 6},
+		Attributes:  map[string]interface{}{"source": "CSANZ"},
+	}
+	c2 := video.Citation()
+	is.True(!c2.Article)
+	is.Equal(c2.Day, 6)
+	is.Equal(len(c2.Authors), 0)
+}
+
+func TestCite(t *testing.T) {
+	is := is.New(t)
+
+	c := article(t).Citation()
+
+	body, contentType, fileName, err := resource.Cite([]resource.Citation{c}, resource.CiteAPA)
+	is.NoErr(err)
+	is.Equal(contentType, "text/plain; charset=utf-8")
+	is.Equal(fileName, "resource-6576.txt")
+	is.Equal(string(body), "Wong, S. H., Zhao, L., & Zhang, X. (2017). Gavage of Fecal Samples From Patients With "+
+		"Colorectal Cancer Promotes Intestinal Carcinogenesis. Gastroenterology, 153(6), 1621–1633.e6. "+
+		"https://doi.org/10.1053/j.gastro.2017.08.022\n")
+
+	body, _, fileName, err = resource.Cite([]resource.Citation{c, c}, resource.CiteBibTeX)
+	is.NoErr(err)
+	is.Equal(fileName, "citations.bib")
+	bib := string(body)
+	is.True(strings.HasPrefix(bib, "@article{Wong2017,\n")) // first key
+	is.True(strings.Contains(bib, "@article{Wong2017b,\n")) // second key is unique
+	is.True(strings.Contains(bib, "  author = {Wong, S. H. and Zhao, L. and Zhang, X.},\n"))
+	is.True(strings.Contains(bib, "  pages = {1621--1633.e6},\n"))
+	is.True(strings.Contains(bib, "  month = {dec},\n"))
+
+	body, _, _, err = resource.Cite([]resource.Citation{c}, resource.CiteRIS)
+	is.NoErr(err)
+	ris := string(body)
+	is.True(strings.HasPrefix(ris, "TY  - JOUR\r\n"))
+	is.True(strings.Contains(ris, "AU  - Wong, S. H.\r\n"))
+	is.True(strings.Contains(ris, "DA  - 2017/12//\r\n"))
+	is.True(strings.Contains(ris, "SP  - 1621\r\nEP  - 1633.e6\r\n"))
+	is.True(strings.HasSuffix(ris, "ER  - \r\n"))
+
+	body, _, _, err = resource.Cite([]resource.Citation{c}, resource.CiteCSLJSON)
+	is.NoErr(err)
+	var items []struct {
+		ID     string            `json:"id"`
+		Type   string            `json:"type"`
+		Author []resource.Author `json:"author"`
+		Issued struct {
+			DateParts [][]int `json:"date-parts"`
+		} `json:"issued"`
+	}
+	err = json.Unmarshal(body, &items)
+	is.NoErr(err) // csl-json should unmarshal
+	is.Equal(len(items), 1)
+	is.Equal(items[0].ID, "6576")
+	is.Equal(items[0].Type, "article-journal")
+	is.Equal(items[0].Author[0], resource.Author{Family: "Wong", Given: "SH"})
+	is.Equal(items[0].Issued.DateParts, [][]int{{2017, 12}})
+
+	_, _, _, err = resource.Cite([]resource.Citation{c}, "mla")
+	is.Equal(err.Error(), resource.ErrorCiteFormat)
+}
@@ -0,0 +1,160 @@
+package resource
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ClickCount is the number of clicks for a single key, such as a day or a referrer
+type ClickCount struct {
+	Key    string `json:"key"`
+	Clicks int    `json:"clicks"`
+}
+
+// ClickStats summarises the short link clicks for a resource over a period. Total is the count
+// held in the Links doc, which includes clicks made before bucketed clicks were recorded.
+type ClickStats struct {
+	ResourceID int          `json:"resourceId"`
+	From       string       `json:"from"`
+	To         string       `json:"to"`
+	Total      int          `json:"total"`
+	Clicks     int          `json:"clicks"`
+	ByDay      []ClickCount `json:"byDay"`
+	ByHour     []ClickCount `json:"byHour"`
+	ByReferrer []ClickCount `json:"byReferrer"`
+}
+
+// LinkByShortPath fetches the Links doc for a short path, eg "r6576". Returns mgo.ErrNotFound if there
+// is no link.
+func LinkByShortPath(ds datastore.Datastore, path string) (Link, error) {
+	return DocLinksOne(ds, map[string]interface{}{"shortUrl": path})
+}
+
+// Resource returns the id of the resource the link points to, and whether it is active. An active
+// resource is preferred when more than one resource has the same url. Returns sql.ErrNoRows if the
+// link does not point to a resource.
+func (l Link) Resource(ds datastore.Datastore) (id int, active bool, err error) {
+	query := `SELECT id, active FROM ol_resource WHERE resource_url = ? ORDER BY active DESC, id LIMIT 1`
+	err = ds.MySQL.Session.QueryRow(query, l.LongUrl).Scan(&id, &active)
+	return id, active, err
+}
+
+// Click records a click on the link. The click count in the Links doc is incremented atomically and,
+// if the link points to a resource, the click is added to the hourly bucket for the referrer.
+// Only the host of the referrer is kept.
+func (l *Link) Click(ds datastore.Datastore, resourceID int, referrer string, t time.Time) error {
+
+	lc, err := ds.MongoDB.LinksCol()
+	if err != nil {
+		return errors.Wrap(err, "Link.Click()")
+	}
+	err = lc.Update(bson.M{"shortUrl": l.ShortUrl}, bson.M{"$inc": bson.M{"clicks": 1}})
+	if err != nil {
+		return errors.Wrap(err, "Link.Click()")
+	}
+	l.Clicks++
+
+	if resourceID == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO ol_resource_click (ol_resource_id, clicked_hour, referrer, clicks)
+	VALUES (?, ?, ?, 1) ON DUPLICATE KEY UPDATE clicks = clicks + 1`
+	hour := t.Truncate(time.Hour).Format("2006-01-02 15:04:05")
+	_, err = ds.MySQL.Session.Exec(query, resourceID, hour, referrerHost(referrer))
+	if err != nil {
+		return errors.Wrap(err, "Link.Click()")
+	}
+
+	return nil
+}
+
+// Clicks fetches the click analytics for a resource, between from and to inclusive. Days are
+// in the form "2006-01-02" and hours are the hour of the day, "00" to "23".
+func Clicks(ds datastore.Datastore, resourceID int, from, to time.Time) (ClickStats, error) {
+
+	cs := ClickStats{
+		ResourceID: resourceID,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		ByDay:      []ClickCount{},
+		ByHour:     []ClickCount{},
+		ByReferrer: []ClickCount{},
+	}
+
+	r, err := ByID(ds, resourceID)
+	if err != nil {
+		return cs, err
+	}
+	if r.ShortURL != "" {
+		l, err := DocLinksOne(ds, map[string]interface{}{"longUrl": r.ResourceURL})
+		if err == nil {
+			cs.Total = l.Clicks
+		}
+	}
+
+	// to is inclusive so the period ends at the start of the following day
+	args := []interface{}{resourceID, cs.From, to.AddDate(0, 0, 1).Format("2006-01-02")}
+	where := `WHERE ol_resource_id = ? AND clicked_hour >= ? AND clicked_hour < ?`
+
+	cs.ByDay, err = clickCounts(ds, `SELECT DATE_FORMAT(clicked_hour, '%Y-%m-%d') AS k, SUM(clicks)
+	FROM ol_resource_click `+where+` GROUP BY k ORDER BY k`, args...)
+	if err != nil {
+		return cs, err
+	}
+	cs.ByHour, err = clickCounts(ds, `SELECT DATE_FORMAT(clicked_hour, '%H') AS k, SUM(clicks)
+	FROM ol_resource_click `+where+` GROUP BY k ORDER BY k`, args...)
+	if err != nil {
+		return cs, err
+	}
+	cs.ByReferrer, err = clickCounts(ds, `SELECT referrer AS k, SUM(clicks) AS n
+	FROM ol_resource_click `+where+` GROUP BY k ORDER BY n DESC, k`, args...)
+	if err != nil {
+		return cs, err
+	}
+
+	for _, c := range cs.ByDay {
+		cs.Clicks += c.Clicks
+	}
+
+	return cs, nil
+}
+
+// clickCounts runs a query that selects a key and a count
+func clickCounts(ds datastore.Datastore, query string, args ...interface{}) ([]ClickCount, error) {
+
+	xc := []ClickCount{}
+
+	rows, err := ds.MySQL.Session.Query(query, args...)
+	if err != nil {
+		return xc, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := ClickCount{}
+		if err := rows.Scan(&c.Key, &c.Clicks); err != nil {
+			return xc, errors.Wrap(err, "failed to scan click row")
+		}
+		xc = append(xc, c)
+	}
+
+	return xc, rows.Err()
+}
+
+// referrerHost returns the host of the referring url, or an empty string for a direct click
+func referrerHost(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	h := u.Hostname()
+	if len(h) > 255 {
+		h = h[:255]
+	}
+	return h
+}
@@ -0,0 +1,314 @@
+package resource
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Feed limits
+const (
+	FeedDefaultLimit = 20
+	FeedMaxLimit     = 100
+)
+
+// FeedFilter selects the resources for a feed. Type matches the type name, and Keyword and Category
+// match a keyword or the category attribute, all ignoring case.
+type FeedFilter struct {
+	TypeID   int
+	Type     string
+	Keyword  string
+	Category string
+	Limit    int
+}
+
+// Feed is a feed of the latest resources
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	FeedURL     string
+	Resources   []Resource
+}
+
+// LatestResources fetches the newest active primary resources from the Resources collection
+func LatestResources(ds datastore.Datastore, f FeedFilter) ([]Resource, error) {
+
+	q := bson.M{"active": true, "primary": true}
+	if f.TypeID > 0 {
+		q["typeId"] = f.TypeID
+	}
+	if f.Type != "" {
+		q["type"] = exactFold(f.Type)
+	}
+	if f.Keyword != "" {
+		q["keywords"] = exactFold(f.Keyword)
+	}
+	if f.Category != "" {
+		q["attributes.category"] = exactFold(f.Category)
+	}
+
+	rc, err := ds.MongoDB.ResourcesCollection()
+	if err != nil {
+		return nil, err
+	}
+	xr := []Resource{}
+	err = rc.Find(q).Sort("-createdAt", "-id").Limit(f.limit()).All(&xr)
+	return xr, err
+}
+
+// limit returns the number of resources for the feed, the default if Limit is not set
+func (f FeedFilter) limit() int {
+	if f.Limit < 1 {
+		return FeedDefaultLimit
+	}
+	if f.Limit > FeedMaxLimit {
+		return FeedMaxLimit
+	}
+	return f.Limit
+}
+
+// exactFold matches the whole of a string field, ignoring case
+func exactFold(s string) bson.RegEx {
+	return bson.RegEx{Pattern: "^" + regexp.QuoteMeta(s) + "$", Options: "i"}
+}
+
+// Updated is the most recent time a resource in the feed was created or updated
+func (f Feed) Updated() time.Time {
+	var t time.Time
+	for _, r := range f.Resources {
+		if r.CreatedAt.After(t) {
+			t = r.CreatedAt
+		}
+		if r.UpdatedAt.After(t) {
+			t = r.UpdatedAt
+		}
+	}
+	return t
+}
+
+// link is the url used for a resource in a feed, the short url so that clicks are counted
+func (r Resource) link() string {
+	if r.ShortURL != "" {
+		return r.ShortURL
+	}
+	return r.ResourceURL
+}
+
+// updated is when the resource was last changed
+func (r Resource) updated() time.Time {
+	if r.UpdatedAt.After(r.CreatedAt) {
+		return r.UpdatedAt
+	}
+	return r.CreatedAt
+}
+
+// guid is a permanent, unique id for a resource in a feed
+func (f Feed) guid(r Resource) string {
+	u := strings.SplitN(f.Link, "://", 2)
+	host := u[len(u)-1]
+	if i := strings.IndexAny(host, "/:"); i >= 0 {
+		host = host[:i]
+	}
+	return fmt.Sprintf("tag:%s,%d:resource/%d", host, r.CreatedAt.Year(), r.ID)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Link       atomLink       `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Author     atomAuthor     `xml:"author"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// Atom formats the feed as an Atom 1.0 document
+func (f Feed) Atom() ([]byte, error) {
+
+	af := atomFeed{
+		ID:      f.FeedURL,
+		Title:   f.Title,
+		Updated: f.Updated().UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate"},
+		},
+	}
+	for _, r := range f.Resources {
+		e := atomEntry{
+			ID:        f.guid(r),
+			Title:     r.Name,
+			Updated:   r.updated().UTC().Format(time.RFC3339),
+			Published: r.CreatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: r.link(), Rel: "alternate"},
+			Summary:   r.Description,
+			Author:    atomAuthor{Name: f.Title},
+		}
+		for _, c := range r.categories() {
+			e.Categories = append(e.Categories, atomCategory{Term: c})
+		}
+		af.Entries = append(af.Entries, e)
+	}
+
+	return marshalXML(af)
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS formats the feed as an RSS 2.0 document
+func (f Feed) RSS() ([]byte, error) {
+
+	ch := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		AtomLink:    atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+	}
+	if u := f.Updated(); !u.IsZero() {
+		ch.LastBuildDate = u.UTC().Format(time.RFC1123Z)
+	}
+	for _, r := range f.Resources {
+		ch.Items = append(ch.Items, rssItem{
+			Title:       r.Name,
+			Link:        r.link(),
+			Description: r.Description,
+			Categories:  r.categories(),
+			GUID:        rssGUID{Value: f.guid(r)},
+			PubDate:     r.CreatedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshalXML(rss{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: ch})
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	ExternalURL   string   `json:"external_url,omitempty"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	Image         string   `json:"image,omitempty"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// JSONFeed formats the feed as a JSON Feed 1.1 document
+func (f Feed) JSONFeed() ([]byte, error) {
+
+	jf := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, r := range f.Resources {
+		i := jsonFeedItem{
+			ID:            f.guid(r),
+			URL:           r.link(),
+			Title:         r.Name,
+			ContentText:   r.Description,
+			Image:         r.ThumbnailURL,
+			DatePublished: r.CreatedAt.UTC().Format(time.RFC3339),
+			DateModified:  r.updated().UTC().Format(time.RFC3339),
+			Tags:          r.categories(),
+		}
+		if i.URL != r.ResourceURL {
+			i.ExternalURL = r.ResourceURL
+		}
+		jf.Items = append(jf.Items, i)
+	}
+
+	return json.MarshalIndent(jf, "", "  ")
+}
+
+// categories are the type and category of a resource, for feed categories and tags
+func (r Resource) categories() []string {
+	var xs []string
+	if r.Type != "" {
+		xs = append(xs, r.Type)
+	}
+	if c, ok := r.Attributes["category"].(string); ok && c != "" {
+		xs = append(xs, c)
+	}
+	return xs
+}
+
+// marshalXML marshals v with an xml declaration
+func marshalXML(v interface{}) ([]byte, error) {
+	xb, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(xb, '\n')...), nil
+}
@@ -0,0 +1,126 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+	"github.com/matryer/is"
+)
+
+func testFeed() resource.Feed {
+	return resource.Feed{
+		Title:       "Latest Resources",
+		Description: "The latest resources added to the library",
+		Link:        "https://api.example.com",
+		FeedURL:     "https://api.example.com/v1/resources.atom?category=Cardiology",
+		Resources: []resource.Resource{
+			{
+				ID:          10012,
+				CreatedAt:   time.Date(2018, 6, 24, 23, 58, 8, 0, time.UTC),
+				UpdatedAt:   time.Date(2018, 6, 25, 13, 30, 56, 0, time.UTC),
+				Type:        "Article (journal)",
+				Name:        "Cardiac arrest & cerebral blood flow",
+				Description: "Lactate <and> glucose",
+				ResourceURL: "https://doi.org/10.1016/j.resuscitation.2017.08.218",
+				ShortURL:    "https://csanz.io/r10012",
+				Attributes:  map[string]interface{}{"category": "Cardiology"},
+			},
+			{
+				ID:          2000,
+				CreatedAt:   time.Date(2016, 9, 12, 4, 35, 22, 0, time.UTC),
+				Type:        "Video (web)",
+				Name:        "Risk factor management as the fourth pillar of AF management",
+				ResourceURL: "https://webcast.gigtv.com.au/Mediasite/Play/bb4663e0c3b64cc58f200064bb6c03db1d",
+			},
+		},
+	}
+}
+
+func TestFeedAtom(t *testing.T) {
+	is := is.New(t)
+
+	xb, err := testFeed().Atom()
+	is.NoErr(err)
+
+	var f struct {
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID         string `xml:"id"`
+			Title      string `xml:"title"`
+			Summary    string `xml:"summary"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Link struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	err = xml.Unmarshal(xb, &f)
+	is.NoErr(err)                                                        // atom should unmarshal
+	is.Equal(f.Updated, "2018-06-25T13:30:56Z")                          // feed updated is the latest change
+	is.Equal(len(f.Entries), 2)                                          // entries
+	is.Equal(f.Entries[0].ID, "tag:api.example.com,2018:resource/10012") // entry id
+	is.Equal(f.Entries[0].Title, "Cardiac arrest & cerebral blood flow") // title is escaped and unescaped
+	is.Equal(f.Entries[0].Summary, "Lactate <and> glucose")
+	is.Equal(f.Entries[0].Link.Href, "https://csanz.io/r10012") // short url is preferred
+	is.Equal(len(f.Entries[0].Categories), 2)                   // type and category
+	is.Equal(f.Entries[1].Link.Href, "https://webcast.gigtv.com.au/Mediasite/Play/bb4663e0c3b64cc58f200064bb6c03db1d")
+	is.True(strings.Contains(string(xb), `xmlns="http://www.w3.org/2005/Atom"`))
+}
+
+func TestFeedRSS(t *testing.T) {
+	is := is.New(t)
+
+	xb, err := testFeed().RSS()
+	is.NoErr(err)
+
+	var f struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Link    string `xml:"link"`
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	err = xml.Unmarshal(xb, &f)
+	is.NoErr(err) // rss should unmarshal
+	is.Equal(f.Version, "2.0")
+	is.Equal(f.Channel.LastBuildDate, "Mon, 25 Jun 2018 13:30:56 +0000")
+	is.Equal(len(f.Channel.Items), 2)
+	is.Equal(f.Channel.Items[1].GUID, "tag:api.example.com,2016:resource/2000")
+	is.Equal(f.Channel.Items[1].PubDate, "Mon, 12 Sep 2016 04:35:22 +0000")
+}
+
+func TestFeedJSON(t *testing.T) {
+	is := is.New(t)
+
+	xb, err := testFeed().JSONFeed()
+	is.NoErr(err)
+
+	var f struct {
+		Version string `json:"version"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			URL         string   `json:"url"`
+			ExternalURL string   `json:"external_url"`
+			Tags        []string `json:"tags"`
+		} `json:"items"`
+	}
+	err = json.Unmarshal(xb, &f)
+	is.NoErr(err) // json feed should unmarshal
+	is.Equal(f.Version, "https://jsonfeed.org/version/1.1")
+	is.Equal(f.FeedURL, "https://api.example.com/v1/resources.atom?category=Cardiology")
+	is.Equal(f.Items[0].URL, "https://csanz.io/r10012")
+	is.Equal(f.Items[0].ExternalURL, "https://doi.org/10.1016/j.resuscitation.2017.08.218")
+	is.Equal(f.Items[0].Tags, []string{"Article (journal)", "Cardiology"})
+	is.Equal(f.Items[1].ExternalURL, "") // no short url so no external url
+}
@@ -0,0 +1,308 @@
+package resource
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxRedirects is the number of redirects followed before a link is reported as broken
+const maxRedirects = 10
+
+// LinkCheck is the result of checking the url of a resource
+type LinkCheck struct {
+	ResourceID  int        `json:"resourceId"`
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	CheckedAt   time.Time  `json:"checkedAt"`
+	StatusCode  int        `json:"statusCode"`
+	Error       string     `json:"error"`
+	Attempts    int        `json:"attempts"`
+	Redirects   []Redirect `json:"redirects"`
+	Failures    int        `json:"failures"`
+	Deactivated bool       `json:"deactivated"`
+}
+
+// Redirect is a single step in a redirect chain
+type Redirect struct {
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location"`
+}
+
+// Broken is true if the url could not be fetched, or the server says it does not exist or failed. Other
+// client errors, such as 401 and 403, are common for journal sites that turn away robots, so those
+// links are not counted as broken.
+func (lc LinkCheck) Broken() bool {
+	return lc.Error != "" || lc.StatusCode == http.StatusNotFound || lc.StatusCode == http.StatusGone ||
+		lc.StatusCode >= 500
+}
+
+// retry is true if the check may succeed if it is tried again
+func (lc LinkCheck) retry() bool {
+	return lc.Error != "" || lc.StatusCode == http.StatusTooManyRequests || lc.StatusCode >= 500
+}
+
+// Checker checks resource urls concurrently. No more than Workers urls are checked at once, and requests
+// to the same host are at least HostDelay apart. Failed checks are retried up to Retries times, waiting
+// a little longer before each retry.
+type Checker struct {
+	Client     *http.Client
+	Workers    int
+	HostDelay  time.Duration
+	Retries    int
+	RetryDelay time.Duration
+	UserAgent  string
+
+	mu    sync.Mutex
+	hosts map[string]time.Time
+}
+
+// NewChecker returns a Checker with sensible defaults
+func NewChecker() *Checker {
+	return &Checker{
+		Client:     &http.Client{Timeout: 30 * time.Second},
+		Workers:    8,
+		HostDelay:  time.Second,
+		Retries:    2,
+		RetryDelay: 5 * time.Second,
+		UserAgent:  "Mozilla/5.0 (compatible; MappCPD link checker)",
+	}
+}
+
+// Check checks the urls of the LinkChecks, and returns them with the results in the same order
+func (c *Checker) Check(xlc []LinkCheck) []LinkCheck {
+
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				c.check(&xlc[j])
+			}
+		}()
+	}
+	for j := range xlc {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	return xlc
+}
+
+// check fetches the url, with retries
+func (c *Checker) check(lc *LinkCheck) {
+	for lc.Attempts = 1; ; lc.Attempts++ {
+		c.fetch(lc)
+		if !lc.retry() || lc.Attempts > c.Retries {
+			return
+		}
+		time.Sleep(c.RetryDelay * time.Duration(lc.Attempts))
+	}
+}
+
+// fetch makes a single request for the url and records the status and the redirects
+func (c *Checker) fetch(lc *LinkCheck) {
+
+	lc.CheckedAt = time.Now()
+	lc.StatusCode = 0
+	lc.Error = ""
+	lc.Redirects = []Redirect{}
+
+	req, err := http.NewRequest("GET", lc.URL, nil)
+	if err != nil {
+		lc.Error = err.Error()
+		return
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	// Copy the client so the redirect policy applies to this check only, and the redirected
+	// requests wait their turn for each host as well
+	client := *c.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		lc.Redirects = append(lc.Redirects, Redirect{req.Response.StatusCode, req.URL.String()})
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		c.wait(req.URL)
+		return nil
+	}
+
+	c.wait(req.URL)
+	res, err := client.Do(req)
+	if err != nil {
+		lc.Error = err.Error()
+		return
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+
+	lc.StatusCode = res.StatusCode
+}
+
+// wait blocks until a request can be made to the host of u
+func (c *Checker) wait(u *url.URL) {
+
+	c.mu.Lock()
+	if c.hosts == nil {
+		c.hosts = map[string]time.Time{}
+	}
+	now := time.Now()
+	next := c.hosts[u.Host]
+	if next.Before(now) {
+		next = now
+	}
+	c.hosts[u.Host] = next.Add(c.HostDelay)
+	c.mu.Unlock()
+
+	time.Sleep(next.Sub(now))
+}
+
+// LinkChecksDue fetches the active primary resources with an external url, those that have not been
+// checked for the longest time first. A limit of 0 fetches all of them.
+func LinkChecksDue(ds datastore.Datastore, limit int) ([]LinkCheck, error) {
+
+	query := "SELECT r.id, r.name, r.resource_url FROM ol_resource r " +
+		"LEFT JOIN ol_resource_link_check lc ON lc.ol_resource_id = r.id " +
+		"WHERE r.active = 1 AND r.`primary` = 1 AND r.resource_url LIKE 'http%' " +
+		"ORDER BY lc.checked_at IS NOT NULL, lc.checked_at, r.id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	var xlc []LinkCheck
+
+	rows, err := ds.MySQL.Session.Query(query)
+	if err != nil {
+		return xlc, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lc := LinkCheck{}
+		if err := rows.Scan(&lc.ResourceID, &lc.Name, &lc.URL); err != nil {
+			return xlc, errors.Wrap(err, "failed to scan resource row")
+		}
+		xlc = append(xlc, lc)
+	}
+
+	return xlc, rows.Err()
+}
+
+// Save records the result of the check, and updates the LastStatusCode in the Links doc for the url,
+// if there is one. Consecutive broken checks are counted, and once there are maxFailures the resource
+// is deactivated. A maxFailures of 0 never deactivates the resource.
+func (lc *LinkCheck) Save(ds datastore.Datastore, maxFailures int) error {
+
+	var failures int
+	err := ds.MySQL.Session.QueryRow(`SELECT failures FROM ol_resource_link_check WHERE ol_resource_id = ?`,
+		lc.ResourceID).Scan(&failures)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.Wrap(err, "LinkCheck.Save()")
+	}
+	lc.Failures = 0
+	if lc.Broken() {
+		lc.Failures = failures + 1
+	}
+	lc.Deactivated = maxFailures > 0 && lc.Failures >= maxFailures
+
+	if len(lc.Error) > 1024 {
+		lc.Error = lc.Error[:1024]
+	}
+	redirects, err := json.Marshal(lc.Redirects)
+	if err != nil {
+		return errors.Wrap(err, "LinkCheck.Save()")
+	}
+
+	query := `INSERT INTO ol_resource_link_check
+	(ol_resource_id, checked_at, url, status_code, error, attempts, redirects, failures, deactivated)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE checked_at = VALUES(checked_at), url = VALUES(url), status_code = VALUES(status_code),
+	error = VALUES(error), attempts = VALUES(attempts), redirects = VALUES(redirects), failures = VALUES(failures),
+	deactivated = VALUES(deactivated)`
+	_, err = ds.MySQL.Session.Exec(query, lc.ResourceID, lc.CheckedAt.Format("2006-01-02 15:04:05"), lc.URL,
+		lc.StatusCode, lc.Error, lc.Attempts, string(redirects), lc.Failures, lc.Deactivated)
+	if err != nil {
+		return errors.Wrap(err, "LinkCheck.Save()")
+	}
+
+	lcol, err := ds.MongoDB.LinksCol()
+	if err != nil {
+		return errors.Wrap(err, "LinkCheck.Save()")
+	}
+	err = lcol.Update(bson.M{"longUrl": lc.URL}, bson.M{"$set": bson.M{"lastStatusCode": lc.StatusCode}})
+	if err != nil && err != mgo.ErrNotFound {
+		return errors.Wrap(err, "LinkCheck.Save()")
+	}
+
+	// updated_at is set so that the change is also picked up by a sync of recent changes
+	if lc.Deactivated {
+		err = outbox.Transact(ds.MySQL.Session, outbox.Resource, outbox.Delete, func(tx *sql.Tx) (int, error) {
+			_, err := tx.Exec(`UPDATE ol_resource SET active = 0, updated_at = NOW() WHERE id = ?`, lc.ResourceID)
+			return lc.ResourceID, err
+		})
+		if err != nil {
+			return errors.Wrap(err, "LinkCheck.Save()")
+		}
+	}
+
+	return nil
+}
+
+// BrokenLinks fetches the resources whose last link check was broken, including those that have been
+// deactivated, most failures first
+func BrokenLinks(ds datastore.Datastore) ([]LinkCheck, error) {
+
+	query := `SELECT lc.ol_resource_id, COALESCE(r.name, ''), lc.url, lc.checked_at, lc.status_code, lc.error,
+	lc.attempts, lc.redirects, lc.failures, lc.deactivated
+	FROM ol_resource_link_check lc
+	LEFT JOIN ol_resource r ON r.id = lc.ol_resource_id
+	WHERE lc.failures > 0
+	ORDER BY lc.failures DESC, lc.checked_at DESC`
+
+	xlc := []LinkCheck{}
+
+	rows, err := ds.MySQL.Session.Query(query)
+	if err != nil {
+		return xlc, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lc := LinkCheck{}
+		var checkedAt, redirects string
+		err := rows.Scan(&lc.ResourceID, &lc.Name, &lc.URL, &checkedAt, &lc.StatusCode, &lc.Error,
+			&lc.Attempts, &redirects, &lc.Failures, &lc.Deactivated)
+		if err != nil {
+			return xlc, errors.Wrap(err, "failed to scan link check row")
+		}
+		lc.CheckedAt, _ = time.Parse("2006-01-02 15:04:05", checkedAt)
+		if err := json.Unmarshal([]byte(redirects), &lc.Redirects); err != nil {
+			return xlc, errors.Wrap(err, "failed to unmarshal redirects")
+		}
+		xlc = append(xlc, lc)
+	}
+
+	return xlc, rows.Err()
+}
@@ -0,0 +1,108 @@
+package resource_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cardiacsociety/web-services/internal/resource"
+	"github.com/matryer/is"
+)
+
+func TestChecker(t *testing.T) {
+	is := is.New(t)
+
+	var mu sync.Mutex
+	flaky := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/found", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/found", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		flaky++
+		if flaky == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := resource.NewChecker()
+	c.Workers = 3
+	c.HostDelay = 0
+	c.RetryDelay = time.Millisecond
+
+	cases := []struct {
+		path      string
+		status    int
+		broken    bool
+		attempts  int
+		redirects int
+	}{
+		{"/ok", 200, false, 1, 0},
+		{"/gone", 410, true, 1, 0},
+		{"/forbidden", 403, false, 1, 0},
+		{"/moved", 200, false, 1, 2},
+		{"/loop", 0, true, 3, 10},
+		{"/flaky", 200, false, 2, 0},
+		{"/down", 500, true, 3, 0},
+		{"/missing", 404, true, 1, 0},
+	}
+	var xlc []resource.LinkCheck
+	for i, c := range cases {
+		xlc = append(xlc, resource.LinkCheck{ResourceID: i + 1, URL: ts.URL + c.path})
+	}
+
+	xlc = c.Check(xlc)
+	for i, c := range cases {
+		lc := xlc[i]
+		is.Equal(lc.ResourceID, i+1)             // results in the same order
+		is.Equal(lc.StatusCode, c.status)        // status
+		is.Equal(lc.Broken(), c.broken)          // broken
+		is.Equal(lc.Attempts, c.attempts)        // attempts, including retries
+		is.Equal(len(lc.Redirects), c.redirects) // redirects followed
+	}
+	is.Equal(xlc[3].Redirects[0], resource.Redirect{StatusCode: 301, Location: ts.URL + "/found"})
+	is.Equal(xlc[3].Redirects[1], resource.Redirect{StatusCode: 302, Location: ts.URL + "/ok"})
+}
+
+func TestCheckerHostDelay(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c := resource.NewChecker()
+	c.Workers = 4
+	c.HostDelay = 50 * time.Millisecond
+
+	xlc := make([]resource.LinkCheck, 4)
+	for i := range xlc {
+		xlc[i].URL = ts.URL
+	}
+
+	start := time.Now()
+	c.Check(xlc)
+	// four requests to the same host must be spaced out, despite the workers
+	is.True(time.Since(start) >= 150*time.Millisecond)
+}
@@ -0,0 +1,128 @@
+package resource
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Memory is an in-memory Repository for unit tests. The resources are the MySQL records, and the docs
+// are kept separately so that a test can check what was saved. Latest lists the docs, as Store does.
+type Memory struct {
+	mu        sync.Mutex
+	resources map[int]Resource
+	Docs      map[int]Resource
+}
+
+// NewMemory returns a Memory holding the resources, each saved as a doc as well
+func NewMemory(xr ...Resource) *Memory {
+	m := &Memory{resources: map[int]Resource{}, Docs: map[int]Resource{}}
+	for _, r := range xr {
+		m.resources[r.ID] = r
+		m.Docs[r.ID] = r
+	}
+	return m
+}
+
+// ByID returns a copy of a resource, or an error with the cause sql.ErrNoRows, as for Store
+func (m *Memory) ByID(id int) (*Resource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.resources[id]
+	if !ok {
+		return nil, errors.Wrap(sql.ErrNoRows, fmt.Sprintf("ByID() could not find record with id %v", id))
+	}
+	return &r, nil
+}
+
+// Latest returns the newest active primary resource docs that match the filter
+func (m *Memory) Latest(f FeedFilter) ([]Resource, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	xr := []Resource{}
+	for _, r := range m.Docs {
+		if f.match(r) {
+			xr = append(xr, r)
+		}
+	}
+	sort.Slice(xr, func(i, j int) bool {
+		if !xr[i].CreatedAt.Equal(xr[j].CreatedAt) {
+			return xr[i].CreatedAt.After(xr[j].CreatedAt)
+		}
+		return xr[i].ID > xr[j].ID
+	})
+	if len(xr) > f.limit() {
+		xr = xr[:f.limit()]
+	}
+	return xr, nil
+}
+
+// SaveDoc saves a copy of the resource to Docs
+func (m *Memory) SaveDoc(r *Resource) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Docs[r.ID] = *r
+	return nil
+}
+
+// RemoveDoc removes the resource from Docs
+func (m *Memory) RemoveDoc(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.Docs[id]
+	delete(m.Docs, id)
+	return ok, nil
+}
+
+// match applies the filter as the LatestResources query does
+func (f FeedFilter) match(r Resource) bool {
+	if !r.Active || !r.Primary {
+		return false
+	}
+	if f.TypeID > 0 && r.TypeID != f.TypeID {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(r.Type, f.Type) {
+		return false
+	}
+	if f.Keyword != "" && !containsFold(r.Keywords, f.Keyword) {
+		return false
+	}
+	if f.Category != "" && !containsFold(attributeStrings(r.Attributes["category"]), f.Category) {
+		return false
+	}
+	return true
+}
+
+// attributeStrings returns the string, or strings, in an attribute value
+func attributeStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var xs []string
+		for _, i := range v {
+			if s, ok := i.(string); ok {
+				xs = append(xs, s)
+			}
+		}
+		return xs
+	}
+	return nil
+}
+
+func containsFold(xs []string, s string) bool {
+	for _, v := range xs {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package resource
+
+import (
+	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+)
+
+// Repository provides the resource records, so that code that needs them can be tested with Memory
+// instead of a database
+type Repository interface {
+	ByID(id int) (*Resource, error)
+	// Latest returns the newest active primary resources, as for the feeds
+	Latest(f FeedFilter) ([]Resource, error)
+	SaveDoc(r *Resource) error
+	RemoveDoc(id int) (bool, error)
+}
+
+var (
+	_ Repository = Store{}
+	_ Repository = (*Memory)(nil)
+)
+
+// Store is the Repository for the MySQL and document databases - resources are read from MySQL, and
+// saved to, and listed from, the document database
+type Store struct {
+	ds datastore.Datastore
+}
+
+// NewStore returns a Repository for the databases in ds
+func NewStore(ds datastore.Datastore) Store {
+	return Store{ds: ds}
+}
+
+// ByID fetches a resource from MySQL
+func (s Store) ByID(id int) (*Resource, error) {
+	return ByID(s.ds, id)
+}
+
+// Latest fetches the newest active primary resources from the Resources collection
+func (s Store) Latest(f FeedFilter) ([]Resource, error) {
+	return LatestResources(s.ds, f)
+}
+
+// SaveDoc upserts the resource doc
+func (s Store) SaveDoc(r *Resource) error {
+	return r.SaveDoc(s.ds)
+}
+
+// RemoveDoc removes the resource doc, and its link doc if no other resource has the url
+func (s Store) RemoveDoc(id int) (bool, error) {
+	return RemoveDoc(s.ds, id)
+}
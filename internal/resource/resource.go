@@ -12,7 +12,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cardiacsociety/web-services/internal/generic"
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 	"github.com/cardiacsociety/web-services/internal/utility"
 	"github.com/pkg/errors"
 	"gopkg.in/mgo.v2"
@@ -415,18 +417,25 @@ func (r *Resource) Add(ds datastore.Datastore) (int, error) {
 		r.Name, r.Description, keywords,
 		r.ResourceURL, r.ShortURL, r.ThumbnailURL, attributes)
 
-	res, err := ds.MySQL.Session.Exec(query)
-	if err != nil {
-		msg := fmt.Sprintf("Error with query: %s\nError: %s", query, err)
-		return 0, errors.New(msg)
-	}
-
-	id, err := res.LastInsertId()
+	var id int
+	err = outbox.Transact(ds.MySQL.Session, outbox.Resource, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		res, err := tx.Exec(query)
+		if err != nil {
+			msg := fmt.Sprintf("Error with query: %s\nError: %s", query, err)
+			return 0, errors.New(msg)
+		}
+		id64, err := res.LastInsertId()
+		if err != nil {
+			msg := fmt.Sprintf("Error fetching last insert id: %s", err)
+			return 0, errors.New(msg)
+		}
+		id = int(id64)
+		return id, nil
+	})
 	if err != nil {
-		msg := fmt.Sprintf("Error fetching last insert id: %s", err)
-		return 0, errors.New(msg)
+		return 0, err
 	}
-	r.ID = int(id)
+	r.ID = id
 	log.Println("Added a new resource with ID", r.ID)
 
 	err = r.SetShortURL(ds)
@@ -462,7 +471,10 @@ func (r *Resource) Update(ds datastore.Datastore, id int) error {
 		r.ResourceURL, r.ShortURL, r.ThumbnailURL,
 		id)
 
-	_, err := ds.MySQL.Session.Exec(query)
+	err := outbox.Transact(ds.MySQL.Session, outbox.Resource, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		_, err := tx.Exec(query)
+		return id, err
+	})
 	if err != nil {
 		fmt.Println("Query error:")
 		return err
@@ -533,7 +545,10 @@ func (r *Resource) SetShortURL(ds datastore.Datastore) error {
 	shortUrl := os.Getenv("MAPPCPD_SHORT_LINK_URL") + "/" + os.Getenv("MAPPCPD_SHORT_LINK_PREFIX") + strconv.Itoa(r.ID)
 	query := fmt.Sprintf("UPDATE ol_resource SET short_url = \"%v\" WHERE id = %v", shortUrl, r.ID)
 	fmt.Println("SetShortLinkURL():", query)
-	_, err = ds.MySQL.Session.Exec(query)
+	err = outbox.Transact(ds.MySQL.Session, outbox.Resource, outbox.Upsert, func(tx *sql.Tx) (int, error) {
+		_, err := tx.Exec(query)
+		return r.ID, err
+	})
 	if err != nil {
 		fmt.Println("SQL error with query: ", query, " -", err)
 		return err
@@ -580,11 +595,104 @@ func ResourceDeepEqual(r, r2 *Resource) bool {
 	return true
 }
 
+// ResourceDiff compares all of the fields in a Resource, as they are saved in the document database, and
+// returns the fields that differ. Unlike ResourceDeepEqual this includes the fields set from the database,
+// such as the timestamps and short url, so it can be used to check a document against the MySQL record.
+func ResourceDiff(want, got *Resource) ([]generic.FieldDiff, error) {
+	return generic.DocDiff(want, got, "_id")
+}
+
+// VerifyDoc compares the resource document with the resource fetched from MySQL, and returns the fields
+// that differ. A missing document, or a document for a resource that has been deleted, is reported as
+// a difference in the id field.
+func VerifyDoc(ds datastore.Datastore, id int) ([]generic.FieldDiff, error) {
+
+	want, err := ByID(ds, id)
+	if errors.Cause(err) == sql.ErrNoRows {
+		want, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	got, err := DocResourcesOne(ds, bson.M{"id": id})
+	if err == mgo.ErrNotFound {
+		if want == nil {
+			return nil, nil
+		}
+		return []generic.FieldDiff{{Field: "id", Want: id}}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "VerifyDoc")
+	}
+	if want == nil {
+		return []generic.FieldDiff{{Field: "id", Got: id}}, nil
+	}
+
+	return ResourceDiff(want, &got)
+}
+
 // Sync saves the Resource to the document database.
 func (r *Resource) Sync(ds datastore.Datastore) error {
 	return r.SaveDoc(ds)
 }
 
+// SyncByID fetches the resource from MySQL and saves it to the document database. Inactive resources
+// are saved with active false, so that they are no longer listed. If the resource has been deleted the
+// document is removed, and removed is true.
+func SyncByID(ds datastore.Datastore, id int) (removed bool, err error) {
+	r, err := ByID(ds, id)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return RemoveDoc(ds, id)
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, r.Sync(ds)
+}
+
+// RemoveDoc removes the resource document, and the Links document for its url if no other resource has
+// the same url. Removed is false if there was no document.
+func RemoveDoc(ds datastore.Datastore, id int) (removed bool, err error) {
+
+	rc, err := ds.MongoDB.ResourcesCollection()
+	if err != nil {
+		return false, fmt.Errorf("resource.RemoveDoc() err = %s", err)
+	}
+	var xr []Resource
+	err = rc.Find(bson.M{"id": id}).All(&xr)
+	if err != nil || len(xr) == 0 {
+		return false, err
+	}
+	if _, err := rc.RemoveAll(bson.M{"id": id}); err != nil {
+		return false, fmt.Errorf("resource.RemoveDoc() err = %s", err)
+	}
+
+	for _, r := range xr {
+		if r.ResourceURL == "" {
+			continue
+		}
+		var n int
+		err := ds.MySQL.Session.QueryRow(`SELECT COUNT(*) FROM ol_resource WHERE resource_url = ?`,
+			r.ResourceURL).Scan(&n)
+		if err != nil {
+			return true, fmt.Errorf("resource.RemoveDoc() err = %s", err)
+		}
+		if n > 0 {
+			continue
+		}
+		lc, err := ds.MongoDB.LinksCol()
+		if err != nil {
+			return true, fmt.Errorf("resource.RemoveDoc() err = %s", err)
+		}
+		if _, err := lc.RemoveAll(bson.M{"longUrl": r.ResourceURL}); err != nil {
+			return true, fmt.Errorf("resource.RemoveDoc() err = %s", err)
+		}
+	}
+
+	return true, nil
+}
+
 // SaveDoc upserts Resource doc to MongoDB
 func (r *Resource) SaveDoc(ds datastore.Datastore) error {
 
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/outbox"
 	"github.com/cardiacsociety/web-services/internal/resource"
 	"github.com/cardiacsociety/web-services/testdata"
 	"github.com/matryer/is"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -31,6 +33,13 @@ func TestResource(t *testing.T) {
 		t.Run("testSyncResource", testSyncResource)
 		t.Run("testSaveNewResource", testSaveNewResource)
 		t.Run("testSaveExistingResource", testSaveExistingResource)
+		t.Run("testClicks", testClicks)
+		t.Run("testLinkCheck", testLinkCheck)
+		t.Run("testChangeEvents", testChangeEvents)
+		t.Run("testCitation", testCitation)
+		t.Run("testLatestResources", testLatestResources)
+		t.Run("testSyncByID", testSyncByID)
+		t.Run("testVerifyDoc", testVerifyDoc)
 	})
 }
 
@@ -299,7 +308,7 @@ func testSaveExistingResource(t *testing.T) {
 	}
 
 	// fetch updated resource
-	r2, err := resource.ByID(ds, id) 
+	r2, err := resource.ByID(ds, id)
 	if err != nil {
 		t.Fatalf("resource.ByID(%d) err = %s", id, err)
 	}
@@ -309,3 +318,237 @@ func testSaveExistingResource(t *testing.T) {
 		t.Errorf("Resource.Name = %q, want %q", gotName, wantName)
 	}
 }
+
+func testClicks(t *testing.T) {
+	is := is.New(t)
+
+	l := resource.Link{
+		ShortUrl: "r6577",
+		LongUrl:  "https://doi.org/10.1136/archdischild-2017-312901",
+		Title:    "NETSstudy",
+	}
+	err := l.DocSave(ds)
+	is.NoErr(err) // error saving link doc
+
+	l, err = resource.LinkByShortPath(ds, "r6577")
+	is.NoErr(err) // error fetching link doc
+	_, err = resource.LinkByShortPath(ds, "r1")
+	is.Equal(err, mgo.ErrNotFound) // expected not found for unknown short path
+
+	id, active, err := l.Resource(ds)
+	is.NoErr(err)      // error fetching link resource
+	is.Equal(id, 6577) // link resource id
+	is.True(active)    // link resource should be active
+
+	t1 := time.Date(2018, 3, 1, 9, 15, 0, 0, time.UTC)
+	t2 := time.Date(2018, 3, 2, 14, 5, 0, 0, time.UTC)
+	clicks := []struct {
+		referrer string
+		at       time.Time
+	}{
+		{"https://www.google.com/search?q=netsstudy", t1},
+		{"https://www.google.com/", t1.Add(30 * time.Minute)},
+		{"", t1},
+		{"https://twitter.com/csanz", t2},
+	}
+	for _, c := range clicks {
+		err := l.Click(ds, id, c.referrer, c.at)
+		is.NoErr(err) // error recording click
+	}
+	is.Equal(l.Clicks, 4) // clicks on link value
+
+	l, err = resource.LinkByShortPath(ds, "r6577")
+	is.NoErr(err)
+	is.Equal(l.Clicks, 4) // clicks in link doc
+
+	cs, err := resource.Clicks(ds, 6577, time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 3, 2, 0, 0, 0, 0, time.UTC))
+	is.NoErr(err)              // error fetching click stats
+	is.Equal(cs.Total, 4)      // total clicks
+	is.Equal(cs.Clicks, 4)     // clicks in period
+	is.Equal(len(cs.ByDay), 2) // days with clicks
+	is.Equal(cs.ByDay[0], resource.ClickCount{Key: "2018-03-01", Clicks: 3})
+	is.Equal(cs.ByHour[0], resource.ClickCount{Key: "09", Clicks: 3})
+	is.Equal(cs.ByReferrer[0], resource.ClickCount{Key: "www.google.com", Clicks: 2})
+	is.Equal(len(cs.ByReferrer), 3) // referrers, including direct
+
+	cs, err = resource.Clicks(ds, 6577, t1, t1)
+	is.NoErr(err)
+	is.Equal(cs.Clicks, 3) // clicks on the first day only
+
+	_, err = ds.MySQL.Session.Exec("UPDATE ol_resource SET active = 0 WHERE id = 6577")
+	is.NoErr(err)
+	_, active, err = l.Resource(ds)
+	is.NoErr(err)
+	is.True(!active) // link resource should be inactive
+}
+
+func testLinkCheck(t *testing.T) {
+	is := is.New(t)
+
+	xlc, err := resource.LinkChecksDue(ds, 0)
+	is.NoErr(err) // error fetching links due
+	due := len(xlc)
+	is.True(due > 0) // expected some links to check
+
+	// a link that keeps failing is deactivated after the second failure
+	lc := resource.LinkCheck{
+		ResourceID: 6576,
+		URL:        "https://doi.org/10.1053/j.gastro.2017.08.022",
+		CheckedAt:  time.Now(),
+		StatusCode: 404,
+		Attempts:   1,
+		Redirects:  []resource.Redirect{{StatusCode: 301, Location: "https://www.gastrojournal.org/article"}},
+	}
+	err = lc.Save(ds, 2)
+	is.NoErr(err)            // error saving first check
+	is.Equal(lc.Failures, 1) // failures after first check
+	is.True(!lc.Deactivated) // should not be deactivated after one failure
+
+	xlc, err = resource.LinkChecksDue(ds, 0)
+	is.NoErr(err)
+	is.Equal(xlc[len(xlc)-1].ResourceID, 6576) // most recently checked is last
+
+	err = lc.Save(ds, 2)
+	is.NoErr(err)            // error saving second check
+	is.Equal(lc.Failures, 2) // failures after second check
+	is.True(lc.Deactivated)  // should be deactivated after two failures
+
+	r, err := resource.ByID(ds, 6576)
+	is.NoErr(err)
+	is.True(!r.Active) // resource should be inactive
+
+	xlc, err = resource.BrokenLinks(ds)
+	is.NoErr(err)
+	is.Equal(len(xlc), 1) // broken links
+	is.Equal(xlc[0].ResourceID, 6576)
+	is.Equal(xlc[0].Redirects, lc.Redirects) // redirect chain
+	is.True(xlc[0].Deactivated)
+
+	// a link that works again is no longer broken
+	lc.StatusCode = 200
+	err = lc.Save(ds, 2)
+	is.NoErr(err)
+	is.Equal(lc.Failures, 0) // failures reset
+	xlc, err = resource.BrokenLinks(ds)
+	is.NoErr(err)
+	is.Equal(len(xlc), 0) // broken links after fix
+}
+
+func testCitation(t *testing.T) {
+	is := is.New(t)
+
+	r, err := resource.ByID(ds, 6576)
+	is.NoErr(err)
+	c := r.Citation()
+	is.True(c.Article)
+	is.Equal(c.Journal, "Gastroenterology")
+	is.Equal(c.Volume, "153")
+	is.Equal(c.Pages, "1621-1633.e6")
+	is.Equal(c.DOI, "10.1053/j.gastro.2017.08.022")
+	is.Equal(c.Year, 2017)
+	is.Equal(len(c.Authors), 3) // authors from attributes
+}
+
+func testLatestResources(t *testing.T) {
+	is := is.New(t)
+
+	xr, err := resource.LatestResources(ds, resource.FeedFilter{Limit: 1})
+	is.NoErr(err)
+	is.Equal(len(xr), 1)      // limit
+	is.Equal(xr[0].ID, 24967) // newest resource
+
+	xr, err = resource.LatestResources(ds, resource.FeedFilter{Type: "video (WEB)"})
+	is.NoErr(err)
+	is.Equal(len(xr), 2) // type name ignores case
+
+	xr, err = resource.LatestResources(ds, resource.FeedFilter{TypeID: 60, Keyword: "pd2018"})
+	is.NoErr(err)
+	is.Equal(len(xr), 1) // type id and keyword
+	is.Equal(xr[0].ID, 24967)
+
+	xr, err = resource.LatestResources(ds, resource.FeedFilter{Category: "Cardiology"})
+	is.NoErr(err)
+	is.Equal(len(xr), 0) // no resources in the category
+}
+
+// testChangeEvents checks the events added by the earlier saves, and reading them with a consumer
+func testChangeEvents(t *testing.T) {
+	is := is.New(t)
+
+	xe, err := outbox.After(ds, 0, 100, outbox.Resource)
+	is.NoErr(err) // error fetching events
+	actions := map[int]string{}
+	for _, e := range outbox.Collapse(xe) {
+		actions[e.AggregateID] = e.Action
+	}
+	is.Equal(actions[6578], outbox.Upsert) // updated resource
+	is.Equal(actions[6576], outbox.Delete) // resource deactivated by the link check
+
+	c := outbox.Consumer{Name: "resource_test", Aggregates: []string{outbox.Resource}}
+	var got []outbox.Event
+	n, err := c.Next(ds, func(xe []outbox.Event) error {
+		got = xe
+		return nil
+	})
+	is.NoErr(err)                    // error reading events
+	is.Equal(n, len(xe))             // all of the events read
+	is.Equal(len(got), len(actions)) // one event per resource
+
+	n, err = c.Next(ds, func(xe []outbox.Event) error { return nil })
+	is.NoErr(err)  // error reading events
+	is.Equal(n, 0) // checkpoint should be after the last event
+}
+
+// testSyncByID syncs a resource, and removes the doc and link for a resource that is not in MySQL
+func testSyncByID(t *testing.T) {
+	is := is.New(t)
+
+	removed, err := resource.SyncByID(ds, 6578)
+	is.NoErr(err)            // error syncing resource
+	is.Equal(removed, false) // resource exists
+	_, err = resource.DocResourcesOne(ds, bson.M{"id": 6578})
+	is.NoErr(err) // doc should exist
+
+	// resource 10012 is in the test docs only
+	url := "https://doi.org/10.1016/j.resuscitation.2017.08.218"
+	l := resource.Link{ShortUrl: "r10012", LongUrl: url}
+	is.NoErr(l.DocSave(ds)) // error saving link doc
+
+	removed, err = resource.SyncByID(ds, 10012)
+	is.NoErr(err)    // error syncing deleted resource
+	is.True(removed) // doc should be removed
+	_, err = resource.DocResourcesOne(ds, bson.M{"id": 10012})
+	is.Equal(err, mgo.ErrNotFound) // doc should be gone
+	_, err = resource.DocLinksOne(ds, bson.M{"longUrl": url})
+	is.Equal(err, mgo.ErrNotFound) // link doc should be gone
+
+	removed, err = resource.SyncByID(ds, 10012)
+	is.NoErr(err)            // error syncing deleted resource
+	is.Equal(removed, false) // nothing left to remove
+}
+
+// testVerifyDoc checks a synced doc, a doc that has been changed and a doc with no MySQL record
+func testVerifyDoc(t *testing.T) {
+	is := is.New(t)
+
+	xd, err := resource.VerifyDoc(ds, 6578)
+	is.NoErr(err)        // error verifying resource
+	is.Equal(len(xd), 0) // doc was synced by testSyncByID
+
+	rc, err := ds.MongoDB.ResourcesCollection()
+	is.NoErr(err) // error getting resources collection
+	err = rc.Update(bson.M{"id": 6578}, bson.M{"$set": bson.M{"name": "Out of date"}})
+	is.NoErr(err) // error updating doc
+	xd, err = resource.VerifyDoc(ds, 6578)
+	is.NoErr(err)                      // error verifying resource
+	is.Equal(len(xd), 1)               // one field differs
+	is.Equal(xd[0].Field, "name")      // name differs
+	is.Equal(xd[0].Got, "Out of date") // doc value
+
+	// resource 10003 is in the test docs only
+	xd, err = resource.VerifyDoc(ds, 10003)
+	is.NoErr(err)               // error verifying resource
+	is.Equal(len(xd), 1)        // doc should not exist
+	is.Equal(xd[0].Field, "id") // reported as the id
+	is.Equal(xd[0].Want, nil)   // no MySQL record
+}
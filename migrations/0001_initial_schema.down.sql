@@ -0,0 +1,2 @@
+-- The initial schema is the baseline for every database, and dropping it would remove all of the data,
+-- so it is not reversed. migrate.Down will not go below version 1.
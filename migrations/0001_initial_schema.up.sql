@@ -1,5 +1,7 @@
--- name: create-table-ad_user
-CREATE TABLE IF NOT EXISTS `%s`.`ad_user` (
+-- The schema before migrations were introduced. The tables are created if they do not exist, so this
+-- migration can be applied to an existing database to start tracking its migrations.
+
+CREATE TABLE IF NOT EXISTS `ad_user` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `acl_admin_role_id` INT NOT NULL COMMENT 'The role (permissions group) into which this admin user is assigned.',
   `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -18,9 +20,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ad_user` (
   ENGINE = InnoDB
   COMMENT = 'Admin user records';
 
-
--- name: create-table-ad_user_permission
-CREATE TABLE IF NOT EXISTS `%s`.`ad_user_permission` (
+CREATE TABLE IF NOT EXISTS `ad_user_permission` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ad_user_id` INT NOT NULL COMMENT 'The id of the admin user that has been granted the permission.',
   `ad_permission_id` INT NOT NULL COMMENT 'The id of the permission that has been granted',
@@ -31,9 +31,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ad_user_permission` (
   ENGINE = InnoDB
   COMMENT = 'Association between admin user and a permission, that is, stores the granting of permissions to specific admin users.';
 
-
--- name: create-table-ce_activity
-CREATE TABLE IF NOT EXISTS `%s`.`ce_activity` (
+CREATE TABLE IF NOT EXISTS `ce_activity` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ce_activity_unit_id` INT NOT NULL COMMENT 'The unit of measurement for the activity type.',
   `ce_activity_category_id` INT NOT NULL,
@@ -50,9 +48,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_activity` (
   ENGINE = InnoDB
   COMMENT = 'Defines the various CPD activities or categories of activity, that members can undertake in order to satisfy their CPD requirements.';
 
-
--- name: create-table-log_data_action
-CREATE TABLE IF NOT EXISTS `%s`.`log_data_action` (
+CREATE TABLE IF NOT EXISTS `log_data_action` (
   `id` INT(10) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `log_data_table_id` INT(10) NOT NULL,
   `record_id` INT NOT NULL COMMENT 'The id of the record that was changed, in the table identified by log_data_table_id.',
@@ -68,9 +64,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`log_data_action` (
   ENGINE = InnoDB
   COMMENT = 'Logs various system events and data changes over time.';
 
-
--- name: create-table-member
-CREATE TABLE IF NOT EXISTS `%s`.`member` (
+CREATE TABLE IF NOT EXISTS `member` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `acl_member_role_id` INT NOT NULL COMMENT 'The ACL role / group to which this member has been assigned.',
   `a_name_prefix_id` INT NOT NULL COMMENT 'Name prefix - eg Dr, Mr etc.',
@@ -104,9 +98,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`member` (
   ENGINE = InnoDB
   COMMENT = 'Member is the central entity of the system and stores basic information about the person (member) including details to login.';
 
-
--- name: create-table-ce_m_activity
-CREATE TABLE IF NOT EXISTS `%s`.`ce_m_activity` (
+CREATE TABLE IF NOT EXISTS `ce_m_activity` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who completed the activity.',
   `ce_activity_id` INT NOT NULL COMMENT 'The activity (type) that was undertaken.',
@@ -126,9 +118,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_m_activity` (
   ENGINE = InnoDB
   COMMENT = 'A record of a particular CPD activity undertaken by a member.';
 
-
--- name: create-table-ms_m_title
-CREATE TABLE IF NOT EXISTS `%s`.`ms_m_title` (
+CREATE TABLE IF NOT EXISTS `ms_m_title` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'Link the member',
   `ms_title_id` INT NOT NULL COMMENT 'Link to the membership (title)',
@@ -143,9 +133,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_m_title` (
   ENGINE = InnoDB
   COMMENT = 'Membership title record history for a member.';
 
-
--- name: create-table-ad_permission
-CREATE TABLE IF NOT EXISTS `%s`.`ad_permission` (
+CREATE TABLE IF NOT EXISTS `ad_permission` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL COMMENT 'Record created',
@@ -157,9 +145,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ad_permission` (
   AUTO_INCREMENT = 6
   COMMENT = 'Stores a list of the various permissions that may be granted to admin users.';
 
-
--- name: create-table-organisation_type
-CREATE TABLE IF NOT EXISTS `%s`.`organisation_type` (
+CREATE TABLE IF NOT EXISTS `organisation_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -170,9 +156,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`organisation_type` (
   ENGINE = InnoDB
   COMMENT = 'Defines types of organisations such as Universities, Colleges, etc etc.';
 
-
--- name: create-table-mp_m_qualification
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_qualification` (
+CREATE TABLE IF NOT EXISTS `mp_m_qualification` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique Identifier',
   `member_id` INT NOT NULL COMMENT 'The member',
   `mp_qualification_id` INT NOT NULL COMMENT 'The qualification',
@@ -187,9 +171,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_qualification` (
   ENGINE = InnoDB
   COMMENT = 'Stores a qualification that has been obtained by a member.';
 
-
--- name: create-table-mp_qualification
-CREATE TABLE IF NOT EXISTS `%s`.`mp_qualification` (
+CREATE TABLE IF NOT EXISTS `mp_qualification` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `organisation_id` INT NOT NULL DEFAULT 0 COMMENT 'Optional link to the Organisation (qualification provider). If 0 then this qualification is general and may come from more than one organisation. Eg. B Sc.',
   `active` TINYINT NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -203,9 +185,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_qualification` (
   ENGINE = InnoDB
   COMMENT = 'Store a list of recognised qualifications that can be assigned to members. Each qualification may optionally be linked to a specific organisation.';
 
-
--- name: create-table-ce_m_evaluation
-CREATE TABLE IF NOT EXISTS `%s`.`ce_m_evaluation` (
+CREATE TABLE IF NOT EXISTS `ce_m_evaluation` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member',
   `ce_evaluation_id` INT NOT NULL COMMENT 'The evaluation period \'type\'',
@@ -221,9 +201,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_m_evaluation` (
   ENGINE = InnoDB
   COMMENT = 'Defines an evaluation period for an individual member. An evaluation period is an arbitrary stretch of time over which the member cpd activity will be assessed.';
 
-
--- name: create-table-organisation
-CREATE TABLE IF NOT EXISTS `%s`.`organisation` (
+CREATE TABLE IF NOT EXISTS `organisation` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'If present, denotes that the record is a group within an existing organisation.',
   `parent_organisation_id` INT NULL DEFAULT NULL,
   `organisation_type_id` INT NOT NULL COMMENT 'Further classifies the organisation type. Eg University, Government Authority etc.',
@@ -248,9 +226,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`organisation` (
   ENGINE = InnoDB
   COMMENT = 'Organisation table stores a list of any type or organisation that may be relevant to the system. This might include Universities, committees, industry or government bodies. \nIt is simply for the purposes of associating various profile attributes with a relevant body.';
 
-
--- name: create-table-ce_evaluation
-CREATE TABLE IF NOT EXISTS `%s`.`ce_evaluation` (
+CREATE TABLE IF NOT EXISTS `ce_evaluation` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -268,9 +244,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_evaluation` (
   ENGINE = InnoDB
   COMMENT = 'Defines a standard evaluation period type which may have a pre-defined length, points requirements etc.\n';
 
-
--- name: create-table-wf_issue_type
-CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_type` (
+CREATE TABLE IF NOT EXISTS `wf_issue_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `wf_issue_category_id` INT UNSIGNED NOT NULL DEFAULT 1 COMMENT 'The category of the issue - simply for grouping and display purposes..',
   `acl_member_role_id` INT NULL COMMENT 'If present this value indicates that an active issue of this type should impose restrictions on the member as though they belonged to this member_role group. If multiple issues are active then the system will take the intersection of all the role restrictions and impose them on the member after they login. This does not change the members actual assigned role and will revert once the issues are resolved.',
@@ -290,9 +264,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_type` (
   AUTO_INCREMENT = 10000
   COMMENT = 'Defines the various types of administrative issues, both system and admin types.';
 
-
--- name: create-table-wf_issue
-CREATE TABLE IF NOT EXISTS `%s`.`wf_issue` (
+CREATE TABLE IF NOT EXISTS `wf_issue` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `wf_issue_type_id` INT NOT NULL COMMENT 'The issue type.',
   `ad_user_id_created` INT NULL COMMENT 'Admin user who created the issue, NULL will be a system issue.',
@@ -310,9 +282,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_issue` (
   ENGINE = InnoDB
   COMMENT = 'An instance of an issue that MAY relate to a member. If member_id is 0 the issue is defines as global in context.';
 
-
--- name: create-table-ce_audit
-CREATE TABLE IF NOT EXISTS `%s`.`ce_audit` (
+CREATE TABLE IF NOT EXISTS `ce_audit` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ce_m_evaluation_id` INT NOT NULL COMMENT 'The evaluation period that is subject to audit audited.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
@@ -326,9 +296,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_audit` (
   ENGINE = InnoDB
   COMMENT = 'An audit record defines an evaluation period for which all of the claimed CPD activity will be verified by an admin user.\n';
 
-
--- name: create-table-ce_audit_m_activity
-CREATE TABLE IF NOT EXISTS `%s`.`ce_audit_m_activity` (
+CREATE TABLE IF NOT EXISTS `ce_audit_m_activity` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ce_audit_id` INT NOT NULL COMMENT 'The audit that this activity is part of. Note: This reference may be redundant but is probably here for convenience.',
   `ce_m_activity_id` INT NOT NULL COMMENT 'The member cps activity record to be verified.',
@@ -341,9 +309,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_audit_m_activity` (
   ENGINE = InnoDB
   COMMENT = 'This table stores a reference to all of the activity records that fall within the date range of the evaluation period being subject to audit. Each CPD activity will be verified by checking supporting evidence.';
 
-
--- name: create-table-fn_inventory
-CREATE TABLE IF NOT EXISTS `%s`.`fn_inventory` (
+CREATE TABLE IF NOT EXISTS `fn_inventory` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -357,9 +323,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_inventory` (
   ENGINE = InnoDB
   COMMENT = 'Common line items that may appear on invoices. Defaults are set here but can be overridden in the actual invoice.';
 
-
--- name: create-table-fn_m_invoice
-CREATE TABLE IF NOT EXISTS `%s`.`fn_m_invoice` (
+CREATE TABLE IF NOT EXISTS `fn_m_invoice` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier, will also be the invoice number so will need to start from a value that exceeds the current highest issued invoices from previous systems.',
   `member_id` INT NOT NULL COMMENT 'The member to whom the invoice has been issued.',
   `fn_subscription_id` INT(11) NULL COMMENT 'The original subscription that the invoice was generated from. The invoice will come from the fn_m_subscription record tied to the member however this may change over time so we keep a record of the original subscription.',
@@ -380,9 +344,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_m_invoice` (
   ENGINE = InnoDB
   COMMENT = 'Invoices issued to members. An invoice is always issued to a member, but the payments may be received from organisations.';
 
-
--- name: create-table-fn_invoice_payment
-CREATE TABLE IF NOT EXISTS `%s`.`fn_invoice_payment` (
+CREATE TABLE IF NOT EXISTS `fn_invoice_payment` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fn_m_invoice_id` INT NOT NULL COMMENT 'The invoice to which the payment has been allocated.',
   `fn_payment_id` INT NOT NULL COMMENT 'The payment record from which the allocation has been deducted.',
@@ -397,9 +359,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_invoice_payment` (
   ENGINE = InnoDB
   COMMENT = 'Records the allocation of an amount of a payment (all or part) to an invoice.';
 
-
--- name: create-table-fn_m_subscription
-CREATE TABLE IF NOT EXISTS `%s`.`fn_m_subscription` (
+CREATE TABLE IF NOT EXISTS `fn_m_subscription` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who owns this subscription.',
   `fn_subscription_id` INT(11) NOT NULL COMMENT 'The subscription template',
@@ -416,9 +376,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_m_subscription` (
   ENGINE = InnoDB
   COMMENT = 'A members subscription.';
 
-
--- name: create-table-fn_payment
-CREATE TABLE IF NOT EXISTS `%s`.`fn_payment` (
+CREATE TABLE IF NOT EXISTS `fn_payment` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fn_payment_type_id` INT NOT NULL COMMENT 'Specifies the type of payment',
   `member_id` INT NOT NULL COMMENT 'Set if the payment was received from a member, else 0.',
@@ -437,9 +395,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_payment` (
   ENGINE = InnoDB
   COMMENT = 'Records the receipt of a sum of money from a member OR an organisation. Thus this table has no _m_ in its name as payments may optionally be specified as being from an organisation. Payments are made and must be allocated against one or more invoices.';
 
-
--- name: create-table-wf_note
-CREATE TABLE IF NOT EXISTS `%s`.`wf_note` (
+CREATE TABLE IF NOT EXISTS `wf_note` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `wf_note_type_id` INT NOT NULL COMMENT 'The type of note.',
   `ad_user_id_created` INT NULL COMMENT 'Admin user who created the note. NULL values were allowed for import of historic data but new records will be set to logged in user.',
@@ -453,9 +409,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_note` (
   ENGINE = InnoDB
   COMMENT = 'Stores administrative notes which may be linked to a member record, or linked to an issue record that is global. That is, an Issue record that does not have a link to a member. \n\nIf the note is linked to a member the member_id field will be filled. The note may also be associated with an issue or an application record, in which case it should STILL have a member_id value.\n\nThe only time the member_id is 0 is when the note is linked to a gloabl Issue, which itself does not have a member_id.\n';
 
-
--- name: create-table-mp_m_accreditation
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_accreditation` (
+CREATE TABLE IF NOT EXISTS `mp_m_accreditation` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who has attained this accreditation.',
   `mp_accreditation_id` INT NOT NULL COMMENT 'The accredittion that is held by the member.',
@@ -471,9 +425,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_accreditation` (
   ENGINE = InnoDB
   COMMENT = 'Accreditations held by individual members. Maintained as a history table.';
 
-
--- name: create-table-mp_accreditation
-CREATE TABLE IF NOT EXISTS `%s`.`mp_accreditation` (
+CREATE TABLE IF NOT EXISTS `mp_accreditation` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `organisation_id` INT NOT NULL COMMENT 'The organisation that is tasked with granting a particular accreditation.',
   `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -487,9 +439,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_accreditation` (
   ENGINE = InnoDB
   COMMENT = 'Accreditations are industry-specific qualifications or acknowledgements, generally with limited tenure and with very specify scope. Otherwise they are identical in most respects to qualifications. This table defines the types of accreditations.';
 
-
--- name: create-table-mp_m_position
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_position` (
+CREATE TABLE IF NOT EXISTS `mp_m_position` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who holds this position.',
   `mp_position_id` INT NOT NULL COMMENT 'The position held by the member.',
@@ -505,9 +455,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_position` (
   ENGINE = InnoDB
   COMMENT = 'Position help by individual members. A member may hold multiple positions, with multiple organisations, but not the same position with same organisation (need to define a unique index here).';
 
-
--- name: create-table-ms_m_status
-CREATE TABLE IF NOT EXISTS `%s`.`ms_m_status` (
+CREATE TABLE IF NOT EXISTS `ms_m_status` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'Link to member',
   `ms_status_id` INT NOT NULL COMMENT 'Link to status',
@@ -522,9 +470,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_m_status` (
   COMMENT = 'The membership status history of a member.'
   PACK_KEYS = Default;
 
-
--- name: create-table-ms_title
-CREATE TABLE IF NOT EXISTS `%s`.`ms_title` (
+CREATE TABLE IF NOT EXISTS `ms_title` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ms_subscription_id_default` INT NOT NULL COMMENT 'Default subscription template for this membership.',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -541,9 +487,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_title` (
   ENGINE = InnoDB
   COMMENT = 'Defines the Membership names or titles as defined by the organisation. Eg Associate, Fellow, Non-member etc. In combination with status table also defined some privileges for the member within the system.';
 
-
--- name: create-table-mp_m_contact
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_contact` (
+CREATE TABLE IF NOT EXISTS `mp_m_contact` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'Member that owns the contact card.',
   `mp_contact_type_id` INT NOT NULL COMMENT 'Type of contact card',
@@ -567,9 +511,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_contact` (
   ENGINE = InnoDB
   COMMENT = 'Stores an individual contact card type for a member.';
 
-
--- name: create-table-mp_contact_type
-CREATE TABLE IF NOT EXISTS `%s`.`mp_contact_type` (
+CREATE TABLE IF NOT EXISTS `mp_contact_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `persistent` TINYINT NOT NULL COMMENT 'If set to \'1\' this card may NOT be deleted. That is, all member records should have a card of this type, even if all the fields are empty.',
@@ -584,9 +526,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_contact_type` (
   ENGINE = InnoDB
   COMMENT = 'Defines the type of contact card e.g. Primary, Courier, Home etc.';
 
-
--- name: create-table-mp_position
-CREATE TABLE IF NOT EXISTS `%s`.`mp_position` (
+CREATE TABLE IF NOT EXISTS `mp_position` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `organisation_id` INT NOT NULL COMMENT 'Optional link to the Organisation if the position is unique to an organisation.',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -600,9 +540,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_position` (
   ENGINE = InnoDB
   COMMENT = 'Stores all the possible positions that a member may hold in various organisations. Eg President, Chair, Member, etc.';
 
-
--- name: create-table-ms_m_application
-CREATE TABLE IF NOT EXISTS `%s`.`ms_m_application` (
+CREATE TABLE IF NOT EXISTS `ms_m_application` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'Link to the member who submitted the application',
   `member_id_nominator` INT NULL COMMENT 'The Member who nominated this member\'s application… allowed NULL to migrate old data ',
@@ -619,9 +557,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_m_application` (
   ENGINE = InnoDB
   COMMENT = 'Stores records of applications from members to attain a specific membership (title).';
 
-
--- name: create-table-a_meeting
-CREATE TABLE IF NOT EXISTS `%s`.`a_meeting` (
+CREATE TABLE IF NOT EXISTS `a_meeting` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `a_meeting_type_id` INT NOT NULL COMMENT 'The meeting type.',
   `active` TINYINT(4) NOT NULL DEFAULT '1' COMMENT 'Soft delete.',
@@ -636,9 +572,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`a_meeting` (
   ENGINE = InnoDB
   COMMENT = 'Stored general info about board meetings which are linked to';
 
-
--- name: create-table-wf_attachment
-CREATE TABLE IF NOT EXISTS `%s`.`wf_attachment` (
+CREATE TABLE IF NOT EXISTS `wf_attachment` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `wf_note_id` INT NOT NULL COMMENT 'The note that this file is attached to / with.',
   `ad_user_id` INT NOT NULL COMMENT 'The admin user who added the file.',
@@ -651,9 +585,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_attachment` (
   ENGINE = InnoDB
   COMMENT = 'Documents table provides a way to attach files to notes. A document is always attached via a note and, therefore, can only be associated with member records. As notes can be associated with applications and issues, documents can also be associated with these entities.';
 
-
--- name: create-table-ms_status
-CREATE TABLE IF NOT EXISTS `%s`.`ms_status` (
+CREATE TABLE IF NOT EXISTS `ms_status` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `system` TINYINT NOT NULL DEFAULT 0 COMMENT 'System flag prevents the record from being editable via the application. If set to 1 we will ensure it does not get modified.',
@@ -671,9 +603,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_status` (
   AUTO_INCREMENT = 10000
   COMMENT = 'Status table describes the membership or members status and, in combination with the membership title, various attributes of the membership.';
 
-
--- name: create-table-fn_invoice_inventory
-CREATE TABLE IF NOT EXISTS `%s`.`fn_invoice_inventory` (
+CREATE TABLE IF NOT EXISTS `fn_invoice_inventory` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fn_m_invoice_id` INT NOT NULL COMMENT 'The invoice on which this line item appears.',
   `fn_inventory_id` INT NOT NULL COMMENT 'The inventory record represented by this line item.',
@@ -691,9 +621,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_invoice_inventory` (
   ENGINE = InnoDB
   COMMENT = 'Defines the line items that appear on an invoice. These will generally come from the inventory table. ';
 
-
--- name: create-table-ol_module
-CREATE TABLE IF NOT EXISTS `%s`.`ol_module` (
+CREATE TABLE IF NOT EXISTS `ol_module` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_module_id_original` INT NOT NULL COMMENT 'References the original module id and is used for versioning. When a new module is created with field will be set to the current id.  All subsequent revisions of a module will refer to a single original, and the version number will be used to determine their order. (Could just use created_at really?)',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -717,9 +645,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_module` (
   ENGINE = InnoDB
   COMMENT = 'A Module is an individual learning unit comprising one or more media resources and an optional set of questions. A module may be assigned to one or more relevant subtopics.';
 
-
--- name: create-table-ol_m_module
-CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module` (
+CREATE TABLE IF NOT EXISTS `ol_m_module` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who has undertaken the module',
   `ol_module_id` INT NOT NULL COMMENT 'The module being attempted',
@@ -735,9 +661,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module` (
   ENGINE = InnoDB
   COMMENT = 'A learning module undertaken by a member.';
 
-
--- name: create-table-ol_module_resource
-CREATE TABLE IF NOT EXISTS `%s`.`ol_module_resource` (
+CREATE TABLE IF NOT EXISTS `ol_module_resource` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_module_id` INT NOT NULL COMMENT 'The module...',
   `ol_resource_id` INT NOT NULL COMMENT 'The media resource',
@@ -749,9 +673,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_module_resource` (
   ENGINE = InnoDB
   COMMENT = 'A resource associated with a learning module.';
 
-
--- name: create-table-ol_slide
-CREATE TABLE IF NOT EXISTS `%s`.`ol_slide` (
+CREATE TABLE IF NOT EXISTS `ol_slide` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_module_id` INT NOT NULL COMMENT 'The module the question relates to.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
@@ -766,9 +688,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_slide` (
   ENGINE = InnoDB
   COMMENT = 'A slide can be of type question or info and is used to progress the user through the module.';
 
-
--- name: create-table-ol_m_module_slide_option
-CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_slide_option` (
+CREATE TABLE IF NOT EXISTS `ol_m_module_slide_option` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_m_module_slide_id` INT NOT NULL COMMENT 'The question (slide) record being answered, in the context of the members attempt on the module - i.e. the instance of this question (slide) within the current attempt on the module. This relationship is required because each question is allowed to have more than one answer given.\n\n',
   `ol_option_id` INT NOT NULL COMMENT 'The option selected by the member.',
@@ -779,9 +699,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_slide_option` (
   ENGINE = InnoDB
   COMMENT = 'A members answer to a module they have undertaken.';
 
-
--- name: create-table-ol_option
-CREATE TABLE IF NOT EXISTS `%s`.`ol_option` (
+CREATE TABLE IF NOT EXISTS `ol_option` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_slide_id` INT NOT NULL COMMENT 'The question this answer relates to.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -796,9 +714,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_option` (
   ENGINE = InnoDB
   COMMENT = 'Each question can have one-to-many answers. A true false question would have two possible answers (true / false) but questions can have any number of answers for the multiple choice format.';
 
-
--- name: create-table-ol_resource
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource` (
+CREATE TABLE IF NOT EXISTS `ol_resource` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_resource_type_id` INT NOT NULL COMMENT 'The \'type\' of resource, e.g. image, video, sound, document.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -821,9 +737,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource` (
   ENGINE = InnoDB
   COMMENT = 'A resource record is an individual piece of media content, such as an image, a video or sound file, a document or an external website. The collection of resources makes up the media library to support all of the individual learning modules.';
 
-
--- name: create-table-ol_category
-CREATE TABLE IF NOT EXISTS `%s`.`ol_category` (
+CREATE TABLE IF NOT EXISTS `ol_category` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -835,9 +749,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_category` (
   ENGINE = InnoDB
   COMMENT = 'The top level topic categories.';
 
-
--- name: create-table-ol_resource_type
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_type` (
+CREATE TABLE IF NOT EXISTS `ol_resource_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` VARCHAR(45) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -848,9 +760,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_type` (
   ENGINE = InnoDB
   COMMENT = 'Defines the type of resource. Eg. Image, Video, Audi etc.';
 
-
--- name: create-table-ce_event
-CREATE TABLE IF NOT EXISTS `%s`.`ce_event` (
+CREATE TABLE IF NOT EXISTS `ce_event` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -865,9 +775,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_event` (
   ENGINE = InnoDB
   COMMENT = 'A record of CPD related events. These records are used to assist with bulk recording of CPD via macros.';
 
-
--- name: create-table-cm_email_template
-CREATE TABLE IF NOT EXISTS `%s`.`cm_email_template` (
+CREATE TABLE IF NOT EXISTS `cm_email_template` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `system` TINYINT NOT NULL DEFAULT 0 COMMENT 'Defines if template is used by system or created by admin. Value of 1 indicates a system email template and cannot be deleted, but can be modified by admins.',
@@ -886,9 +794,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`cm_email_template` (
   COMMENT = 'Stores standard  template emails that may be sent frequently. Simply used as a starting point for the creation of a new email.'
   PACK_KEYS = Default;
 
-
--- name: create-table-cm_email
-CREATE TABLE IF NOT EXISTS `%s`.`cm_email` (
+CREATE TABLE IF NOT EXISTS `cm_email` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unqiue identifier',
   `cm_email_template_id` INT NULL COMMENT 'The (optional) template that was used as a starting point for this email.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -908,9 +814,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`cm_email` (
   ENGINE = InnoDB
   COMMENT = 'An email that is blasted to one or more members.';
 
-
--- name: create-table-cm_m_email
-CREATE TABLE IF NOT EXISTS `%s`.`cm_m_email` (
+CREATE TABLE IF NOT EXISTS `cm_m_email` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member that received the email communication.',
   `cm_email_id` INT NOT NULL COMMENT 'The email that was sent.',
@@ -930,9 +834,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`cm_m_email` (
   ENGINE = InnoDB
   COMMENT = 'Records all instances of email communications sent to individual members.';
 
-
--- name: create-table-acl_member_resource
-CREATE TABLE IF NOT EXISTS `%s`.`acl_member_resource` (
+CREATE TABLE IF NOT EXISTS `acl_member_resource` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `parent_id` INT(11) NULL COMMENT 'Self-referencing id to indicate parent record.',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -946,9 +848,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_member_resource` (
   AUTO_INCREMENT = 10000
   COMMENT = 'Defined ACL for resources in the application.';
 
-
--- name: create-table-acl_member_role
-CREATE TABLE IF NOT EXISTS `%s`.`acl_member_role` (
+CREATE TABLE IF NOT EXISTS `acl_member_role` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `is_default` TINYINT NOT NULL DEFAULT 0 COMMENT 'Specified that this role is the default assigned to a member',
@@ -960,9 +860,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_member_role` (
   ENGINE = InnoDB
   COMMENT = 'Defines roles of groups into which a member is assigned. The member with then inherit all of the permissions associated with this role.';
 
-
--- name: create-table-acl_member_role_resource
-CREATE TABLE IF NOT EXISTS `%s`.`acl_member_role_resource` (
+CREATE TABLE IF NOT EXISTS `acl_member_role_resource` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `acl_member_role_id` INT(11) NOT NULL COMMENT 'The member role from which this resource may be accessed',
   `acl_member_resource_id` INT(11) NOT NULL COMMENT 'The member resource that may be accessed from this role',
@@ -975,9 +873,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_member_role_resource` (
   ENGINE = InnoDB
   AUTO_INCREMENT = 9;
 
-
--- name: create-table-ad_macro
-CREATE TABLE IF NOT EXISTS `%s`.`ad_macro` (
+CREATE TABLE IF NOT EXISTS `ad_macro` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ad_user_id` INT NOT NULL COMMENT 'The id of the admin user that executed the macro.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
@@ -989,9 +885,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ad_macro` (
   ENGINE = InnoDB
   COMMENT = 'Stores  records of Macros that are executed by admin users.';
 
-
--- name: create-table-ol_slide_resource
-CREATE TABLE IF NOT EXISTS `%s`.`ol_slide_resource` (
+CREATE TABLE IF NOT EXISTS `ol_slide_resource` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_slide_id` INT NOT NULL,
   `ol_resource_id` INT NOT NULL,
@@ -1003,9 +897,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_slide_resource` (
   ENGINE = InnoDB
   COMMENT = 'Resources associated with a specific slide.';
 
-
--- name: create-table-ol_module_category
-CREATE TABLE IF NOT EXISTS `%s`.`ol_module_category` (
+CREATE TABLE IF NOT EXISTS `ol_module_category` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_module_id` INT NOT NULL COMMENT 'The module in question',
   `ol_category_id` INT NOT NULL COMMENT 'The category this module belongs to.',
@@ -1017,9 +909,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_module_category` (
   ENGINE = InnoDB
   COMMENT = 'Assignment of individual learning modules to one or more categories.';
 
-
--- name: create-table-ol_module_rating
-CREATE TABLE IF NOT EXISTS `%s`.`ol_module_rating` (
+CREATE TABLE IF NOT EXISTS `ol_module_rating` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1031,9 +921,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_module_rating` (
   ENGINE = InnoDB
   COMMENT = 'Stores the questions or prompts that illicit a rating (e.g. from 1-5) from the member in relation to the module.';
 
-
--- name: create-table-ol_m_module_rating
-CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_rating` (
+CREATE TABLE IF NOT EXISTS `ol_m_module_rating` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_m_module_id` INT NOT NULL COMMENT 'The member\'s instance of the module being rated.',
   `ol_module_rating_id` INT NOT NULL COMMENT 'The module rating (question) being scored.',
@@ -1045,9 +933,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_rating` (
   ENGINE = InnoDB
   COMMENT = 'The rating / score given for each rating question, from a member for a particular module.';
 
-
--- name: create-table-fn_payment_type
-CREATE TABLE IF NOT EXISTS `%s`.`fn_payment_type` (
+CREATE TABLE IF NOT EXISTS `fn_payment_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1062,9 +948,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_payment_type` (
   ENGINE = InnoDB
   COMMENT = 'Defines the different types of payments. Have used generalised descriptive column names in this table for the corresponding data fields in the fn_payment table. This is not great design but there is no point creating an excessively generalised design for this.';
 
-
--- name: create-table-wf_note_type
-CREATE TABLE IF NOT EXISTS `%s`.`wf_note_type` (
+CREATE TABLE IF NOT EXISTS `wf_note_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `system` TINYINT NOT NULL DEFAULT 0 COMMENT 'Denotes a note type used by system which cannot be deleted / modified by admin. Set auto_increment to high value like 10000 so all system ids can be placed before this value.',
@@ -1077,9 +961,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_note_type` (
   AUTO_INCREMENT = 10000
   COMMENT = 'Descriptive of the type of note e.g.. Contact, General etc.';
 
-
--- name: create-table-wf_note_association
-CREATE TABLE IF NOT EXISTS `%s`.`wf_note_association` (
+CREATE TABLE IF NOT EXISTS `wf_note_association` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'unique identifer',
   `wf_note_id` INT NOT NULL COMMENT 'The note record',
   `member_id` INT NULL COMMENT 'Defines the note as member-specific, i.e. this is the Member the note relates to. May be empty if the note relates to a \"global\" issue.  Ff this value is NULL the note should have a value for association_entity_id. That is, either member_id OR association_entity_id OR BOTH should have values.',
@@ -1092,9 +974,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_note_association` (
   ENGINE = InnoDB
   COMMENT = 'Maintains details of a Note record relationship to other entities.';
 
-
--- name: create-table-wf_issue_association
-CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_association` (
+CREATE TABLE IF NOT EXISTS `wf_issue_association` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `wf_issue_id` INT NOT NULL COMMENT 'The issue for which the association is being created.',
   `member_id` INT NULL COMMENT 'The member associated with the issue, if empty the issue is deemed to be global.',
@@ -1107,9 +987,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_association` (
   ENGINE = InnoDB
   COMMENT = 'Defines associations between Issues and other entities. The main association will be with members via member_id, however an iassue can also be associated with other entities using this method.';
 
-
--- name: create-table-ce_activity_unit
-CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_unit` (
+CREATE TABLE IF NOT EXISTS `ce_activity_unit` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier.',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1121,9 +999,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_unit` (
   ENGINE = InnoDB
   COMMENT = 'Stores the types of units used to measure CPD activity such as hours, days, items, rounds and whatever. ';
 
-
--- name: create-table-mp_tag
-CREATE TABLE IF NOT EXISTS `%s`.`mp_tag` (
+CREATE TABLE IF NOT EXISTS `mp_tag` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1133,9 +1009,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_tag` (
   PRIMARY KEY (`id`))
   ENGINE = InnoDB;
 
-
--- name: create-table-mp_m_tag
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_tag` (
+CREATE TABLE IF NOT EXISTS `mp_m_tag` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who is tagged',
   `mp_tag_id` INT NOT NULL COMMENT 'The tag being applauds',
@@ -1146,9 +1020,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_tag` (
   UNIQUE INDEX `member_tag_id_UNIQUE` (`member_id` ASC, `mp_tag_id` ASC))
   ENGINE = InnoDB;
 
-
--- name: create-table-wf_issue_category
-CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_category` (
+CREATE TABLE IF NOT EXISTS `wf_issue_category` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created at',
@@ -1159,9 +1031,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`wf_issue_category` (
   ENGINE = InnoDB
   COMMENT = 'Grouping for issue types for display and reporting purposes. this is NOT managed by the users and is setup in the initial rollout.';
 
-
--- name: create-table-country
-CREATE TABLE IF NOT EXISTS `%s`.`country` (
+CREATE TABLE IF NOT EXISTS `country` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `membership` TINYINT NOT NULL COMMENT 'Defines if the country is a membership country for the organisation. Used for organisations that span multiple countries and have some kind of administrative division or jurisdiction based on country. For example may be used to determine the appropriate tax names and rates for membership subscriptions.',
@@ -1174,9 +1044,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`country` (
   ENGINE = InnoDB
   COMMENT = 'Stores a list of countries for use in the system.';
 
-
--- name: create-table-ol_resource_filetype
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_filetype` (
+CREATE TABLE IF NOT EXISTS `ol_resource_filetype` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_resource_type_id` INT NOT NULL COMMENT 'A more specific sub category of type that explains the file type specifically, e.g. jpg, pdf, mpeg etc.',
   `active` VARCHAR(45) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -1191,9 +1059,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_filetype` (
   ENGINE = InnoDB
   COMMENT = 'NOT IN USE - intended to specifically define file types so we can handle them in a specific way when the user want to view the resource file.';
 
-
--- name: create-table-ol_m_category
-CREATE TABLE IF NOT EXISTS `%s`.`ol_m_category` (
+CREATE TABLE IF NOT EXISTS `ol_m_category` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'Link to member record.',
   `ol_category_id` INT NOT NULL COMMENT 'Link to category record',
@@ -1205,9 +1071,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_m_category` (
   ENGINE = InnoDB
   COMMENT = 'Defines the learning categories of interest to the member.';
 
-
--- name: create-table-ol_m_module_slide
-CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_slide` (
+CREATE TABLE IF NOT EXISTS `ol_m_module_slide` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_m_module_id` INT NOT NULL COMMENT 'The member\'s instance of the module being undertaken.',
   `ol_slide_id` INT NOT NULL COMMENT 'The question being referenced ',
@@ -1220,9 +1084,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_m_module_slide` (
   ENGINE = InnoDB
   COMMENT = 'A copy of the question records attempted by a member for a module. Stored here because each question may have more than one answer given.';
 
-
--- name: create-table-ol_module_cpd
-CREATE TABLE IF NOT EXISTS `%s`.`ol_module_cpd` (
+CREATE TABLE IF NOT EXISTS `ol_module_cpd` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier.',
   `ol_module_id` INT NOT NULL COMMENT 'The module to which this CPD data applies. NOTE this is unique because we have a one-to-one relationship.',
   `ce_activity_id` INT NOT NULL COMMENT 'Links to the relevant CPD activity for which we will record the CPD points for completion of the module.',
@@ -1239,9 +1101,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_module_cpd` (
   ENGINE = InnoDB
   COMMENT = 'Stores all CPD-related information about a module. Even though this is a one-to-one relationship it has been separated out to simplify the management of CPD points allocation for learning modules. If there exists in this table the appropriate data for allocating CPD points then the system will do so, otherwise CPD points will be ignored.';
 
-
--- name: create-table-mp_speciality
-CREATE TABLE IF NOT EXISTS `%s`.`mp_speciality` (
+CREATE TABLE IF NOT EXISTS `mp_speciality` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1253,9 +1113,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_speciality` (
   ENGINE = InnoDB
   COMMENT = 'Professional areas or specialities for members. A member may have one or more of these.';
 
-
--- name: create-table-mp_m_speciality
-CREATE TABLE IF NOT EXISTS `%s`.`mp_m_speciality` (
+CREATE TABLE IF NOT EXISTS `mp_m_speciality` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `member_id` INT NOT NULL COMMENT 'The member who has the speciality',
   `mp_speciality_id` INT UNSIGNED NOT NULL COMMENT 'The speciality that the member has',
@@ -1270,9 +1128,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`mp_m_speciality` (
   ENGINE = InnoDB
   COMMENT = '	';
 
-
--- name: create-table-ms_permission
-CREATE TABLE IF NOT EXISTS `%s`.`ms_permission` (
+CREATE TABLE IF NOT EXISTS `ms_permission` (
   `id` INT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1284,9 +1140,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_permission` (
   UNIQUE INDEX `name_UNIQUE` (`name` ASC))
   ENGINE = InnoDB;
 
-
--- name: create-table-ms_m_permission
-CREATE TABLE IF NOT EXISTS `%s`.`ms_m_permission` (
+CREATE TABLE IF NOT EXISTS `ms_m_permission` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier.',
   `active` TINYINT UNSIGNED NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1296,9 +1150,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_m_permission` (
   PRIMARY KEY (`id`))
   ENGINE = InnoDB;
 
-
--- name: create-table-ce_activity_category
-CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_category` (
+CREATE TABLE IF NOT EXISTS `ce_activity_category` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1309,9 +1161,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_category` (
   ENGINE = InnoDB
   COMMENT = 'Used to store CPD activity categories or groups for collecting related CPD activity types and showing grouped lists etc.';
 
-
--- name: create-table-fs_set
-CREATE TABLE IF NOT EXISTS `%s`.`fs_set` (
+CREATE TABLE IF NOT EXISTS `fs_set` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL COMMENT 'Soft delete',
   `current` TINYINT NOT NULL COMMENT 'This is the current volume / set for this component. Tells the system to USE this set.',
@@ -1326,9 +1176,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fs_set` (
   ENGINE = InnoDB
   COMMENT = 'Defines file sets which are separate volumes and locations for files associated with components of the application. This table defines file sets that are accessible via the local file system.';
 
-
--- name: create-table-fs_url
-CREATE TABLE IF NOT EXISTS `%s`.`fs_url` (
+CREATE TABLE IF NOT EXISTS `fs_url` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fs_set_id` INT NOT NULL COMMENT 'The set for which this URL dispatch method is defined.',
   `active` TINYINT NOT NULL COMMENT 'Soft delete',
@@ -1340,9 +1188,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fs_url` (
   ENGINE = InnoDB
   COMMENT = 'Defines file urls which may (optionally) be used for downloading files from a CDN, cloud store or similar - that is, accessing files by URLs.';
 
-
--- name: create-table-ol_resource_attribute
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_attribute` (
+CREATE TABLE IF NOT EXISTS `ol_resource_attribute` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(4) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1358,9 +1204,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_attribute` (
   AUTO_INCREMENT = 4
   COMMENT = 'Defines attribute names that can be assigned to resources as required.';
 
-
--- name: create-table-ol_resource_attribute_value
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_attribute_value` (
+CREATE TABLE IF NOT EXISTS `ol_resource_attribute_value` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_resource_id` INT NOT NULL COMMENT 'The resource that has the value',
   `ol_resource_attribute_id` INT NOT NULL COMMENT 'The attribute assigned to the resource.',
@@ -1373,9 +1217,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_attribute_value` (
   AUTO_INCREMENT = 4
   COMMENT = 'Defines the values of attributes assigned to a resource. ';
 
-
--- name: create-table-ol_resource_file
-CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_file` (
+CREATE TABLE IF NOT EXISTS `ol_resource_file` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ol_resource_id` INT NOT NULL,
   `ad_user_id` INT NOT NULL COMMENT 'The admin user who added the file.',
@@ -1390,9 +1232,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ol_resource_file` (
   ENGINE = InnoDB
   COMMENT = 'Stores information about the actual resource files that have been uploaded and managed within the application. ';
 
-
--- name: create-table-fn_subscription
-CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription` (
+CREATE TABLE IF NOT EXISTS `fn_subscription` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fn_subscription_type_id` INT(11) NOT NULL,
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -1407,9 +1247,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription` (
   ENGINE = InnoDB
   COMMENT = 'Defines Subscription templates used to generate renewal invoices.';
 
-
--- name: create-table-fn_subscription_inventory
-CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription_inventory` (
+CREATE TABLE IF NOT EXISTS `fn_subscription_inventory` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `fn_subscription_id` INT NOT NULL COMMENT 'The subscription to which this line item belongs',
   `fn_inventory_id` INT NOT NULL COMMENT 'The inventory item on this subscription.',
@@ -1422,9 +1260,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription_inventory` (
   ENGINE = InnoDB
   COMMENT = 'Defines the inventory items that appear on subscription rene';
 
-
--- name: create-table-fn_subscription_type
-CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription_type` (
+CREATE TABLE IF NOT EXISTS `fn_subscription_type` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT(4) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1435,9 +1271,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_subscription_type` (
   PRIMARY KEY (`id`))
   ENGINE = InnoDB;
 
-
--- name: create-table-fn_tax
-CREATE TABLE IF NOT EXISTS `%s`.`fn_tax` (
+CREATE TABLE IF NOT EXISTS `fn_tax` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `country_id` INT NOT NULL COMMENT 'The country for which this tax applies.',
   `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
@@ -1450,9 +1284,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`fn_tax` (
   ENGINE = InnoDB
   COMMENT = 'Tax table that defines taxes for each country.';
 
-
--- name: create-table-log_data_field
-CREATE TABLE IF NOT EXISTS `%s`.`log_data_field` (
+CREATE TABLE IF NOT EXISTS `log_data_field` (
   `id` INT(10) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `log_data_action_id` INT(10) NOT NULL,
   `active` TINYINT NOT NULL DEFAULT 1,
@@ -1465,9 +1297,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`log_data_field` (
   ENGINE = InnoDB
   COMMENT = 'Logs various system events and data changes over time.';
 
-
--- name: create-table-log_data_table
-CREATE TABLE IF NOT EXISTS `%s`.`log_data_table` (
+CREATE TABLE IF NOT EXISTS `log_data_table` (
   `id` INT(10) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1,
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created at - ie time and date that the action was performed.',
@@ -1477,9 +1307,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`log_data_table` (
   ENGINE = InnoDB
   COMMENT = 'Defines the table names for which we will log data changes. These values need to be added as part of setup.';
 
-
--- name: create-table-a_name_prefix
-CREATE TABLE IF NOT EXISTS `%s`.`a_name_prefix` (
+CREATE TABLE IF NOT EXISTS `a_name_prefix` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1489,9 +1317,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`a_name_prefix` (
   ENGINE = InnoDB
   COMMENT = 'Name prefix is for title or honorific - e.g. Dr, Mr, Professor etc.';
 
-
--- name: create-table-a_meeting_type
-CREATE TABLE IF NOT EXISTS `%s`.`a_meeting_type` (
+CREATE TABLE IF NOT EXISTS `a_meeting_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete.',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
@@ -1502,9 +1328,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`a_meeting_type` (
   ENGINE = InnoDB
   COMMENT = 'Meeting types are used to group meetings';
 
-
--- name: create-table-ms_m_application_meeting
-CREATE TABLE IF NOT EXISTS `%s`.`ms_m_application_meeting` (
+CREATE TABLE IF NOT EXISTS `ms_m_application_meeting` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ms_m_application_id` INT NOT NULL COMMENT 'The application record that will be reviewed at the meeting.',
   `ad_macro_id` INT NULL COMMENT 'If this record was inserted or updated by a macro we store the macro id here.',
@@ -1519,9 +1343,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ms_m_application_meeting` (
   ENGINE = InnoDB
   COMMENT = 'One or more associated meetings relevant to the processing of a member application. Provides a workflow or review or approval processes.';
 
-
--- name: create-table-ad_macro_transaction
-CREATE TABLE IF NOT EXISTS `%s`.`ad_macro_transaction` (
+CREATE TABLE IF NOT EXISTS `ad_macro_transaction` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ad_macro_id` INT NOT NULL COMMENT 'The macro to which this transaction belongs.',
   `member_id` INT NULL COMMENT 'The member against whose record the transaction was taken. This is set to NULL in the event that the member id was malformed or not found.',
@@ -1534,9 +1356,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ad_macro_transaction` (
   ENGINE = InnoDB
   COMMENT = 'Stores  records of the individual transactions / events in a macro.';
 
-
--- name: create-table-acl_admin_role
-CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_role` (
+CREATE TABLE IF NOT EXISTS `acl_admin_role` (
   `id` INT(11) NOT NULL COMMENT 'Unique identifier',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
   `is_default` TINYINT NOT NULL DEFAULT 0 COMMENT 'Specified that this role is the default assigned to admin user',
@@ -1548,9 +1368,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_role` (
   ENGINE = InnoDB
   COMMENT = 'Defines roles of groups into which a member is assigned. The member with then inherit all of the permissions associated with this role.';
 
-
--- name: create-table-acl_admin_resource
-CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_resource` (
+CREATE TABLE IF NOT EXISTS `acl_admin_resource` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `parent_id` INT(11) NULL COMMENT 'Self-referencing id to indicate parent record.',
   `active` TINYINT(1) NOT NULL DEFAULT '1' COMMENT 'Soft delete',
@@ -1564,9 +1382,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_resource` (
   AUTO_INCREMENT = 10000
   COMMENT = 'Defined ACL for resources in the application.';
 
-
--- name: create-table-acl_admin_role_resource
-CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_role_resource` (
+CREATE TABLE IF NOT EXISTS `acl_admin_role_resource` (
   `id` INT(11) NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `acl_admin_role_id` INT(11) NOT NULL COMMENT 'The role that will be allowed to access the resource',
   `acl_admin_resource_id` INT(11) NOT NULL COMMENT 'The resource that can be accessed by the role',
@@ -1579,9 +1395,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`acl_admin_role_resource` (
   ENGINE = InnoDB
   AUTO_INCREMENT = 9;
 
-
--- name: create-table-cm_email_variable
-CREATE TABLE IF NOT EXISTS `%s`.`cm_email_variable` (
+CREATE TABLE IF NOT EXISTS `cm_email_variable` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
   `persistent` TINYINT NOT NULL DEFAULT 0 COMMENT 'If set to 1 this variable will always be available in all email templates.',
@@ -1596,9 +1410,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`cm_email_variable` (
   ENGINE = InnoDB
   COMMENT = 'Defines the variables available for use in email templates.';
 
-
--- name: create-table-cm_email_log
-CREATE TABLE IF NOT EXISTS `%s`.`cm_email_log` (
+CREATE TABLE IF NOT EXISTS `cm_email_log` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `cm_email_id` INT NOT NULL COMMENT 'The parent email record that was sent - i.e. the email that was the source for the broadcast. This is redundant as it can be looked up using cm_m_email_id however is included for implementation ease. This value is initially sent the the mail exchanger as an additional email header and is then posted back from the remote system to reconcile email events. ',
   `cm_m_email_id` INT NOT NULL COMMENT 'The email that the logged event relates to. This value is initially sent the the mail exchanger as an additional email header and is then posted back from the remote system to reconcile email events. ',
@@ -1613,9 +1425,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`cm_email_log` (
   ENGINE = InnoDB
   COMMENT = 'Stores information about the status of emails that are relayed via an external mail exchanger. This data is posted back to our application by web hooks or gather via the remote system API, and then written to this table. It is used to keep a history of the email transactions and to populate the cm_m_email table with the current status of each email.';
 
-
--- name: create-table-session_admin
-CREATE TABLE IF NOT EXISTS `%s`.`session_admin` (
+CREATE TABLE IF NOT EXISTS `session_admin` (
   `id` CHAR(32) NOT NULL,
   `modified` INT(11) NULL DEFAULT NULL,
   `lifetime` INT(11) NULL DEFAULT NULL,
@@ -1624,9 +1434,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`session_admin` (
   ENGINE = InnoDB
   COMMENT = 'Session table - structure required by Zend Framework';
 
-
--- name: create-table-session_member
-CREATE TABLE IF NOT EXISTS `%s`.`session_member` (
+CREATE TABLE IF NOT EXISTS `session_member` (
   `id` CHAR(32) NOT NULL,
   `modified` INT(11) NULL DEFAULT NULL,
   `lifetime` INT(11) NULL DEFAULT NULL,
@@ -1635,9 +1443,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`session_member` (
   ENGINE = InnoDB
   COMMENT = 'Session table - structure required by Zend Framework';
 
-
--- name: create-table-ce_m_activity_attachment
-CREATE TABLE IF NOT EXISTS `%s`.`ce_m_activity_attachment` (
+CREATE TABLE IF NOT EXISTS `ce_m_activity_attachment` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ce_m_activity_id` INT NOT NULL COMMENT 'The member cpd activity with which this file is associated. ',
   `fs_set_id` INT NOT NULL COMMENT 'Tells us in which set we will find file system information that will allow us to locate the file.',
@@ -1650,8 +1456,7 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_m_activity_attachment` (
   ENGINE = InnoDB
   COMMENT = 'Documents table provides a way to attach files to notes. A document is always attached via a note and, therefore, can only be associated with member records. As notes can be associated with applications and issues, documents can also be associated with these entities.';
 
--- name: create-table-ce_activity_type
-CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_type` (
+CREATE TABLE IF NOT EXISTS `ce_activity_type` (
   `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
   `ce_activity_id` INT NOT NULL COMMENT 'The activity to which this type relates.',
   `active` TINYINT NOT NULL DEFAULT 1,
@@ -1661,4 +1466,3 @@ CREATE TABLE IF NOT EXISTS `%s`.`ce_activity_type` (
   PRIMARY KEY (`id`))
   ENGINE = InnoDB
   COMMENT = 'This table was added to allow for prescriptive activity descriptions.';
-
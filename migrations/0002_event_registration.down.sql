@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `ce_event_checkin`;
+DROP TABLE IF EXISTS `ce_event_registration`;
+
+ALTER TABLE `ce_event`
+  DROP COLUMN `cpd_quantity`,
+  DROP COLUMN `ce_activity_type_id`,
+  DROP COLUMN `ce_activity_id`,
+  DROP COLUMN `capacity`;
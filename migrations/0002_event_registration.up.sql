@@ -0,0 +1,36 @@
+-- Event capacity and the CPD recorded for attendance, member registrations with the waitlist, and
+-- ticket scans at check-in.
+
+ALTER TABLE `ce_event`
+  ADD COLUMN `capacity` INT NULL DEFAULT NULL COMMENT 'Maximum number of registrations, further registrations are waitlisted. NULL for no limit.',
+  ADD COLUMN `ce_activity_id` INT NULL DEFAULT NULL COMMENT 'The activity recorded as CPD for members who attend the event.',
+  ADD COLUMN `ce_activity_type_id` INT NULL DEFAULT NULL COMMENT 'The activity type recorded as CPD for members who attend the event.',
+  ADD COLUMN `cpd_quantity` DECIMAL(6,2) NULL DEFAULT NULL COMMENT 'The quantity (eg hours) of CPD recorded for members who attend the event.';
+
+CREATE TABLE IF NOT EXISTS `ce_event_registration` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ce_event_id` INT NOT NULL COMMENT 'The event.',
+  `member_id` INT NOT NULL COMMENT 'The member registered for the event.',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created, determines the order of the waitlist',
+  `updated_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Record last updated',
+  `status` ENUM('registered','waitlisted','cancelled','attended') NOT NULL DEFAULT 'registered' COMMENT 'Registration status.',
+  `attended_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When attendance was confirmed.',
+  `ce_m_activity_id` INT NULL DEFAULT NULL COMMENT 'The member activity (CPD) recorded for attendance.',
+  `checked_in_at` DATETIME NULL DEFAULT NULL COMMENT 'Time of the earliest ticket scan at the event.',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `event_member` (`ce_event_id`, `member_id`))
+  ENGINE = InnoDB
+  COMMENT = 'Member registrations for events, including the waitlist and attendance.';
+
+
+CREATE TABLE IF NOT EXISTS `ce_event_checkin` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ce_event_registration_id` INT NOT NULL COMMENT 'The registration identified by the scanned ticket.',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'When the scan was received by the server.',
+  `scanned_at` DATETIME NOT NULL COMMENT 'When the ticket was scanned, which may be earlier than created_at for scans made offline.',
+  `device` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'Identifies the scanning device.',
+  `duplicate` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'The registration had already been checked in when the scan was received.',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `scan` (`ce_event_registration_id`, `device`, `scanned_at`))
+  ENGINE = InnoDB
+  COMMENT = 'Ticket scans at events, including duplicates.';
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS `ol_m_module_attempt_option`;
+DROP TABLE IF EXISTS `ol_m_module_attempt_question`;
+DROP TABLE IF EXISTS `ol_m_module_attempt`;
+
+ALTER TABLE `ol_module_cpd` DROP COLUMN `ce_activity_type_id`;
+ALTER TABLE `ol_slide` DROP COLUMN `question_type`;
+ALTER TABLE `ol_module_resource` DROP COLUMN `sequence`;
+ALTER TABLE `ol_module`
+  DROP COLUMN `published`,
+  DROP COLUMN `quiz_question_count`;
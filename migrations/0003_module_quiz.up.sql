@@ -0,0 +1,59 @@
+-- Quiz attempts for modules, the question type of slides, and the fields used by module authoring.
+
+ALTER TABLE `ol_module`
+  ADD COLUMN `quiz_question_count` SMALLINT UNSIGNED NOT NULL DEFAULT 0 COMMENT 'The number of questions drawn at random from the question slides for each quiz attempt. 0 uses all of the questions.',
+  ADD COLUMN `published` TINYINT NOT NULL DEFAULT 1 COMMENT 'Flag set when the module is available to members. Modules created with the authoring api are unpublished until they are ready.';
+
+ALTER TABLE `ol_module_resource`
+  ADD COLUMN `sequence` SMALLINT NOT NULL DEFAULT 0 COMMENT 'The order in which the resources are listed for the module.';
+
+ALTER TABLE `ol_slide`
+  ADD COLUMN `question_type` ENUM('single','multiple') NULL DEFAULT NULL COMMENT 'For a question slide, whether one option (single) or any number of options (multiple) can be selected. NULL for info slides.';
+
+ALTER TABLE `ol_module_cpd`
+  ADD COLUMN `ce_activity_type_id` INT NULL DEFAULT NULL COMMENT 'The activity type recorded with the CPD. If NULL the first type of the activity is used.';
+
+CREATE TABLE IF NOT EXISTS `ol_m_module_attempt` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ol_m_module_id` INT NOT NULL COMMENT 'The member\'s instance of the module being undertaken.',
+  `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created, also the start of the attempt.',
+  `updated_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Record last updated',
+  `submitted_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'The date and time the answers were submitted and scored. NULL while the attempt is in progress.',
+  `score` SMALLINT NOT NULL DEFAULT 0 COMMENT 'Total score for the attempt.',
+  `max_score` SMALLINT NOT NULL DEFAULT 0 COMMENT 'The highest possible score for the questions in the attempt.',
+  `pass_percentage` TINYINT UNSIGNED NULL COMMENT 'Copy of ol_module.pass_percentage when the attempt was started.',
+  `passed` TINYINT NOT NULL DEFAULT 0 COMMENT 'Flag set when a submitted attempt reaches the pass percentage.',
+  PRIMARY KEY (`id`),
+  INDEX `ol_m_module_id_IDX` (`ol_m_module_id` ASC))
+  ENGINE = InnoDB
+  COMMENT = 'An attempt at the quiz for a module. Each attempt is a random selection of the question slides for the module.';
+
+
+CREATE TABLE IF NOT EXISTS `ol_m_module_attempt_question` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ol_m_module_attempt_id` INT NOT NULL COMMENT 'The attempt.',
+  `ol_slide_id` INT NOT NULL COMMENT 'The question slide drawn for the attempt.',
+  `active` TINYINT NOT NULL DEFAULT 1 COMMENT 'Soft delete',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
+  `updated_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Record last updated',
+  `sequence` TINYINT NOT NULL COMMENT 'The order in which the question appears in the attempt.',
+  `option_order` VARCHAR(255) NOT NULL COMMENT 'Comma separated ol_option ids, in the order they are shown in the attempt.',
+  `score` SMALLINT NOT NULL DEFAULT 0 COMMENT 'The score for the answer to the question.',
+  `max_score` SMALLINT NOT NULL DEFAULT 0 COMMENT 'The highest possible score for the question.',
+  `correct` TINYINT NOT NULL DEFAULT 0 COMMENT 'Flag set when exactly the correct options were selected.',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `attempt_slide_UNIQUE` (`ol_m_module_attempt_id` ASC, `ol_slide_id` ASC))
+  ENGINE = InnoDB
+  COMMENT = 'A question drawn for a quiz attempt, and the result for the answer given.';
+
+
+CREATE TABLE IF NOT EXISTS `ol_m_module_attempt_option` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ol_m_module_attempt_question_id` INT NOT NULL COMMENT 'The question in the attempt being answered.',
+  `ol_option_id` INT NOT NULL COMMENT 'The option selected by the member.',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Record created',
+  PRIMARY KEY (`id`),
+  INDEX `ol_option_id_IDX` (`ol_option_id` ASC))
+  ENGINE = InnoDB
+  COMMENT = 'An option selected by a member when answering a question in a quiz attempt.';
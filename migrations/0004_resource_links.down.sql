@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `ol_resource_link_check`;
+DROP TABLE IF EXISTS `ol_resource_click`;
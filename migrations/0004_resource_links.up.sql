@@ -0,0 +1,27 @@
+-- Short link click counts and the results of resource link checks.
+
+CREATE TABLE IF NOT EXISTS `ol_resource_click` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier',
+  `ol_resource_id` INT NOT NULL COMMENT 'The resource the short link points to',
+  `clicked_hour` DATETIME NOT NULL COMMENT 'The hour the clicks were made, truncated to the hour',
+  `referrer` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'The host of the referring page, empty for direct clicks',
+  `clicks` INT NOT NULL DEFAULT 0 COMMENT 'The number of clicks in the hour from the referrer',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `ol_resource_click_uq` (`ol_resource_id`, `clicked_hour`, `referrer`))
+  ENGINE = InnoDB
+  COMMENT = 'Short link clicks for a resource, counted in hourly buckets by referrer.';
+
+
+CREATE TABLE IF NOT EXISTS `ol_resource_link_check` (
+  `ol_resource_id` INT NOT NULL COMMENT 'The resource that was checked',
+  `checked_at` DATETIME NOT NULL COMMENT 'When the resource url was last checked',
+  `url` VARCHAR(255) NOT NULL COMMENT 'The url that was checked',
+  `status_code` SMALLINT NOT NULL DEFAULT 0 COMMENT 'The final http status, 0 if the url could not be fetched',
+  `error` VARCHAR(1024) NOT NULL DEFAULT '' COMMENT 'The error if the url could not be fetched',
+  `attempts` TINYINT NOT NULL DEFAULT 1 COMMENT 'The number of requests made, including retries',
+  `redirects` TEXT NOT NULL COMMENT 'JSON array of the redirects that were followed',
+  `failures` INT NOT NULL DEFAULT 0 COMMENT 'The number of consecutive checks that found the link broken',
+  `deactivated` TINYINT NOT NULL DEFAULT 0 COMMENT 'Set when the resource was deactivated because the link is broken',
+  PRIMARY KEY (`ol_resource_id`))
+  ENGINE = InnoDB
+  COMMENT = 'The last link check for each resource url.';
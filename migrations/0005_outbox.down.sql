@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `outbox_consumer`;
+DROP TABLE IF EXISTS `outbox_event`;
@@ -0,0 +1,21 @@
+-- Change events for the member, resource and module records, and the checkpoint of each consumer.
+
+CREATE TABLE IF NOT EXISTS `outbox_event` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Unique identifier, in the order the events were added',
+  `created_at` DATETIME NOT NULL COMMENT 'When the change was made',
+  `aggregate` VARCHAR(32) NOT NULL COMMENT 'The type of record that changed - member, resource or module',
+  `aggregate_id` INT NOT NULL COMMENT 'The id of the record that changed',
+  `action` VARCHAR(16) NOT NULL COMMENT 'upsert or delete',
+  PRIMARY KEY (`id`),
+  INDEX `outbox_event_created_at` (`created_at`))
+  ENGINE = InnoDB
+  COMMENT = 'Change events for the member, resource and module records, read by consumers such as algr.';
+
+
+CREATE TABLE IF NOT EXISTS `outbox_consumer` (
+  `name` VARCHAR(64) NOT NULL COMMENT 'The consumer name',
+  `last_event_id` BIGINT NOT NULL DEFAULT 0 COMMENT 'The last outbox event processed by the consumer',
+  `updated_at` DATETIME NOT NULL COMMENT 'When the checkpoint was last moved',
+  PRIMARY KEY (`name`))
+  ENGINE = InnoDB
+  COMMENT = 'The checkpoint of each outbox consumer.';
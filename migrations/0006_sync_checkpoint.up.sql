@@ -0,0 +1,12 @@
+-- The progress of syncr runs, so that a run that stops can be resumed.
+
+CREATE TABLE IF NOT EXISTS `sync_checkpoint` (
+  `collection` VARCHAR(32) NOT NULL COMMENT 'The collection being synced - member, module or resource',
+  `since` DATETIME NOT NULL COMMENT 'Records updated at or after this time are included in the run',
+  `started_at` DATETIME NOT NULL COMMENT 'When the run started',
+  `last_id` INT NOT NULL DEFAULT 0 COMMENT 'Every record up to and including this id has been synced',
+  `finished_at` DATETIME NULL DEFAULT NULL COMMENT 'When the run finished, NULL while it is in progress or if it stopped',
+  `updated_at` DATETIME NOT NULL COMMENT 'When the checkpoint was last saved',
+  PRIMARY KEY (`collection`))
+  ENGINE = InnoDB
+  COMMENT = 'The progress of the latest syncr run for each collection, so that a run that stops can be resumed.';
@@ -132,7 +132,12 @@ INSERT INTO `%s`.`ce_evaluation` VALUES
   (2, 1, '2015-08-30 17:10:13', '2015-08-30 17:10:13', 0, 1, 1, 31, 12, 36, 250, 'CPD Triennium',
    '36 month CPD period');
 
--- insert-data-ce_event
+-- name: insert-data-ce_event
+INSERT INTO `%s`.`ce_event` VALUES
+  (1, 1, '2019-01-10 09:00:00', '2019-01-12 10:30:00', '2019-08-08', '2019-08-11', 'Adelaide Convention Centre, SA', 'CSANZ Annual Scientific Meeting', 'The annual meeting of the society; all members welcome.', 'https://www.csanz.edu.au/asm', 2, 20, 1, 6.50),
+  (2, 1, '2019-01-10 09:00:00', NULL, '2019-05-10', '2019-05-10', 'Melbourne, VIC', 'Echocardiography Workshop', 'Hands-on workshop', NULL, NULL, NULL, NULL, NULL),
+  (3, 1, '2019-01-10 09:00:00', NULL, '2019-11-20', '2019-11-21', 'Auckland, NZ', 'NZ Regional Meeting', 'Regional meeting', NULL, NULL, NULL, NULL, NULL),
+  (4, 0, '2019-01-10 09:00:00', NULL, '2019-06-01', '2019-06-01', 'Sydney, NSW', 'Cancelled Meeting', 'Soft deleted', NULL, NULL, NULL, NULL, NULL);
 
 -- name: insert-data-ce_m_activity
 INSERT INTO `%s`.`ce_m_activity` VALUES
@@ -488,17 +493,46 @@ INSERT INTO `%s`.`ol_m_category` VALUES
 
 -- insert-data-ol_m_module_slide_option
 
--- insert-data-ol_module
+-- name: insert-data-ol_module
+INSERT INTO `%s`.`ol_module` VALUES
+  (1, 1, 1, 1, 1, 0, 0, 0, '2019-01-01 09:00:00', '2019-01-01 09:00:00', '2019-01-01 09:00:00',
+   'Atrial Fibrillation Update', 'Current management of atrial fibrillation.', 'Understand rate and rhythm control.',
+   'Work through each section, then mark the module as finished.', NULL, 90, 0, 0, 0, 1),
+  (2, 2, 1, 1, 1, 0, 0, 0, '2019-01-01 09:00:00', '2019-01-01 09:00:00', '2019-01-01 09:00:00',
+   'ECG Basics', 'An introduction to the ECG.', 'Interpret a normal ECG.', 'Read the section, then pass the quiz.', 60, 30, 2, 0, 3, 1),
+  (3, 3, 0, 0, 1, 0, 0, 0, '2019-01-01 09:00:00', '2019-01-01 09:00:00', '2019-01-01 09:00:00',
+   'Retired Module', 'Soft deleted.', '', '', NULL, 60, 0, 0, 0, 0);
 
 -- insert-data-ol_module_category
 
--- insert-data-ol_module_cpd
+-- name: insert-data-ol_module_cpd
+INSERT INTO `%s`.`ol_module_cpd` VALUES
+  (1, 1, 24, 1, '2019-01-01 09:00:00', '2019-01-01 09:00:00', 1, 0, 0, 0, 1.50, 35),
+  (2, 2, 24, 1, '2019-01-01 09:00:00', '2019-01-01 09:00:00', 1, 1, 0, 0, 0.50, 35);
 
 -- insert-data-ol_module_rating
 
--- insert-data-ol_module_resource
-
--- insert-data-ol_option
+-- name: insert-data-ol_module_resource
+INSERT INTO `%s`.`ol_module_resource` VALUES
+  (1, 1, 6577, 1, '2019-01-01 09:00:00', NULL, 2),
+  (2, 1, 6576, 1, '2019-01-01 09:00:00', NULL, 1);
+
+-- name: insert-data-ol_option
+INSERT INTO `%s`.`ol_option` VALUES
+  (1, 5, 1, '2019-01-01 09:00:00', NULL, 1, 1, 0, '120 to 200 ms', NULL),
+  (2, 5, 1, '2019-01-01 09:00:00', NULL, 2, 0, 0, '40 to 80 ms', 'This is too short, and suggests pre-excitation.'),
+  (3, 5, 1, '2019-01-01 09:00:00', NULL, 3, 0, 0, '250 to 400 ms', NULL),
+  (4, 6, 1, '2019-01-01 09:00:00', NULL, 1, 1, 0, 'II', NULL),
+  (5, 6, 1, '2019-01-01 09:00:00', NULL, 2, 1, 0, 'III', NULL),
+  (6, 6, 1, '2019-01-01 09:00:00', NULL, 3, 1, 0, 'aVF', NULL),
+  (7, 6, 1, '2019-01-01 09:00:00', NULL, 4, 0, 1, 'V1', 'V1 is a precordial lead.'),
+  (8, 7, 1, '2019-01-01 09:00:00', NULL, 1, 1, 0, '120 ms', NULL),
+  (9, 7, 1, '2019-01-01 09:00:00', NULL, 2, 0, 0, '200 ms', NULL),
+  (10, 7, 1, '2019-01-01 09:00:00', NULL, 3, 0, 0, '300 ms', NULL),
+  (11, 8, 1, '2019-01-01 09:00:00', NULL, 1, 1, 0, 'Atrial depolarisation', NULL),
+  (12, 8, 1, '2019-01-01 09:00:00', NULL, 2, 0, 0, 'Ventricular depolarisation', NULL),
+  (13, 8, 1, '2019-01-01 09:00:00', NULL, 3, 0, 0, 'Ventricular repolarisation', NULL),
+  (14, 8, 0, '2019-01-01 09:00:00', NULL, 4, 0, 0, 'Soft deleted option', NULL);
 
 -- name: insert-data-ol_resource
 INSERT INTO `%s`.`ol_resource` VALUES
@@ -507,7 +541,7 @@ INSERT INTO `%s`.`ol_resource` VALUES
    'Altered gut microbiota is implicated in development of colorectal cancer (CRC). Some intestinal bacteria have been reported to potentiate intestinal carcinogenesis by producing genotoxins, altering the immune response and intestinal microenvironment, and activating oncogenic signaling pathways. We investigated whether stool from patients with CRC could directly induce colorectal carcinogenesis in mice.',
    'Carcinogenesis,Colon Cancer,Germ-Free,Stool Transplantation,Animals,Azoxymethane,Case-Control Studies,Cell Proliferation,Cell Transformation Neoplastic,Colon,Colonic Polyps,Colorectal Neoplasms,Disease Models Animal,Feces,Gastrointestinal Microbiome,Gene Expression Regulation Neoplastic,Germ-Free Life,Host-Pathogen Interactions,Humans,Inflammation Mediators,Ki-67 Antigen,Lymphocytes Tumor-Infiltrating,Male,Mice Inbred C57BL,Th1 Cells,Th17 Cells,Wong SH,Zhao L,Zhang X,Nakatsu G,Han J,Xu W,Xiao X,Kwong TNY,Tsoi H,Wu WKK,Zeng B,Chan FKL,Sung JJY,Wei H,Yu J,28823860',
    'https://doi.org/10.1053/j.gastro.2017.08.022', 'http://localhost:8080/r6576', '', NULL,
-   '{\"category\":\"\",\"free\":false,\"public\":false,\"source\":\"pubmed\",\"sourceId\":\"28823860\",\"sourceName\":\"Gastroenterology\",\"sourceNameAbbrev\":\"Gastroenterology\",\"sourcePubDate\":\"2017 Dec\",\"sourceVolume\":\"153\",\"sourceIssue\":\"6\",\"sourcePages\":\"1621-1633.e6\"}'),
+   '{\"category\":\"\",\"free\":false,\"public\":false,\"source\":\"pubmed\",\"sourceId\":\"28823860\",\"authors\":[\"Wong SH\",\"Zhao L\",\"Zhang X\"],\"sourceName\":\"Gastroenterology\",\"sourceNameAbbrev\":\"Gastroenterology\",\"sourcePubDate\":\"2017 Dec\",\"sourceVolume\":\"153\",\"sourceIssue\":\"6\",\"sourcePages\":\"1621-1633.e6\"}'),
   (6577, 80, 1, 1, '2018-02-14 23:34:22', '2018-02-15 05:25:36', '2017-12-01', 2017, 12, 1,
          'NETSstudy: development of a Hirschsprung\'s disease core outcome set.',
    'The objective of this study was to develop a Hirschsprung\'s disease (HD) core outcome set (COS).',
@@ -531,7 +565,17 @@ INSERT INTO `%s`.`ol_resource` VALUES
 
 -- insert-data-ol_resource_type
 
--- insert-data-ol_slide
+-- name: insert-data-ol_slide
+INSERT INTO `%s`.`ol_slide` VALUES
+  (1, 1, 1, '2019-01-01 09:00:00', NULL, 1, 5, 'info', 'Introduction', NULL, NULL),
+  (2, 1, 1, '2019-01-01 09:00:00', NULL, 2, 5, 'info', 'Rate and rhythm control', '<p>Rate control first...</p>', NULL),
+  (3, 1, 0, '2019-01-01 09:00:00', NULL, 3, 5, 'info', 'Soft deleted section', NULL, NULL),
+  (4, 2, 1, '2019-01-01 09:00:00', NULL, 1, 5, 'info', 'The normal ECG', '<p>Waves and intervals...</p>', NULL),
+  (5, 2, 1, '2019-01-01 09:00:00', NULL, 2, 5, 'question', 'What is the normal PR interval?', NULL, 'single'),
+  (6, 2, 1, '2019-01-01 09:00:00', NULL, 3, 5, 'question', 'Which leads look at the inferior wall?', NULL, 'multiple'),
+  (7, 2, 1, '2019-01-01 09:00:00', NULL, 4, 5, 'question', 'A normal QRS duration is less than?', NULL, 'single'),
+  (8, 2, 1, '2019-01-01 09:00:00', NULL, 5, 5, 'question', 'What does the P wave represent?', NULL, 'single'),
+  (9, 2, 0, '2019-01-01 09:00:00', NULL, 6, 5, 'question', 'Soft deleted question', NULL, 'single');
 
 -- insert-data-ol_slide_resource
 
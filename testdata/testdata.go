@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/cardiacsociety/web-services/internal/platform/datastore"
+	"github.com/cardiacsociety/web-services/internal/platform/migrate"
 	"github.com/hashicorp/go-uuid"
 	"github.com/nleof/goyesql"
 	"github.com/pkg/errors"
@@ -19,7 +20,7 @@ const MongoDSN = "mongodb://localhost/"
 
 var path = os.Getenv("GOPATH") + "/src/github.com/cardiacsociety/web-services/testdata/"
 var schemaQueries = goyesql.MustParseFile(path + "schema.sql")
-var tableQueries = goyesql.MustParseFile(path + "tables.sql")
+var migrations = path + "../migrations"
 var dataQueries = goyesql.MustParseFile(path + "data.sql")
 var memberDocs = path + "members.json"
 var resourcesDocs = path + "resources.json"
@@ -74,13 +75,16 @@ func (t *TestStore) SetupMySQL() error {
 		return errors.Wrap(err, "Error connecting to the test database")
 	}
 
-	for _, q := range tableQueries {
-		query = fmt.Sprintf(q, t.Name)
-		_, err = t.Store.MySQL.Session.Exec(query)
-		if err != nil {
-			t.TearDownMySQL()
-			return errors.Wrap(err, "Error creating tables")
-		}
+	// Tables are created with the same migrations as the live database
+	xm, err := migrate.Load(migrations)
+	if err != nil {
+		t.TearDownMySQL()
+		return errors.Wrap(err, "Error loading migrations")
+	}
+	_, err = migrate.Up(t.Store, xm)
+	if err != nil {
+		t.TearDownMySQL()
+		return errors.Wrap(err, "Error creating tables")
 	}
 
 	for _, q := range dataQueries {